@@ -0,0 +1,394 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/api"
+
+	"github.com/gorilla/websocket"
+)
+
+// room is a room created for the run
+type room struct {
+	id          string
+	subscribers atomic.Int64
+
+	mu       sync.Mutex
+	messages []string
+}
+
+func (r *room) addMessage(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, id)
+}
+
+func (r *room) randomMessage() (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.messages) == 0 {
+		return "", false
+	}
+	return r.messages[rand.IntN(len(r.messages))], true
+}
+
+type generator struct {
+	opts    options
+	client  *http.Client
+	dialer  *websocket.Dialer
+	tracker *tracker
+	rooms   []*room
+	runID   string
+
+	// stopping tells readers that closed sockets are expected
+	stopping atomic.Bool
+
+	connected     atomic.Int64
+	connectErrors atomic.Int64
+	disconnects   atomic.Int64
+	messages      atomic.Int64
+	reactions     atomic.Int64
+	requestErrors atomic.Int64
+	skipped       atomic.Int64
+
+	// mu guards the counts by HTTP status
+	mu             sync.Mutex
+	httpErrors     map[int]int64
+	connectRejects map[int]int64
+}
+
+// run creates the rooms, connects the subscribers, generates load until the
+// duration elapses or ctx is cancelled and waits for late deliveries
+func run(ctx context.Context, opts options) (report, error) {
+	g := &generator{
+		opts: opts,
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{MaxIdleConnsPerHost: opts.Concurrency},
+		},
+		dialer:         &websocket.Dialer{HandshakeTimeout: 10 * time.Second},
+		tracker:        newTracker(),
+		runID:          fmt.Sprintf("%08x", rand.Uint32()),
+		httpErrors:     make(map[int]int64),
+		connectRejects: make(map[int]int64),
+	}
+	started := time.Now()
+
+	before, err := g.scrapeServer()
+	if err != nil {
+		return report{}, fmt.Errorf("read server metrics: %w", err)
+	}
+
+	log.Printf("Creating %d rooms...", opts.Rooms)
+	for i := range opts.Rooms {
+		var created struct {
+			ID string `json:"id"`
+		}
+		theme := fmt.Sprintf("msgwss-load %s #%d", g.runID, i+1)
+		if !g.call(http.MethodPost, "/api/rooms/", map[string]any{"theme": theme}, &created) {
+			return report{}, fmt.Errorf("failed to create room %d, see the errors above", i+1)
+		}
+		g.rooms = append(g.rooms, &room{id: created.ID})
+	}
+
+	log.Printf("Connecting %d subscribers...", opts.Subscribers)
+	conns := g.connect(ctx)
+	defer func() {
+		g.stopping.Store(true)
+		for _, c := range conns {
+			c.Close()
+		}
+	}()
+
+	log.Printf("Generating %.1f actions/s for %s...", opts.Rate, opts.Duration)
+	loadStarted := time.Now()
+	g.generate(ctx)
+	elapsed := time.Since(loadStarted)
+
+	log.Printf("Waiting up to %s for deliveries...", opts.Drain)
+	deadline := time.Now().Add(opts.Drain)
+	for !g.tracker.done() && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	after, err := g.scrapeServer()
+	if err != nil {
+		return report{}, fmt.Errorf("read server metrics: %w", err)
+	}
+
+	return g.report(started, elapsed, before, after), nil
+}
+
+// connect dials the subscribers round-robin across the rooms, a bounded
+// number at a time
+func (g *generator) connect(ctx context.Context) []*websocket.Conn {
+	base := "ws" + strings.TrimPrefix(g.opts.URL, "http")
+	header := http.Header{}
+	if g.opts.Token != "" {
+		header.Set("Authorization", "Bearer "+g.opts.Token)
+	}
+
+	var (
+		mu    sync.Mutex
+		conns []*websocket.Conn
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, g.opts.Concurrency)
+	)
+	for i := 0; i < g.opts.Subscribers && ctx.Err() == nil; i++ {
+		r := g.rooms[i%len(g.rooms)]
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			c, resp, err := g.dialer.DialContext(ctx, base+"/subscribe/"+r.id, header)
+			if err != nil {
+				g.connectErrors.Add(1)
+				if resp != nil {
+					g.mu.Lock()
+					g.connectRejects[resp.StatusCode]++
+					g.mu.Unlock()
+				}
+				return
+			}
+
+			g.connected.Add(1)
+			r.subscribers.Add(1)
+			mu.Lock()
+			conns = append(conns, c)
+			mu.Unlock()
+			go g.read(c, r)
+		}()
+	}
+	wg.Wait()
+	return conns
+}
+
+// read feeds the events of one subscriber to the tracker
+func (g *generator) read(c *websocket.Conn, r *room) {
+	defer r.subscribers.Add(-1)
+
+	for {
+		_, data, err := c.ReadMessage()
+		arrived := time.Now()
+		if err != nil {
+			if !g.stopping.Load() {
+				g.disconnects.Add(1)
+			}
+			return
+		}
+
+		var event struct {
+			Kind  string `json:"kind"`
+			Value struct {
+				ID    string `json:"id"`
+				Count int64  `json:"count"`
+			} `json:"value"`
+		}
+		if json.Unmarshal(data, &event) != nil {
+			continue
+		}
+		switch event.Kind {
+		case api.MessageKindMessageCreated:
+			g.tracker.arrive(createdKey(event.Value.ID), arrived)
+		case api.MessageKindMessageRactionIncreased:
+			g.tracker.arrive(reactionKey(event.Value.ID, event.Value.Count), arrived)
+		}
+	}
+}
+
+// generate starts one action per tick until the duration elapses, skipping
+// ticks while too many actions are in flight
+func (g *generator) generate(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, g.opts.Duration)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / g.opts.Rate))
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, g.opts.Concurrency)
+	for i := 0; ; i++ {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+		}
+
+		select {
+		case sem <- struct{}{}:
+		default:
+			g.skipped.Add(1)
+			continue
+		}
+
+		r := g.rooms[i%len(g.rooms)]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			g.act(r)
+		}()
+	}
+}
+
+// act reacts to a message posted earlier in the room or posts a new one
+func (g *generator) act(r *room) {
+	if rand.Float64() < g.opts.ReactionShare {
+		if id, ok := r.randomMessage(); ok {
+			g.react(r, id)
+			return
+		}
+	}
+	g.post(r)
+}
+
+func (g *generator) post(r *room) {
+	subscribers := r.subscribers.Load()
+	sent := time.Now()
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	text := fmt.Sprintf("msgwss-load %s at %s", g.runID, sent.Format(time.RFC3339Nano))
+	if !g.call(http.MethodPost, "/api/rooms/"+r.id+"/messages/", map[string]any{"message": text}, &created) {
+		return
+	}
+
+	g.messages.Add(1)
+	r.addMessage(created.ID)
+	g.tracker.send(createdKey(created.ID), sent, subscribers)
+}
+
+func (g *generator) react(r *room, messageID string) {
+	subscribers := r.subscribers.Load()
+	sent := time.Now()
+
+	var reaction struct {
+		Count int64 `json:"count"`
+	}
+	if !g.call(http.MethodPatch, "/api/rooms/"+r.id+"/messages/"+messageID+"/react", nil, &reaction) {
+		return
+	}
+
+	g.reactions.Add(1)
+	g.tracker.send(reactionKey(messageID, reaction.Count), sent, subscribers)
+}
+
+// call sends a JSON request and decodes the response into out, counting
+// failures instead of returning them
+func (g *generator) call(method, path string, body, out any) bool {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			panic(err)
+		}
+	}
+
+	req, err := http.NewRequest(method, g.opts.URL+path, &payload)
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.opts.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.opts.Token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		g.requestErrors.Add(1)
+		return false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		g.mu.Lock()
+		g.httpErrors[resp.StatusCode]++
+		first := g.httpErrors[resp.StatusCode] == 1
+		g.mu.Unlock()
+		if first {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			log.Printf("%s %s: %s %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+		}
+		return false
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		g.requestErrors.Add(1)
+		return false
+	}
+	return true
+}
+
+// serverCounters are the server side failure counters read from Prometheus
+type serverCounters struct {
+	WriteFailures float64 `json:"websocket_write_failures"`
+	Disconnects   float64 `json:"websocket_disconnects"`
+	ServerErrors  float64 `json:"http_5xx"`
+}
+
+// scrapeServer reads the counters from -metrics-url, returning nil when it is
+// not set
+func (g *generator) scrapeServer() (*serverCounters, error) {
+	if g.opts.MetricsURL == "" {
+		return nil, nil
+	}
+
+	resp, err := g.client.Get(g.opts.MetricsURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", g.opts.MetricsURL, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseServerCounters(string(data)), nil
+}
+
+// parseServerCounters sums the samples of the counters it knows in the
+// Prometheus text format
+func parseServerCounters(text string) *serverCounters {
+	var c serverCounters
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		if i < 0 {
+			continue
+		}
+		var value float64
+		if _, err := fmt.Sscan(line[i+1:], &value); err != nil {
+			continue
+		}
+
+		series := line[:i]
+		switch {
+		case series == "msgwss_websocket_write_failures_total":
+			c.WriteFailures += value
+		case series == "msgwss_websocket_disconnects_total":
+			c.Disconnects += value
+		case strings.HasPrefix(series, "msgwss_http_requests_total{") && strings.Contains(series, `status="5`):
+			c.ServerErrors += value
+		}
+	}
+	return &c
+}
@@ -0,0 +1,140 @@
+// Command msgwss-load measures how msgwss fans events out to WebSocket
+// subscribers. It creates rooms, connects simulated subscribers spread across
+// them, posts messages and reactions at a fixed rate and reports end-to-end
+// delivery latency percentiles, dropped deliveries and errors, as text and
+// optionally as JSON so that runs can be compared over time.
+//
+// Every request comes from the same IP, so run the server with rate limits
+// off or the generator mostly measures 429s:
+//
+//	msgwss --store=memory --rate-limit-messages=off --rate-limit-reactions=off --rate-limit-subscribe=off
+//	msgwss-load -rooms 10 -subscribers 1000 -rate 50 -duration 1m -json run.json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+type options struct {
+	URL           string
+	Rooms         int
+	Subscribers   int
+	Rate          float64
+	ReactionShare float64
+	Duration      time.Duration
+	Drain         time.Duration
+	Concurrency   int
+	Label         string
+	Token         string
+	MetricsURL    string
+	JSONOut       string
+}
+
+func parseFlags(args []string) (options, error) {
+	var o options
+	fs := flag.NewFlagSet("msgwss-load", flag.ContinueOnError)
+	fs.StringVar(&o.URL, "url", "http://localhost:8080", "base URL of the msgwss server")
+	fs.IntVar(&o.Rooms, "rooms", 10, "rooms to create")
+	fs.IntVar(&o.Subscribers, "subscribers", 100, "WebSocket subscribers, spread evenly across the rooms")
+	fs.Float64Var(&o.Rate, "rate", 20, "actions per second across every room")
+	fs.Float64Var(&o.ReactionShare, "reactions", 0.5, "share of actions that react to a message instead of posting one, 0 to 1")
+	fs.DurationVar(&o.Duration, "duration", 30*time.Second, "how long to generate load")
+	fs.DurationVar(&o.Drain, "drain", 5*time.Second, "how long to wait for deliveries after the last action")
+	fs.IntVar(&o.Concurrency, "concurrency", 64, "maximum actions in flight; ticks beyond it are skipped")
+	fs.StringVar(&o.Label, "label", "", "free-form label stored in the report, e.g. a commit or host")
+	fs.StringVar(&o.Token, "token", "", "bearer JWT or API key sent with every request (default guest)")
+	fs.StringVar(&o.MetricsURL, "metrics-url", "", "server Prometheus endpoint to read write failures from, e.g. http://localhost:9090/metrics")
+	fs.StringVar(&o.JSONOut, "json", "", "write the JSON report to this file, - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return options{}, err
+	}
+
+	var errs []error
+	if u, err := url.Parse(o.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("-url must be an absolute http or https URL, got %q", o.URL))
+	}
+	if o.Rooms < 1 {
+		errs = append(errs, errors.New("-rooms must be at least 1"))
+	}
+	if o.Subscribers < 0 {
+		errs = append(errs, errors.New("-subscribers cannot be negative"))
+	}
+	if o.Rate <= 0 {
+		errs = append(errs, errors.New("-rate must be positive"))
+	}
+	if o.ReactionShare < 0 || o.ReactionShare > 1 {
+		errs = append(errs, errors.New("-reactions must be between 0 and 1"))
+	}
+	if o.Duration <= 0 {
+		errs = append(errs, errors.New("-duration must be positive"))
+	}
+	if o.Drain < 0 {
+		errs = append(errs, errors.New("-drain cannot be negative"))
+	}
+	if o.Concurrency < 1 {
+		errs = append(errs, errors.New("-concurrency must be at least 1"))
+	}
+	return o, errors.Join(errs...)
+}
+
+func main() {
+	opts, err := parseFlags(os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatalf("Invalid flags:\n%v", err)
+	}
+
+	// The first signal stops the load and still prints the report, a second
+	// one kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	rep, err := run(ctx, opts)
+	if err != nil {
+		log.Fatalf("Load test failed: %v", err)
+	}
+
+	var text io.Writer = os.Stdout
+	if opts.JSONOut == "-" {
+		text = os.Stderr
+	}
+	rep.writeText(text)
+
+	if opts.JSONOut != "" {
+		if err := writeJSON(opts.JSONOut, rep); err != nil {
+			log.Fatalf("Failed to write the JSON report: %v", err)
+		}
+	}
+}
+
+func writeJSON(path string, rep report) error {
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rep)
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// report is the outcome of a run. Its JSON form is meant to be kept and
+// compared with later runs.
+type report struct {
+	Label           string     `json:"label,omitempty"`
+	RunID           string     `json:"run_id"`
+	StartedAt       time.Time  `json:"started_at"`
+	DurationSeconds float64    `json:"duration_seconds"`
+	Options         runOptions `json:"options"`
+
+	Subscribers subscriberStats `json:"subscribers"`
+	Actions     actionStats     `json:"actions"`
+	Deliveries  deliveryStats   `json:"deliveries"`
+	LatencyMS   latencyStats    `json:"latency_ms"`
+
+	// Server holds the growth of the server counters during the run, when
+	// -metrics-url is set
+	Server *serverCounters `json:"server,omitempty"`
+}
+
+// runOptions are the flags that shape the load, without credentials
+type runOptions struct {
+	URL             string  `json:"url"`
+	Rooms           int     `json:"rooms"`
+	Subscribers     int     `json:"subscribers"`
+	Rate            float64 `json:"rate"`
+	ReactionShare   float64 `json:"reaction_share"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	DrainSeconds    float64 `json:"drain_seconds"`
+	Concurrency     int     `json:"concurrency"`
+}
+
+type subscriberStats struct {
+	Connected     int64 `json:"connected"`
+	ConnectErrors int64 `json:"connect_errors"`
+	// Rejected counts failed handshakes by HTTP status
+	Rejected     map[string]int64 `json:"rejected,omitempty"`
+	Disconnected int64            `json:"disconnected"`
+}
+
+type actionStats struct {
+	Messages      int64   `json:"messages"`
+	Reactions     int64   `json:"reactions"`
+	PerSecond     float64 `json:"per_second"`
+	RequestErrors int64   `json:"request_errors"`
+	// HTTPErrors counts responses by status code
+	HTTPErrors map[string]int64 `json:"http_errors,omitempty"`
+	Skipped    int64            `json:"skipped"`
+}
+
+type deliveryStats struct {
+	Expected  int64   `json:"expected"`
+	Delivered int64   `json:"delivered"`
+	Dropped   int64   `json:"dropped"`
+	DropRate  float64 `json:"drop_rate"`
+	// Unmatched counts events that no action of this run explains
+	Unmatched int64 `json:"unmatched"`
+}
+
+type latencyStats struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	P999 float64 `json:"p999"`
+	Max  float64 `json:"max"`
+}
+
+func (g *generator) report(started time.Time, elapsed time.Duration, before, after *serverCounters) report {
+	expected, latencies, unmatched := g.tracker.result()
+	delivered := int64(len(latencies))
+
+	rep := report{
+		Label:           g.opts.Label,
+		RunID:           g.runID,
+		StartedAt:       started.UTC(),
+		DurationSeconds: elapsed.Seconds(),
+		Options: runOptions{
+			URL:             g.opts.URL,
+			Rooms:           g.opts.Rooms,
+			Subscribers:     g.opts.Subscribers,
+			Rate:            g.opts.Rate,
+			ReactionShare:   g.opts.ReactionShare,
+			DurationSeconds: g.opts.Duration.Seconds(),
+			DrainSeconds:    g.opts.Drain.Seconds(),
+			Concurrency:     g.opts.Concurrency,
+		},
+		Subscribers: subscriberStats{
+			Connected:     g.connected.Load(),
+			ConnectErrors: g.connectErrors.Load(),
+			Disconnected:  g.disconnects.Load(),
+		},
+		Actions: actionStats{
+			Messages:      g.messages.Load(),
+			Reactions:     g.reactions.Load(),
+			RequestErrors: g.requestErrors.Load(),
+			Skipped:       g.skipped.Load(),
+		},
+		Deliveries: deliveryStats{
+			Expected:  expected,
+			Delivered: delivered,
+			Dropped:   max(expected-delivered, 0),
+			Unmatched: unmatched,
+		},
+		LatencyMS: newLatencyStats(latencies),
+	}
+
+	if elapsed > 0 {
+		rep.Actions.PerSecond = float64(rep.Actions.Messages+rep.Actions.Reactions) / elapsed.Seconds()
+	}
+	if expected > 0 {
+		rep.Deliveries.DropRate = float64(rep.Deliveries.Dropped) / float64(expected)
+	}
+
+	g.mu.Lock()
+	rep.Subscribers.Rejected = byStatus(g.connectRejects)
+	rep.Actions.HTTPErrors = byStatus(g.httpErrors)
+	g.mu.Unlock()
+
+	if before != nil && after != nil {
+		rep.Server = &serverCounters{
+			WriteFailures: after.WriteFailures - before.WriteFailures,
+			Disconnects:   after.Disconnects - before.Disconnects,
+			ServerErrors:  after.ServerErrors - before.ServerErrors,
+		}
+	}
+	return rep
+}
+
+func byStatus(counts map[int]int64) map[string]int64 {
+	if len(counts) == 0 {
+		return nil
+	}
+	m := make(map[string]int64, len(counts))
+	for status, n := range counts {
+		m[strconv.Itoa(status)] = n
+	}
+	return m
+}
+
+func newLatencyStats(latencies []time.Duration) latencyStats {
+	if len(latencies) == 0 {
+		return latencyStats{}
+	}
+	slices.Sort(latencies)
+
+	var sum time.Duration
+	for _, l := range latencies {
+		sum += l
+	}
+	return latencyStats{
+		Min:  ms(latencies[0]),
+		Mean: ms(sum / time.Duration(len(latencies))),
+		P50:  ms(percentile(latencies, 50)),
+		P90:  ms(percentile(latencies, 90)),
+		P95:  ms(percentile(latencies, 95)),
+		P99:  ms(percentile(latencies, 99)),
+		P999: ms(percentile(latencies, 99.9)),
+		Max:  ms(latencies[len(latencies)-1]),
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p/100*float64(len(sorted)) + 0.999999)
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func (r report) writeText(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+
+	title := "msgwss load report " + r.RunID
+	if r.Label != "" {
+		title += " (" + r.Label + ")"
+	}
+	fmt.Fprintln(tw, title)
+	fmt.Fprintf(tw, "load\t%d subscribers in %d rooms, %.1f actions/s for %.1fs\n",
+		r.Options.Subscribers, r.Options.Rooms, r.Options.Rate, r.DurationSeconds)
+
+	s := r.Subscribers
+	fmt.Fprintf(tw, "subscribers\t%d connected, %d failed%s, %d disconnected early\n",
+		s.Connected, s.ConnectErrors, formatStatuses(s.Rejected), s.Disconnected)
+
+	a := r.Actions
+	fmt.Fprintf(tw, "actions\t%d messages, %d reactions (%.1f/s), %d skipped\n", a.Messages, a.Reactions, a.PerSecond, a.Skipped)
+	fmt.Fprintf(tw, "errors\t%d requests failed%s\n", a.RequestErrors, formatStatuses(a.HTTPErrors))
+
+	d := r.Deliveries
+	fmt.Fprintf(tw, "deliveries\t%d expected, %d delivered, %d dropped (%.3f%%), %d unmatched\n",
+		d.Expected, d.Delivered, d.Dropped, d.DropRate*100, d.Unmatched)
+
+	l := r.LatencyMS
+	fmt.Fprintf(tw, "latency ms\tp50 %.2f  p90 %.2f  p95 %.2f  p99 %.2f  p99.9 %.2f  max %.2f  mean %.2f\n",
+		l.P50, l.P90, l.P95, l.P99, l.P999, l.Max, l.Mean)
+
+	if r.Server != nil {
+		fmt.Fprintf(tw, "server\t%.0f websocket write failures, %.0f disconnects, %.0f 5xx responses\n",
+			r.Server.WriteFailures, r.Server.Disconnects, r.Server.ServerErrors)
+	}
+}
+
+// formatStatuses renders counts by status as ", HTTP 429: 3, HTTP 500: 1"
+func formatStatuses(counts map[string]int64) string {
+	statuses := make([]string, 0, len(counts))
+	for status := range counts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	var b strings.Builder
+	for _, status := range statuses {
+		fmt.Fprintf(&b, ", HTTP %s: %d", status, counts[status])
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+func createdKey(messageID string) string {
+	return "created:" + messageID
+}
+
+func reactionKey(messageID string, count int64) string {
+	return "reaction:" + messageID + ":" + strconv.FormatInt(count, 10)
+}
+
+// tracker matches the events read by subscribers with the actions that caused
+// them. The event key is only known once the request returns, and broadcasts
+// may beat the response, so early arrivals wait for their action.
+type tracker struct {
+	mu        sync.Mutex
+	sent      map[string]time.Time
+	early     map[string][]time.Time
+	latencies []time.Duration
+	expected  int64
+}
+
+func newTracker() *tracker {
+	return &tracker{
+		sent:  make(map[string]time.Time),
+		early: make(map[string][]time.Time),
+	}
+}
+
+// send records an action started at the given time, whose event should reach
+// every subscriber connected to the room at that moment
+func (t *tracker) send(key string, at time.Time, subscribers int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sent[key] = at
+	t.expected += subscribers
+	for _, arrived := range t.early[key] {
+		t.latencies = append(t.latencies, arrived.Sub(at))
+	}
+	delete(t.early, key)
+}
+
+// arrive records one subscriber reading the event
+func (t *tracker) arrive(key string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if sent, ok := t.sent[key]; ok {
+		t.latencies = append(t.latencies, at.Sub(sent))
+		return
+	}
+	t.early[key] = append(t.early[key], at)
+}
+
+// done reports whether every expected delivery arrived
+func (t *tracker) done() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return int64(len(t.latencies)) >= t.expected
+}
+
+// result returns the expected deliveries, the latency of each delivery seen
+// and how many events matched no action of this run
+func (t *tracker) result() (expected int64, latencies []time.Duration, unmatched int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, arrivals := range t.early {
+		unmatched += int64(len(arrivals))
+	}
+	return t.expected, append([]time.Duration(nil), t.latencies...), unmatched
+}
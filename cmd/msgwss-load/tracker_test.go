@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	tr := newTracker()
+	start := time.Now()
+
+	// One subscriber reads the event before the request returns
+	tr.arrive(createdKey("m1"), start.Add(2*time.Millisecond))
+	tr.send(createdKey("m1"), start, 2)
+	tr.arrive(createdKey("m1"), start.Add(3*time.Millisecond))
+
+	tr.send(reactionKey("m1", 1), start, 2)
+	tr.arrive(reactionKey("m1", 1), start.Add(time.Millisecond))
+	if tr.done() {
+		t.Error("done() = true with a delivery missing")
+	}
+
+	tr.arrive(reactionKey("m2", 1), start)
+
+	expected, latencies, unmatched := tr.result()
+	if expected != 4 || unmatched != 1 {
+		t.Errorf("result() expected = %d, unmatched = %d, want 4 and 1", expected, unmatched)
+	}
+	want := []time.Duration{2 * time.Millisecond, 3 * time.Millisecond, time.Millisecond}
+	if len(latencies) != len(want) {
+		t.Fatalf("latencies = %v, want %v", latencies, want)
+	}
+	for i := range want {
+		if latencies[i] != want[i] {
+			t.Errorf("latencies = %v, want %v", latencies, want)
+			break
+		}
+	}
+}
+
+func TestLatencyStats(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	got := newLatencyStats(latencies)
+	want := latencyStats{Min: 1, Mean: 50.5, P50: 50, P90: 90, P95: 95, P99: 99, P999: 100, Max: 100}
+	if got != want {
+		t.Errorf("newLatencyStats() = %+v, want %+v", got, want)
+	}
+
+	if got := newLatencyStats(nil); got != (latencyStats{}) {
+		t.Errorf("newLatencyStats(nil) = %+v, want zero", got)
+	}
+}
+
+func TestParseServerCounters(t *testing.T) {
+	text := `# HELP msgwss_websocket_write_failures_total Broadcast writes that failed and dropped the subscriber.
+# TYPE msgwss_websocket_write_failures_total counter
+msgwss_websocket_write_failures_total 3
+msgwss_websocket_disconnects_total 12
+msgwss_http_requests_total{method="POST",route="/api/rooms/",status="200"} 40
+msgwss_http_requests_total{method="POST",route="/api/rooms/{room_id}/messages/",status="500"} 2
+msgwss_http_requests_total{method="GET",route="/subscribe/{room_id}",status="503"} 1
+`
+	got := parseServerCounters(text)
+	want := serverCounters{WriteFailures: 3, Disconnects: 12, ServerErrors: 3}
+	if *got != want {
+		t.Errorf("parseServerCounters() = %+v, want %+v", *got, want)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/config"
+	"github.com/felipemacedo1/go-msg-wss/internal/export"
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+	"github.com/felipemacedo1/go-msg-wss/internal/store/pgstore"
+	"github.com/felipemacedo1/go-msg-wss/internal/store/sqlitestore"
+
+	"github.com/google/uuid"
+)
+
+// exportFlags are the flags of `msgwss export`, parsed together with the
+// configuration flags
+type exportFlags struct {
+	room, format, out        *string
+	includeHidden, anonymize *bool
+}
+
+func newExportFlags(fs *flag.FlagSet) exportFlags {
+	return exportFlags{
+		room:          fs.String("room", "", "ID of the room to export (required)"),
+		format:        fs.String("format", "json", "output format: json, csv or markdown"),
+		out:           fs.String("out", "", "output file (default stdout)"),
+		includeHidden: fs.Bool("include-hidden", false, "include messages hidden by moderators"),
+		anonymize:     fs.Bool("anonymize", false, "replace author names and IDs with pseudonyms"),
+	}
+}
+
+// runExport implements `msgwss export`, writing a room transcript to a file
+// or to stdout
+func runExport(ctx context.Context, cfg config.Config, f exportFlags) error {
+	roomID, err := uuid.Parse(*f.room)
+	if err != nil {
+		return fmt.Errorf("invalid -room %q: %w", *f.room, err)
+	}
+
+	format, err := export.ParseFormat(*f.format)
+	if err != nil {
+		return err
+	}
+
+	q, closeStore, err := openExportStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	room, err := q.GetRoom(ctx, roomID)
+	if err != nil {
+		return fmt.Errorf("get room: %w", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *f.out != "" {
+		file, err := os.Create(*f.out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	return export.Room(ctx, w, q, room, export.Options{
+		Format:        format,
+		IncludeHidden: *f.includeHidden,
+		Anonymize:     *f.anonymize,
+	})
+}
+
+// openExportStore opens the configured SQLite or Postgres store, returning a
+// function that closes it
+func openExportStore(ctx context.Context, cfg config.Config) (store.Store, func(), error) {
+	if cfg.Store == "sqlite" {
+		db, err := sqlitestore.Open(ctx, cfg.SQLitePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open the SQLite database: %w", err)
+		}
+		return sqlitestore.NewStore(db), func() { db.Close() }, nil
+	}
+
+	pool, err := newPool(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect to database: %w", err)
+	}
+	return pgstore.NewStore(pool), pool.Close, nil
+}
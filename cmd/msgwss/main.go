@@ -3,65 +3,256 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/felipemacedo1/go-msg-wss/internal/api"
+	"github.com/felipemacedo1/go-msg-wss/internal/config"
+	"github.com/felipemacedo1/go-msg-wss/internal/health"
+	"github.com/felipemacedo1/go-msg-wss/internal/metrics"
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+	"github.com/felipemacedo1/go-msg-wss/internal/store/memstore"
 	"github.com/felipemacedo1/go-msg-wss/internal/store/pgstore"
+	"github.com/felipemacedo1/go-msg-wss/internal/store/sqlitestore"
+	"github.com/felipemacedo1/go-msg-wss/internal/tracing"
 
 	"log"
 
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/joho/godotenv"
 )
 
 func main() {
-	log.Println("Loading environment variables...")
-	if err := godotenv.Load(); err != nil {
-		log.Fatalf("Failed to load .env file: %v", err)
+	ctx := context.Background()
+
+	args := os.Args[1:]
+	command := "serve"
+	if len(args) > 0 && (args[0] == "export" || args[0] == "config" || args[0] == "migrate") {
+		command, args = args[0], args[1:]
 	}
 
-	ctx := context.Background()
+	switch command {
+	case "export", "migrate":
+		fs := flag.NewFlagSet(command, flag.ContinueOnError)
+		var exportArgs exportFlags
+		if command == "export" {
+			exportArgs = newExportFlags(fs)
+		}
+		cfg, err := loadCommandConfig(command, fs, args)
+		if err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return
+			}
+			log.Fatalf("Invalid configuration:\n%v", err)
+		}
+		if command == "export" {
+			err = runExport(ctx, cfg, exportArgs)
+		} else {
+			err = runMigrate(cfg, fs.Args())
+		}
+		if err != nil {
+			log.Fatalf("%s failed: %v", command, err)
+		}
+		return
+	}
 
-	log.Println("Connecting to the database...")
-	pool, err := pgxpool.New(ctx, fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		os.Getenv("MSGWSS_DATABASE_USER"),
-		os.Getenv("MSGWSS_DATABASE_PASSWORD"),
-		os.Getenv("MSGWSS_DATABASE_HOST"),
-		os.Getenv("MSGWSS_DATABASE_PORT"),
-		os.Getenv("MSGWSS_DATABASE_NAME"),
-	))
+	cfg, err := config.Load(args)
 	if err != nil {
-		log.Fatalf("Failed to create database connection pool: %v", err)
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	if command == "config" {
+		cfg.Print(os.Stdout)
+		return
 	}
 
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: cfg.LogLevel})))
+	for _, v := range cfg.Effective() {
+		slog.Debug("config", "key", v.Key, "value", v.Value, "source", v.Source)
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
 	defer func() {
-		log.Println("Closing database connection pool...")
-		pool.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Printf("Failed to flush traces: %v", err)
+		}
 	}()
 
-	log.Println("Pinging the database...")
-	if err := pool.Ping(ctx); err != nil {
-		log.Fatalf("Failed to ping the database: %v", err)
+	var (
+		st     store.Store
+		checks []health.Check
+	)
+	switch cfg.Store {
+	case "memory":
+		log.Println("Using the in-memory store, nothing is kept across restarts")
+		st = memstore.New()
+	case "sqlite":
+		log.Printf("Opening the SQLite database %s...", cfg.SQLitePath)
+		db, err := sqlitestore.Open(ctx, cfg.SQLitePath)
+		if err != nil {
+			log.Fatalf("Failed to open the SQLite database: %v", err)
+		}
+
+		defer func() {
+			log.Println("Closing the SQLite database...")
+			db.Close()
+		}()
+
+		checks = append(checks, health.Check{Name: "database", Run: db.PingContext})
+		st = sqlitestore.NewStore(db)
+	default:
+		log.Println("Connecting to the database...")
+		pool, err := newPool(ctx, cfg.DatabaseURL)
+		if err != nil {
+			log.Fatalf("Failed to create database connection pool: %v", err)
+		}
+
+		defer func() {
+			log.Println("Closing database connection pool...")
+			pool.Close()
+		}()
+
+		log.Println("Pinging the database...")
+		if err := pool.Ping(ctx); err != nil {
+			log.Fatalf("Failed to ping the database: %v", err)
+		}
+
+		if cfg.MigrateOnStart {
+			log.Println("Applying database migrations...")
+			if err := migrateOnStart(cfg.DatabaseURL); err != nil {
+				log.Fatalf("Failed to apply migrations: %v", err)
+			}
+		}
+		if err := pgstore.CheckSchemaVersion(ctx, pool); err != nil {
+			log.Fatalf("Refusing to start: %v (run `msgwss migrate up` or set MSGWSS_MIGRATE_ON_START=true)", err)
+		}
+
+		checks = append(checks,
+			health.Check{Name: "database", Run: pool.Ping},
+			health.Check{Name: "migrations", Run: func(ctx context.Context) error {
+				return pgstore.CheckSchemaVersion(ctx, pool)
+			}},
+		)
+		metrics.RegisterPool(pool)
+		st = pgstore.NewStore(pool)
 	}
 
-	handler := api.NewHandler(pgstore.New(pool))
+	checker := health.New(cfg.ReadyTimeout, checks...)
+	handler := api.NewHandler(st, cfg)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", checker.Liveness)
+	mux.HandleFunc("GET /readyz", checker.Readiness)
+	mux.Handle("/", handler)
+
+	srv := &http.Server{
+		Addr:              net.JoinHostPort("0.0.0.0", strconv.Itoa(cfg.Port)),
+		Handler:           mux,
+		ReadHeaderTimeout: cfg.ReadTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+
+	var metricsSrv *http.Server
+	if cfg.MetricsAddr != "off" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("GET /metrics", metrics.Handler())
+		metricsSrv = &http.Server{
+			Addr:              cfg.MetricsAddr,
+			Handler:           metricsMux,
+			ReadHeaderTimeout: cfg.ReadTimeout,
+		}
+
+		log.Printf("Starting metrics server on %s...", cfg.MetricsAddr)
+		go func() {
+			if err := metricsSrv.ListenAndServe(); err != nil {
+				if !errors.Is(err, http.ErrServerClosed) {
+					log.Fatalf("Metrics server error: %v", err)
+				}
+			}
+		}()
+	}
 
-	log.Println("Starting HTTP server on port 8080...")
+	log.Printf("Starting HTTP server on %s...", srv.Addr)
 	go func() {
-		if err := http.ListenAndServe("0.0.0.0:8080", handler); err != nil {
+		if err := srv.ListenAndServe(); err != nil {
 			if !errors.Is(err, http.ErrServerClosed) {
 				log.Fatalf("HTTP server error: %v", err)
 			}
 		}
 	}()
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	log.Println("Application is running. Press Ctrl+C to stop.")
-	<-quit
+	<-ctx.Done()
+	stop()
+
 	log.Println("Shutting down application...")
+	// The probes are served by srv, so they must see draining before it
+	// stops accepting connections. A second signal exits right away.
+	if cfg.DrainDelay > 0 {
+		log.Printf("Draining for %s...", cfg.DrainDelay)
+	}
+	checker.Drain(context.Background(), cfg.DrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// In-flight requests finish first since they may still queue broadcasts.
+	// Hijacked WebSocket connections are not tracked by the server.
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP server shutdown: %v", err)
+	}
+	if err := handler.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Handler shutdown: %v", err)
+	}
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Metrics server shutdown: %v", err)
+		}
+	}
+}
+
+// loadCommandConfig loads the configuration of the export and migrate
+// commands from args, which may hold the configuration flags before those of
+// the command. Migrations are only run by hand on Postgres, SQLite applies
+// its own when opened; export reads any store that outlives the server.
+func loadCommandConfig(command string, fs *flag.FlagSet, args []string) (config.Config, error) {
+	cfg, err := config.LoadStore(fs, args)
+	if err != nil {
+		return config.Config{}, err
+	}
+	switch {
+	case command == "migrate" && cfg.Store != "postgres":
+		return config.Config{}, fmt.Errorf("%s needs MSGWSS_STORE=postgres", command)
+	case cfg.Store == "memory":
+		return config.Config{}, fmt.Errorf("%s cannot read the memory store of another process", command)
+	}
+	return cfg, nil
+}
+
+func newPool(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ConnConfig.Tracer = tracing.QueryTracer{}
+
+	return pgxpool.NewWithConfig(ctx, cfg)
 }
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+	"github.com/felipemacedo1/go-msg-wss/internal/store/sqlitestore"
+)
+
+func TestLoadCommandConfigWithoutJWT(t *testing.T) {
+	// Only the database is configured, as in `make migrate-up` from a clean shell
+	for _, env := range os.Environ() {
+		key, _, _ := strings.Cut(env, "=")
+		if strings.HasPrefix(key, "MSGWSS_") {
+			t.Setenv(key, "")
+		}
+	}
+	t.Setenv("DATABASE_URL", "postgres://u:p@localhost:1/db")
+
+	for _, command := range []string{"migrate", "export"} {
+		cfg, err := loadCommandConfig(command, newFlagSet(command), nil)
+		if err != nil {
+			t.Fatalf("loadCommandConfig(%s) error = %v", command, err)
+		}
+		if cfg.DatabaseURL != "postgres://u:p@localhost:1/db" {
+			t.Errorf("DatabaseURL = %q", cfg.DatabaseURL)
+		}
+	}
+
+	t.Setenv("MSGWSS_STORE", "memory")
+	if _, err := loadCommandConfig("migrate", newFlagSet("migrate"), nil); err == nil || !strings.Contains(err.Error(), "MSGWSS_STORE=postgres") {
+		t.Errorf("loadCommandConfig(migrate) with the memory store error = %v", err)
+	}
+	if _, err := loadCommandConfig("export", newFlagSet("export"), nil); err == nil {
+		t.Error("loadCommandConfig(export) with the memory store error = nil")
+	}
+
+	t.Setenv("MSGWSS_STORE", "sqlite")
+	if _, err := loadCommandConfig("migrate", newFlagSet("migrate"), nil); err == nil {
+		t.Error("loadCommandConfig(migrate) with the sqlite store error = nil")
+	}
+	if _, err := loadCommandConfig("export", newFlagSet("export"), nil); err != nil {
+		t.Errorf("loadCommandConfig(export) with the sqlite store error = %v", err)
+	}
+}
+
+func newFlagSet(command string) *flag.FlagSet {
+	return flag.NewFlagSet(command, flag.ContinueOnError)
+}
+
+func TestLoadCommandConfigFlags(t *testing.T) {
+	for _, env := range os.Environ() {
+		key, _, _ := strings.Cut(env, "=")
+		if strings.HasPrefix(key, "MSGWSS_") || key == "DATABASE_URL" {
+			t.Setenv(key, "")
+		}
+	}
+	t.Setenv("DATABASE_URL", "postgres://env@localhost:1/db")
+	envFile := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(envFile, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := newFlagSet("migrate")
+	cfg, err := loadCommandConfig("migrate", fs, []string{"-env-file", envFile, "-database-url", "postgres://flag@localhost:1/db", "down", "-all"})
+	if err != nil {
+		t.Fatalf("loadCommandConfig(migrate) error = %v", err)
+	}
+	if cfg.DatabaseURL != "postgres://flag@localhost:1/db" {
+		t.Errorf("DatabaseURL = %q, want the flag", cfg.DatabaseURL)
+	}
+	if got := fs.Args(); !slices.Equal(got, []string{"down", "-all"}) {
+		t.Errorf("migrate arguments = %q, want the command left over", got)
+	}
+
+	// Export mixes its own flags with the configuration flags
+	fs = newFlagSet("export")
+	flags := newExportFlags(fs)
+	if _, err := loadCommandConfig("export", fs, []string{"-room", "42", "-env-file", envFile, "-store", "memory"}); err == nil {
+		t.Error("loadCommandConfig(export) with -store memory error = nil")
+	}
+	if *flags.room != "42" {
+		t.Errorf("-room = %q, want 42", *flags.room)
+	}
+}
+
+func TestExportSQLite(t *testing.T) {
+	ctx := context.Background()
+	sqlitePath := filepath.Join(t.TempDir(), "msgwss.db")
+
+	db, err := sqlitestore.Open(ctx, sqlitePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := sqlitestore.NewStore(db)
+	roomID, err := q.InsertRoom(ctx, store.InsertRoomParams{Theme: "Go", AnonymousPolicy: "named"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.InsertMessage(ctx, store.InsertMessageParams{RoomID: roomID, Message: "Is Go fast?", AuthorID: "guest", AuthorName: "Guest"}); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	out := filepath.Join(t.TempDir(), "room.md")
+	fs := newFlagSet("export")
+	flags := newExportFlags(fs)
+	args := []string{"-store", "sqlite", "-sqlite-path", sqlitePath, "-room", roomID.String(), "-format", "markdown", "-out", out}
+	cfg, err := loadCommandConfig("export", fs, args)
+	if err != nil {
+		t.Fatalf("loadCommandConfig(export) error = %v", err)
+	}
+	if err := runExport(ctx, cfg, flags); err != nil {
+		t.Fatalf("runExport() error = %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Is Go fast?") {
+		t.Errorf("transcript = %q, want the message", data)
+	}
+}
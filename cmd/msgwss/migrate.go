@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/config"
+	"github.com/felipemacedo1/go-msg-wss/internal/store/pgstore"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+const migrateUsage = `usage: msgwss migrate [configuration flags] <command>
+
+commands:
+  up             apply every pending migration
+  down [-all]    revert the last migration, or all of them
+  status         print the current and the expected schema version
+  force VERSION  set the version without running migrations, to recover
+                 from a dirty schema after fixing it by hand`
+
+// runMigrate implements `msgwss migrate`, applying the migrations embedded in
+// the binary
+func runMigrate(cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	m, err := pgstore.NewMigrator(cfg.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch command, args := args[0], args[1:]; command {
+	case "up":
+		return ignoreNoChange(m.Up())
+
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		all := fs.Bool("all", false, "revert every migration")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *all {
+			return ignoreNoChange(m.Down())
+		}
+		return ignoreNoChange(m.Steps(-1))
+
+	case "status":
+		return printMigrationStatus(os.Stdout, m)
+
+	case "force":
+		if len(args) != 1 {
+			return errors.New("usage: msgwss migrate force VERSION")
+		}
+		version, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", args[0], err)
+		}
+		return m.Force(version)
+
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", command, migrateUsage)
+	}
+}
+
+// migrateOnStart applies pending migrations before the server starts
+func migrateOnStart(databaseURL string) error {
+	m, err := pgstore.NewMigrator(databaseURL)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	return ignoreNoChange(m.Up())
+}
+
+func printMigrationStatus(w io.Writer, m *migrate.Migrate) error {
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Fprintf(w, "version: none\nexpected: %d\n", pgstore.SchemaVersion())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "version: %d\nexpected: %d\n", version, pgstore.SchemaVersion())
+	if dirty {
+		fmt.Fprintln(w, "dirty: true (fix the failed migration, then run `msgwss migrate force VERSION`)")
+	}
+	return nil
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+)
+
+// Room policies for anonymous messages
+const (
+	anonymousPolicyNamed   = "named"
+	anonymousPolicyAllowed = "allowed"
+	anonymousPolicyOnly    = "only"
+)
+
+const (
+	anonymousAuthorID   = "anonymous"
+	anonymousAuthorName = "Anonymous"
+)
+
+func validAnonymousPolicy(policy string) bool {
+	switch policy {
+	case anonymousPolicyNamed, anonymousPolicyAllowed, anonymousPolicyOnly:
+		return true
+	}
+	return false
+}
+
+// resolveAnonymous applies the room policy to the flag a participant sent,
+// reporting false when the room does not accept anonymous messages
+func resolveAnonymous(room store.Room, requested bool) (anonymous, ok bool) {
+	switch room.AnonymousPolicy {
+	case anonymousPolicyOnly:
+		return true, true
+	case anonymousPolicyAllowed:
+		return requested, true
+	default:
+		return false, !requested
+	}
+}
+
+// maskMessage hides the author of anonymous messages. The real author stays
+// in the database for moderation.
+func maskMessage(m store.Message) store.Message {
+	if m.Anonymous {
+		m.AuthorID = anonymousAuthorID
+		m.AuthorName = anonymousAuthorName
+		m.UserID = nil
+	}
+	return m
+}
+
+type anonymousPolicyRequest struct {
+	AnonymousPolicy string `json:"anonymous_policy"`
+}
+
+func (h apiHandler) handleSetRoomAnonymousPolicy(w http.ResponseWriter, r *http.Request) {
+	before, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.requireModerator(w, r) {
+		return
+	}
+
+	var body anonymousPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, errInvalidJSON)
+		return
+	}
+	if !validAnonymousPolicy(body.AnonymousPolicy) {
+		writeError(w, r, errInvalidParameter.with("anonymous_policy"))
+		return
+	}
+
+	room, err := h.q.SetRoomAnonymousPolicy(r.Context(), store.SetRoomAnonymousPolicyParams{
+		ID:              roomID,
+		AnonymousPolicy: body.AnonymousPolicy,
+	})
+	if err != nil {
+		slog.Error("failed to update room anonymous policy", "error", err, "room_id", rawRoomID)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	slog.Info("room anonymous policy changed", "room_id", rawRoomID, "anonymous_policy", room.AnonymousPolicy)
+
+	h.audit(r, auditEntry{
+		Action:     auditRoomAnonymousPolicy,
+		RoomID:     roomID,
+		TargetType: "room",
+		TargetID:   rawRoomID,
+		Before:     map[string]string{"anonymous_policy": before.AnonymousPolicy},
+		After:      map[string]string{"anonymous_policy": room.AnonymousPolicy},
+	})
+
+	sendJSON(w, room)
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+)
+
+func TestResolveAnonymous(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        string
+		requested     bool
+		wantAnonymous bool
+		wantOK        bool
+	}{
+		{"Named room, named message", anonymousPolicyNamed, false, false, true},
+		{"Named room, anonymous message", anonymousPolicyNamed, true, false, false},
+		{"Allowed room, named message", anonymousPolicyAllowed, false, false, true},
+		{"Allowed room, anonymous message", anonymousPolicyAllowed, true, true, true},
+		{"Anonymous only room", anonymousPolicyOnly, false, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anonymous, ok := resolveAnonymous(store.Room{AnonymousPolicy: tt.policy}, tt.requested)
+			if anonymous != tt.wantAnonymous || ok != tt.wantOK {
+				t.Errorf("resolveAnonymous() = (%v, %v), want (%v, %v)", anonymous, ok, tt.wantAnonymous, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestMaskMessage(t *testing.T) {
+	named := store.Message{AuthorID: "alice", AuthorName: "Alice"}
+	if got := maskMessage(named); got.AuthorID != "alice" || got.AuthorName != "Alice" {
+		t.Errorf("maskMessage() masked a named message: %+v", got)
+	}
+
+	anonymous := store.Message{AuthorID: "alice", AuthorName: "Alice", Anonymous: true}
+	if got := maskMessage(anonymous); got.AuthorID != anonymousAuthorID || got.AuthorName != anonymousAuthorName {
+		t.Errorf("maskMessage() = %+v, want the anonymous author", got)
+	}
+}
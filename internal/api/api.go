@@ -3,72 +3,189 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
-	"strings"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/felipemacedo1/go-msg-wss/internal/store/pgstore"
+	"github.com/felipemacedo1/go-msg-wss/internal/auth"
+	"github.com/felipemacedo1/go-msg-wss/internal/config"
+	"github.com/felipemacedo1/go-msg-wss/internal/i18n"
+	"github.com/felipemacedo1/go-msg-wss/internal/metrics"
+	"github.com/felipemacedo1/go-msg-wss/internal/ratelimit"
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+	"github.com/felipemacedo1/go-msg-wss/internal/tracing"
+	"github.com/felipemacedo1/go-msg-wss/internal/webhook"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type apiHandler struct {
-	q           *pgstore.Queries
+	q           store.Store
 	r           *chi.Mux
 	upgrader    websocket.Upgrader
-	subscribers map[string]map[*websocket.Conn]context.CancelFunc
+	subscribers map[string]map[*websocket.Conn]*subscriber
 	mu          *sync.Mutex
+	limiter     ratelimit.Limiter
+	limits      map[string]ratelimit.Limit
+	webhooks    *webhook.Dispatcher
+	verifier    *auth.Verifier
+	guests      *guestIssuer
+	users       *userSync
+
+	// pending tracks broadcasts and background loops for Shutdown
+	pending  *sync.WaitGroup
+	draining *atomic.Bool
+	stop     context.CancelFunc
+}
+
+// subscriber is a websocket connection listening to a room
+type subscriber struct {
+	cancel   context.CancelFunc
+	authorID string
+	ip       string
+	// locale and requestID come from the handshake, for error frames
+	locale    i18n.Locale
+	requestID string
 }
 
 func (h apiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.r.ServeHTTP(w, r)
 }
 
-func NewHandler(q *pgstore.Queries) http.Handler {
+// Handler serves the HTTP API and owns the WebSocket subscribers
+type Handler interface {
+	http.Handler
+	// Shutdown rejects new subscribers, waits for pending broadcasts and
+	// closes every socket with a reconnect hint. Stop the HTTP server first.
+	Shutdown(ctx context.Context) error
+}
+
+func NewHandler(q store.Store, cfg config.Config) Handler {
+	background, stop := context.WithCancel(context.Background())
 	a := apiHandler{
 		q:           q,
-		upgrader:    websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }},
-		subscribers: make(map[string]map[*websocket.Conn]context.CancelFunc),
+		upgrader:    websocket.Upgrader{CheckOrigin: checkOrigin(cfg.AllowedOrigins), Error: handshakeError},
+		subscribers: make(map[string]map[*websocket.Conn]*subscriber),
 		mu:          &sync.Mutex{},
+		users:       newUserSync(),
+		pending:     &sync.WaitGroup{},
+		draining:    &atomic.Bool{},
+		stop:        stop,
 	}
+	a.verifier = loadVerifier(cfg.JWT)
+	a.guests = newGuestIssuer(cfg.JWT, cfg.GuestTokenTTL)
+	a.limiter, a.limits = loadRateLimits(q, cfg)
+
+	if cfg.TopQuestionsInterval > 0 {
+		a.goTracked(func() { a.watchTopQuestions(background, cfg.TopQuestionsInterval, cfg.TopQuestionsCount) })
+	}
+
+	a.webhooks = webhook.NewDispatcher(q, cfg.Webhook)
+	a.goTracked(func() { a.webhooks.Run(background) })
 
 	r := chi.NewRouter()
-	r.Use(middleware.RequestID, middleware.Recoverer, middleware.Logger)
+	r.Use(middleware.RequestID, tracing.Middleware, metrics.Middleware, middleware.Recoverer, middleware.Logger)
 
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*"},
+		AllowedOrigins:   cfg.AllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", apiKeyHeader},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 
-	r.Get("/subscribe/{room_id}", a.handleSubscribe)
+	r.Use(a.authenticateJWT, a.authenticateAPIKey, a.syncUser)
+
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) { writeError(w, r, errRouteNotFound) })
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) { writeError(w, r, errMethodNotAllowed) })
+
+	r.Get("/openapi.json", handleOpenAPI)
+	r.Get("/asyncapi.json", handleAsyncAPI)
+
+	r.With(a.authorize(permRead), a.rateLimit(rateLimitSubscribe)).Get("/subscribe/{room_id}", a.handleSubscribe)
 
 	r.Route("/api", func(r chi.Router) {
+		r.Route("/auth/guest", func(r chi.Router) {
+			r.With(a.rateLimit(rateLimitGuests)).Post("/", a.handleIssueGuestToken)
+			r.With(a.rateLimit(rateLimitGuests)).Post("/refresh", a.handleRefreshGuestToken)
+		})
+
+		r.Route("/apikeys", func(r chi.Router) {
+			r.Post("/", a.handleCreateAPIKey)
+			r.Get("/", a.handleGetAPIKeys)
+			r.Delete("/{key_id}", a.handleRevokeAPIKey)
+		})
+
+		r.With(a.authorizeAcrossRooms(permRead)).Get("/users/{user_id}", a.handleGetUser)
+		r.Get("/audit", a.handleGetAuditLog)
+
+		r.Route("/webhooks", func(r chi.Router) {
+			r.Post("/", a.handleCreateWebhook)
+			r.Get("/", a.handleGetWebhooks)
+			r.Delete("/{webhook_id}", a.handleDeleteWebhook)
+
+			r.Get("/deliveries", a.handleGetWebhookDeliveries)
+			r.Post("/deliveries/{delivery_id}/redeliver", a.handleRedeliverWebhook)
+		})
+
 		r.Route("/rooms", func(r chi.Router) {
-			r.Post("/", a.handleCreateRoom)
-			r.Get("/", a.handleGetRooms)
+			r.With(a.authorize(permModerate)).Post("/", a.handleCreateRoom)
+			r.With(a.authorize(permRead)).Get("/", a.handleGetRooms)
 
 			r.Route("/{room_id}", func(r chi.Router) {
-				r.Get("/", a.handleGetRoom)
+				r.With(a.authorize(permRead)).Get("/", a.handleGetRoom)
+				r.With(a.authorize(permRead)).Get("/export", a.handleExportRoom)
+				r.With(a.authorize(permModerate)).Patch("/guests", a.handleSetRoomAllowGuests)
+				r.With(a.authorize(permModerate)).Patch("/anonymous-policy", a.handleSetRoomAnonymousPolicy)
+
+				r.Route("/moderation", func(r chi.Router) {
+					r.Use(a.authorize(permModerate))
+
+					r.Post("/kick", a.handleKickParticipant)
+
+					r.Post("/bans", a.handleCreateSanction(sanctionKindBan))
+					r.Get("/bans", a.handleGetSanctions(sanctionKindBan))
+					r.Delete("/bans/{sanction_id}", a.handleRevokeSanction(sanctionKindBan))
+
+					r.Post("/mutes", a.handleCreateSanction(sanctionKindMute))
+					r.Get("/mutes", a.handleGetSanctions(sanctionKindMute))
+					r.Delete("/mutes/{sanction_id}", a.handleRevokeSanction(sanctionKindMute))
+				})
+
+				r.Route("/polls", func(r chi.Router) {
+					r.With(a.authorize(permModerate)).Post("/", a.handleCreatePoll)
+					r.With(a.authorize(permRead)).Get("/", a.handleGetRoomPolls)
+
+					r.Route("/{poll_id}", func(r chi.Router) {
+						r.With(a.authorize(permRead)).Get("/", a.handleGetPoll)
+						r.With(a.authorize(permPost), a.rateLimit(rateLimitReactions)).Post("/votes", a.handleVotePoll)
+						r.With(a.authorize(permModerate)).Patch("/close", a.handleClosePoll)
+					})
+				})
 
 				r.Route("/messages", func(r chi.Router) {
-					r.Post("/", a.handleCreateRoomMessage)
-					r.Get("/", a.handleGetRoomMessages)
+					r.With(a.authorize(permPost), a.rateLimit(rateLimitMessages)).Post("/", a.handleCreateRoomMessage)
+					r.With(a.authorize(permRead)).Get("/", a.handleGetRoomMessages)
 
 					r.Route("/{message_id}", func(r chi.Router) {
-						r.Get("/", a.handleGetRoomMessage)
-						r.Patch("/react", a.handleReactToMessage)
-						r.Delete("/react", a.handleRemoveReactFromMessage)
-						r.Patch("/answer", a.handleMarkMessageAsAnswered)
+						r.With(a.authorize(permRead)).Get("/", a.handleGetRoomMessage)
+						r.With(a.authorize(permPost), a.rateLimit(rateLimitReactions)).Patch("/react", a.handleReactToMessage)
+						r.With(a.authorize(permPost), a.rateLimit(rateLimitReactions)).Delete("/react", a.handleRemoveReactFromMessage)
+						r.With(a.authorize(permAnswer)).Patch("/answer", a.handleMarkMessageAsAnswered)
+						r.With(a.authorize(permModerate)).Patch("/hide", a.handleSetMessageHidden(true))
+						r.With(a.authorize(permModerate)).Patch("/unhide", a.handleSetMessageHidden(false))
 					})
 				})
 			})
@@ -84,6 +201,21 @@ const (
 	MessageKindMessageRactionIncreased = "message_reaction_increased"
 	MessageKindMessageRactionDecreased = "message_reaction_decreased"
 	MessageKindMessageAnswered         = "message_answered"
+	MessageKindMessageHidden           = "message_hidden"
+	MessageKindMessageUnhidden         = "message_unhidden"
+	MessageKindPollCreated             = "poll_created"
+	MessageKindPollVotesUpdated        = "poll_votes_updated"
+	MessageKindPollClosed              = "poll_closed"
+	MessageKindTopQuestionsChanged     = "top_questions_changed"
+)
+
+// Frames exchanged with a single connection rather than broadcast to the
+// room: the keepalive that browsers, which cannot send ping control frames,
+// use instead, and the errors whose value is an errorBody
+const (
+	messageKindClientPing = "client_ping"
+	messageKindServerPong = "server_pong"
+	messageKindError      = "error"
 )
 
 type MessageMessageReactionIncreased struct {
@@ -100,10 +232,22 @@ type MessageMessageAnswered struct {
 	ID string `json:"id"`
 }
 
-type MessageMessageCreated struct {
-	ID      string `json:"id"`
-	Message string `json:"message"`
-	Author  string `json:"author"`
+type MessageMessageHidden struct {
+	ID string `json:"id"`
+}
+
+type MessageMessageUnhidden struct {
+	ID string `json:"id"`
+}
+
+type MessagePollVotesUpdated struct {
+	ID         string              `json:"id"`
+	Options    []pollOptionResults `json:"options"`
+	TotalVotes int64               `json:"total_votes"`
+}
+
+type MessageTopQuestionsChanged struct {
+	Messages []messageResponse `json:"messages"`
 }
 
 type Message struct {
@@ -112,7 +256,21 @@ type Message struct {
 	RoomID string `json:"room_id"`
 }
 
-func (h apiHandler) notifyClients(msg Message) {
+// wsWriteWait bounds every data frame written to a subscriber. The writes
+// hold h.mu, so a client that stops reading must not stall the other rooms.
+var wsWriteWait = 10 * time.Second
+
+// notifyClients broadcasts msg to the room. ctx carries the span of the
+// request that caused the event and must not be cancelled with it.
+func (h apiHandler) notifyClients(ctx context.Context, msg Message) {
+	ctx, span := otel.Tracer(tracing.TracerName).Start(ctx, "notifyClients", trace.WithAttributes(
+		attribute.String("msgwss.room_id", msg.RoomID),
+		attribute.String("msgwss.kind", msg.Kind),
+	))
+	defer span.End()
+
+	h.enqueueWebhooks(ctx, msg)
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
@@ -125,13 +283,22 @@ func (h apiHandler) notifyClients(msg Message) {
 	}
 
 	slog.Info("notifyClients: sending to subscribers", "room_id", msg.RoomID, "subscriber_count", len(subscribers))
+	start := time.Now()
 	var failedConns []*websocket.Conn
-	for conn, cancel := range subscribers {
+	for conn, sub := range subscribers {
+		_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
 		if err := conn.WriteJSON(msg); err != nil {
 			failedConns = append(failedConns, conn)
-			cancel()
+			sub.cancel()
 		}
 	}
+	metrics.BroadcastFanout.Observe(float64(len(subscribers)))
+	metrics.BroadcastDuration.Observe(time.Since(start).Seconds())
+	metrics.WSWriteFailures.Add(float64(len(failedConns)))
+	span.SetAttributes(
+		attribute.Int("msgwss.fanout", len(subscribers)),
+		attribute.Int("msgwss.write_failures", len(failedConns)),
+	)
 	for _, conn := range failedConns {
 		delete(subscribers, conn)
 	}
@@ -140,18 +307,30 @@ func (h apiHandler) notifyClients(msg Message) {
 func (h apiHandler) handleSubscribe(w http.ResponseWriter, r *http.Request) {
 	slog.Info("handleSubscribe called", "url", r.URL.Path)
 
-	_, rawRoomID, _, ok := h.readRoom(w, r)
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
 	if !ok {
 		slog.Warn("handleSubscribe: invalid room", "room_id", chi.URLParam(r, "room_id"))
 		return
 	}
 
+	if !h.checkSanctions(w, r, roomID, sanctionKindBan) {
+		return
+	}
+
+	if h.draining.Load() {
+		w.Header().Set("Retry-After", strconv.Itoa(int(shutdownReconnectAfter.Seconds())))
+		writeError(w, r, errShuttingDown)
+		return
+	}
+
+	authorID, _ := authorFromRequest(r)
+
 	slog.Info("handleSubscribe: upgrading to websocket", "room_id", rawRoomID)
 
 	c, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
+		// The upgrader has already answered, see handshakeError
 		slog.Warn("failed to upgrade connection", "error", err)
-		http.Error(w, "failed to upgrade to ws connection", http.StatusBadRequest)
 		return
 	}
 
@@ -166,12 +345,20 @@ func (h apiHandler) handleSubscribe(w http.ResponseWriter, r *http.Request) {
 	// Add client to subscribers
 	h.mu.Lock()
 	if _, ok := h.subscribers[rawRoomID]; !ok {
-		h.subscribers[rawRoomID] = make(map[*websocket.Conn]context.CancelFunc)
+		h.subscribers[rawRoomID] = make(map[*websocket.Conn]*subscriber)
 		slog.Info("created subscriber map for room", "room_id", rawRoomID)
 	}
 	slog.Info("new client connected", "room_id", rawRoomID, "client_ip", r.RemoteAddr)
-	h.subscribers[rawRoomID][c] = cancel
+	sub := &subscriber{
+		cancel:    cancel,
+		authorID:  authorID,
+		ip:        clientIP(r),
+		locale:    requestLocale(r),
+		requestID: middleware.GetReqID(r.Context()),
+	}
+	h.subscribers[rawRoomID][c] = sub
 	slog.Info("subscriber added", "room_id", rawRoomID, "total_subscribers", len(h.subscribers[rawRoomID]))
+	metrics.WSConnections.WithLabelValues(rawRoomID).Inc()
 	h.mu.Unlock()
 
 	// Cleanup when function exits
@@ -179,10 +366,13 @@ func (h apiHandler) handleSubscribe(w http.ResponseWriter, r *http.Request) {
 		h.mu.Lock()
 		delete(h.subscribers[rawRoomID], c)
 		slog.Info("client disconnected", "room_id", rawRoomID, "client_ip", r.RemoteAddr, "remaining_subscribers", len(h.subscribers[rawRoomID]))
+		metrics.WSConnections.WithLabelValues(rawRoomID).Dec()
+		metrics.WSDisconnects.Inc()
 
 		// Clean up empty room maps
 		if len(h.subscribers[rawRoomID]) == 0 {
 			delete(h.subscribers, rawRoomID)
+			metrics.WSConnections.DeleteLabelValues(rawRoomID)
 			slog.Info("removed empty room from subscribers", "room_id", rawRoomID)
 		}
 		h.mu.Unlock()
@@ -199,105 +389,137 @@ func (h apiHandler) handleSubscribe(w http.ResponseWriter, r *http.Request) {
 	ticker := time.NewTicker(54 * time.Second) // Slightly less than read deadline
 	defer ticker.Stop()
 
-	// Ping routine
+	// Ping routine. Control frames may be written concurrently with the
+	// broadcasts, which hold h.mu while they write.
 	go func() {
-
 		for {
 			select {
 			case <-ticker.C:
-				if err := c.WriteMessage(websocket.PingMessage, nil); err != nil {
+				if err := c.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
 					slog.Warn("ping failed", "room_id", rawRoomID, "error", err)
 					return
 				}
 			case <-ctx.Done():
 				return
 			}
-			_, msgBytes, err := c.ReadMessage()
-			if err != nil {
-				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-					slog.Warn("websocket error", "room_id", rawRoomID, "error", err)
-				}
-				return
-			}
-			// Optional: tratar mensagens tipo 'ping'
-			var raw map[string]interface{}
-			if err := json.Unmarshal(msgBytes, &raw); err == nil {
-				if kind, ok := raw["kind"].(string); ok && kind == "client_ping" {
-					slog.Info("received client ping", "room_id", rawRoomID, "ip", r.RemoteAddr)
-					_ = c.WriteMessage(websocket.TextMessage, []byte(`{"kind":"server_pong"}`))
-					continue
-				}
-			}
 		}
 	}()
 
-	// Message reading loop (keeps connection alive)
+	// Message reading loop: keeps the read deadline moving and answers the
+	// application level client_ping that browsers use instead of control
+	// frames
 	for {
 		select {
 		case <-ctx.Done():
 			slog.Info("context cancelled, closing connection", "room_id", rawRoomID)
 			return
 		default:
-			// Read messages (even if we don't process them, this keeps connection alive)
-			_, _, err := c.ReadMessage()
+			_, msgBytes, err := c.ReadMessage()
 			if err != nil {
 				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 					slog.Warn("websocket error", "room_id", rawRoomID, "error", err)
 				}
 				return
 			}
-			// You can add message processing logic here if needed
+			c.SetReadDeadline(time.Now().Add(60 * time.Second))
+
+			var frame struct {
+				Kind string `json:"kind"`
+			}
+			h.mu.Lock()
+			_ = c.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if json.Unmarshal(msgBytes, &frame) == nil && frame.Kind == messageKindClientPing {
+				err = c.WriteMessage(websocket.TextMessage, []byte(`{"kind":"`+messageKindServerPong+`"}`))
+			} else {
+				err = sendErrorFrame(c, sub, rawRoomID, errInvalidFrame)
+			}
+			h.mu.Unlock()
+			if err != nil {
+				slog.Warn("reply failed", "room_id", rawRoomID, "error", err)
+				return
+			}
 		}
 	}
 }
 
+type createRoomRequest struct {
+	Theme           string `json:"theme"`
+	AllowGuests     *bool  `json:"allow_guests,omitempty"`
+	AnonymousPolicy string `json:"anonymous_policy,omitempty"`
+}
+
+type idResponse struct {
+	ID string `json:"id"`
+}
+
 func (h apiHandler) handleCreateRoom(w http.ResponseWriter, r *http.Request) {
 	slog.Info("handleCreateRoom called", "url", r.URL.Path)
 
-	type _body struct {
-		Theme string `json:"theme"`
-	}
-	var body _body
+	var body createRoomRequest
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		slog.Warn("handleCreateRoom: invalid json", "error", err)
-		http.Error(w, "invalid json", http.StatusBadRequest)
+		writeError(w, r, errInvalidJSON)
 		return
 	}
 
 	slog.Info("handleCreateRoom: received", "theme", body.Theme)
 
-	if strings.TrimSpace(body.Theme) == "" {
-		slog.Warn("handleCreateRoom: empty theme")
-		http.Error(w, "theme cannot be empty", http.StatusBadRequest)
+	if err := ValidateTheme(body.Theme); err != nil {
+		slog.Warn("handleCreateRoom: invalid theme", "error", err)
+		writeError(w, r, err)
+		return
+	}
+
+	// Rooms are open to guests unless told otherwise
+	allowGuests := body.AllowGuests == nil || *body.AllowGuests
+
+	if body.AnonymousPolicy == "" {
+		body.AnonymousPolicy = anonymousPolicyNamed
+	}
+	if !validAnonymousPolicy(body.AnonymousPolicy) {
+		writeError(w, r, errInvalidParameter.with("anonymous_policy"))
 		return
 	}
 
-	roomID, err := h.q.InsertRoom(r.Context(), body.Theme)
+	roomID, err := h.q.InsertRoom(r.Context(), store.InsertRoomParams{
+		Theme:           body.Theme,
+		AllowGuests:     allowGuests,
+		AnonymousPolicy: body.AnonymousPolicy,
+	})
 	if err != nil {
 		slog.Error("failed to insert room", "error", err)
-		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		writeError(w, r, errInternal)
 		return
 	}
 
 	slog.Info("handleCreateRoom: room created", "room_id", roomID.String())
 
-	type response struct {
-		ID string `json:"id"`
-	}
+	h.audit(r, auditEntry{
+		Action:     auditRoomCreate,
+		RoomID:     roomID,
+		TargetType: "room",
+		TargetID:   roomID.String(),
+		After: store.Room{
+			ID:              roomID,
+			Theme:           body.Theme,
+			AllowGuests:     allowGuests,
+			AnonymousPolicy: body.AnonymousPolicy,
+		},
+	})
 
-	sendJSON(w, response{ID: roomID.String()})
+	sendJSON(w, idResponse{ID: roomID.String()})
 }
 
 func (h apiHandler) handleGetRooms(w http.ResponseWriter, r *http.Request) {
 	rooms, err := h.q.GetRooms(r.Context())
 	if err != nil {
-		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		writeError(w, r, errInternal)
 		slog.Error("failed to get rooms", "error", err)
 		return
 	}
 
 	if rooms == nil {
-		rooms = []pgstore.Room{}
+		rooms = []store.Room{}
 	}
 
 	sendJSON(w, rooms)
@@ -312,48 +534,63 @@ func (h apiHandler) handleGetRoom(w http.ResponseWriter, r *http.Request) {
 	sendJSON(w, room)
 }
 
+type createMessageRequest struct {
+	Message   string `json:"message"`
+	Anonymous bool   `json:"anonymous,omitempty"`
+}
+
 func (h apiHandler) handleCreateRoomMessage(w http.ResponseWriter, r *http.Request) {
-	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	room, rawRoomID, roomID, ok := h.readRoom(w, r)
 	if !ok {
 		return
 	}
 
 	slog.Info("handleCreateRoomMessage called", "room_id", rawRoomID)
 
-	type _body struct {
-		Message string `json:"message"`
+	if !h.checkSanctions(w, r, roomID, sanctionKindBan, sanctionKindMute) {
+		return
 	}
-	var body _body
+
+	var body createMessageRequest
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		http.Error(w, "invalid json", http.StatusBadRequest)
+		writeError(w, r, errInvalidJSON)
 		slog.Warn("handleCreateRoomMessage: invalid json", "error", err)
 		return
 	}
 
+	if err := ValidateMessage(body.Message); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	anonymous, ok := resolveAnonymous(room, body.Anonymous)
+	if !ok {
+		writeError(w, r, errAnonymousNotAllowed)
+		return
+	}
+
 	slog.Info("handleCreateRoomMessage: received message", "message", body.Message, "room_id", rawRoomID)
 
-	claims := extractClaimsFromJWT(r)
-	authorID := "guest"
-	authorName := "Guest"
+	authorID, authorName := authorFromRequest(r)
+	_, isBot := apiKeyFromContext(r.Context())
 
-	if claims != nil {
-		if sub, ok := claims["sub"].(string); ok {
-			authorID = sub
-		}
-		if name, ok := claims["name"].(string); ok {
-			authorName = name
-		}
+	var userID *string
+	if p, ok := profileFromRequest(r); ok {
+		userID = &p.ID
 	}
 
-	messageID, err := h.q.InsertMessage(r.Context(), pgstore.InsertMessageParams{
+	messageID, err := h.q.InsertMessage(r.Context(), store.InsertMessageParams{
 		RoomID:     roomID,
 		Message:    body.Message,
 		AuthorID:   authorID,
 		AuthorName: authorName,
+		IsBot:      isBot,
+		Anonymous:  anonymous,
+		UserID:     userID,
 	})
 	if err != nil {
 		slog.Error("failed to insert message", "error", err, "room_id", rawRoomID)
-		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		writeError(w, r, errInternal)
 		return
 	}
 
@@ -361,26 +598,32 @@ func (h apiHandler) handleCreateRoomMessage(w http.ResponseWriter, r *http.Reque
 	fullMessage, err := h.q.GetMessage(r.Context(), messageID.ID)
 	if err != nil {
 		slog.Error("failed to retrieve full message", "error", err, "message_id", messageID)
-		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		writeError(w, r, errInternal)
 		return
 	}
 
 	slog.Info("handleCreateRoomMessage: message created", "message_id", messageID, "room_id", rawRoomID)
+	metrics.MessagesCreated.Inc()
 
-	type response struct {
-		ID string `json:"id"`
-	}
+	h.audit(r, auditEntry{
+		Action:     auditMessageCreate,
+		RoomID:     roomID,
+		TargetType: "message",
+		TargetID:   fullMessage.ID.String(),
+		After:      fullMessage,
+	})
 
-	sendJSON(w, response{ID: messageID.ID.String()})
+	sendJSON(w, idResponse{ID: messageID.ID.String()})
 
-	go func() {
+	ctx := context.WithoutCancel(r.Context())
+	h.goTracked(func() {
 		slog.Info("handleCreateRoomMessage: notifying clients", "room_id", rawRoomID)
-		h.notifyClients(Message{
+		h.notifyClients(ctx, Message{
 			Kind:   MessageKindMessageCreated,
 			RoomID: rawRoomID,
-			Value:  fullMessage, // Enviar a mensagem completa do banco
+			Value:  h.presentMessage(ctx, fullMessage), // Enviar a mensagem completa do banco
 		})
-	}()
+	})
 }
 
 func (h apiHandler) handleGetRoomMessages(w http.ResponseWriter, r *http.Request) {
@@ -389,22 +632,64 @@ func (h apiHandler) handleGetRoomMessages(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	messages, err := h.q.GetRoomMessages(r.Context(), roomID)
+	var messages []store.Message
+	var err error
+
+	switch r.URL.Query().Get("sort") {
+	case "":
+		includeHidden := false
+		if raw := r.URL.Query().Get("include_hidden"); raw != "" {
+			includeHidden, err = strconv.ParseBool(raw)
+			if err != nil {
+				writeError(w, r, errInvalidParameter.with("include_hidden"))
+				return
+			}
+		}
+		// Moderators list hidden messages to find the ones to unhide
+		if includeHidden && !h.requireModerator(w, r) {
+			return
+		}
+
+		messages, err = h.q.GetRoomMessages(r.Context(), store.GetRoomMessagesParams{RoomID: roomID, IncludeHidden: includeHidden})
+	case "hot":
+		includeAnswered := false
+		if raw := r.URL.Query().Get("include_answered"); raw != "" {
+			includeAnswered, err = strconv.ParseBool(raw)
+			if err != nil {
+				writeError(w, r, errInvalidParameter.with("include_answered"))
+				return
+			}
+		}
+
+		var limit int64
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			limit, err = strconv.ParseInt(raw, 10, 32)
+			if err != nil || limit < 0 {
+				writeError(w, r, errInvalidParameter.with("limit"))
+				return
+			}
+		}
+
+		messages, err = h.getHotMessages(r.Context(), roomID, includeAnswered, int32(limit))
+	default:
+		writeError(w, r, errInvalidParameter.with("sort"))
+		return
+	}
 	if err != nil {
-		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		writeError(w, r, errInternal)
 		slog.Error("failed to get room messages", "error", err)
 		return
 	}
 
 	if messages == nil {
-		messages = []pgstore.Message{}
+		messages = []store.Message{}
 	}
 
-	sendJSON(w, messages)
+	sendJSON(w, h.presentMessages(r.Context(), messages))
 }
 
 func (h apiHandler) handleGetRoomMessage(w http.ResponseWriter, r *http.Request) {
-	_, _, _, ok := h.readRoom(w, r)
+	_, _, roomID, ok := h.readRoom(w, r)
 	if !ok {
 		return
 	}
@@ -412,115 +697,214 @@ func (h apiHandler) handleGetRoomMessage(w http.ResponseWriter, r *http.Request)
 	messageID := chi.URLParam(r, "message_id")
 	parsedMessageID, err := uuid.Parse(messageID)
 	if err != nil {
-		http.Error(w, "invalid message id", http.StatusBadRequest)
+		writeError(w, r, errInvalidMessageID)
 		return
 	}
 
 	msg, err := h.q.GetMessage(r.Context(), parsedMessageID)
 	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, r, errMessageNotFound)
+			return
+		}
 		slog.Error("failed to get message", "message_id", parsedMessageID, "error", err)
-		http.Error(w, "failed to get message", http.StatusInternalServerError)
+		writeError(w, r, errInternal)
+		return
+	}
+	// Hidden messages stay visible to moderators only, like with include_hidden
+	// on the listing
+	if msg.RoomID != roomID || (msg.Hidden && !isModerator(r)) {
+		writeError(w, r, errMessageNotFound)
 		return
 	}
 
-	sendJSON(w, msg)
+	sendJSON(w, h.presentMessage(r.Context(), msg))
+}
+
+type reactionResponse struct {
+	Count int64 `json:"count"`
 }
 
 func (h apiHandler) handleReactToMessage(w http.ResponseWriter, r *http.Request) {
-	_, rawRoomID, _, ok := h.readRoom(w, r)
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
 	if !ok {
 		return
 	}
 
+	if !h.checkSanctions(w, r, roomID, sanctionKindBan, sanctionKindMute) {
+		return
+	}
+
 	rawID := chi.URLParam(r, "message_id")
 	id, err := uuid.Parse(rawID)
 	if err != nil {
-		http.Error(w, "invalid message id", http.StatusBadRequest)
+		writeError(w, r, errInvalidMessageID)
 		return
 	}
 
-	count, err := h.q.ReactToMessage(r.Context(), id)
+	msg, err := h.q.GetMessage(r.Context(), id)
 	if err != nil {
-		http.Error(w, "something went wrong", http.StatusInternalServerError)
-		slog.Error("failed to react to message", "error", err)
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, r, errMessageNotFound)
+			return
+		}
+		writeError(w, r, errInternal)
+		slog.Error("failed to get message", "error", err)
+		return
+	}
+	if msg.RoomID != roomID {
+		writeError(w, r, errMessageNotFound)
 		return
 	}
 
-	type response struct {
-		Count int64 `json:"count"`
+	reaction, err := h.q.ReactToMessage(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, r, errMessageNotFound)
+			return
+		}
+		writeError(w, r, errInternal)
+		slog.Error("failed to react to message", "error", err)
+		return
 	}
+	metrics.ReactionsCreated.Inc()
+
+	h.audit(r, auditEntry{
+		Action:     auditMessageReact,
+		RoomID:     roomID,
+		TargetType: "message",
+		TargetID:   rawID,
+		Before:     map[string]int64{"reaction_count": reaction.PreviousCount},
+		After:      map[string]int64{"reaction_count": reaction.ReactionCount},
+	})
 
-	sendJSON(w, response{Count: count})
+	sendJSON(w, reactionResponse{Count: reaction.ReactionCount})
 
-	go h.notifyClients(Message{
+	h.broadcast(context.WithoutCancel(r.Context()), Message{
 		Kind:   MessageKindMessageRactionIncreased,
 		RoomID: rawRoomID,
 		Value: MessageMessageReactionIncreased{
 			ID:    rawID,
-			Count: count,
+			Count: reaction.ReactionCount,
 		},
 	})
 }
 
 func (h apiHandler) handleRemoveReactFromMessage(w http.ResponseWriter, r *http.Request) {
-	_, rawRoomID, _, ok := h.readRoom(w, r)
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
 	if !ok {
 		return
 	}
 
+	if !h.checkSanctions(w, r, roomID, sanctionKindBan, sanctionKindMute) {
+		return
+	}
+
 	rawID := chi.URLParam(r, "message_id")
 	id, err := uuid.Parse(rawID)
 	if err != nil {
-		http.Error(w, "invalid message id", http.StatusBadRequest)
+		writeError(w, r, errInvalidMessageID)
 		return
 	}
 
-	count, err := h.q.RemoveReactionFromMessage(r.Context(), id)
+	msg, err := h.q.GetMessage(r.Context(), id)
 	if err != nil {
-		http.Error(w, "something went wrong", http.StatusInternalServerError)
-		slog.Error("failed to react to message", "error", err)
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, r, errMessageNotFound)
+			return
+		}
+		writeError(w, r, errInternal)
+		slog.Error("failed to get message", "error", err)
+		return
+	}
+	if msg.RoomID != roomID {
+		writeError(w, r, errMessageNotFound)
 		return
 	}
 
-	type response struct {
-		Count int64 `json:"count"`
+	reaction, err := h.q.RemoveReactionFromMessage(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, r, errMessageNotFound)
+			return
+		}
+		writeError(w, r, errInternal)
+		slog.Error("failed to react to message", "error", err)
+		return
 	}
 
-	sendJSON(w, response{Count: count})
+	h.audit(r, auditEntry{
+		Action:     auditMessageUnreact,
+		RoomID:     roomID,
+		TargetType: "message",
+		TargetID:   rawID,
+		Before:     map[string]int64{"reaction_count": reaction.PreviousCount},
+		After:      map[string]int64{"reaction_count": reaction.ReactionCount},
+	})
+
+	sendJSON(w, reactionResponse{Count: reaction.ReactionCount})
 
-	go h.notifyClients(Message{
+	h.broadcast(context.WithoutCancel(r.Context()), Message{
 		Kind:   MessageKindMessageRactionDecreased,
 		RoomID: rawRoomID,
 		Value: MessageMessageReactionDecreased{
 			ID:    rawID,
-			Count: count,
+			Count: reaction.ReactionCount,
 		},
 	})
 }
 
 func (h apiHandler) handleMarkMessageAsAnswered(w http.ResponseWriter, r *http.Request) {
-	_, rawRoomID, _, ok := h.readRoom(w, r)
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
 	if !ok {
 		return
 	}
 
+	if !h.checkSanctions(w, r, roomID, sanctionKindBan) {
+		return
+	}
+
 	rawID := chi.URLParam(r, "message_id")
 	id, err := uuid.Parse(rawID)
 	if err != nil {
-		http.Error(w, "invalid message id", http.StatusBadRequest)
+		writeError(w, r, errInvalidMessageID)
+		return
+	}
+
+	before, err := h.q.GetMessage(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, r, errMessageNotFound)
+			return
+		}
+		writeError(w, r, errInternal)
+		slog.Error("failed to get message", "error", err)
+		return
+	}
+	if before.RoomID != roomID {
+		writeError(w, r, errMessageNotFound)
 		return
 	}
 
 	err = h.q.MarkMessageAsAnswered(r.Context(), id)
 	if err != nil {
-		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		writeError(w, r, errInternal)
 		slog.Error("failed to react to message", "error", err)
 		return
 	}
 
+	h.audit(r, auditEntry{
+		Action:     auditMessageAnswer,
+		RoomID:     roomID,
+		TargetType: "message",
+		TargetID:   rawID,
+		Before:     map[string]bool{"answered": before.Answered},
+		After:      map[string]bool{"answered": true},
+	})
+
 	w.WriteHeader(http.StatusOK)
 
-	go h.notifyClients(Message{
+	h.broadcast(context.WithoutCancel(r.Context()), Message{
 		Kind:   MessageKindMessageAnswered,
 		RoomID: rawRoomID,
 		Value: MessageMessageAnswered{
@@ -528,3 +912,66 @@ func (h apiHandler) handleMarkMessageAsAnswered(w http.ResponseWriter, r *http.R
 		},
 	})
 }
+
+func (h apiHandler) handleSetMessageHidden(hidden bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, rawRoomID, roomID, ok := h.readRoom(w, r)
+		if !ok {
+			return
+		}
+
+		if !h.requireModerator(w, r) {
+			return
+		}
+
+		rawID := chi.URLParam(r, "message_id")
+		id, err := uuid.Parse(rawID)
+		if err != nil {
+			writeError(w, r, errInvalidMessageID)
+			return
+		}
+
+		before, err := h.q.GetMessage(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				writeError(w, r, errMessageNotFound)
+				return
+			}
+			writeError(w, r, errInternal)
+			slog.Error("failed to get message", "error", err, "message_id", rawID)
+			return
+		}
+		if before.RoomID != roomID {
+			writeError(w, r, errMessageNotFound)
+			return
+		}
+
+		err = h.q.SetMessageHidden(r.Context(), store.SetMessageHiddenParams{ID: id, Hidden: hidden})
+		if err != nil {
+			writeError(w, r, errInternal)
+			slog.Error("failed to set message visibility", "error", err, "message_id", rawID)
+			return
+		}
+
+		action := auditMessageHide
+		if !hidden {
+			action = auditMessageUnhide
+		}
+		h.audit(r, auditEntry{
+			Action:     action,
+			RoomID:     roomID,
+			TargetType: "message",
+			TargetID:   rawID,
+			Before:     map[string]bool{"hidden": before.Hidden},
+			After:      map[string]bool{"hidden": hidden},
+		})
+
+		w.WriteHeader(http.StatusOK)
+
+		msg := Message{Kind: MessageKindMessageHidden, RoomID: rawRoomID, Value: MessageMessageHidden{ID: rawID}}
+		if !hidden {
+			msg = Message{Kind: MessageKindMessageUnhidden, RoomID: rawRoomID, Value: MessageMessageUnhidden{ID: rawID}}
+		}
+		h.broadcast(context.WithoutCancel(r.Context()), msg)
+	}
+}
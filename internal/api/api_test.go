@@ -0,0 +1,131 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/auth"
+	"github.com/felipemacedo1/go-msg-wss/internal/config"
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+	"github.com/felipemacedo1/go-msg-wss/internal/store/memstore"
+	"github.com/felipemacedo1/go-msg-wss/internal/store/storetest"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testJWTSecret = "test-jwt-secret"
+
+// newTestStore returns the Postgres store when storetest.DatabaseURLEnv is
+// set and a fresh in-memory store otherwise
+func newTestStore(t *testing.T) store.Store {
+	t.Helper()
+
+	if q := storetest.Postgres(t); q != nil {
+		return q
+	}
+	return memstore.New()
+}
+
+// testServer keeps the handler around to inspect its subscribers
+type testServer struct {
+	*httptest.Server
+	h apiHandler
+}
+
+// newTestServer serves a handler backed by a fresh test store. Options
+// adjust the configuration before the handler is built.
+func newTestServer(t *testing.T, options ...func(*config.Config)) *testServer {
+	t.Helper()
+
+	return newTestServerWithStore(t, newTestStore(t), options...)
+}
+
+// newTestServerWithStore is newTestServer on the given store
+func newTestServerWithStore(t *testing.T, s store.Store, options ...func(*config.Config)) *testServer {
+	t.Helper()
+
+	cfg := config.Config{
+		AllowedOrigins:   []string{"*"},
+		JWT:              auth.Config{HMACSecret: []byte(testJWTSecret)},
+		GuestTokenTTL:    time.Hour,
+		RateLimitBackend: "memory",
+	}
+	for _, option := range options {
+		option(&cfg)
+	}
+	h := NewHandler(s, cfg)
+	srv := httptest.NewServer(h)
+	t.Cleanup(func() {
+		srv.Close()
+		_ = h.Shutdown(context.Background())
+	})
+	return &testServer{Server: srv, h: h.(apiHandler)}
+}
+
+// waitSubscribers blocks until the room has n subscribers, since sockets are
+// registered after the handshake completes
+func (srv *testServer) waitSubscribers(t *testing.T, roomID string, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		srv.h.mu.Lock()
+		got := len(srv.h.subscribers[roomID])
+		srv.h.mu.Unlock()
+		if got == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("room %s has %d subscribers, want %d", roomID, got, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func testToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// call sends a JSON request and decodes the JSON response into out
+func call(t *testing.T, srv *testServer, token, method, path string, body, out any) int {
+	t.Helper()
+
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, srv.URL+path, &payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decode response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
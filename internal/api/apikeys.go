@@ -0,0 +1,295 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Permissions that can be granted to an API key
+const (
+	permPost     = "post"
+	permAnswer   = "answer"
+	permModerate = "moderate"
+	permRead     = "read"
+)
+
+var apiKeyPermissions = []string{permPost, permAnswer, permModerate, permRead}
+
+const (
+	apiKeyPrefix      = "msgwss_"
+	apiKeyHeader      = "X-API-Key"
+	apiKeyTouchPeriod = time.Minute
+)
+
+type apiKeyContextKey struct{}
+
+// apiKeyFromContext returns the API key that authenticated the request, if any
+func apiKeyFromContext(ctx context.Context) (store.ApiKey, bool) {
+	key, ok := ctx.Value(apiKeyContextKey{}).(store.ApiKey)
+	return key, ok
+}
+
+// hashAPIKey returns the hex encoded SHA-256 of a key. Keys carry 256 bits of
+// randomness, so a fast hash is enough.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// rawAPIKey reads the key from the X-API-Key header or a Bearer token that
+// starts with the key prefix
+func rawAPIKey(r *http.Request) string {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && strings.HasPrefix(token, apiKeyPrefix) {
+		return token
+	}
+	return ""
+}
+
+// authenticateAPIKey resolves API keys alongside the Bearer JWTs handled by
+// extractClaimsFromJWT. Requests with an unknown or revoked key are rejected.
+func (h apiHandler) authenticateAPIKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw := rawAPIKey(r)
+		if raw == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, err := h.q.GetAPIKeyByHash(r.Context(), hashAPIKey(raw))
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				writeError(w, r, errInvalidAPIKey)
+				return
+			}
+			slog.Error("failed to get api key", "error", err)
+			writeError(w, r, errInternal)
+			return
+		}
+
+		if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > apiKeyTouchPeriod {
+			if err := h.q.TouchAPIKey(r.Context(), key.ID); err != nil {
+				slog.Warn("failed to update api key usage", "error", err, "key_id", key.ID)
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key)))
+	})
+}
+
+// authorize rejects API keys that lack the permission or are not scoped to
+// the room in the URL. Other callers are left to the handler's own checks.
+func (h apiHandler) authorize(permission string) func(http.Handler) http.Handler {
+	return h.authorizeKey(permission, true)
+}
+
+// authorizeAcrossRooms is authorize for routes outside a room that filter
+// what they return with canReadRoom, so room-scoped keys are let through
+func (h apiHandler) authorizeAcrossRooms(permission string) func(http.Handler) http.Handler {
+	return h.authorizeKey(permission, false)
+}
+
+func (h apiHandler) authorizeKey(permission string, checkRoom bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := apiKeyFromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !slices.Contains(key.Permissions, permission) {
+				writeError(w, r, errAPIKeyPermission.with(permission))
+				return
+			}
+
+			if checkRoom && len(key.RoomIds) > 0 {
+				roomID, err := uuid.Parse(chi.URLParam(r, "room_id"))
+				// Routes outside a room need an unscoped key
+				if err != nil || !slices.Contains(key.RoomIds, roomID) {
+					writeError(w, r, errAPIKeyRoom)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// canReadRoom reports whether the caller may read the room, applying the
+// scope of its API key or the guest rules of checkGuestAccess
+func canReadRoom(r *http.Request, room store.Room) bool {
+	if key, ok := apiKeyFromContext(r.Context()); ok {
+		return len(key.RoomIds) == 0 || slices.Contains(key.RoomIds, room.ID)
+	}
+	return guestAccessError(r, room) == nil
+}
+
+// apiKeyResponse never exposes the key hash
+type apiKeyResponse struct {
+	store.ApiKey
+	KeyHash string `json:"key_hash,omitempty"`
+	Key     string `json:"key,omitempty"`
+}
+
+func newAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(b), nil
+}
+
+type createAPIKeyRequest struct {
+	Name        string   `json:"name"`
+	RoomIDs     []string `json:"room_ids,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+func (h apiHandler) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	var body createAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, errInvalidJSON)
+		return
+	}
+
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		writeError(w, r, errEmptyName)
+		return
+	}
+
+	if len(body.Permissions) == 0 {
+		writeError(w, r, errNoPermission)
+		return
+	}
+	permissions := make([]string, 0, len(body.Permissions))
+	for _, p := range body.Permissions {
+		if !slices.Contains(apiKeyPermissions, p) {
+			writeError(w, r, errUnknownPermission.with(p))
+			return
+		}
+		if !slices.Contains(permissions, p) {
+			permissions = append(permissions, p)
+		}
+	}
+
+	roomIDs := make([]uuid.UUID, 0, len(body.RoomIDs))
+	for _, raw := range body.RoomIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			writeError(w, r, errInvalidRoomID)
+			return
+		}
+		roomIDs = append(roomIDs, id)
+	}
+
+	raw, err := newAPIKey()
+	if err != nil {
+		slog.Error("failed to generate api key", "error", err)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	createdBy, _ := authorFromRequest(r)
+	key, err := h.q.InsertAPIKey(r.Context(), store.InsertAPIKeyParams{
+		Name:        name,
+		Prefix:      raw[:len(apiKeyPrefix)+8],
+		KeyHash:     hashAPIKey(raw),
+		RoomIds:     roomIDs,
+		Permissions: permissions,
+		CreatedBy:   createdBy,
+	})
+	if err != nil {
+		slog.Error("failed to insert api key", "error", err)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	slog.Info("api key created", "key_id", key.ID, "name", key.Name)
+
+	h.audit(r, auditEntry{
+		Action:     auditAPIKeyCreate,
+		TargetType: "apikey",
+		TargetID:   key.ID.String(),
+		After:      apiKeyResponse{ApiKey: key},
+	})
+
+	// The plain key is shown once and cannot be recovered afterwards
+	sendJSON(w, apiKeyResponse{ApiKey: key, Key: raw})
+}
+
+func (h apiHandler) handleGetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	keys, err := h.q.GetAPIKeys(r.Context())
+	if err != nil {
+		slog.Error("failed to get api keys", "error", err)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	response := make([]apiKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, apiKeyResponse{ApiKey: key})
+	}
+
+	sendJSON(w, response)
+}
+
+func (h apiHandler) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "key_id"))
+	if err != nil {
+		writeError(w, r, errInvalidKeyID)
+		return
+	}
+
+	key, err := h.q.RevokeAPIKey(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, r, errAPIKeyNotFound)
+			return
+		}
+		slog.Error("failed to revoke api key", "error", err, "key_id", id)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	slog.Info("api key revoked", "key_id", key.ID)
+
+	h.audit(r, auditEntry{
+		Action:     auditAPIKeyRevoke,
+		TargetType: "apikey",
+		TargetID:   key.ID.String(),
+		After:      apiKeyResponse{ApiKey: key},
+	})
+
+	sendJSON(w, apiKeyResponse{ApiKey: key})
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestAuthorizeAPIKey(t *testing.T) {
+	roomID := uuid.New()
+	otherRoomID := uuid.New()
+
+	tests := []struct {
+		name       string
+		key        *store.ApiKey
+		permission string
+		roomID     string
+		wantStatus int
+	}{
+		{
+			name:       "No key",
+			permission: permModerate,
+			roomID:     roomID.String(),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Unscoped key with permission",
+			key:        &store.ApiKey{Permissions: []string{permPost}},
+			permission: permPost,
+			roomID:     roomID.String(),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Missing permission",
+			key:        &store.ApiKey{Permissions: []string{permRead}},
+			permission: permPost,
+			roomID:     roomID.String(),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Scoped key in its room",
+			key:        &store.ApiKey{Permissions: []string{permRead}, RoomIds: []uuid.UUID{roomID}},
+			permission: permRead,
+			roomID:     roomID.String(),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Scoped key in another room",
+			key:        &store.ApiKey{Permissions: []string{permRead}, RoomIds: []uuid.UUID{roomID}},
+			permission: permRead,
+			roomID:     otherRoomID.String(),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Scoped key outside a room",
+			key:        &store.ApiKey{Permissions: []string{permModerate}, RoomIds: []uuid.UUID{roomID}},
+			permission: permModerate,
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	var h apiHandler
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rctx := chi.NewRouteContext()
+			if tt.roomID != "" {
+				rctx.URLParams.Add("room_id", tt.roomID)
+			}
+			ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+			if tt.key != nil {
+				ctx = context.WithValue(ctx, apiKeyContextKey{}, *tt.key)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+			w := httptest.NewRecorder()
+			h.authorize(tt.permission)(ok).ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("authorize() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
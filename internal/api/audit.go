@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+// Audited actions, named <target>.<verb>
+const (
+	auditRoomCreate          = "room.create"
+	auditRoomAllowGuests     = "room.allow_guests"
+	auditRoomAnonymousPolicy = "room.anonymous_policy"
+	auditMessageCreate       = "message.create"
+	auditMessageReact        = "message.react"
+	auditMessageUnreact      = "message.unreact"
+	auditMessageAnswer       = "message.answer"
+	auditMessageHide         = "message.hide"
+	auditMessageUnhide       = "message.unhide"
+	auditParticipantKick     = "participant.kick"
+	auditSanctionCreate      = "sanction.create"
+	auditSanctionRevoke      = "sanction.revoke"
+	auditPollCreate          = "poll.create"
+	auditPollVote            = "poll.vote"
+	auditPollClose           = "poll.close"
+	auditAPIKeyCreate        = "apikey.create"
+	auditAPIKeyRevoke        = "apikey.revoke"
+	auditWebhookCreate       = "webhook.create"
+	auditWebhookDelete       = "webhook.delete"
+	auditWebhookRedeliver    = "webhook_delivery.redeliver"
+)
+
+const (
+	defaultAuditLogResults = 100
+	maxAuditLogResults     = 1000
+)
+
+// auditEntry describes one state change. A zero RoomID records an action
+// outside any room.
+type auditEntry struct {
+	Action     string
+	RoomID     uuid.UUID
+	TargetType string
+	TargetID   string
+	Before     any
+	After      any
+}
+
+// audit appends the entry to the audit log with the actor, IP and request ID
+// of r. Failures are logged but never undo the action.
+func (h apiHandler) audit(r *http.Request, e auditEntry) {
+	actorID, _ := authorFromRequest(r)
+
+	err := h.q.InsertAuditLogEntry(r.Context(), store.InsertAuditLogEntryParams{
+		Action:     e.Action,
+		ActorID:    actorID,
+		Ip:         clientIP(r),
+		RequestID:  middleware.GetReqID(r.Context()),
+		RoomID:     nullable(e.RoomID),
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Before:     auditValue(e.Before),
+		After:      auditValue(e.After),
+	})
+	if err != nil {
+		slog.Error("failed to write audit log", "error", err, "action", e.Action, "target_id", e.TargetID)
+	}
+}
+
+func auditValue(v any) []byte {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		slog.Error("failed to encode audit value", "error", err)
+		return nil
+	}
+	return data
+}
+
+// auditLogResponse exposes before and after as JSON instead of base64
+type auditLogResponse struct {
+	store.AuditLog
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+func (h apiHandler) handleGetAuditLog(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	params := store.GetAuditLogParams{
+		Action:     nullable(query.Get("action")),
+		ActorID:    nullable(query.Get("actor_id")),
+		TargetID:   nullable(query.Get("target_id")),
+		MaxResults: defaultAuditLogResults,
+	}
+
+	if raw := query.Get("room_id"); raw != "" {
+		roomID, err := uuid.Parse(raw)
+		if err != nil {
+			writeError(w, r, errInvalidRoomID)
+			return
+		}
+		params.RoomID = &roomID
+	}
+
+	times := map[string]**time.Time{"since": &params.Since, "until": &params.Until}
+	for key, dst := range times {
+		if raw := query.Get(key); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				writeError(w, r, errInvalidParameter.with(key))
+				return
+			}
+			*dst = &t
+		}
+	}
+
+	if raw := query.Get("before_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			writeError(w, r, errInvalidParameter.with("before_id"))
+			return
+		}
+		params.BeforeID = id
+	}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil || limit <= 0 || limit > maxAuditLogResults {
+			writeError(w, r, errInvalidParameter.with("limit"))
+			return
+		}
+		params.MaxResults = int32(limit)
+	}
+
+	entries, err := h.q.GetAuditLog(r.Context(), params)
+	if err != nil {
+		slog.Error("failed to get audit log", "error", err)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	response := make([]auditLogResponse, 0, len(entries))
+	for _, e := range entries {
+		response = append(response, auditLogResponse{AuditLog: e, Before: e.Before, After: e.After})
+	}
+
+	sendJSON(w, response)
+}
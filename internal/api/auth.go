@@ -0,0 +1,45 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/auth"
+)
+
+type claimsContextKey struct{}
+
+// loadVerifier builds the JWT verifier. The configuration has already been
+// validated, so an error here is unexpected.
+func loadVerifier(cfg auth.Config) *auth.Verifier {
+	verifier, err := auth.NewVerifier(cfg)
+	if err != nil {
+		slog.Error("invalid jwt configuration", "error", err)
+		os.Exit(1)
+	}
+	return verifier
+}
+
+// authenticateJWT verifies the Bearer token and stores its claims in the
+// request context. Requests without a valid token carry on as guests.
+func (h apiHandler) authenticateJWT(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || strings.HasPrefix(token, apiKeyPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		claims, err := h.verifier.Verify(r.Context(), token)
+		if err != nil {
+			slog.Debug("rejected jwt", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsContextKey{}, map[string]interface{}(claims))))
+	})
+}
@@ -0,0 +1,305 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/apidoc"
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+)
+
+// The documents are generated from the types the handlers encode and decode.
+// Optional request fields are tagged omitempty, which is how the schemas tell
+// them from the required ones. Every route of NewHandler must be listed in
+// docRoutes, which docs_test.go checks.
+
+var docInfo = apidoc.Info{
+	Title:       "go-msg-wss",
+	Version:     "1.0.0",
+	Description: "Rooms where participants post questions, react to them and vote in polls, with live updates over WebSocket. Error messages are in English or Brazilian Portuguese, following Accept-Language.",
+}
+
+var uuidSchema = apidoc.Object{"type": "string", "format": "uuid"}
+
+var docPathParams = map[string]apidoc.Param{
+	"room_id":     {Description: "The room", Schema: uuidSchema},
+	"message_id":  {Description: "A message of the room", Schema: uuidSchema},
+	"poll_id":     {Description: "A poll of the room", Schema: uuidSchema},
+	"sanction_id": {Description: "A ban or mute of the room", Schema: uuidSchema},
+	"key_id":      {Description: "The API key", Schema: uuidSchema},
+	"webhook_id":  {Description: "The webhook", Schema: uuidSchema},
+	"delivery_id": {Description: "The webhook delivery", Schema: uuidSchema},
+	"user_id":     {Description: "The subject of the user's tokens"},
+}
+
+var (
+	limitParam   = apidoc.Param{Name: "limit", Description: "Maximum number of results", Schema: apidoc.Object{"type": "integer"}}
+	booleanParam = apidoc.Object{"type": "boolean"}
+)
+
+var docRoutes = []apidoc.Route{
+	{
+		Method: http.MethodGet, Path: "/openapi.json", Tag: "docs",
+		Summary: "This document", ContentTypes: []string{"application/json"},
+	},
+	{
+		Method: http.MethodGet, Path: "/asyncapi.json", Tag: "docs",
+		Summary: "The AsyncAPI document of the WebSocket stream", ContentTypes: []string{"application/json"},
+	},
+	{
+		Method: http.MethodGet, Path: "/subscribe/{room_id}", Tag: "rooms", Permission: permRead,
+		Summary:     "Subscribe to the events of the room",
+		Description: "Upgrades to a WebSocket that streams the events described by /asyncapi.json.",
+		Status:      http.StatusSwitchingProtocols,
+	},
+
+	{
+		Method: http.MethodPost, Path: "/api/auth/guest", Tag: "guests",
+		Summary: "Issue a guest token for a room", Request: guestTokenRequest{}, Response: guestTokenResponse{},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/auth/guest/refresh", Tag: "guests",
+		Summary: "Refresh the guest token of the caller", Response: guestTokenResponse{},
+	},
+
+	{
+		Method: http.MethodPost, Path: "/api/apikeys", Tag: "admin",
+		Summary:     "Create an API key",
+		Description: "The key is only returned by this call.",
+		Request:     createAPIKeyRequest{}, Response: apiKeyResponse{},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/apikeys", Tag: "admin",
+		Summary: "List the API keys", Response: []apiKeyResponse{},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/apikeys/{key_id}", Tag: "admin",
+		Summary: "Revoke an API key", Response: apiKeyResponse{},
+	},
+
+	{
+		Method: http.MethodGet, Path: "/api/users/{user_id}", Tag: "users", Permission: permRead,
+		Summary: "Get a user and their latest messages", Query: []apidoc.Param{limitParam}, Response: userResponse{},
+		Description: "Only messages of rooms the caller can read are returned, so guests and room-scoped keys may get fewer than `limit`.",
+	},
+	{
+		Method: http.MethodGet, Path: "/api/audit", Tag: "admin",
+		Summary: "Search the audit log, newest first",
+		Query: []apidoc.Param{
+			{Name: "action"},
+			{Name: "actor_id"},
+			{Name: "target_id"},
+			{Name: "room_id", Schema: uuidSchema},
+			{Name: "since", Schema: apidoc.Object{"type": "string", "format": "date-time"}},
+			{Name: "until", Schema: apidoc.Object{"type": "string", "format": "date-time"}},
+			{Name: "before_id", Description: "Entries older than this one", Schema: apidoc.Object{"type": "integer"}},
+			limitParam,
+		},
+		Response: []auditLogResponse{},
+	},
+
+	{
+		Method: http.MethodPost, Path: "/api/webhooks", Tag: "admin",
+		Summary:     "Create a webhook",
+		Description: "The signing secret is only returned by this call.",
+		Request:     createWebhookRequest{}, Response: webhookResponse{},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/webhooks", Tag: "admin",
+		Summary: "List the webhooks", Response: []webhookResponse{},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/webhooks/{webhook_id}", Tag: "admin",
+		Summary: "Delete a webhook", Status: http.StatusNoContent,
+	},
+	{
+		Method: http.MethodGet, Path: "/api/webhooks/deliveries", Tag: "admin",
+		Summary: "List webhook deliveries",
+		Query: []apidoc.Param{
+			{Name: "status", Description: "dead by default", Schema: apidoc.Object{
+				"type": "string",
+				"enum": []string{deliveryStatusPending, deliveryStatusDelivered, deliveryStatusDead},
+			}},
+			limitParam,
+		},
+		Response: []webhookDeliveryResponse{},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/webhooks/deliveries/{delivery_id}/redeliver", Tag: "admin",
+		Summary: "Send a delivery again", Response: webhookDeliveryResponse{},
+	},
+
+	{
+		Method: http.MethodPost, Path: "/api/rooms", Tag: "rooms", Permission: permModerate,
+		Summary: "Create a room", Request: createRoomRequest{}, Response: idResponse{},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/rooms", Tag: "rooms", Permission: permRead,
+		Summary: "List the rooms", Response: []store.Room{},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/rooms/{room_id}", Tag: "rooms", Permission: permRead,
+		Summary: "Get a room", Response: store.Room{},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/rooms/{room_id}/export", Tag: "rooms", Permission: permRead,
+		Summary:     "Export the messages of the room",
+		Description: "Including hidden messages requires the moderate permission.",
+		Query: []apidoc.Param{
+			{Name: "format", Schema: apidoc.Object{"type": "string", "enum": []string{"json", "csv", "md", "markdown"}}},
+			{Name: "include_hidden", Schema: booleanParam},
+			{Name: "anonymize", Description: "Replace the authors with stable pseudonyms", Schema: booleanParam},
+		},
+		ContentTypes: []string{"application/json", "text/csv", "text/markdown"},
+	},
+	{
+		Method: http.MethodPatch, Path: "/api/rooms/{room_id}/guests", Tag: "rooms", Permission: permModerate,
+		Summary: "Allow or forbid guests", Request: allowGuestsRequest{}, Response: store.Room{},
+	},
+	{
+		Method: http.MethodPatch, Path: "/api/rooms/{room_id}/anonymous-policy", Tag: "rooms", Permission: permModerate,
+		Summary: "Set whether messages may be anonymous", Request: anonymousPolicyRequest{}, Response: store.Room{},
+	},
+
+	{
+		Method: http.MethodPost, Path: "/api/rooms/{room_id}/moderation/kick", Tag: "moderation", Permission: permModerate,
+		Summary: "Disconnect the matching subscribers", Request: sanctionTarget{}, Response: kickResponse{},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/rooms/{room_id}/moderation/bans", Tag: "moderation", Permission: permModerate,
+		Summary: "Ban an author or an IP from the room", Request: sanctionRequest{}, Response: store.RoomSanction{},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/rooms/{room_id}/moderation/bans", Tag: "moderation", Permission: permModerate,
+		Summary: "List the active bans", Response: []store.RoomSanction{},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/rooms/{room_id}/moderation/bans/{sanction_id}", Tag: "moderation", Permission: permModerate,
+		Summary: "Revoke a ban", Response: store.RoomSanction{},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/rooms/{room_id}/moderation/mutes", Tag: "moderation", Permission: permModerate,
+		Summary: "Mute an author or an IP in the room", Request: sanctionRequest{}, Response: store.RoomSanction{},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/rooms/{room_id}/moderation/mutes", Tag: "moderation", Permission: permModerate,
+		Summary: "List the active mutes", Response: []store.RoomSanction{},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/rooms/{room_id}/moderation/mutes/{sanction_id}", Tag: "moderation", Permission: permModerate,
+		Summary: "Revoke a mute", Response: store.RoomSanction{},
+	},
+
+	{
+		Method: http.MethodPost, Path: "/api/rooms/{room_id}/polls", Tag: "polls", Permission: permModerate,
+		Summary: "Create a poll", Request: createPollRequest{}, Response: pollResults{},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/rooms/{room_id}/polls", Tag: "polls", Permission: permRead,
+		Summary: "List the polls of the room", Response: []pollResults{},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/rooms/{room_id}/polls/{poll_id}", Tag: "polls", Permission: permRead,
+		Summary: "Get a poll and its tallies", Response: pollResults{},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/rooms/{room_id}/polls/{poll_id}/votes", Tag: "polls", Permission: permPost,
+		Summary:     "Vote in a poll",
+		Description: "Each participant votes once, a second ballot is a conflict.",
+		Request:     voteRequest{}, Response: pollResults{},
+	},
+	{
+		Method: http.MethodPatch, Path: "/api/rooms/{room_id}/polls/{poll_id}/close", Tag: "polls", Permission: permModerate,
+		Summary: "Close a poll", Response: pollResults{},
+	},
+
+	{
+		Method: http.MethodPost, Path: "/api/rooms/{room_id}/messages", Tag: "messages", Permission: permPost,
+		Summary: "Post a message", Request: createMessageRequest{}, Response: idResponse{},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/rooms/{room_id}/messages", Tag: "messages", Permission: permRead,
+		Summary: "List the messages of the room",
+		Query: []apidoc.Param{
+			{Name: "sort", Description: "hot ranks the questions by votes and age", Schema: apidoc.Object{"type": "string", "enum": []string{"hot"}}},
+			{Name: "include_hidden", Description: "Without sort, keep the hidden messages; requires the moderate permission", Schema: booleanParam},
+			{Name: "include_answered", Description: "With sort=hot, keep the answered questions", Schema: booleanParam},
+			{Name: "limit", Description: "With sort=hot, the maximum number of results", Schema: apidoc.Object{"type": "integer"}},
+		},
+		Response: []messageResponse{},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/rooms/{room_id}/messages/{message_id}", Tag: "messages", Permission: permRead,
+		Summary: "Get a message", Response: messageResponse{},
+	},
+	{
+		Method: http.MethodPatch, Path: "/api/rooms/{room_id}/messages/{message_id}/react", Tag: "messages", Permission: permPost,
+		Summary: "React to a message", Response: reactionResponse{},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/rooms/{room_id}/messages/{message_id}/react", Tag: "messages", Permission: permPost,
+		Summary: "Remove a reaction", Response: reactionResponse{},
+	},
+	{
+		Method: http.MethodPatch, Path: "/api/rooms/{room_id}/messages/{message_id}/answer", Tag: "messages", Permission: permAnswer,
+		Summary: "Mark a message as answered",
+	},
+	{
+		Method: http.MethodPatch, Path: "/api/rooms/{room_id}/messages/{message_id}/hide", Tag: "messages", Permission: permModerate,
+		Summary: "Hide a message",
+	},
+	{
+		Method: http.MethodPatch, Path: "/api/rooms/{room_id}/messages/{message_id}/unhide", Tag: "messages", Permission: permModerate,
+		Summary: "Show a hidden message again",
+	},
+}
+
+var docChannel = apidoc.Channel{
+	Name:        "room",
+	Address:     "/subscribe/{room_id}",
+	Description: "The events of a room. Clients may send client_ping to keep idle connections open through proxies.",
+	Params:      map[string]apidoc.Param{"room_id": docPathParams["room_id"]},
+	Receive: []apidoc.Event{
+		{Kind: MessageKindMessageCreated, Summary: "A message was posted", Value: messageResponse{}},
+		{Kind: MessageKindMessageRactionIncreased, Summary: "A message got a reaction", Value: MessageMessageReactionIncreased{}},
+		{Kind: MessageKindMessageRactionDecreased, Summary: "A message lost a reaction", Value: MessageMessageReactionDecreased{}},
+		{Kind: MessageKindMessageAnswered, Summary: "A message was answered", Value: MessageMessageAnswered{}},
+		{Kind: MessageKindMessageHidden, Summary: "A moderator hid a message", Value: MessageMessageHidden{}},
+		{Kind: MessageKindMessageUnhidden, Summary: "A moderator showed a hidden message again", Value: MessageMessageUnhidden{}},
+		{Kind: MessageKindPollCreated, Summary: "A poll was opened", Value: pollResults{}},
+		{Kind: MessageKindPollVotesUpdated, Summary: "The tallies of a poll changed", Value: MessagePollVotesUpdated{}},
+		{Kind: MessageKindPollClosed, Summary: "A poll was closed", Value: pollResults{}},
+		{Kind: MessageKindTopQuestionsChanged, Summary: "The ranking of the hot questions changed", Value: MessageTopQuestionsChanged{}},
+		{Kind: messageKindServerPong, Summary: "The answer to client_ping"},
+		{Kind: messageKindError, Summary: "A frame was invalid, or the connection is about to be closed by a moderator", Value: errorBody{}},
+	},
+	Send: []apidoc.Event{
+		{Kind: messageKindClientPing, Summary: "Keeps the connection open, answered with server_pong"},
+	},
+}
+
+// jsonDocument encodes the document once, on first use
+func jsonDocument(build func() apidoc.Object) http.HandlerFunc {
+	encoded := sync.OnceValue(func() []byte {
+		data, err := json.Marshal(build())
+		if err != nil {
+			panic(err)
+		}
+		return data
+	})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(encoded())
+	}
+}
+
+var (
+	handleOpenAPI = jsonDocument(func() apidoc.Object {
+		return apidoc.OpenAPI(docInfo, docPathParams, docRoutes, errorResponse{})
+	})
+	handleAsyncAPI = jsonDocument(func() apidoc.Object {
+		return apidoc.AsyncAPI(docInfo, docChannel)
+	})
+)
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestDocumentedRoutes(t *testing.T) {
+	srv := newTestServer(t)
+
+	documented := make(map[string]bool, len(docRoutes))
+	for _, route := range docRoutes {
+		documented[route.Method+" "+route.Path] = false
+	}
+
+	walk := func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if len(route) > 1 {
+			route = strings.TrimSuffix(route, "/")
+		}
+		key := method + " " + route
+		if _, ok := documented[key]; !ok {
+			t.Errorf("%s is missing from docRoutes", key)
+		}
+		documented[key] = true
+		return nil
+	}
+	if err := chi.Walk(srv.h.r, walk); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, served := range documented {
+		if !served {
+			t.Errorf("%s is documented but not served", key)
+		}
+	}
+}
+
+func TestDocumentedEvents(t *testing.T) {
+	documented := make(map[string]bool)
+	for _, e := range docChannel.Receive {
+		documented[e.Kind] = true
+	}
+
+	// Every MessageKind constant is an event, wherever it is declared
+	file, err := parser.ParseFile(token.NewFileSet(), "api.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds int
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+		for i, name := range spec.Names {
+			if !strings.HasPrefix(name.Name, "MessageKind") || i >= len(spec.Values) {
+				continue
+			}
+			kinds++
+			lit, ok := spec.Values[i].(*ast.BasicLit)
+			if !ok {
+				t.Errorf("%s is not a string literal", name.Name)
+				continue
+			}
+			kind, _ := strconv.Unquote(lit.Value)
+			if !documented[kind] {
+				t.Errorf("%s (%s) is missing from docChannel", name.Name, kind)
+			}
+		}
+		return true
+	})
+	if kinds == 0 {
+		t.Fatal("found no MessageKind constants")
+	}
+}
+
+var schemaRefPattern = regexp.MustCompile(`"\$ref":"#/components/(schemas|messages)/([^"]+)"`)
+
+func TestServeDocuments(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, path := range []string{"/openapi.json", "/asyncapi.json"} {
+		resp, err := srv.Client().Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc struct {
+			OpenAPI    string `json:"openapi"`
+			AsyncAPI   string `json:"asyncapi"`
+			Components map[string]map[string]json.RawMessage
+		}
+		var raw json.RawMessage
+		err = json.NewDecoder(resp.Body).Decode(&raw)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("GET %s = %d %s", path, resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+		if err := json.Unmarshal(raw, &doc); err != nil {
+			t.Fatal(err)
+		}
+		if doc.OpenAPI == "" && doc.AsyncAPI == "" {
+			t.Errorf("GET %s has no version", path)
+		}
+
+		for _, m := range schemaRefPattern.FindAllStringSubmatch(string(raw), -1) {
+			if _, ok := doc.Components[m[1]][m[2]]; !ok {
+				t.Errorf("GET %s: dangling reference to %s %s", path, m[1], m[2])
+			}
+		}
+	}
+}
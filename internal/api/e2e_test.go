@@ -0,0 +1,1260 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+type testMessage struct {
+	ID            string  `json:"id"`
+	RoomID        string  `json:"room_id"`
+	Message       string  `json:"message"`
+	ReactionCount int64   `json:"reaction_count"`
+	Answered      bool    `json:"answered"`
+	AuthorID      string  `json:"author_id"`
+	AuthorName    string  `json:"author_name"`
+	Hidden        bool    `json:"hidden"`
+	IsBot         bool    `json:"is_bot"`
+	Anonymous     bool    `json:"anonymous"`
+	UserID        *string `json:"user_id"`
+}
+
+type testPoll struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	MultipleChoice bool   `json:"multiple_choice"`
+	Options        []struct {
+		ID    string `json:"id"`
+		Label string `json:"label"`
+		Votes int64  `json:"votes"`
+	} `json:"options"`
+	TotalVotes int64 `json:"total_votes"`
+}
+
+type testSanction struct {
+	ID       string  `json:"id"`
+	Kind     string  `json:"kind"`
+	AuthorID *string `json:"author_id"`
+	Ip       *string `json:"ip"`
+}
+
+func moderatorToken(t *testing.T) string {
+	return testToken(t, jwt.MapClaims{"sub": "mod", "name": "Mod", "role": "moderator"})
+}
+
+func adminToken(t *testing.T) string {
+	return testToken(t, jwt.MapClaims{"sub": "root", "name": "Root", "role": "admin"})
+}
+
+func userToken(t *testing.T, sub, name string) string {
+	return testToken(t, jwt.MapClaims{"sub": sub, "name": name})
+}
+
+func createRoom(t *testing.T, srv *testServer, body map[string]any) string {
+	t.Helper()
+
+	var room idResponse
+	if status := call(t, srv, "", http.MethodPost, "/api/rooms/", body, &room); status != http.StatusOK {
+		t.Fatalf("create room status = %d", status)
+	}
+	return room.ID
+}
+
+func postMessage(t *testing.T, srv *testServer, token, roomID, text string) string {
+	t.Helper()
+
+	var message idResponse
+	status := call(t, srv, token, http.MethodPost, "/api/rooms/"+roomID+"/messages/", map[string]any{"message": text}, &message)
+	if status != http.StatusOK {
+		t.Fatalf("post message status = %d", status)
+	}
+	return message.ID
+}
+
+// dialSubscribe opens /subscribe/{room_id} with gorilla's client
+func dialSubscribe(srv *testServer, roomID, token string) (*websocket.Conn, *http.Response, error) {
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/subscribe/"+roomID, header)
+}
+
+// subscribe joins the room and waits until the server registered the socket,
+// so no broadcast sent afterwards can be missed
+func subscribe(t *testing.T, srv *testServer, roomID, token string) *websocket.Conn {
+	t.Helper()
+
+	srv.h.mu.Lock()
+	before := len(srv.h.subscribers[roomID])
+	srv.h.mu.Unlock()
+
+	conn, resp, err := dialSubscribe(srv, roomID, token)
+	if err != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		t.Fatalf("subscribe: %v (status %d)", err, status)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	srv.waitSubscribers(t, roomID, before+1)
+	return conn
+}
+
+func readFrame(t *testing.T, conn *websocket.Conn) []byte {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read event: %v", err)
+	}
+	return data
+}
+
+// expectEvent reads the next frame and compares it with want as JSON values
+func expectEvent(t *testing.T, conn *websocket.Conn, want Message) {
+	t.Helper()
+
+	wantData, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := readFrame(t, conn)
+
+	var gotValue, wantValue any
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("event %s is not JSON: %v", got, err)
+	}
+	if err := json.Unmarshal(wantData, &wantValue); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Fatalf("event = %s\nwant    %s", got, wantData)
+	}
+}
+
+// expectClosed reads until the server closes the socket with code and reason.
+// Kicks and bans must be announced by an error frame whose code is the reason.
+func expectClosed(t *testing.T, conn *websocket.Conn, code int, reason string) {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var announced string
+	for {
+		_, data, err := conn.ReadMessage()
+		if err == nil {
+			var frame struct {
+				Kind  string    `json:"kind"`
+				Value errorBody `json:"value"`
+			}
+			if json.Unmarshal(data, &frame) == nil && frame.Kind == messageKindError {
+				announced = frame.Value.Code
+			}
+			continue
+		}
+
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) || closeErr.Code != code || closeErr.Text != reason {
+			t.Fatalf("read error = %v, want close %d %q", err, code, reason)
+		}
+		if code == websocket.ClosePolicyViolation && announced != reason {
+			t.Errorf("error frame code = %q, want %q", announced, reason)
+		}
+		return
+	}
+}
+
+func TestSubscribeEvents(t *testing.T) {
+	srv := newTestServer(t)
+	moderator := moderatorToken(t)
+	user := userToken(t, "ada", "Ada")
+
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go"})
+	otherRoomID := createRoom(t, srv, map[string]any{"theme": "Rust"})
+	conn := subscribe(t, srv, roomID, moderator)
+	other := subscribe(t, srv, otherRoomID, "")
+
+	messagesPath := "/api/rooms/" + roomID + "/messages/"
+	messageID := postMessage(t, srv, user, roomID, "Is Go fast?")
+	var created json.RawMessage
+	if status := call(t, srv, user, http.MethodGet, messagesPath+messageID+"/", nil, &created); status != http.StatusOK {
+		t.Fatalf("get message status = %d", status)
+	}
+	expectEvent(t, conn, Message{Kind: MessageKindMessageCreated, RoomID: roomID, Value: created})
+
+	messagePath := messagesPath + messageID
+	steps := []struct {
+		name   string
+		token  string
+		method string
+		path   string
+		want   Message
+	}{
+		{
+			name: "React", token: user, method: http.MethodPatch, path: messagePath + "/react",
+			want: Message{Kind: MessageKindMessageRactionIncreased, Value: MessageMessageReactionIncreased{ID: messageID, Count: 1}},
+		},
+		{
+			name: "React again", token: user, method: http.MethodPatch, path: messagePath + "/react",
+			want: Message{Kind: MessageKindMessageRactionIncreased, Value: MessageMessageReactionIncreased{ID: messageID, Count: 2}},
+		},
+		{
+			name: "Unreact", token: user, method: http.MethodDelete, path: messagePath + "/react",
+			want: Message{Kind: MessageKindMessageRactionDecreased, Value: MessageMessageReactionDecreased{ID: messageID, Count: 1}},
+		},
+		{
+			name: "Answer", token: moderator, method: http.MethodPatch, path: messagePath + "/answer",
+			want: Message{Kind: MessageKindMessageAnswered, Value: MessageMessageAnswered{ID: messageID}},
+		},
+		{
+			name: "Hide", token: moderator, method: http.MethodPatch, path: messagePath + "/hide",
+			want: Message{Kind: MessageKindMessageHidden, Value: MessageMessageHidden{ID: messageID}},
+		},
+		{
+			name: "Unhide", token: moderator, method: http.MethodPatch, path: messagePath + "/unhide",
+			want: Message{Kind: MessageKindMessageUnhidden, Value: MessageMessageUnhidden{ID: messageID}},
+		},
+	}
+	for _, step := range steps {
+		if status := call(t, srv, step.token, step.method, step.path, nil, nil); status != http.StatusOK {
+			t.Fatalf("%s status = %d", step.name, status)
+		}
+		step.want.RoomID = roomID
+		expectEvent(t, conn, step.want)
+	}
+
+	pollsPath := "/api/rooms/" + roomID + "/polls/"
+	var poll json.RawMessage
+	status := call(t, srv, moderator, http.MethodPost, pollsPath, map[string]any{"question": "Tabs or spaces?", "options": []string{"Tabs", "Spaces"}}, &poll)
+	if status != http.StatusOK {
+		t.Fatalf("create poll status = %d", status)
+	}
+	expectEvent(t, conn, Message{Kind: MessageKindPollCreated, RoomID: roomID, Value: poll})
+
+	var results testPoll
+	if err := json.Unmarshal(poll, &results); err != nil {
+		t.Fatal(err)
+	}
+	var voted struct {
+		Options    json.RawMessage `json:"options"`
+		TotalVotes int64           `json:"total_votes"`
+	}
+	status = call(t, srv, user, http.MethodPost, pollsPath+results.ID+"/votes", map[string]any{"option_ids": []string{results.Options[0].ID}}, &voted)
+	if status != http.StatusOK {
+		t.Fatalf("vote status = %d", status)
+	}
+	expectEvent(t, conn, Message{Kind: MessageKindPollVotesUpdated, RoomID: roomID, Value: map[string]any{
+		"id":          results.ID,
+		"options":     voted.Options,
+		"total_votes": voted.TotalVotes,
+	}})
+
+	var closed json.RawMessage
+	if status := call(t, srv, moderator, http.MethodPatch, pollsPath+results.ID+"/close", nil, &closed); status != http.StatusOK {
+		t.Fatalf("close poll status = %d", status)
+	}
+	expectEvent(t, conn, Message{Kind: MessageKindPollClosed, RoomID: roomID, Value: closed})
+
+	// The other room only sees its own events
+	postMessage(t, srv, user, otherRoomID, "Is Rust fast?")
+	var event Message
+	if err := json.Unmarshal(readFrame(t, other), &event); err != nil || event.Kind != MessageKindMessageCreated || event.RoomID != otherRoomID {
+		t.Errorf("other room event = %+v, want its own %s", event, MessageKindMessageCreated)
+	}
+}
+
+func TestSubscribeClientPing(t *testing.T) {
+	srv := newTestServer(t)
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go"})
+	conn := subscribe(t, srv, roomID, "")
+
+	for range 3 {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"kind":"client_ping"}`)); err != nil {
+			t.Fatal(err)
+		}
+		if got := string(readFrame(t, conn)); got != `{"kind":"server_pong"}` {
+			t.Fatalf("reply to client_ping = %s, want server_pong", got)
+		}
+	}
+
+	// Anything else is answered with an error frame and the socket stays open
+	for _, frame := range []string{`{"kind":"subscribe"}`, "not json"} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(frame)); err != nil {
+			t.Fatal(err)
+		}
+		var got struct {
+			Kind   string    `json:"kind"`
+			RoomID string    `json:"room_id"`
+			Value  errorBody `json:"value"`
+		}
+		if err := json.Unmarshal(readFrame(t, conn), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Kind != messageKindError || got.RoomID != roomID || got.Value.Code != "invalid_frame" || got.Value.RequestID == "" {
+			t.Errorf("reply to %s = %+v, want an invalid_frame error", frame, got)
+		}
+	}
+}
+
+func TestSubscribeRejected(t *testing.T) {
+	srv := newTestServer(t)
+	closedRoomID := createRoom(t, srv, map[string]any{"theme": "Members only", "allow_guests": false})
+
+	tests := []struct {
+		name   string
+		roomID string
+		token  string
+		want   int
+	}{
+		{"Invalid room id", "not-a-uuid", "", http.StatusBadRequest},
+		{"Missing room", uuid.NewString(), "", http.StatusNotFound},
+		{"Guest in a members only room", closedRoomID, "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, resp, err := dialSubscribe(srv, tt.roomID, tt.token)
+			if err == nil {
+				conn.Close()
+				t.Fatal("subscribe succeeded")
+			}
+			if resp == nil || resp.StatusCode != tt.want {
+				t.Fatalf("subscribe error = %v, want status %d", err, tt.want)
+			}
+		})
+	}
+
+	subscribe(t, srv, closedRoomID, userToken(t, "ada", "Ada"))
+}
+
+func TestRooms(t *testing.T) {
+	srv := newTestServer(t)
+	moderator := moderatorToken(t)
+	user := userToken(t, "ada", "Ada")
+
+	for _, body := range []any{
+		"not an object",
+		map[string]any{"theme": "  "},
+		map[string]any{"theme": "Go", "anonymous_policy": "sometimes"},
+	} {
+		if status := call(t, srv, "", http.MethodPost, "/api/rooms/", body, nil); status != http.StatusBadRequest {
+			t.Errorf("create room %v status = %d, want %d", body, status, http.StatusBadRequest)
+		}
+	}
+
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go", "anonymous_policy": "allowed"})
+
+	type room struct {
+		ID              string `json:"id"`
+		Theme           string `json:"theme"`
+		AllowGuests     bool   `json:"allow_guests"`
+		AnonymousPolicy string `json:"anonymous_policy"`
+	}
+	want := room{ID: roomID, Theme: "Go", AllowGuests: true, AnonymousPolicy: "allowed"}
+
+	var rooms []room
+	if status := call(t, srv, "", http.MethodGet, "/api/rooms/", nil, &rooms); status != http.StatusOK {
+		t.Fatalf("list rooms status = %d", status)
+	}
+	if len(rooms) != 1 || rooms[0] != want {
+		t.Errorf("rooms = %+v, want [%+v]", rooms, want)
+	}
+
+	var got room
+	if status := call(t, srv, "", http.MethodGet, "/api/rooms/"+roomID+"/", nil, &got); status != http.StatusOK {
+		t.Fatalf("get room status = %d", status)
+	}
+	if got != want {
+		t.Errorf("room = %+v, want %+v", got, want)
+	}
+
+	roomPath := "/api/rooms/" + roomID
+	tests := []struct {
+		name  string
+		token string
+		path  string
+		body  any
+		want  int
+	}{
+		{"Guests without token", "", "/guests", map[string]any{"allow_guests": false}, http.StatusUnauthorized},
+		{"Guests as user", user, "/guests", map[string]any{"allow_guests": false}, http.StatusForbidden},
+		{"Guests without value", moderator, "/guests", map[string]any{}, http.StatusBadRequest},
+		{"Guests", moderator, "/guests", map[string]any{"allow_guests": false}, http.StatusOK},
+		{"Policy as user", user, "/anonymous-policy", map[string]any{"anonymous_policy": "only"}, http.StatusForbidden},
+		{"Invalid policy", moderator, "/anonymous-policy", map[string]any{"anonymous_policy": "sometimes"}, http.StatusBadRequest},
+		{"Policy", moderator, "/anonymous-policy", map[string]any{"anonymous_policy": "only"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := call(t, srv, tt.token, http.MethodPatch, roomPath+tt.path, tt.body, nil); status != tt.want {
+				t.Errorf("status = %d, want %d", status, tt.want)
+			}
+		})
+	}
+
+	// Guests are now turned away while users still get the updated room
+	if status := call(t, srv, "", http.MethodGet, roomPath+"/", nil, nil); status != http.StatusForbidden {
+		t.Errorf("guest get room status = %d, want %d", status, http.StatusForbidden)
+	}
+	want.AllowGuests, want.AnonymousPolicy = false, "only"
+	if status := call(t, srv, user, http.MethodGet, roomPath+"/", nil, &got); status != http.StatusOK || got != want {
+		t.Errorf("get room = %d %+v, want %+v", status, got, want)
+	}
+}
+
+func TestMessages(t *testing.T) {
+	srv := newTestServer(t)
+	moderator := moderatorToken(t)
+	user := userToken(t, "ada", "Ada")
+
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go"})
+	messagesPath := "/api/rooms/" + roomID + "/messages/"
+
+	guestID := postMessage(t, srv, "", roomID, "Who are you?")
+	adaID := postMessage(t, srv, user, roomID, "Is Go fast?")
+	otherRoomID := createRoom(t, srv, map[string]any{"theme": "Rust"})
+	otherID := postMessage(t, srv, moderator, otherRoomID, "Is Rust fast?")
+
+	var guestMessage, adaMessage testMessage
+	call(t, srv, "", http.MethodGet, messagesPath+guestID+"/", nil, &guestMessage)
+	if guestMessage.AuthorID != "guest" || guestMessage.AuthorName != "Guest" || guestMessage.RoomID != roomID {
+		t.Errorf("message without JWT = %+v, want the guest author", guestMessage)
+	}
+	call(t, srv, "", http.MethodGet, messagesPath+adaID+"/", nil, &adaMessage)
+	if adaMessage.AuthorID != "ada" || adaMessage.AuthorName != "Ada" || adaMessage.Message != "Is Go fast?" {
+		t.Errorf("message with JWT = %+v, want Ada as author", adaMessage)
+	}
+
+	if status := call(t, srv, user, http.MethodPost, messagesPath, map[string]any{"message": "Secret", "anonymous": true}, nil); status != http.StatusForbidden {
+		t.Errorf("anonymous message in a named room status = %d, want %d", status, http.StatusForbidden)
+	}
+	if status := call(t, srv, user, http.MethodPost, messagesPath, "not an object", nil); status != http.StatusBadRequest {
+		t.Errorf("invalid json status = %d, want %d", status, http.StatusBadRequest)
+	}
+
+	call(t, srv, user, http.MethodPatch, messagesPath+adaID+"/react", nil, nil)
+
+	var hot []testMessage
+	if status := call(t, srv, "", http.MethodGet, messagesPath+"?sort=hot", nil, &hot); status != http.StatusOK {
+		t.Fatalf("hot messages status = %d", status)
+	}
+	if len(hot) != 2 || hot[0].ID != adaID || hot[1].ID != guestID {
+		t.Errorf("hot messages = %+v, want the reacted message first", hot)
+	}
+
+	// Answered and hidden messages leave the hot list
+	call(t, srv, moderator, http.MethodPatch, messagesPath+adaID+"/answer", nil, nil)
+	call(t, srv, moderator, http.MethodPatch, messagesPath+guestID+"/hide", nil, nil)
+	call(t, srv, "", http.MethodGet, messagesPath+"?sort=hot", nil, &hot)
+	if len(hot) != 0 {
+		t.Errorf("hot messages = %+v, want none", hot)
+	}
+	call(t, srv, "", http.MethodGet, messagesPath+"?sort=hot&include_answered=true&limit=1", nil, &hot)
+	if len(hot) != 1 || hot[0].ID != adaID || !hot[0].Answered {
+		t.Errorf("hot messages with answered = %+v, want the answered message", hot)
+	}
+
+	var messages []testMessage
+	call(t, srv, "", http.MethodGet, messagesPath, nil, &messages)
+	if len(messages) != 1 || messages[0].ID != adaID {
+		t.Errorf("messages = %+v, want only the visible message", messages)
+	}
+	if status := call(t, srv, moderator, http.MethodGet, messagesPath+"?include_hidden=true", nil, &messages); status != http.StatusOK || len(messages) != 2 {
+		t.Errorf("messages with hidden = %d %+v, want both messages for a moderator", status, messages)
+	}
+
+	var profile struct {
+		ID       string        `json:"id"`
+		Name     string        `json:"name"`
+		Messages []testMessage `json:"messages"`
+	}
+	if status := call(t, srv, "", http.MethodGet, "/api/users/ada", nil, &profile); status != http.StatusOK {
+		t.Fatalf("get user status = %d", status)
+	}
+	if profile.Name != "Ada" || len(profile.Messages) != 1 || profile.Messages[0].ID != adaID {
+		t.Errorf("user = %+v, want Ada with her message", profile)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		path   string
+		want   int
+	}{
+		{"Invalid sort", "", http.MethodGet, messagesPath + "?sort=new", http.StatusBadRequest},
+		{"Invalid include_answered", "", http.MethodGet, messagesPath + "?sort=hot&include_answered=maybe", http.StatusBadRequest},
+		{"Invalid limit", "", http.MethodGet, messagesPath + "?sort=hot&limit=-1", http.StatusBadRequest},
+		{"Invalid include_hidden", moderator, http.MethodGet, messagesPath + "?include_hidden=maybe", http.StatusBadRequest},
+		{"List hidden as user", user, http.MethodGet, messagesPath + "?include_hidden=true", http.StatusForbidden},
+		{"Invalid message id", "", http.MethodGet, messagesPath + "42/", http.StatusBadRequest},
+		{"React to invalid id", user, http.MethodPatch, messagesPath + "42/react", http.StatusBadRequest},
+		{"Answer invalid id", moderator, http.MethodPatch, messagesPath + "42/answer", http.StatusBadRequest},
+		{"Hide without token", "", http.MethodPatch, messagesPath + adaID + "/hide", http.StatusUnauthorized},
+		{"Hide as user", user, http.MethodPatch, messagesPath + adaID + "/hide", http.StatusForbidden},
+		{"Hide from another room", moderator, http.MethodPatch, messagesPath + otherID + "/hide", http.StatusNotFound},
+		{"Answer from another room", moderator, http.MethodPatch, messagesPath + otherID + "/answer", http.StatusNotFound},
+		{"Get from another room", "", http.MethodGet, messagesPath + otherID + "/", http.StatusNotFound},
+		{"Get hidden message", user, http.MethodGet, messagesPath + guestID + "/", http.StatusNotFound},
+		{"Get hidden message as moderator", moderator, http.MethodGet, messagesPath + guestID + "/", http.StatusOK},
+		{"React from another room", user, http.MethodPatch, messagesPath + otherID + "/react", http.StatusNotFound},
+		{"Unreact from another room", user, http.MethodDelete, messagesPath + otherID + "/react", http.StatusNotFound},
+		{"Invalid user limit", "", http.MethodGet, "/api/users/ada?limit=0", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := call(t, srv, tt.token, tt.method, tt.path, nil, nil); status != tt.want {
+				t.Errorf("status = %d, want %d", status, tt.want)
+			}
+		})
+	}
+
+	var other testMessage
+	call(t, srv, "", http.MethodGet, "/api/rooms/"+otherRoomID+"/messages/"+otherID+"/", nil, &other)
+	if other.Hidden || other.Answered || other.ReactionCount != 0 {
+		t.Errorf("message of another room = %+v, want it untouched", other)
+	}
+}
+
+// TestReactions checks the counts returned while reacting, which never go
+// below zero
+func TestReactions(t *testing.T) {
+	srv := newTestServer(t)
+	user := userToken(t, "ada", "Ada")
+
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go"})
+	reactPath := "/api/rooms/" + roomID + "/messages/" + postMessage(t, srv, user, roomID, "Is Go fast?") + "/react"
+
+	for _, step := range []struct {
+		method string
+		want   int64
+	}{
+		{http.MethodPatch, 1},
+		{http.MethodPatch, 2},
+		{http.MethodDelete, 1},
+		{http.MethodDelete, 0},
+		{http.MethodDelete, 0},
+	} {
+		var reaction reactionResponse
+		if status := call(t, srv, user, step.method, reactPath, nil, &reaction); status != http.StatusOK {
+			t.Fatalf("%s react status = %d", step.method, status)
+		}
+		if reaction.Count != step.want {
+			t.Errorf("%s react count = %d, want %d", step.method, reaction.Count, step.want)
+		}
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	srv := newTestServer(t)
+	moderator := moderatorToken(t)
+
+	roomPath := "/api/rooms/" + createRoom(t, srv, map[string]any{"theme": "Go"})
+	missing := uuid.NewString()
+	tests := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{"Room", http.MethodGet, "/api/rooms/" + missing + "/"},
+		{"Message", http.MethodGet, roomPath + "/messages/" + missing + "/"},
+		{"React", http.MethodPatch, roomPath + "/messages/" + missing + "/react"},
+		{"Unreact", http.MethodDelete, roomPath + "/messages/" + missing + "/react"},
+		{"Answer", http.MethodPatch, roomPath + "/messages/" + missing + "/answer"},
+		{"Hide", http.MethodPatch, roomPath + "/messages/" + missing + "/hide"},
+		{"Poll", http.MethodGet, roomPath + "/polls/" + missing + "/"},
+		{"Vote", http.MethodPost, roomPath + "/polls/" + missing + "/votes"},
+		{"Close poll", http.MethodPatch, roomPath + "/polls/" + missing + "/close"},
+		{"Sanction", http.MethodDelete, roomPath + "/moderation/mutes/" + missing},
+		{"User", http.MethodGet, "/api/users/nobody"},
+		{"Route", http.MethodGet, "/api/nothing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := call(t, srv, moderator, tt.method, tt.path, nil, nil); status != http.StatusNotFound {
+				t.Errorf("status = %d, want %d", status, http.StatusNotFound)
+			}
+		})
+	}
+}
+
+func TestAnonymousMessages(t *testing.T) {
+	srv := newTestServer(t)
+	ada := userToken(t, "ada", "Ada")
+
+	masked := func(m testMessage) bool {
+		return m.Anonymous && m.AuthorID == anonymousAuthorID && m.AuthorName == anonymousAuthorName && m.UserID == nil
+	}
+
+	var anonymousIDs []string
+	for _, policy := range []string{anonymousPolicyAllowed, anonymousPolicyOnly} {
+		t.Run(policy, func(t *testing.T) {
+			roomID := createRoom(t, srv, map[string]any{"theme": "Go", "anonymous_policy": policy})
+			messagesPath := "/api/rooms/" + roomID + "/messages/"
+			conn := subscribe(t, srv, roomID, "")
+
+			var created idResponse
+			body := map[string]any{"message": "Is my code slow?", "anonymous": true}
+			if status := call(t, srv, ada, http.MethodPost, messagesPath, body, &created); status != http.StatusOK {
+				t.Fatalf("post anonymous message status = %d", status)
+			}
+			anonymousIDs = append(anonymousIDs, created.ID)
+
+			var event struct {
+				Kind  string      `json:"kind"`
+				Value testMessage `json:"value"`
+			}
+			if err := json.Unmarshal(readFrame(t, conn), &event); err != nil || event.Kind != MessageKindMessageCreated || !masked(event.Value) {
+				t.Errorf("%s event = %+v, want the author masked", MessageKindMessageCreated, event)
+			}
+
+			var message testMessage
+			if status := call(t, srv, ada, http.MethodGet, messagesPath+created.ID+"/", nil, &message); status != http.StatusOK || !masked(message) {
+				t.Errorf("get message = %d %+v, want the author masked", status, message)
+			}
+
+			var messages []testMessage
+			if status := call(t, srv, ada, http.MethodGet, messagesPath, nil, &messages); status != http.StatusOK || len(messages) != 1 || !masked(messages[0]) {
+				t.Errorf("list messages = %d %+v, want the author masked", status, messages)
+			}
+		})
+	}
+
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go", "anonymous_policy": anonymousPolicyAllowed})
+	namedID := postMessage(t, srv, ada, roomID, "Is Go fast?")
+
+	// Only the author finds their anonymous messages on their profile
+	tests := []struct {
+		name  string
+		token string
+		want  []string
+	}{
+		{"Author", ada, []string{namedID, anonymousIDs[1], anonymousIDs[0]}},
+		{"Other user", userToken(t, "bob", "Bob"), []string{namedID}},
+		{"Guest", "", []string{namedID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var profile struct {
+				Messages []testMessage `json:"messages"`
+			}
+			if status := call(t, srv, tt.token, http.MethodGet, "/api/users/ada", nil, &profile); status != http.StatusOK {
+				t.Fatalf("get user status = %d", status)
+			}
+			var got []string
+			for _, m := range profile.Messages {
+				got = append(got, m.ID)
+				if m.ID != namedID && !masked(m) {
+					t.Errorf("profile message = %+v, want the author masked", m)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserMessagesRoomScope(t *testing.T) {
+	srv := newTestServer(t)
+	ada := userToken(t, "ada", "Ada")
+
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go"})
+	otherRoomID := createRoom(t, srv, map[string]any{"theme": "Rust"})
+	closedRoomID := createRoom(t, srv, map[string]any{"theme": "Members only", "allow_guests": false})
+	goID := postMessage(t, srv, ada, roomID, "Is Go fast?")
+	rustID := postMessage(t, srv, ada, otherRoomID, "Is Rust fast?")
+	closedID := postMessage(t, srv, ada, closedRoomID, "Secret")
+
+	var key struct {
+		Key string `json:"key"`
+	}
+	body := map[string]any{"name": "reader", "room_ids": []string{roomID}, "permissions": []string{"read"}}
+	if status := call(t, srv, adminToken(t), http.MethodPost, "/api/apikeys/", body, &key); status != http.StatusOK {
+		t.Fatalf("create api key status = %d", status)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  []string
+	}{
+		{"Room-scoped key", key.Key, []string{goID}},
+		{"Guest", "", []string{rustID, goID}},
+		{"User", ada, []string{closedID, rustID, goID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var profile struct {
+				Messages []testMessage `json:"messages"`
+			}
+			if status := call(t, srv, tt.token, http.MethodGet, "/api/users/ada", nil, &profile); status != http.StatusOK {
+				t.Fatalf("get user status = %d", status)
+			}
+			var got []string
+			for _, m := range profile.Messages {
+				got = append(got, m.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuestTokens(t *testing.T) {
+	srv := newTestServer(t)
+
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go"})
+	otherRoomID := createRoom(t, srv, map[string]any{"theme": "Rust"})
+	closedRoomID := createRoom(t, srv, map[string]any{"theme": "Members only", "allow_guests": false})
+
+	type guestToken struct {
+		Token   string `json:"token"`
+		GuestID string `json:"guest_id"`
+		Name    string `json:"name"`
+		RoomID  string `json:"room_id"`
+	}
+
+	var ana, ana2 guestToken
+	if status := call(t, srv, "", http.MethodPost, "/api/auth/guest/", map[string]any{"room_id": roomID, "name": "Ana"}, &ana); status != http.StatusOK {
+		t.Fatalf("issue guest token status = %d", status)
+	}
+	if ana.Name != "Ana" || ana.RoomID != roomID || !strings.HasPrefix(ana.GuestID, "guest:") || ana.Token == "" {
+		t.Errorf("guest token = %+v, want Ana in the room", ana)
+	}
+	call(t, srv, "", http.MethodPost, "/api/auth/guest/", map[string]any{"room_id": roomID, "name": "ana"}, &ana2)
+	if ana2.Name != "ana 2" || ana2.GuestID == ana.GuestID {
+		t.Errorf("second guest = %+v, want a numbered name", ana2)
+	}
+
+	messageID := postMessage(t, srv, ana.Token, roomID, "Hi, I am Ana")
+	var message testMessage
+	call(t, srv, ana.Token, http.MethodGet, "/api/rooms/"+roomID+"/messages/"+messageID+"/", nil, &message)
+	if message.AuthorID != ana.GuestID || message.AuthorName != "Ana" {
+		t.Errorf("guest message = %+v, want Ana as author", message)
+	}
+
+	var refreshed guestToken
+	if status := call(t, srv, ana.Token, http.MethodPost, "/api/auth/guest/refresh", nil, &refreshed); status != http.StatusOK {
+		t.Fatalf("refresh status = %d", status)
+	}
+	if refreshed.GuestID != ana.GuestID || refreshed.Name != ana.Name || refreshed.RoomID != roomID {
+		t.Errorf("refreshed = %+v, want the same guest as %+v", refreshed, ana)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		path   string
+		body   any
+		want   int
+	}{
+		{"Invalid room id", "", http.MethodPost, "/api/auth/guest/", map[string]any{"room_id": "nope", "name": "Bo"}, http.StatusBadRequest},
+		{"Missing room", "", http.MethodPost, "/api/auth/guest/", map[string]any{"room_id": uuid.NewString(), "name": "Bo"}, http.StatusNotFound},
+		{"Empty name", "", http.MethodPost, "/api/auth/guest/", map[string]any{"room_id": roomID, "name": " "}, http.StatusBadRequest},
+		{"Members only room", "", http.MethodPost, "/api/auth/guest/", map[string]any{"room_id": closedRoomID, "name": "Bo"}, http.StatusForbidden},
+		{"Refresh without token", "", http.MethodPost, "/api/auth/guest/refresh", nil, http.StatusUnauthorized},
+		{"Refresh a user token", userToken(t, "ada", "Ada"), http.MethodPost, "/api/auth/guest/refresh", nil, http.StatusUnauthorized},
+		{"Token of another room", ana.Token, http.MethodGet, "/api/rooms/" + otherRoomID + "/", nil, http.StatusForbidden},
+		{"Post in another room", ana.Token, http.MethodPost, "/api/rooms/" + otherRoomID + "/messages/", map[string]any{"message": "Hi"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := call(t, srv, tt.token, tt.method, tt.path, tt.body, nil); status != tt.want {
+				t.Errorf("status = %d, want %d", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolls(t *testing.T) {
+	srv := newTestServer(t)
+	moderator := moderatorToken(t)
+	ada := userToken(t, "ada", "Ada")
+	bob := userToken(t, "bob", "Bob")
+
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go"})
+	pollsPath := "/api/rooms/" + roomID + "/polls/"
+
+	body := map[string]any{"question": "Tabs or spaces?", "options": []string{"Tabs", "Spaces"}}
+	if status := call(t, srv, ada, http.MethodPost, pollsPath, body, nil); status != http.StatusForbidden {
+		t.Errorf("create poll as user status = %d, want %d", status, http.StatusForbidden)
+	}
+	if status := call(t, srv, moderator, http.MethodPost, pollsPath, map[string]any{"question": "Tabs?", "options": []string{"Yes"}}, nil); status != http.StatusBadRequest {
+		t.Errorf("create poll with one option status = %d, want %d", status, http.StatusBadRequest)
+	}
+
+	var poll testPoll
+	if status := call(t, srv, moderator, http.MethodPost, pollsPath, body, &poll); status != http.StatusOK {
+		t.Fatalf("create poll status = %d", status)
+	}
+	if poll.Status != pollStatusOpen || len(poll.Options) != 2 || poll.Options[0].Label != "Tabs" || poll.TotalVotes != 0 {
+		t.Fatalf("poll = %+v, want an open poll with two options", poll)
+	}
+	pollPath := pollsPath + poll.ID
+	tabs, spaces := poll.Options[0].ID, poll.Options[1].ID
+
+	votes := []struct {
+		name  string
+		token string
+		body  any
+		want  int
+	}{
+		{"Unknown option", ada, map[string]any{"option_ids": []string{uuid.NewString()}}, http.StatusBadRequest},
+		{"No option", ada, map[string]any{"option_ids": []string{}}, http.StatusBadRequest},
+		{"Two options on single choice", ada, map[string]any{"option_ids": []string{tabs, spaces}}, http.StatusBadRequest},
+		{"Vote", ada, map[string]any{"option_ids": []string{tabs}}, http.StatusOK},
+		{"Vote twice", ada, map[string]any{"option_ids": []string{spaces}}, http.StatusConflict},
+		{"Another voter", bob, map[string]any{"option_ids": []string{tabs}}, http.StatusOK},
+	}
+	for _, v := range votes {
+		if status := call(t, srv, v.token, http.MethodPost, pollPath+"/votes", v.body, nil); status != v.want {
+			t.Errorf("%s status = %d, want %d", v.name, status, v.want)
+		}
+	}
+
+	var polls []testPoll
+	if status := call(t, srv, "", http.MethodGet, pollsPath, nil, &polls); status != http.StatusOK {
+		t.Fatalf("list polls status = %d", status)
+	}
+	if len(polls) != 1 || polls[0].TotalVotes != 2 || polls[0].Options[0].Votes != 2 || polls[0].Options[1].Votes != 0 {
+		t.Errorf("polls = %+v, want two votes for tabs", polls)
+	}
+
+	if status := call(t, srv, ada, http.MethodPatch, pollPath+"/close", nil, nil); status != http.StatusForbidden {
+		t.Errorf("close as user status = %d, want %d", status, http.StatusForbidden)
+	}
+	if status := call(t, srv, moderator, http.MethodPatch, pollPath+"/close", nil, &poll); status != http.StatusOK || poll.Status != pollStatusClosed {
+		t.Errorf("close = %d %+v, want a closed poll", status, poll)
+	}
+	if status := call(t, srv, moderator, http.MethodPatch, pollPath+"/close", nil, nil); status != http.StatusConflict {
+		t.Errorf("close twice status = %d, want %d", status, http.StatusConflict)
+	}
+	carol := userToken(t, "carol", "Carol")
+	if status := call(t, srv, carol, http.MethodPost, pollPath+"/votes", map[string]any{"option_ids": []string{spaces}}, nil); status != http.StatusConflict {
+		t.Errorf("vote on closed poll status = %d, want %d", status, http.StatusConflict)
+	}
+
+	if status := call(t, srv, "", http.MethodGet, pollPath+"/", nil, &poll); status != http.StatusOK || poll.Status != pollStatusClosed || poll.TotalVotes != 2 {
+		t.Errorf("get poll = %d %+v, want the closed poll with two votes", status, poll)
+	}
+	if status := call(t, srv, "", http.MethodGet, pollsPath+"42/", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("invalid poll id status = %d, want %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestModeration(t *testing.T) {
+	srv := newTestServer(t)
+	moderator := moderatorToken(t)
+	ada := userToken(t, "ada", "Ada")
+
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go"})
+	roomPath := "/api/rooms/" + roomID
+	messagesPath := roomPath + "/messages/"
+	moderationPath := roomPath + "/moderation"
+
+	adaMessageID := postMessage(t, srv, ada, roomID, "Buy my course")
+	guestMessageID := postMessage(t, srv, "", roomID, "Me too")
+
+	invalid := []struct {
+		name  string
+		token string
+		body  any
+		want  int
+	}{
+		{"Without token", "", map[string]any{"author_id": "ada"}, http.StatusUnauthorized},
+		{"As user", ada, map[string]any{"author_id": "ada"}, http.StatusForbidden},
+		{"No target", moderator, map[string]any{"reason": "spam"}, http.StatusBadRequest},
+		{"Shared guest author", moderator, map[string]any{"author_id": "guest"}, http.StatusBadRequest},
+		{"Guest message", moderator, map[string]any{"message_id": guestMessageID}, http.StatusBadRequest},
+		{"Invalid message id", moderator, map[string]any{"message_id": "42"}, http.StatusBadRequest},
+		{"Missing message", moderator, map[string]any{"message_id": uuid.NewString()}, http.StatusNotFound},
+		{"Negative duration", moderator, map[string]any{"author_id": "ada", "duration_seconds": -1}, http.StatusBadRequest},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := call(t, srv, tt.token, http.MethodPost, moderationPath+"/mutes", tt.body, nil); status != tt.want {
+				t.Errorf("status = %d, want %d", status, tt.want)
+			}
+		})
+	}
+
+	// A muted author still reads the room but cannot post or react
+	var mute testSanction
+	if status := call(t, srv, moderator, http.MethodPost, moderationPath+"/mutes", map[string]any{"author_id": "ada", "reason": "spam"}, &mute); status != http.StatusOK {
+		t.Fatalf("mute status = %d", status)
+	}
+	if mute.Kind != sanctionKindMute || mute.AuthorID == nil || *mute.AuthorID != "ada" {
+		t.Errorf("mute = %+v, want ada muted", mute)
+	}
+	if status := call(t, srv, ada, http.MethodPost, messagesPath, map[string]any{"message": "Hello?"}, nil); status != http.StatusForbidden {
+		t.Errorf("muted post status = %d, want %d", status, http.StatusForbidden)
+	}
+	if status := call(t, srv, ada, http.MethodPatch, messagesPath+guestMessageID+"/react", nil, nil); status != http.StatusForbidden {
+		t.Errorf("muted react status = %d, want %d", status, http.StatusForbidden)
+	}
+	var mutes []testSanction
+	if call(t, srv, moderator, http.MethodGet, moderationPath+"/mutes", nil, &mutes); len(mutes) != 1 || mutes[0].ID != mute.ID {
+		t.Errorf("mutes = %+v, want the mute", mutes)
+	}
+
+	conn := subscribe(t, srv, roomID, ada)
+
+	if status := call(t, srv, moderator, http.MethodDelete, moderationPath+"/mutes/"+mute.ID, nil, nil); status != http.StatusOK {
+		t.Fatalf("revoke mute status = %d", status)
+	}
+	if status := call(t, srv, moderator, http.MethodDelete, moderationPath+"/bans/"+mute.ID, nil, nil); status != http.StatusNotFound {
+		t.Errorf("revoke mute as ban status = %d, want %d", status, http.StatusNotFound)
+	}
+	postMessage(t, srv, ada, roomID, "Sorry")
+	readFrame(t, conn)
+
+	var kicked struct {
+		Kicked int `json:"kicked"`
+	}
+	if status := call(t, srv, moderator, http.MethodPost, moderationPath+"/kick", map[string]any{"author_id": "ada"}, &kicked); status != http.StatusOK || kicked.Kicked != 1 {
+		t.Fatalf("kick = %d %+v, want one socket closed", status, kicked)
+	}
+	expectClosed(t, conn, websocket.ClosePolicyViolation, "kicked")
+
+	// Banning by message reaches the author, who is disconnected and locked out
+	conn = subscribe(t, srv, roomID, ada)
+	var ban testSanction
+	if status := call(t, srv, moderator, http.MethodPost, moderationPath+"/bans", map[string]any{"message_id": adaMessageID}, &ban); status != http.StatusOK {
+		t.Fatalf("ban status = %d", status)
+	}
+	if ban.Kind != sanctionKindBan || ban.AuthorID == nil || *ban.AuthorID != "ada" {
+		t.Errorf("ban = %+v, want ada banned", ban)
+	}
+	expectClosed(t, conn, websocket.ClosePolicyViolation, "banned")
+
+	if _, resp, err := dialSubscribe(srv, roomID, ada); err == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("banned subscribe error = %v, want status %d", err, http.StatusForbidden)
+	}
+	if status := call(t, srv, ada, http.MethodPatch, messagesPath+guestMessageID+"/answer", nil, nil); status != http.StatusForbidden {
+		t.Errorf("banned answer status = %d, want %d", status, http.StatusForbidden)
+	}
+
+	if status := call(t, srv, moderator, http.MethodDelete, moderationPath+"/bans/"+ban.ID, nil, nil); status != http.StatusOK {
+		t.Fatalf("revoke ban status = %d", status)
+	}
+	postMessage(t, srv, ada, roomID, "Thanks")
+	if status := call(t, srv, moderator, http.MethodDelete, moderationPath+"/bans/42", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("invalid sanction id status = %d, want %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	srv := newTestServer(t)
+	admin := adminToken(t)
+
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go"})
+	otherRoomID := createRoom(t, srv, map[string]any{"theme": "Rust"})
+
+	body := map[string]any{"name": "bot", "room_ids": []string{roomID}, "permissions": []string{"post"}}
+	invalid := []struct {
+		name  string
+		token string
+		body  any
+		want  int
+	}{
+		{"Without token", "", body, http.StatusUnauthorized},
+		{"As moderator", moderatorToken(t), body, http.StatusForbidden},
+		{"Empty name", admin, map[string]any{"name": " ", "permissions": []string{"post"}}, http.StatusBadRequest},
+		{"No permission", admin, map[string]any{"name": "bot"}, http.StatusBadRequest},
+		{"Unknown permission", admin, map[string]any{"name": "bot", "permissions": []string{"delete"}}, http.StatusBadRequest},
+		{"Invalid room id", admin, map[string]any{"name": "bot", "room_ids": []string{"42"}, "permissions": []string{"post"}}, http.StatusBadRequest},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := call(t, srv, tt.token, http.MethodPost, "/api/apikeys/", tt.body, nil); status != tt.want {
+				t.Errorf("status = %d, want %d", status, tt.want)
+			}
+		})
+	}
+
+	var key struct {
+		ID     string `json:"id"`
+		Key    string `json:"key"`
+		Prefix string `json:"prefix"`
+	}
+	if status := call(t, srv, admin, http.MethodPost, "/api/apikeys/", body, &key); status != http.StatusOK {
+		t.Fatalf("create api key status = %d", status)
+	}
+	if !strings.HasPrefix(key.Key, apiKeyPrefix) || !strings.HasPrefix(key.Key, key.Prefix) {
+		t.Errorf("api key = %+v, want a prefixed key", key)
+	}
+
+	var keys []struct {
+		ID  string `json:"id"`
+		Key string `json:"key"`
+	}
+	if call(t, srv, admin, http.MethodGet, "/api/apikeys/", nil, &keys); len(keys) != 1 || keys[0].ID != key.ID || keys[0].Key != "" {
+		t.Errorf("api keys = %+v, want the key without its secret", keys)
+	}
+
+	messageID := postMessage(t, srv, key.Key, roomID, "Deploy finished")
+	var message testMessage
+	call(t, srv, "", http.MethodGet, "/api/rooms/"+roomID+"/messages/"+messageID+"/", nil, &message)
+	if message.AuthorID != "bot:"+key.ID || message.AuthorName != "bot" || !message.IsBot {
+		t.Errorf("bot message = %+v, want the bot as author", message)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{"Missing permission", http.MethodGet, "/api/rooms/" + roomID + "/messages/", http.StatusForbidden},
+		{"Another room", http.MethodPost, "/api/rooms/" + otherRoomID + "/messages/", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := call(t, srv, key.Key, tt.method, tt.path, map[string]any{"message": "Hi"}, nil); status != tt.want {
+				t.Errorf("status = %d, want %d", status, tt.want)
+			}
+		})
+	}
+
+	if status := call(t, srv, admin, http.MethodDelete, "/api/apikeys/"+key.ID, nil, nil); status != http.StatusOK {
+		t.Fatalf("revoke api key status = %d", status)
+	}
+	if status := call(t, srv, key.Key, http.MethodPost, "/api/rooms/"+roomID+"/messages/", map[string]any{"message": "Hi"}, nil); status != http.StatusUnauthorized {
+		t.Errorf("revoked key status = %d, want %d", status, http.StatusUnauthorized)
+	}
+	if status := call(t, srv, apiKeyPrefix+"unknown", http.MethodGet, "/api/rooms/", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("unknown key status = %d, want %d", status, http.StatusUnauthorized)
+	}
+	if status := call(t, srv, admin, http.MethodDelete, "/api/apikeys/"+uuid.NewString(), nil, nil); status != http.StatusNotFound {
+		t.Errorf("revoke missing key status = %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	srv := newTestServer(t)
+	admin := adminToken(t)
+	ada := userToken(t, "ada", "Ada")
+
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go"})
+	messageID := postMessage(t, srv, ada, roomID, "Is Go fast?")
+	call(t, srv, ada, http.MethodPatch, "/api/rooms/"+roomID+"/messages/"+messageID+"/react", nil, nil)
+	// The second removal finds no reaction left, so it logs no change
+	call(t, srv, ada, http.MethodDelete, "/api/rooms/"+roomID+"/messages/"+messageID+"/react", nil, nil)
+	call(t, srv, ada, http.MethodDelete, "/api/rooms/"+roomID+"/messages/"+messageID+"/react", nil, nil)
+
+	type entry struct {
+		Action   string          `json:"action"`
+		ActorID  string          `json:"actor_id"`
+		TargetID string          `json:"target_id"`
+		Before   json.RawMessage `json:"before"`
+		After    json.RawMessage `json:"after"`
+	}
+	var entries []entry
+	if status := call(t, srv, admin, http.MethodGet, "/api/audit?room_id="+roomID, nil, &entries); status != http.StatusOK {
+		t.Fatalf("audit log status = %d", status)
+	}
+
+	want := []entry{
+		{Action: auditMessageUnreact, ActorID: "ada", TargetID: messageID, Before: json.RawMessage(`{"reaction_count":0}`), After: json.RawMessage(`{"reaction_count":0}`)},
+		{Action: auditMessageUnreact, ActorID: "ada", TargetID: messageID, Before: json.RawMessage(`{"reaction_count":1}`), After: json.RawMessage(`{"reaction_count":0}`)},
+		{Action: auditMessageReact, ActorID: "ada", TargetID: messageID, After: json.RawMessage(`{"reaction_count":1}`)},
+		{Action: auditMessageCreate, ActorID: "ada", TargetID: messageID},
+		{Action: auditRoomCreate, ActorID: "guest", TargetID: roomID},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("audit log = %+v, want %d entries", entries, len(want))
+	}
+	for i, w := range want {
+		got := entries[i]
+		if got.Action != w.Action || got.ActorID != w.ActorID || got.TargetID != w.TargetID || (w.Before != nil && string(got.Before) != string(w.Before)) || (w.After != nil && string(got.After) != string(w.After)) {
+			t.Errorf("entry %d = %+v, want %+v", i, got, w)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		path  string
+		want  int
+	}{
+		{"Without token", "", "/api/audit", http.StatusUnauthorized},
+		{"As user", ada, "/api/audit", http.StatusForbidden},
+		{"Invalid room id", admin, "/api/audit?room_id=42", http.StatusBadRequest},
+		{"Invalid since", admin, "/api/audit?since=yesterday", http.StatusBadRequest},
+		{"Invalid before_id", admin, "/api/audit?before_id=0", http.StatusBadRequest},
+		{"Invalid limit", admin, "/api/audit?limit=0", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := call(t, srv, tt.token, http.MethodGet, tt.path, nil, nil); status != tt.want {
+				t.Errorf("status = %d, want %d", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	srv := newTestServer(t)
+	admin := adminToken(t)
+
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go"})
+
+	invalid := []struct {
+		name  string
+		token string
+		body  any
+		want  int
+	}{
+		{"Without token", "", map[string]any{"url": "https://example.com/hook"}, http.StatusUnauthorized},
+		{"As moderator", moderatorToken(t), map[string]any{"url": "https://example.com/hook"}, http.StatusForbidden},
+		{"Relative url", admin, map[string]any{"url": "/hook"}, http.StatusBadRequest},
+		{"Invalid room id", admin, map[string]any{"url": "https://example.com/hook", "room_id": "42"}, http.StatusBadRequest},
+		{"Missing room", admin, map[string]any{"url": "https://example.com/hook", "room_id": uuid.NewString()}, http.StatusNotFound},
+		{"Unknown event kind", admin, map[string]any{"url": "https://example.com/hook", "event_kinds": []string{"message_creatd"}}, http.StatusBadRequest},
+		{"Connection frame kind", admin, map[string]any{"url": "https://example.com/hook", "event_kinds": []string{messageKindServerPong}}, http.StatusBadRequest},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := call(t, srv, tt.token, http.MethodPost, "/api/webhooks/", tt.body, nil); status != tt.want {
+				t.Errorf("status = %d, want %d", status, tt.want)
+			}
+		})
+	}
+
+	type webhook struct {
+		ID         string   `json:"id"`
+		RoomID     *string  `json:"room_id"`
+		Url        string   `json:"url"`
+		Secret     string   `json:"secret"`
+		EventKinds []string `json:"event_kinds"`
+	}
+	var hook webhook
+	body := map[string]any{"url": "https://example.com/hook", "room_id": roomID, "event_kinds": []string{" message_created ", "", "message_created"}}
+	if status := call(t, srv, admin, http.MethodPost, "/api/webhooks/", body, &hook); status != http.StatusOK {
+		t.Fatalf("create webhook status = %d", status)
+	}
+	if !strings.HasPrefix(hook.Secret, "whsec_") || hook.RoomID == nil || *hook.RoomID != roomID || !reflect.DeepEqual(hook.EventKinds, []string{MessageKindMessageCreated}) {
+		t.Errorf("webhook = %+v, want a signed room webhook for %s", hook, MessageKindMessageCreated)
+	}
+
+	var hooks []webhook
+	if call(t, srv, admin, http.MethodGet, "/api/webhooks/", nil, &hooks); len(hooks) != 1 || hooks[0].ID != hook.ID || hooks[0].Secret != "" {
+		t.Errorf("webhooks = %+v, want the webhook without its secret", hooks)
+	}
+
+	var deliveries []json.RawMessage
+	if status := call(t, srv, admin, http.MethodGet, "/api/webhooks/deliveries", nil, &deliveries); status != http.StatusOK || len(deliveries) != 0 {
+		t.Errorf("dead deliveries = %d %s, want none", status, deliveries)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{"Invalid delivery status", http.MethodGet, "/api/webhooks/deliveries?status=lost", http.StatusBadRequest},
+		{"Invalid delivery limit", http.MethodGet, "/api/webhooks/deliveries?limit=501", http.StatusBadRequest},
+		{"Redeliver invalid id", http.MethodPost, "/api/webhooks/deliveries/42/redeliver", http.StatusBadRequest},
+		{"Redeliver missing delivery", http.MethodPost, "/api/webhooks/deliveries/" + uuid.NewString() + "/redeliver", http.StatusNotFound},
+		{"Delete invalid id", http.MethodDelete, "/api/webhooks/42", http.StatusBadRequest},
+		{"Delete", http.MethodDelete, "/api/webhooks/" + hook.ID, http.StatusNoContent},
+		{"Delete twice", http.MethodDelete, "/api/webhooks/" + hook.ID, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := call(t, srv, admin, tt.method, tt.path, nil, nil); status != tt.want {
+				t.Errorf("status = %d, want %d", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportRoom(t *testing.T) {
+	srv := newTestServer(t)
+	moderator := moderatorToken(t)
+
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go"})
+	visibleID := postMessage(t, srv, userToken(t, "ada", "Ada"), roomID, "Is Go fast?")
+	hiddenID := postMessage(t, srv, "", roomID, "Spam")
+	call(t, srv, moderator, http.MethodPatch, "/api/rooms/"+roomID+"/messages/"+hiddenID+"/hide", nil, nil)
+
+	exportPath := "/api/rooms/" + roomID + "/export"
+	for _, tt := range []struct {
+		query       string
+		token       string
+		contentType string
+		contains    []string
+		excludes    []string
+	}{
+		{"", "", "application/json", []string{visibleID, `"Go"`}, []string{hiddenID}},
+		{"?format=csv", "", "text/csv", []string{visibleID}, []string{hiddenID}},
+		{"?format=md&include_hidden=true", moderator, "text/markdown", []string{"Is Go fast?", "Spam"}, nil},
+	} {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+exportPath+tt.query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body strings.Builder
+		_, err = io.Copy(&body, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), tt.contentType) {
+			t.Errorf("export%s = %d %s, want %s", tt.query, resp.StatusCode, resp.Header.Get("Content-Type"), tt.contentType)
+		}
+		for _, s := range tt.contains {
+			if !strings.Contains(body.String(), s) {
+				t.Errorf("export%s does not contain %q", tt.query, s)
+			}
+		}
+		for _, s := range tt.excludes {
+			if strings.Contains(body.String(), s) {
+				t.Errorf("export%s contains %q", tt.query, s)
+			}
+		}
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		query string
+		want  int
+	}{
+		{"Invalid format", "", "?format=pdf", http.StatusBadRequest},
+		{"Invalid flag", "", "?anonymize=maybe", http.StatusBadRequest},
+		{"Hidden without token", "", "?include_hidden=true", http.StatusUnauthorized},
+		{"Hidden as user", userToken(t, "ada", "Ada"), "?include_hidden=true", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := call(t, srv, tt.token, http.MethodGet, exportPath+tt.query, nil, nil); status != tt.want {
+				t.Errorf("status = %d, want %d", status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/i18n"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/gorilla/websocket"
+)
+
+// errorMessages holds the text of every apiError, by code
+var errorMessages = i18n.Catalog{}
+
+// apiError is a failure reported to the client. The code is stable and meant
+// for programs, the message is translated for people.
+type apiError struct {
+	status int
+	code   string
+	args   []any
+}
+
+// newAPIError registers the messages of the code in English and Brazilian
+// Portuguese, which may be formats for the arguments given to with
+func newAPIError(status int, code, en, ptBR string) apiError {
+	errorMessages.Add(code, en, ptBR)
+	return apiError{status: status, code: code}
+}
+
+func (e apiError) Error() string {
+	return e.message(i18n.Default)
+}
+
+func (e apiError) message(l i18n.Locale) string {
+	return errorMessages.Sprintf(l, e.code, e.args...)
+}
+
+// with returns the error with the arguments of its message
+func (e apiError) with(args ...any) apiError {
+	e.args = args
+	return e
+}
+
+var (
+	errInternal         = newAPIError(http.StatusInternalServerError, "internal_error", "something went wrong", "algo deu errado")
+	errInvalidJSON      = newAPIError(http.StatusBadRequest, "invalid_json", "invalid json", "JSON inválido")
+	errInvalidParameter = newAPIError(http.StatusBadRequest, "invalid_parameter", "invalid %s", "valor inválido para %s")
+	errRouteNotFound    = newAPIError(http.StatusNotFound, "route_not_found", "no such route", "rota inexistente")
+	errMethodNotAllowed = newAPIError(http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed", "método não permitido")
+	errRateLimited      = newAPIError(http.StatusTooManyRequests, "rate_limited", "too many requests", "muitas requisições")
+	errShuttingDown     = newAPIError(http.StatusServiceUnavailable, "shutting_down", "server is shutting down", "o servidor está sendo desligado")
+
+	errInvalidRoomID     = newAPIError(http.StatusBadRequest, "invalid_room_id", "invalid room id", "ID de sala inválido")
+	errInvalidMessageID  = newAPIError(http.StatusBadRequest, "invalid_message_id", "invalid message id", "ID de mensagem inválido")
+	errInvalidPollID     = newAPIError(http.StatusBadRequest, "invalid_poll_id", "invalid poll id", "ID de enquete inválido")
+	errInvalidOptionID   = newAPIError(http.StatusBadRequest, "invalid_option_id", "invalid option id", "ID de opção inválido")
+	errInvalidSanctionID = newAPIError(http.StatusBadRequest, "invalid_sanction_id", "invalid sanction id", "ID de sanção inválido")
+	errInvalidKeyID      = newAPIError(http.StatusBadRequest, "invalid_key_id", "invalid key id", "ID de chave inválido")
+	errInvalidWebhookID  = newAPIError(http.StatusBadRequest, "invalid_webhook_id", "invalid webhook id", "ID de webhook inválido")
+	errInvalidDeliveryID = newAPIError(http.StatusBadRequest, "invalid_delivery_id", "invalid delivery id", "ID de entrega inválido")
+
+	errRoomNotFound     = newAPIError(http.StatusNotFound, "room_not_found", "room not found", "sala não encontrada")
+	errMessageNotFound  = newAPIError(http.StatusNotFound, "message_not_found", "message not found", "mensagem não encontrada")
+	errPollNotFound     = newAPIError(http.StatusNotFound, "poll_not_found", "poll not found", "enquete não encontrada")
+	errSanctionNotFound = newAPIError(http.StatusNotFound, "sanction_not_found", "sanction not found", "sanção não encontrada")
+	errAPIKeyNotFound   = newAPIError(http.StatusNotFound, "api_key_not_found", "api key not found", "chave de API não encontrada")
+	errWebhookNotFound  = newAPIError(http.StatusNotFound, "webhook_not_found", "webhook not found", "webhook não encontrado")
+	errDeliveryNotFound = newAPIError(http.StatusNotFound, "delivery_not_found", "delivery not found", "entrega não encontrada")
+	errUserNotFound     = newAPIError(http.StatusNotFound, "user_not_found", "user not found", "usuário não encontrado")
+
+	errAuthenticationRequired = newAPIError(http.StatusUnauthorized, "authentication_required", "authentication required", "autenticação necessária")
+	errModeratorRequired      = newAPIError(http.StatusForbidden, "moderator_required", "moderator role required", "papel de moderador necessário")
+	errAdminRequired          = newAPIError(http.StatusForbidden, "admin_required", "admin role required", "papel de administrador necessário")
+	errInvalidAPIKey          = newAPIError(http.StatusUnauthorized, "invalid_api_key", "invalid api key", "chave de API inválida")
+	errAPIKeyPermission       = newAPIError(http.StatusForbidden, "api_key_permission_denied", "api key lacks the %s permission", "a chave de API não tem a permissão %s")
+	errAPIKeyRoom             = newAPIError(http.StatusForbidden, "api_key_room_denied", "api key is not allowed in this room", "a chave de API não tem acesso a esta sala")
+	errBanned                 = newAPIError(http.StatusForbidden, "banned", "you are banned from this room", "você foi banido desta sala")
+	errMuted                  = newAPIError(http.StatusForbidden, "muted", "you are muted in this room", "você foi silenciado nesta sala")
+	errKicked                 = newAPIError(http.StatusForbidden, "kicked", "you were removed from this room", "você foi removido desta sala")
+
+	errGuestTokensDisabled = newAPIError(http.StatusNotImplemented, "guest_tokens_disabled", "guest tokens are disabled", "tokens de convidado estão desativados")
+	errGuestTokenRequired  = newAPIError(http.StatusUnauthorized, "guest_token_required", "guest token required", "token de convidado necessário")
+	errInvalidGuestToken   = newAPIError(http.StatusUnauthorized, "invalid_guest_token", "invalid guest token", "token de convidado inválido")
+	errGuestsNotAllowed    = newAPIError(http.StatusForbidden, "guests_not_allowed", "guests are not allowed in this room", "convidados não são permitidos nesta sala")
+	errGuestTokenRoom      = newAPIError(http.StatusForbidden, "guest_token_room_mismatch", "guest token is not valid in this room", "o token de convidado não vale nesta sala")
+	errNameTaken           = newAPIError(http.StatusConflict, "guest_name_taken", "name is already taken in this room", "o nome já está em uso nesta sala")
+
+	errAnonymousNotAllowed = newAPIError(http.StatusForbidden, "anonymous_not_allowed", "anonymous messages are not allowed in this room", "mensagens anônimas não são permitidas nesta sala")
+	errPollClosed          = newAPIError(http.StatusConflict, "poll_closed", "poll is closed", "a enquete está encerrada")
+	errAlreadyVoted        = newAPIError(http.StatusConflict, "already_voted", "already voted", "você já votou")
+	errNoOption            = newAPIError(http.StatusBadRequest, "option_required", "at least one option is required", "escolha ao menos uma opção")
+	errSingleChoice        = newAPIError(http.StatusBadRequest, "single_choice", "poll accepts a single option", "a enquete aceita uma única opção")
+
+	errEmptyName         = newAPIError(http.StatusBadRequest, "empty_name", "name cannot be empty", "o nome não pode ser vazio")
+	errNoPermission      = newAPIError(http.StatusBadRequest, "permission_required", "at least one permission is required", "informe ao menos uma permissão")
+	errUnknownPermission = newAPIError(http.StatusBadRequest, "unknown_permission", "unknown permission %s", "permissão desconhecida: %s")
+	errInvalidWebhookURL = newAPIError(http.StatusBadRequest, "invalid_webhook_url", "url must be an absolute http or https url", "a url deve ser absoluta, http ou https")
+	errUnknownEventKind  = newAPIError(http.StatusBadRequest, "unknown_event_kind", "unknown event kind %s", "tipo de evento desconhecido: %s")
+
+	errNoSanctionTarget  = newAPIError(http.StatusBadRequest, "target_required", "author_id, ip or message_id is required", "informe author_id, ip ou message_id")
+	errGuestAuthorTarget = newAPIError(http.StatusBadRequest, "shared_guest_author", "guests share an author id, sanction them by ip", "convidados compartilham o mesmo author_id, aplique a sanção por ip")
+	errGuestMessage      = newAPIError(http.StatusBadRequest, "unidentified_guest", "message was posted by an unidentified guest, sanction them by ip", "a mensagem foi enviada por um convidado não identificado, aplique a sanção por ip")
+	errNegativeDuration  = newAPIError(http.StatusBadRequest, "negative_duration", "duration_seconds cannot be negative", "duration_seconds não pode ser negativo")
+
+	errHandshake    = newAPIError(http.StatusBadRequest, "websocket_handshake_failed", "failed to upgrade to ws connection", "falha ao abrir a conexão WebSocket")
+	errInvalidFrame = newAPIError(http.StatusBadRequest, "invalid_frame", "invalid frame", "frame inválido")
+)
+
+// validationErrors reports the errors of validators.go, which stay plain
+// errors for the callers outside of the API
+var validationErrors = map[error]apiError{
+	ErrInvalidID:         newAPIError(http.StatusBadRequest, "invalid_id", "invalid ID format", "formato de ID inválido"),
+	ErrEmptyMessage:      newAPIError(http.StatusBadRequest, "empty_message", "message cannot be empty", "a mensagem não pode ser vazia"),
+	ErrMessageTooLong:    newAPIError(http.StatusBadRequest, "message_too_long", "message exceeds maximum length", "a mensagem excede o tamanho máximo"),
+	ErrInvalidAuthorID:   newAPIError(http.StatusBadRequest, "empty_author_id", "author ID cannot be empty", "o ID do autor não pode ser vazio"),
+	ErrInvalidAuthorName: newAPIError(http.StatusBadRequest, "empty_author_name", "author name cannot be empty", "o nome do autor não pode ser vazio"),
+	ErrInvalidTheme:      newAPIError(http.StatusBadRequest, "empty_theme", "theme cannot be empty", "o tema não pode ser vazio"),
+	ErrThemeTooLong:      newAPIError(http.StatusBadRequest, "theme_too_long", "theme exceeds maximum length", "o tema excede o tamanho máximo"),
+	ErrEmptyPollQuestion: newAPIError(http.StatusBadRequest, "empty_poll_question", "poll question cannot be empty", "a pergunta da enquete não pode ser vazia"),
+	ErrPollQuestionLong:  newAPIError(http.StatusBadRequest, "poll_question_too_long", "poll question exceeds maximum length", "a pergunta da enquete excede o tamanho máximo"),
+	ErrPollOptionCount:   newAPIError(http.StatusBadRequest, "poll_option_count", "poll must have between 2 and 10 options", "a enquete deve ter entre 2 e 10 opções"),
+	ErrEmptyPollOption:   newAPIError(http.StatusBadRequest, "empty_poll_option", "poll option cannot be empty", "a opção da enquete não pode ser vazia"),
+	ErrPollOptionTooLong: newAPIError(http.StatusBadRequest, "poll_option_too_long", "poll option exceeds maximum length", "a opção da enquete excede o tamanho máximo"),
+	ErrDuplicateOption:   newAPIError(http.StatusBadRequest, "duplicate_poll_option", "poll options must be unique", "as opções da enquete devem ser únicas"),
+	ErrEmptyGuestName:    newAPIError(http.StatusBadRequest, "empty_guest_name", "guest name cannot be empty", "o nome do convidado não pode ser vazio"),
+	ErrGuestNameTooLong:  newAPIError(http.StatusBadRequest, "guest_name_too_long", "guest name exceeds maximum length", "o nome do convidado excede o tamanho máximo"),
+	ErrInvalidGuestName:  newAPIError(http.StatusBadRequest, "invalid_guest_name", "guest name contains invalid characters", "o nome do convidado contém caracteres inválidos"),
+	ErrReservedGuestName: newAPIError(http.StatusBadRequest, "reserved_guest_name", "guest name is reserved", "o nome do convidado é reservado"),
+}
+
+// toAPIError returns the apiError of err. Anything unexpected is an internal
+// error, whose details are for the logs only.
+func toAPIError(err error) apiError {
+	var e apiError
+	if errors.As(err, &e) {
+		return e
+	}
+	if e, ok := validationErrors[err]; ok {
+		return e
+	}
+	return errInternal
+}
+
+// errorBody is the error of the JSON envelope, also sent over WebSocket as
+// the value of error frames
+type errorBody struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// errorResponse is the body of every error response
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+func (e apiError) body(l i18n.Locale, requestID string) errorBody {
+	return errorBody{Code: e.code, Message: e.message(l), RequestID: requestID}
+}
+
+// requestLocale returns the locale the client asked for with Accept-Language
+func requestLocale(r *http.Request) i18n.Locale {
+	return i18n.Negotiate(r.Header.Get("Accept-Language"))
+}
+
+// writeError writes the JSON envelope of err in the language of the request
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	e := toAPIError(err)
+	locale := requestLocale(r)
+
+	data, _ := json.Marshal(errorResponse{Error: e.body(locale, middleware.GetReqID(r.Context()))})
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", string(locale))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Add("Vary", "Accept-Language")
+	w.WriteHeader(e.status)
+	_, _ = w.Write(data)
+}
+
+// sendErrorFrame writes the error to one subscriber, in the language of its
+// handshake. The caller holds h.mu and has set the write deadline.
+func sendErrorFrame(conn *websocket.Conn, sub *subscriber, rawRoomID string, e apiError) error {
+	return conn.WriteJSON(Message{Kind: messageKindError, RoomID: rawRoomID, Value: e.body(sub.locale, sub.requestID)})
+}
+
+// handshakeError answers the WebSocket handshakes that the upgrader refuses,
+// e.g. with 403 for a foreign origin
+func handshakeError(w http.ResponseWriter, r *http.Request, status int, _ error) {
+	e := errHandshake
+	e.status = status
+	writeError(w, r, e)
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	srv := newTestServer(t)
+	moderator := testToken(t, jwt.MapClaims{"sub": "mod", "name": "Mod", "role": "moderator"})
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go"})
+
+	tests := []struct {
+		name     string
+		language string
+		method   string
+		path     string
+		body     string
+		status   int
+		code     string
+		message  string
+	}{
+		{"Missing room", "", http.MethodGet, "/api/rooms/" + uuid.NewString() + "/", "", http.StatusNotFound, "room_not_found", "room not found"},
+		{"Missing room in Portuguese", "pt-BR,pt;q=0.9", http.MethodGet, "/api/rooms/" + uuid.NewString() + "/", "", http.StatusNotFound, "room_not_found", "sala não encontrada"},
+		{"Unsupported language", "de", http.MethodGet, "/api/rooms/42/", "", http.StatusBadRequest, "invalid_room_id", "invalid room id"},
+		{"Invalid parameter", "pt", http.MethodGet, "/api/rooms/" + roomID + "/messages/?sort=new", "", http.StatusBadRequest, "invalid_parameter", "valor inválido para sort"},
+		{"Validator error", "", http.MethodPost, "/api/rooms/", `{"theme":" "}`, http.StatusBadRequest, "empty_theme", "theme cannot be empty"},
+		{"Unknown route", "", http.MethodGet, "/api/nope", "", http.StatusNotFound, "route_not_found", "no such route"},
+		{"Wrong method", "pt-BR", http.MethodPut, "/api/rooms/", "", http.StatusMethodNotAllowed, "method_not_allowed", "método não permitido"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, srv.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+moderator)
+			if tt.language != "" {
+				req.Header.Set("Accept-Language", tt.language)
+			}
+
+			resp, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			var got errorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("decode envelope: %v", err)
+			}
+			if resp.StatusCode != tt.status || resp.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("response = %d %s, want %d application/json", resp.StatusCode, resp.Header.Get("Content-Type"), tt.status)
+			}
+			if got.Error.Code != tt.code || got.Error.Message != tt.message || got.Error.RequestID == "" {
+				t.Errorf("error = %+v, want %s %q with a request id", got.Error, tt.code, tt.message)
+			}
+		})
+	}
+}
+
+func TestErrorFrameLanguage(t *testing.T) {
+	srv := newTestServer(t)
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go"})
+
+	header := http.Header{"Accept-Language": {"pt-BR"}}
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/subscribe/"+roomID, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("?")); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Kind  string    `json:"kind"`
+		Value errorBody `json:"value"`
+	}
+	if err := json.Unmarshal(readFrame(t, conn), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Kind != messageKindError || got.Value.Message != "frame inválido" {
+		t.Errorf("error frame = %+v, want invalid_frame in Portuguese", got)
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	// Every Err variable of validators.go must reach clients with its own code
+	file, err := parser.ParseFile(token.NewFileSet(), "validators.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		if spec, ok := n.(*ast.ValueSpec); ok {
+			for _, name := range spec.Names {
+				if strings.HasPrefix(name.Name, "Err") {
+					names = append(names, name.Name)
+				}
+			}
+		}
+		return true
+	})
+
+	if len(names) != len(validationErrors) {
+		t.Errorf("validators.go declares %d errors, validationErrors maps %d", len(names), len(validationErrors))
+	}
+	for err, e := range validationErrors {
+		if e.Error() != err.Error() {
+			t.Errorf("%s says %q, want the text of the error %q", e.code, e.Error(), err.Error())
+		}
+	}
+}
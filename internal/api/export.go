@@ -0,0 +1,53 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/export"
+)
+
+// handleExportRoom streams the room transcript with its messages and poll
+// results as JSON, CSV or Markdown
+func (h apiHandler) handleExportRoom(w http.ResponseWriter, r *http.Request) {
+	room, rawRoomID, _, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+
+	format, err := export.ParseFormat(query.Get("format"))
+	if err != nil {
+		writeError(w, r, errInvalidParameter.with("format"))
+		return
+	}
+
+	opts := export.Options{Format: format}
+	for name, dst := range map[string]*bool{"include_hidden": &opts.IncludeHidden, "anonymize": &opts.Anonymize} {
+		if raw := query.Get(name); raw != "" {
+			if *dst, err = strconv.ParseBool(raw); err != nil {
+				writeError(w, r, errInvalidParameter.with(name))
+				return
+			}
+		}
+	}
+
+	// Hidden messages were removed by moderators and stay visible to them only
+	if opts.IncludeHidden && !h.requireModerator(w, r) {
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="room-%s.%s"`, rawRoomID, format.Extension()))
+
+	if err := export.Room(r.Context(), w, h.q, room, opts); err != nil {
+		// Headers are gone by now, so the client sees a truncated body
+		if !errors.Is(err, r.Context().Err()) {
+			slog.Error("failed to export room", "error", err, "room_id", rawRoomID)
+		}
+	}
+}
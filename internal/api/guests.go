@@ -0,0 +1,314 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/auth"
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	roleGuest = "guest"
+
+	// How many numbered variants of a taken name are tried, e.g. "Ana 2"
+	guestNameAttempts  = 20
+	guestPruneInterval = 10 * time.Minute
+)
+
+var errGuestNameTaken = errors.New("guest name is taken")
+
+// guestIssuer signs guest tokens with the HMAC secret the verifier accepts
+type guestIssuer struct {
+	secret   []byte
+	issuer   string
+	audience string
+	ttl      time.Duration
+
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+// newGuestIssuer returns nil, disabling guest tokens, when no HMAC secret is
+// configured
+func newGuestIssuer(cfg auth.Config, ttl time.Duration) *guestIssuer {
+	if len(cfg.HMACSecret) == 0 {
+		return nil
+	}
+	return &guestIssuer{secret: cfg.HMACSecret, issuer: cfg.Issuer, audience: cfg.Audience, ttl: ttl}
+}
+
+func (g *guestIssuer) sign(guestID, name string, roomID uuid.UUID, expiresAt time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"sub":     guestID,
+		"name":    name,
+		"role":    roleGuest,
+		"room_id": roomID.String(),
+		"iat":     time.Now().Unix(),
+		"exp":     expiresAt.Unix(),
+	}
+	if g.issuer != "" {
+		claims["iss"] = g.issuer
+	}
+	if g.audience != "" {
+		claims["aud"] = g.audience
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(g.secret)
+}
+
+// isGuest reports whether the request comes from an anonymous caller or a
+// guest token
+func isGuest(r *http.Request) bool {
+	if _, ok := apiKeyFromContext(r.Context()); ok {
+		return false
+	}
+	claims := extractClaimsFromJWT(r)
+	return claims == nil || hasRole(claims, roleGuest)
+}
+
+// checkGuestAccess writes 403 and returns false when the room denies guests
+// or the guest token was issued for another room
+func checkGuestAccess(w http.ResponseWriter, r *http.Request, room store.Room) bool {
+	if err := guestAccessError(r, room); err != nil {
+		writeError(w, r, err)
+		return false
+	}
+	return true
+}
+
+func guestAccessError(r *http.Request, room store.Room) error {
+	if !isGuest(r) {
+		return nil
+	}
+
+	if !room.AllowGuests {
+		return errGuestsNotAllowed
+	}
+
+	if claims := extractClaimsFromJWT(r); claims != nil {
+		if roomID, _ := claims["room_id"].(string); roomID != room.ID.String() {
+			return errGuestTokenRoom
+		}
+	}
+	return nil
+}
+
+// claimGuestName reserves the name in the room, numbering it when another
+// guest already holds it, and returns the name actually taken
+func (h apiHandler) claimGuestName(ctx context.Context, roomID uuid.UUID, guestID, name string, expiresAt time.Time) (string, error) {
+	h.pruneGuestNames(ctx)
+
+	for i := 1; i <= guestNameAttempts; i++ {
+		candidate := name
+		if i > 1 {
+			candidate = fmt.Sprintf("%s %d", name, i)
+		}
+
+		_, err := h.q.ClaimGuestName(ctx, store.ClaimGuestNameParams{
+			RoomID:    roomID,
+			NameKey:   strings.ToLower(candidate),
+			Name:      candidate,
+			GuestID:   guestID,
+			ExpiresAt: expiresAt,
+		})
+		if err == nil {
+			return candidate, nil
+		}
+		if !errors.Is(err, store.ErrNotFound) {
+			return "", err
+		}
+	}
+	return "", errGuestNameTaken
+}
+
+// pruneGuestNames removes expired reservations every few minutes
+func (h apiHandler) pruneGuestNames(ctx context.Context) {
+	h.guests.mu.Lock()
+	if time.Since(h.guests.lastPrune) < guestPruneInterval {
+		h.guests.mu.Unlock()
+		return
+	}
+	h.guests.lastPrune = time.Now()
+	h.guests.mu.Unlock()
+
+	if err := h.q.DeleteExpiredGuestNames(ctx); err != nil {
+		slog.Warn("failed to prune guest names", "error", err)
+	}
+}
+
+type guestTokenResponse struct {
+	Token     string    `json:"token"`
+	GuestID   string    `json:"guest_id"`
+	Name      string    `json:"name"`
+	RoomID    string    `json:"room_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// issueGuestToken reserves the name and writes a fresh token for the guest
+func (h apiHandler) issueGuestToken(w http.ResponseWriter, r *http.Request, room store.Room, guestID, name string) {
+	if !room.AllowGuests {
+		writeError(w, r, errGuestsNotAllowed)
+		return
+	}
+
+	expiresAt := time.Now().Add(h.guests.ttl).Truncate(time.Second)
+
+	name, err := h.claimGuestName(r.Context(), room.ID, guestID, name, expiresAt)
+	if err != nil {
+		if errors.Is(err, errGuestNameTaken) {
+			writeError(w, r, errNameTaken)
+			return
+		}
+		slog.Error("failed to claim guest name", "error", err, "room_id", room.ID)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	token, err := h.guests.sign(guestID, name, room.ID, expiresAt)
+	if err != nil {
+		slog.Error("failed to sign guest token", "error", err)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	sendJSON(w, guestTokenResponse{
+		Token:     token,
+		GuestID:   guestID,
+		Name:      name,
+		RoomID:    room.ID.String(),
+		ExpiresAt: expiresAt,
+	})
+}
+
+type guestTokenRequest struct {
+	RoomID string `json:"room_id"`
+	Name   string `json:"name"`
+}
+
+func (h apiHandler) handleIssueGuestToken(w http.ResponseWriter, r *http.Request) {
+	if h.guests == nil {
+		writeError(w, r, errGuestTokensDisabled)
+		return
+	}
+
+	var body guestTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, errInvalidJSON)
+		return
+	}
+
+	roomID, err := uuid.Parse(body.RoomID)
+	if err != nil {
+		writeError(w, r, errInvalidRoomID)
+		return
+	}
+
+	name := strings.TrimSpace(body.Name)
+	if err := ValidateGuestName(name); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	room, err := h.q.GetRoom(r.Context(), roomID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, r, errRoomNotFound)
+			return
+		}
+		slog.Error("failed to get room", "error", err)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	h.issueGuestToken(w, r, room, "guest:"+uuid.NewString(), name)
+}
+
+// handleRefreshGuestToken exchanges a valid guest token for a new one with
+// the same identity
+func (h apiHandler) handleRefreshGuestToken(w http.ResponseWriter, r *http.Request) {
+	if h.guests == nil {
+		writeError(w, r, errGuestTokensDisabled)
+		return
+	}
+
+	claims := extractClaimsFromJWT(r)
+	if claims == nil || !hasRole(claims, roleGuest) {
+		writeError(w, r, errGuestTokenRequired)
+		return
+	}
+
+	guestID, _ := claims["sub"].(string)
+	name, _ := claims["name"].(string)
+	roomID, err := uuid.Parse(fmt.Sprint(claims["room_id"]))
+	if !strings.HasPrefix(guestID, "guest:") || name == "" || err != nil {
+		writeError(w, r, errInvalidGuestToken)
+		return
+	}
+
+	room, err := h.q.GetRoom(r.Context(), roomID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, r, errRoomNotFound)
+			return
+		}
+		slog.Error("failed to get room", "error", err)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	h.issueGuestToken(w, r, room, guestID, name)
+}
+
+type allowGuestsRequest struct {
+	AllowGuests *bool `json:"allow_guests"`
+}
+
+func (h apiHandler) handleSetRoomAllowGuests(w http.ResponseWriter, r *http.Request) {
+	before, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.requireModerator(w, r) {
+		return
+	}
+
+	var body allowGuestsRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.AllowGuests == nil {
+		writeError(w, r, errInvalidJSON)
+		return
+	}
+
+	room, err := h.q.SetRoomAllowGuests(r.Context(), store.SetRoomAllowGuestsParams{
+		ID:          roomID,
+		AllowGuests: *body.AllowGuests,
+	})
+	if err != nil {
+		slog.Error("failed to update room guests", "error", err, "room_id", rawRoomID)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	slog.Info("room guest access changed", "room_id", rawRoomID, "allow_guests", room.AllowGuests)
+
+	h.audit(r, auditEntry{
+		Action:     auditRoomAllowGuests,
+		RoomID:     roomID,
+		TargetType: "room",
+		TargetID:   rawRoomID,
+		Before:     map[string]bool{"allow_guests": before.AllowGuests},
+		After:      map[string]bool{"allow_guests": room.AllowGuests},
+	})
+
+	sendJSON(w, room)
+}
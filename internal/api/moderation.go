@@ -0,0 +1,417 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	sanctionKindBan  = "ban"
+	sanctionKindMute = "mute"
+)
+
+// Roles that may moderate any room
+var moderatorRoles = []string{"moderator", "admin"}
+
+// hasRole reports whether the JWT claims grant one of the given roles, either
+// through a "role" string or a "roles" array
+func hasRole(claims map[string]interface{}, roles ...string) bool {
+	if claims == nil {
+		return false
+	}
+
+	var granted []string
+	if role, ok := claims["role"].(string); ok {
+		granted = append(granted, role)
+	}
+	if list, ok := claims["roles"].([]interface{}); ok {
+		for _, v := range list {
+			if role, ok := v.(string); ok {
+				granted = append(granted, role)
+			}
+		}
+	}
+
+	for _, g := range granted {
+		for _, role := range roles {
+			if g == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requireModerator writes 401 or 403 and returns false unless the request
+// carries a moderator JWT
+func (h apiHandler) requireModerator(w http.ResponseWriter, r *http.Request) bool {
+	return h.requireRole(w, r, errModeratorRequired, moderatorRoles...)
+}
+
+// isModerator reports whether the request carries a moderator JWT or an API
+// key with the moderate permission, without writing an error
+func isModerator(r *http.Request) bool {
+	if key, ok := apiKeyFromContext(r.Context()); ok {
+		return slices.Contains(key.Permissions, permModerate)
+	}
+	claims := extractClaimsFromJWT(r)
+	return claims != nil && hasRole(claims, moderatorRoles...)
+}
+
+// requireAdmin writes 401 or 403 and returns false unless the request carries
+// an admin JWT
+func (h apiHandler) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	return h.requireRole(w, r, errAdminRequired, "admin")
+}
+
+func (h apiHandler) requireRole(w http.ResponseWriter, r *http.Request, denied apiError, roles ...string) bool {
+	// API keys stand in for moderators through the moderate permission but
+	// are never admins
+	if key, ok := apiKeyFromContext(r.Context()); ok {
+		if !slices.Contains(roles, "moderator") || !slices.Contains(key.Permissions, permModerate) {
+			writeError(w, r, denied)
+			return false
+		}
+		return true
+	}
+
+	claims := extractClaimsFromJWT(r)
+	if claims == nil {
+		writeError(w, r, errAuthenticationRequired)
+		return false
+	}
+	if !hasRole(claims, roles...) {
+		writeError(w, r, denied)
+		return false
+	}
+	return true
+}
+
+// checkSanctions writes 403 and returns false when the caller has an active
+// sanction of one of the given kinds in the room
+func (h apiHandler) checkSanctions(w http.ResponseWriter, r *http.Request, roomID uuid.UUID, kinds ...string) bool {
+	// The guest author is shared by every anonymous participant, so guests
+	// can only be matched by IP
+	var author *string
+	if authorID, _ := authorFromRequest(r); authorID != "guest" {
+		author = &authorID
+	}
+	ip := clientIP(r)
+
+	sanction, err := h.q.FindActiveRoomSanction(r.Context(), store.FindActiveRoomSanctionParams{
+		RoomID:   roomID,
+		Kinds:    kinds,
+		AuthorID: author,
+		Ip:       &ip,
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return true
+		}
+		slog.Error("failed to check room sanctions", "error", err, "room_id", roomID)
+		writeError(w, r, errInternal)
+		return false
+	}
+
+	if sanction.Kind == sanctionKindBan {
+		writeError(w, r, errBanned)
+	} else {
+		writeError(w, r, errMuted)
+	}
+	return false
+}
+
+// kickParticipant closes every socket in the room that belongs to the author
+// or comes from the IP, returning how many were closed. The reason is sent as
+// an error frame, and its code as the reason of the close frame.
+func (h apiHandler) kickParticipant(rawRoomID, authorID, ip string, reason apiError) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	kicked := 0
+	for conn, sub := range h.subscribers[rawRoomID] {
+		if (authorID == "" || sub.authorID != authorID) && (ip == "" || sub.ip != ip) {
+			continue
+		}
+
+		_ = conn.SetWriteDeadline(time.Now().Add(time.Second))
+		_ = sendErrorFrame(conn, sub, rawRoomID, reason)
+		frame := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason.code)
+		_ = conn.WriteControl(websocket.CloseMessage, frame, time.Now().Add(time.Second))
+		sub.cancel()
+		_ = conn.Close()
+		delete(h.subscribers[rawRoomID], conn)
+		kicked++
+	}
+
+	if kicked > 0 {
+		slog.Info("participant kicked", "room_id", rawRoomID, "author_id", authorID, "ip", ip, "connections", kicked)
+	}
+	return kicked
+}
+
+// sanctionTarget names the participant by author ID, IP or one of their
+// messages, which also reaches the authors of anonymous messages
+type sanctionTarget struct {
+	AuthorID  string `json:"author_id,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	MessageID string `json:"message_id,omitempty"`
+}
+
+// validate trims the target and rejects targets that would match nobody or
+// every guest
+func (t *sanctionTarget) validate() error {
+	t.AuthorID = strings.TrimSpace(t.AuthorID)
+	t.IP = strings.TrimSpace(t.IP)
+	t.MessageID = strings.TrimSpace(t.MessageID)
+
+	if t.AuthorID == "" && t.IP == "" && t.MessageID == "" {
+		return errNoSanctionTarget
+	}
+	if t.AuthorID == "guest" {
+		return errGuestAuthorTarget
+	}
+	return nil
+}
+
+// resolveTarget replaces a message_id target with the real author of the
+// message, which must belong to the room
+func (h apiHandler) resolveTarget(w http.ResponseWriter, r *http.Request, roomID uuid.UUID, t *sanctionTarget) bool {
+	if t.MessageID == "" {
+		return true
+	}
+
+	messageID, err := uuid.Parse(t.MessageID)
+	if err != nil {
+		writeError(w, r, errInvalidMessageID)
+		return false
+	}
+
+	msg, err := h.q.GetMessage(r.Context(), messageID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, r, errMessageNotFound)
+			return false
+		}
+		slog.Error("failed to get message", "error", err, "message_id", messageID)
+		writeError(w, r, errInternal)
+		return false
+	}
+	if msg.RoomID != roomID {
+		writeError(w, r, errMessageNotFound)
+		return false
+	}
+	if msg.AuthorID == "guest" {
+		writeError(w, r, errGuestMessage)
+		return false
+	}
+
+	t.AuthorID = msg.AuthorID
+	t.MessageID = ""
+	return true
+}
+
+type kickResponse struct {
+	Kicked int `json:"kicked"`
+}
+
+func (h apiHandler) handleKickParticipant(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.requireModerator(w, r) {
+		return
+	}
+
+	var body sanctionTarget
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, errInvalidJSON)
+		return
+	}
+	if err := body.validate(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if !h.resolveTarget(w, r, roomID, &body) {
+		return
+	}
+
+	kicked := h.kickParticipant(rawRoomID, body.AuthorID, body.IP, errKicked)
+
+	target := body.AuthorID
+	if target == "" {
+		target = body.IP
+	}
+	h.audit(r, auditEntry{
+		Action:     auditParticipantKick,
+		RoomID:     roomID,
+		TargetType: "participant",
+		TargetID:   target,
+		After:      map[string]any{"author_id": body.AuthorID, "ip": body.IP, "connections": kicked},
+	})
+
+	sendJSON(w, kickResponse{Kicked: kicked})
+}
+
+type sanctionRequest struct {
+	sanctionTarget
+	Reason          string `json:"reason,omitempty"`
+	DurationSeconds int64  `json:"duration_seconds,omitempty"`
+}
+
+func (h apiHandler) handleCreateSanction(kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, rawRoomID, roomID, ok := h.readRoom(w, r)
+		if !ok {
+			return
+		}
+
+		if !h.requireModerator(w, r) {
+			return
+		}
+
+		var body sanctionRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, r, errInvalidJSON)
+			return
+		}
+		if err := body.validate(); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if !h.resolveTarget(w, r, roomID, &body.sanctionTarget) {
+			return
+		}
+		if body.DurationSeconds < 0 {
+			writeError(w, r, errNegativeDuration)
+			return
+		}
+
+		// A zero duration makes the sanction permanent
+		var expiresAt *time.Time
+		if body.DurationSeconds > 0 {
+			t := time.Now().Add(time.Duration(body.DurationSeconds) * time.Second)
+			expiresAt = &t
+		}
+
+		moderatorID, _ := authorFromRequest(r)
+		sanction, err := h.q.InsertRoomSanction(r.Context(), store.InsertRoomSanctionParams{
+			RoomID:    roomID,
+			Kind:      kind,
+			AuthorID:  nullable(body.AuthorID),
+			Ip:        nullable(body.IP),
+			Reason:    strings.TrimSpace(body.Reason),
+			CreatedBy: moderatorID,
+			ExpiresAt: expiresAt,
+		})
+		if err != nil {
+			slog.Error("failed to insert room sanction", "error", err, "room_id", rawRoomID, "kind", kind)
+			writeError(w, r, errInternal)
+			return
+		}
+
+		slog.Info("room sanction created", "room_id", rawRoomID, "kind", kind, "sanction_id", sanction.ID)
+
+		h.audit(r, auditEntry{
+			Action:     auditSanctionCreate,
+			RoomID:     roomID,
+			TargetType: kind,
+			TargetID:   sanction.ID.String(),
+			After:      sanction,
+		})
+
+		if kind == sanctionKindBan {
+			h.kickParticipant(rawRoomID, body.AuthorID, body.IP, errBanned)
+		}
+
+		sendJSON(w, sanction)
+	}
+}
+
+func (h apiHandler) handleGetSanctions(kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, _, roomID, ok := h.readRoom(w, r)
+		if !ok {
+			return
+		}
+
+		if !h.requireModerator(w, r) {
+			return
+		}
+
+		sanctions, err := h.q.GetActiveRoomSanctions(r.Context(), store.GetActiveRoomSanctionsParams{
+			RoomID: roomID,
+			Kind:   kind,
+		})
+		if err != nil {
+			slog.Error("failed to get room sanctions", "error", err, "kind", kind)
+			writeError(w, r, errInternal)
+			return
+		}
+
+		if sanctions == nil {
+			sanctions = []store.RoomSanction{}
+		}
+
+		sendJSON(w, sanctions)
+	}
+}
+
+func (h apiHandler) handleRevokeSanction(kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, rawRoomID, roomID, ok := h.readRoom(w, r)
+		if !ok {
+			return
+		}
+
+		if !h.requireModerator(w, r) {
+			return
+		}
+
+		sanctionID, err := uuid.Parse(chi.URLParam(r, "sanction_id"))
+		if err != nil {
+			writeError(w, r, errInvalidSanctionID)
+			return
+		}
+
+		sanction, err := h.q.RevokeRoomSanction(r.Context(), store.RevokeRoomSanctionParams{
+			ID:     sanctionID,
+			RoomID: roomID,
+			Kind:   kind,
+		})
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				writeError(w, r, errSanctionNotFound)
+				return
+			}
+			slog.Error("failed to revoke room sanction", "error", err, "sanction_id", sanctionID)
+			writeError(w, r, errInternal)
+			return
+		}
+
+		slog.Info("room sanction revoked", "room_id", rawRoomID, "kind", kind, "sanction_id", sanctionID)
+
+		h.audit(r, auditEntry{
+			Action:     auditSanctionRevoke,
+			RoomID:     roomID,
+			TargetType: kind,
+			TargetID:   sanctionID.String(),
+			After:      sanction,
+		})
+
+		sendJSON(w, sanction)
+	}
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+	"github.com/felipemacedo1/go-msg-wss/internal/tracing/tracingtest"
+	"github.com/felipemacedo1/go-msg-wss/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel"
+)
+
+func TestNotifyClientsContinuesRequestTrace(t *testing.T) {
+	exporter := tracingtest.Setup()
+
+	h := apiHandler{
+		subscribers: make(map[string]map[*websocket.Conn]*subscriber),
+		mu:          &sync.Mutex{},
+	}
+
+	ctx, request := otel.Tracer("test").Start(context.Background(), "POST /api/rooms/{room_id}/messages")
+	request.End()
+	h.notifyClients(context.WithoutCancel(ctx), Message{Kind: MessageKindMessageCreated, RoomID: "room"})
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	notify := spans[1]
+	if notify.Name != "notifyClients" {
+		t.Fatalf("span name = %q, want notifyClients", notify.Name)
+	}
+	if notify.Parent.SpanID() != request.SpanContext().SpanID() {
+		t.Errorf("notifyClients parent = %s, want the request span %s", notify.Parent.SpanID(), request.SpanContext().SpanID())
+	}
+}
+
+// blockingWebhookStore holds every enqueue until release is closed
+type blockingWebhookStore struct {
+	webhook.Store
+	release chan struct{}
+}
+
+func (s blockingWebhookStore) EnqueueWebhookDeliveries(context.Context, store.EnqueueWebhookDeliveriesParams) (int64, error) {
+	<-s.release
+	return 1, nil
+}
+
+func TestNotifyClientsDoesNotWaitForWebhooks(t *testing.T) {
+	release := make(chan struct{})
+	h := apiHandler{
+		subscribers: make(map[string]map[*websocket.Conn]*subscriber),
+		mu:          &sync.Mutex{},
+		webhooks:    webhook.NewDispatcher(blockingWebhookStore{release: release}, webhook.Options{}),
+		pending:     &sync.WaitGroup{},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.notifyClients(context.Background(), Message{Kind: MessageKindMessageCreated, RoomID: uuid.NewString()})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("notifyClients waited for the webhook enqueue")
+	}
+
+	// The enqueue is tracked, so Shutdown waits for it and the event is not lost
+	close(release)
+	h.pending.Wait()
+}
+
+func TestSlowSubscriberDoesNotStallBroadcasts(t *testing.T) {
+	defer func(wait time.Duration) { wsWriteWait = wait }(wsWriteWait)
+	wsWriteWait = 100 * time.Millisecond
+
+	srv := newTestServer(t)
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go"})
+	// stuck never reads, so its socket buffers fill up
+	stuck := subscribe(t, srv, roomID, "")
+	defer stuck.Close()
+	live := subscribe(t, srv, roomID, "")
+	srv.waitSubscribers(t, roomID, 2)
+
+	go func() {
+		for {
+			if _, _, err := live.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	big := Message{Kind: MessageKindMessageCreated, RoomID: roomID, Value: strings.Repeat("x", 1<<20)}
+	for i := 0; ; i++ {
+		done := make(chan struct{})
+		go func() {
+			srv.h.notifyClients(context.Background(), big)
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("a broadcast is stuck behind a subscriber that does not read")
+		}
+
+		srv.h.mu.Lock()
+		n := len(srv.h.subscribers[roomID])
+		srv.h.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if i == 200 {
+			t.Fatal("the stuck subscriber was never dropped")
+		}
+	}
+}
@@ -0,0 +1,397 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	pollStatusOpen   = "open"
+	pollStatusClosed = "closed"
+)
+
+type pollOptionResults struct {
+	ID    uuid.UUID `json:"id"`
+	Label string    `json:"label"`
+	Votes int64     `json:"votes"`
+}
+
+// pollResults is a poll together with its current vote tallies
+type pollResults struct {
+	store.Poll
+	Options    []pollOptionResults `json:"options"`
+	TotalVotes int64               `json:"total_votes"`
+}
+
+func newPollResults(poll store.Poll, tallies []store.GetPollTalliesRow) pollResults {
+	res := pollResults{Poll: poll, Options: make([]pollOptionResults, 0, len(tallies))}
+	for _, t := range tallies {
+		res.Options = append(res.Options, pollOptionResults{ID: t.ID, Label: t.Label, Votes: t.Votes})
+		res.TotalVotes += t.Votes
+	}
+	return res
+}
+
+func (h apiHandler) getPollResults(ctx context.Context, poll store.Poll) (pollResults, error) {
+	tallies, err := h.q.GetPollTallies(ctx, poll.ID)
+	if err != nil {
+		return pollResults{}, err
+	}
+	return newPollResults(poll, tallies), nil
+}
+
+// getRoomPollResults loads every poll of a room with its tallies in two queries
+func (h apiHandler) getRoomPollResults(ctx context.Context, roomID uuid.UUID) ([]pollResults, error) {
+	polls, err := h.q.GetRoomPolls(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	tallies, err := h.q.GetRoomPollTallies(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	byPoll := make(map[uuid.UUID][]store.GetPollTalliesRow, len(polls))
+	for _, t := range tallies {
+		byPoll[t.PollID] = append(byPoll[t.PollID], store.GetPollTalliesRow(t))
+	}
+
+	results := make([]pollResults, 0, len(polls))
+	for _, poll := range polls {
+		results = append(results, newPollResults(poll, byPoll[poll.ID]))
+	}
+	return results, nil
+}
+
+// readPoll loads the poll named in the URL and makes sure it belongs to the room
+func (h apiHandler) readPoll(w http.ResponseWriter, r *http.Request, roomID uuid.UUID) (store.Poll, bool) {
+	pollID, err := uuid.Parse(chi.URLParam(r, "poll_id"))
+	if err != nil {
+		writeError(w, r, errInvalidPollID)
+		return store.Poll{}, false
+	}
+
+	poll, err := h.q.GetPoll(r.Context(), pollID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, r, errPollNotFound)
+			return store.Poll{}, false
+		}
+		slog.Error("failed to get poll", "error", err, "poll_id", pollID)
+		writeError(w, r, errInternal)
+		return store.Poll{}, false
+	}
+
+	if poll.RoomID != roomID {
+		writeError(w, r, errPollNotFound)
+		return store.Poll{}, false
+	}
+
+	return poll, true
+}
+
+// voterID identifies a voter; guests share an author id so they vote per IP
+func voterID(r *http.Request) string {
+	authorID, _ := authorFromRequest(r)
+	if authorID == "guest" {
+		return "guest:" + clientIP(r)
+	}
+	return authorID
+}
+
+type createPollRequest struct {
+	Question       string   `json:"question"`
+	Options        []string `json:"options"`
+	MultipleChoice bool     `json:"multiple_choice,omitempty"`
+}
+
+func (h apiHandler) handleCreatePoll(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.requireModerator(w, r) {
+		return
+	}
+
+	var body createPollRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, errInvalidJSON)
+		return
+	}
+
+	if err := ValidatePoll(body.Question, body.Options); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	options := make([]string, 0, len(body.Options))
+	for _, option := range body.Options {
+		options = append(options, strings.TrimSpace(option))
+	}
+
+	createdBy, _ := authorFromRequest(r)
+	pollID, err := h.q.InsertPoll(r.Context(), store.InsertPollParams{
+		RoomID:         roomID,
+		Question:       strings.TrimSpace(body.Question),
+		MultipleChoice: body.MultipleChoice,
+		CreatedBy:      createdBy,
+		Options:        options,
+	})
+	if err != nil {
+		slog.Error("failed to insert poll", "error", err, "room_id", rawRoomID)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	poll, err := h.q.GetPoll(r.Context(), pollID)
+	if err != nil {
+		slog.Error("failed to retrieve poll", "error", err, "poll_id", pollID)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	results, err := h.getPollResults(r.Context(), poll)
+	if err != nil {
+		slog.Error("failed to get poll tallies", "error", err, "poll_id", pollID)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	slog.Info("handleCreatePoll: poll created", "poll_id", pollID, "room_id", rawRoomID)
+
+	h.audit(r, auditEntry{
+		Action:     auditPollCreate,
+		RoomID:     roomID,
+		TargetType: "poll",
+		TargetID:   pollID.String(),
+		After:      results,
+	})
+
+	sendJSON(w, results)
+
+	h.broadcast(context.WithoutCancel(r.Context()), Message{
+		Kind:   MessageKindPollCreated,
+		RoomID: rawRoomID,
+		Value:  results,
+	})
+}
+
+func (h apiHandler) handleGetRoomPolls(w http.ResponseWriter, r *http.Request) {
+	_, _, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	results, err := h.getRoomPollResults(r.Context(), roomID)
+	if err != nil {
+		slog.Error("failed to get room polls", "error", err)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	sendJSON(w, results)
+}
+
+func (h apiHandler) handleGetPoll(w http.ResponseWriter, r *http.Request) {
+	_, _, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	poll, ok := h.readPoll(w, r, roomID)
+	if !ok {
+		return
+	}
+
+	results, err := h.getPollResults(r.Context(), poll)
+	if err != nil {
+		slog.Error("failed to get poll tallies", "error", err, "poll_id", poll.ID)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	sendJSON(w, results)
+}
+
+type voteRequest struct {
+	OptionIDs []string `json:"option_ids"`
+}
+
+func (h apiHandler) handleVotePoll(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.checkSanctions(w, r, roomID, sanctionKindBan, sanctionKindMute) {
+		return
+	}
+
+	poll, ok := h.readPoll(w, r, roomID)
+	if !ok {
+		return
+	}
+
+	if poll.Status != pollStatusOpen {
+		writeError(w, r, errPollClosed)
+		return
+	}
+
+	var body voteRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, errInvalidJSON)
+		return
+	}
+
+	tallies, err := h.q.GetPollTallies(r.Context(), poll.ID)
+	if err != nil {
+		slog.Error("failed to get poll tallies", "error", err, "poll_id", poll.ID)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	valid := make(map[uuid.UUID]bool, len(tallies))
+	for _, t := range tallies {
+		valid[t.ID] = true
+	}
+
+	optionIDs := make([]uuid.UUID, 0, len(body.OptionIDs))
+	seen := make(map[uuid.UUID]bool, len(body.OptionIDs))
+	for _, raw := range body.OptionIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil || !valid[id] {
+			writeError(w, r, errInvalidOptionID)
+			return
+		}
+		if !seen[id] {
+			seen[id] = true
+			optionIDs = append(optionIDs, id)
+		}
+	}
+
+	if len(optionIDs) == 0 {
+		writeError(w, r, errNoOption)
+		return
+	}
+	if !poll.MultipleChoice && len(optionIDs) > 1 {
+		writeError(w, r, errSingleChoice)
+		return
+	}
+
+	voted, err := h.q.CastPollVote(r.Context(), store.CastPollVoteParams{
+		VoterID:   voterID(r),
+		PollID:    poll.ID,
+		OptionIds: optionIDs,
+	})
+	if err != nil {
+		slog.Error("failed to cast poll vote", "error", err, "poll_id", poll.ID)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	// No rows means the ballot already existed or the poll closed meanwhile
+	if len(voted) == 0 {
+		current, err := h.q.GetPoll(r.Context(), poll.ID)
+		if err != nil {
+			slog.Error("failed to get poll", "error", err, "poll_id", poll.ID)
+			writeError(w, r, errInternal)
+			return
+		}
+		if current.Status != pollStatusOpen {
+			writeError(w, r, errPollClosed)
+			return
+		}
+		writeError(w, r, errAlreadyVoted)
+		return
+	}
+
+	results, err := h.getPollResults(r.Context(), poll)
+	if err != nil {
+		slog.Error("failed to get poll tallies", "error", err, "poll_id", poll.ID)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	h.audit(r, auditEntry{
+		Action:     auditPollVote,
+		RoomID:     roomID,
+		TargetType: "poll",
+		TargetID:   poll.ID.String(),
+		After:      map[string][]uuid.UUID{"option_ids": voted},
+	})
+
+	sendJSON(w, results)
+
+	h.broadcast(context.WithoutCancel(r.Context()), Message{
+		Kind:   MessageKindPollVotesUpdated,
+		RoomID: rawRoomID,
+		Value: MessagePollVotesUpdated{
+			ID:         poll.ID.String(),
+			Options:    results.Options,
+			TotalVotes: results.TotalVotes,
+		},
+	})
+}
+
+func (h apiHandler) handleClosePoll(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.requireModerator(w, r) {
+		return
+	}
+
+	poll, ok := h.readPoll(w, r, roomID)
+	if !ok {
+		return
+	}
+
+	closed, err := h.q.ClosePoll(r.Context(), store.ClosePollParams{ID: poll.ID, RoomID: roomID})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, r, errPollClosed)
+			return
+		}
+		slog.Error("failed to close poll", "error", err, "poll_id", poll.ID)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	results, err := h.getPollResults(r.Context(), closed)
+	if err != nil {
+		slog.Error("failed to get poll tallies", "error", err, "poll_id", poll.ID)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	h.audit(r, auditEntry{
+		Action:     auditPollClose,
+		RoomID:     roomID,
+		TargetType: "poll",
+		TargetID:   poll.ID.String(),
+		Before:     map[string]string{"status": poll.Status},
+		After:      map[string]string{"status": closed.Status},
+	})
+
+	sendJSON(w, results)
+
+	h.broadcast(context.WithoutCancel(r.Context()), Message{
+		Kind:   MessageKindPollClosed,
+		RoomID: rawRoomID,
+		Value:  results,
+	})
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+	"github.com/felipemacedo1/go-msg-wss/internal/store/memstore"
+
+	"github.com/google/uuid"
+)
+
+// closingStore closes the poll right before the vote is cast, as a moderator
+// racing the voter would
+type closingStore struct {
+	store.Store
+}
+
+func (s closingStore) CastPollVote(ctx context.Context, arg store.CastPollVoteParams) ([]uuid.UUID, error) {
+	poll, err := s.GetPoll(ctx, arg.PollID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.ClosePoll(ctx, store.ClosePollParams{ID: poll.ID, RoomID: poll.RoomID}); err != nil {
+		return nil, err
+	}
+	return s.Store.CastPollVote(ctx, arg)
+}
+
+func TestVotePollClosedMeanwhile(t *testing.T) {
+	srv := newTestServerWithStore(t, closingStore{memstore.New()})
+
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go"})
+	pollsPath := "/api/rooms/" + roomID + "/polls/"
+
+	var poll testPoll
+	body := map[string]any{"question": "Tabs or spaces?", "options": []string{"Tabs", "Spaces"}}
+	if status := call(t, srv, moderatorToken(t), http.MethodPost, pollsPath, body, &poll); status != http.StatusOK {
+		t.Fatalf("create poll status = %d", status)
+	}
+
+	// Both conflicts share the status, only the code tells them apart
+	vote := strings.NewReader(`{"option_ids":["` + poll.Options[0].ID + `"]}`)
+	req, err := http.NewRequest(http.MethodPost, srv.URL+pollsPath+poll.ID+"/votes", vote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+userToken(t, "ada", "Ada"))
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var got errorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode envelope: %v", err)
+	}
+	if resp.StatusCode != http.StatusConflict || got.Error.Code != "poll_closed" {
+		t.Errorf("vote = %d %s, want %d poll_closed", resp.StatusCode, got.Error.Code, http.StatusConflict)
+	}
+}
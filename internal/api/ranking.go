@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+// hotGravity controls how fast questions sink with age in the hot ranking,
+// the same exponent Hacker News uses
+const hotGravity = 1.8
+
+func (h apiHandler) getHotMessages(ctx context.Context, roomID uuid.UUID, includeAnswered bool, limit int32) ([]store.Message, error) {
+	return h.q.GetRoomHotMessages(ctx, store.GetRoomHotMessagesParams{
+		RoomID:          roomID,
+		IncludeAnswered: includeAnswered,
+		Gravity:         hotGravity,
+		MaxResults:      limit,
+	})
+}
+
+// watchTopQuestions periodically ranks the unanswered questions of every room
+// with subscribers and pushes top_questions_changed when the top N changes
+func (h apiHandler) watchTopQuestions(ctx context.Context, interval time.Duration, count int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := make(map[string][]uuid.UUID)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		h.mu.Lock()
+		rooms := make([]string, 0, len(h.subscribers))
+		for rawRoomID := range h.subscribers {
+			rooms = append(rooms, rawRoomID)
+		}
+		h.mu.Unlock()
+
+		active := make(map[string][]uuid.UUID, len(rooms))
+		for _, rawRoomID := range rooms {
+			roomID, err := uuid.Parse(rawRoomID)
+			if err != nil {
+				continue
+			}
+
+			top, err := h.getHotMessages(ctx, roomID, false, int32(count))
+			if err != nil {
+				slog.Error("failed to rank top questions", "error", err, "room_id", rawRoomID)
+				active[rawRoomID] = last[rawRoomID]
+				continue
+			}
+
+			ids := make([]uuid.UUID, 0, len(top))
+			for _, m := range top {
+				ids = append(ids, m.ID)
+			}
+			active[rawRoomID] = ids
+
+			if sameIDs(last[rawRoomID], ids) {
+				continue
+			}
+
+			h.notifyClients(ctx, Message{
+				Kind:   MessageKindTopQuestionsChanged,
+				RoomID: rawRoomID,
+				Value:  MessageTopQuestionsChanged{Messages: h.presentMessages(ctx, top)},
+			})
+		}
+
+		// Rooms without subscribers are forgotten and start over on reconnect
+		last = active
+	}
+}
+
+func sameIDs(a, b []uuid.UUID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/config"
+)
+
+func TestTopQuestionsChanged(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	srv := newTestServer(t, func(cfg *config.Config) {
+		cfg.TopQuestionsInterval = interval
+		cfg.TopQuestionsCount = 2
+	})
+	user := userToken(t, "ada", "Ada")
+
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go"})
+	messagesPath := "/api/rooms/" + roomID + "/messages/"
+	olderID := postMessage(t, srv, user, roomID, "Is Go fast?")
+	newerID := postMessage(t, srv, user, roomID, "Is Go simple?")
+
+	// The reactions are broadcast too, only the rankings are collected
+	conn := subscribe(t, srv, roomID, "")
+	pushes := make(chan []string, 16)
+	go func() {
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var event struct {
+				Kind  string `json:"kind"`
+				Value struct {
+					Messages []testMessage `json:"messages"`
+				} `json:"value"`
+			}
+			if json.Unmarshal(data, &event) != nil || event.Kind != MessageKindTopQuestionsChanged {
+				continue
+			}
+			var ids []string
+			for _, m := range event.Value.Messages {
+				ids = append(ids, m.ID)
+			}
+			pushes <- ids
+		}
+	}()
+
+	expectPush := func(want []string) {
+		t.Helper()
+		select {
+		case got := <-pushes:
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("top questions = %v, want %v", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s push, want %v", MessageKindTopQuestionsChanged, want)
+		}
+	}
+	expectQuiet := func() {
+		t.Helper()
+		select {
+		case got := <-pushes:
+			t.Fatalf("top questions = %v pushed while the ranking did not change", got)
+		case <-time.After(5 * interval):
+		}
+	}
+
+	// Subscribing starts with the current ranking, which is not repeated
+	expectPush([]string{newerID, olderID})
+	expectQuiet()
+
+	// A vote that reorders the top N pushes it once
+	call(t, srv, user, http.MethodPatch, messagesPath+olderID+"/react", nil, nil)
+	expectPush([]string{olderID, newerID})
+	expectQuiet()
+
+	// Votes that keep the order push nothing
+	call(t, srv, user, http.MethodPatch, messagesPath+olderID+"/react", nil, nil)
+	expectQuiet()
+}
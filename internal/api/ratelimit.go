@@ -0,0 +1,86 @@
+package api
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/config"
+	"github.com/felipemacedo1/go-msg-wss/internal/ratelimit"
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+	"github.com/felipemacedo1/go-msg-wss/internal/store/pgstore"
+)
+
+// Route classes that share a rate limit budget
+const (
+	rateLimitMessages  = "messages"
+	rateLimitReactions = "reactions"
+	rateLimitSubscribe = "subscribe"
+	rateLimitGuests    = "guests"
+)
+
+// loadRateLimits builds the limiter and the per route class limits. The
+// Postgres limiter shares the buckets of the store's database, so it needs the
+// Postgres store.
+func loadRateLimits(s store.Store, cfg config.Config) (ratelimit.Limiter, map[string]ratelimit.Limit) {
+	limits := map[string]ratelimit.Limit{
+		rateLimitMessages:  cfg.RateLimits.Messages,
+		rateLimitReactions: cfg.RateLimits.Reactions,
+		rateLimitSubscribe: cfg.RateLimits.Subscribe,
+		rateLimitGuests:    cfg.RateLimits.Guests,
+	}
+
+	if cfg.RateLimitBackend == "postgres" {
+		if pg, ok := s.(*pgstore.Store); ok {
+			return ratelimit.NewPostgres(pg.Queries()), limits
+		}
+		slog.Warn("postgres rate limiting needs the postgres store, falling back to memory")
+	}
+	return ratelimit.NewMemory(), limits
+}
+
+// rateLimitKey identifies the caller by JWT subject, falling back to the
+// client IP for guests
+func rateLimitKey(class string, r *http.Request) string {
+	if key, ok := apiKeyFromContext(r.Context()); ok {
+		return class + ":key:" + key.ID.String()
+	}
+	if claims := extractClaimsFromJWT(r); claims != nil {
+		if sub, ok := claims["sub"].(string); ok && sub != "" {
+			return class + ":sub:" + sub
+		}
+	}
+	return class + ":ip:" + clientIP(r)
+}
+
+// rateLimit rejects requests over the budget of the given route class with
+// 429 Too Many Requests
+func (h apiHandler) rateLimit(class string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := h.limits[class]
+			if limit.Disabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res, err := h.limiter.Allow(r.Context(), rateLimitKey(class, r), limit)
+			if err != nil {
+				// Fail open: an unavailable limiter must not take the API down
+				slog.Error("rate limiter failed", "class", class, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !res.Allowed {
+				seconds := int(math.Ceil(res.RetryAfter.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+				writeError(w, r, errRateLimited)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
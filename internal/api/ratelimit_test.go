@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/config"
+	"github.com/felipemacedo1/go-msg-wss/internal/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	srv := newTestServer(t, func(cfg *config.Config) {
+		// Two messages, then one more an hour later
+		cfg.RateLimits.Messages = ratelimit.Limit{Rate: 1.0 / 3600, Burst: 2}
+	})
+	roomID := createRoom(t, srv, map[string]any{"theme": "Go"})
+	messagesURL := srv.URL + "/api/rooms/" + roomID + "/messages/"
+
+	var key struct {
+		Key string `json:"key"`
+	}
+	body := map[string]any{"name": "bot", "permissions": []string{"post"}}
+	if status := call(t, srv, adminToken(t), http.MethodPost, "/api/apikeys/", body, &key); status != http.StatusOK {
+		t.Fatalf("create api key status = %d", status)
+	}
+
+	post := func(header, value string) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodPost, messagesURL, strings.NewReader(`{"message":"Is Go fast?"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept-Language", "pt-BR")
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	// Each caller has its own bucket: JWT subject, API key or client IP
+	callers := []struct {
+		name, header, value string
+	}{
+		{"Ada", "Authorization", "Bearer " + userToken(t, "ada", "Ada")},
+		{"Bob", "Authorization", "Bearer " + userToken(t, "bob", "Bob")},
+		{"API key", apiKeyHeader, key.Key},
+		{"Guest", "", ""},
+	}
+	for _, c := range callers {
+		t.Run(c.name, func(t *testing.T) {
+			for i := range 2 {
+				if resp := post(c.header, c.value); resp.StatusCode != http.StatusOK {
+					t.Fatalf("message %d status = %d, want %d", i+1, resp.StatusCode, http.StatusOK)
+				}
+			}
+
+			resp := post(c.header, c.value)
+			if resp.StatusCode != http.StatusTooManyRequests {
+				t.Fatalf("status over the limit = %d, want %d", resp.StatusCode, http.StatusTooManyRequests)
+			}
+			if retry, err := strconv.Atoi(resp.Header.Get("Retry-After")); err != nil || retry < 1 {
+				t.Errorf("Retry-After = %q, want at least 1 second", resp.Header.Get("Retry-After"))
+			}
+
+			var got errorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("decode envelope: %v", err)
+			}
+			if got.Error.Code != "rate_limited" || got.Error.Message != "muitas requisições" {
+				t.Errorf("error = %+v, want rate_limited in Portuguese", got.Error)
+			}
+		})
+	}
+}
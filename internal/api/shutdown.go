@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// shutdownReconnectAfter is how long clients are asked to wait before
+// reconnecting, enough for a rolling deploy to route them elsewhere
+const shutdownReconnectAfter = time.Second
+
+// reconnectHint is sent as the reason of the CloseGoingAway frame
+type reconnectHint struct {
+	Reconnect      bool  `json:"reconnect"`
+	RetryAfterMsec int64 `json:"retry_after_ms"`
+}
+
+// goTracked runs fn in a goroutine that Shutdown waits for
+func (h apiHandler) goTracked(fn func()) {
+	h.pending.Add(1)
+	go func() {
+		defer h.pending.Done()
+		fn()
+	}()
+}
+
+// broadcast notifies the room without blocking the request. ctx must outlive
+// the request, see notifyClients.
+func (h apiHandler) broadcast(ctx context.Context, msg Message) {
+	h.goTracked(func() { h.notifyClients(ctx, msg) })
+}
+
+func (h apiHandler) Shutdown(ctx context.Context) error {
+	h.draining.Store(true)
+	h.stop()
+
+	done := make(chan struct{})
+	go func() {
+		h.pending.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		slog.Warn("shutdown: gave up waiting for pending broadcasts", "error", err)
+	}
+
+	closed := h.closeSubscribers()
+	slog.Info("shutdown: closed websocket connections", "connections", closed)
+	return err
+}
+
+// closeSubscribers sends CloseGoingAway with a reconnect hint to every
+// socket, returning how many were closed
+func (h apiHandler) closeSubscribers() int {
+	reason, _ := json.Marshal(reconnectHint{Reconnect: true, RetryAfterMsec: shutdownReconnectAfter.Milliseconds()})
+	frame := websocket.FormatCloseMessage(websocket.CloseGoingAway, string(reason))
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	closed := 0
+	for rawRoomID, subscribers := range h.subscribers {
+		for conn, sub := range subscribers {
+			_ = conn.WriteControl(websocket.CloseMessage, frame, time.Now().Add(time.Second))
+			sub.cancel()
+			_ = conn.Close()
+			delete(subscribers, conn)
+			closed++
+		}
+		delete(h.subscribers, rawRoomID)
+	}
+	return closed
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestShutdownClosesSubscribersAfterPendingBroadcasts(t *testing.T) {
+	h := apiHandler{
+		subscribers: make(map[string]map[*websocket.Conn]*subscriber),
+		mu:          &sync.Mutex{},
+		pending:     &sync.WaitGroup{},
+		draining:    &atomic.Bool{},
+		stop:        func() {},
+	}
+
+	// Registers the socket like handleSubscribe, without the room lookup
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := h.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		h.mu.Lock()
+		h.subscribers["room"] = map[*websocket.Conn]*subscriber{c: {cancel: cancel}}
+		h.mu.Unlock()
+		<-ctx.Done()
+	}))
+	defer srv.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer client.Close()
+
+	for {
+		h.mu.Lock()
+		n := len(h.subscribers["room"])
+		h.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	release := make(chan struct{})
+	h.goTracked(func() {
+		<-release
+		h.notifyClients(context.Background(), Message{Kind: MessageKindMessageAnswered, RoomID: "room", Value: MessageMessageAnswered{ID: "m1"}})
+	})
+
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- h.Shutdown(context.Background()) }()
+	close(release)
+
+	_, data, err := client.ReadMessage()
+	if err != nil {
+		t.Fatalf("the pending broadcast was not delivered before closing: %v", err)
+	}
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Kind != MessageKindMessageAnswered {
+		t.Fatalf("got %s, want the pending %s broadcast", data, MessageKindMessageAnswered)
+	}
+
+	_, _, err = client.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseGoingAway {
+		t.Fatalf("read error = %v, want CloseGoingAway", err)
+	}
+	var hint reconnectHint
+	if err := json.Unmarshal([]byte(closeErr.Text), &hint); err != nil || !hint.Reconnect || hint.RetryAfterMsec <= 0 {
+		t.Errorf("close reason = %q, want a reconnect hint", closeErr.Text)
+	}
+
+	if err := <-shutdownErr; err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+	if !h.draining.Load() {
+		t.Error("handler is not draining after Shutdown")
+	}
+}
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// How long a synced profile is trusted before the claims are written again
+	userSyncInterval = 10 * time.Minute
+
+	defaultUserMessages = 50
+	maxUserMessages     = 200
+)
+
+// userProfile is the identity carried by a verified, non-guest JWT
+type userProfile struct {
+	ID        string
+	Name      string
+	AvatarURL string
+}
+
+// profileFromRequest returns the profile of the JWT user behind the request.
+// Guests and API keys have none.
+func profileFromRequest(r *http.Request) (userProfile, bool) {
+	if _, ok := apiKeyFromContext(r.Context()); ok {
+		return userProfile{}, false
+	}
+
+	claims := extractClaimsFromJWT(r)
+	if claims == nil || hasRole(claims, roleGuest) {
+		return userProfile{}, false
+	}
+
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return userProfile{}, false
+	}
+
+	p := userProfile{ID: sub, Name: sub}
+	if name, ok := claims["name"].(string); ok && name != "" {
+		p.Name = name
+	}
+	// OIDC names the avatar "picture"
+	for _, key := range []string{"picture", "avatar_url"} {
+		if url, ok := claims[key].(string); ok && url != "" {
+			p.AvatarURL = url
+			break
+		}
+	}
+	return p, true
+}
+
+// userSync remembers recently written profiles so that the users table is
+// only touched when the claims change or the entry gets old
+type userSync struct {
+	mu   sync.Mutex
+	seen map[string]userSyncEntry
+}
+
+type userSyncEntry struct {
+	profile  userProfile
+	syncedAt time.Time
+}
+
+func newUserSync() *userSync {
+	return &userSync{seen: make(map[string]userSyncEntry)}
+}
+
+func (s *userSync) fresh(p userProfile) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.seen[p.ID]
+	return ok && entry.profile == p && time.Since(entry.syncedAt) < userSyncInterval
+}
+
+func (s *userSync) remember(p userProfile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, entry := range s.seen {
+		if time.Since(entry.syncedAt) >= userSyncInterval {
+			delete(s.seen, id)
+		}
+	}
+	s.seen[p.ID] = userSyncEntry{profile: p, syncedAt: time.Now()}
+}
+
+// syncUser upserts the profile of JWT users into the users table
+func (h apiHandler) syncUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := profileFromRequest(r); ok && !h.users.fresh(p) {
+			err := h.q.UpsertUser(r.Context(), store.UpsertUserParams{
+				ID:        p.ID,
+				Name:      p.Name,
+				AvatarUrl: nullable(p.AvatarURL),
+			})
+			if err != nil {
+				slog.Warn("failed to sync user profile", "error", err, "user_id", p.ID)
+			} else {
+				h.users.remember(p)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// messageResponse is a message as sent to clients, with the author's current
+// profile instead of the name copied at insert time
+type messageResponse struct {
+	store.Message
+	AuthorAvatarURL string `json:"author_avatar_url,omitempty"`
+}
+
+// presentMessages masks anonymous authors and joins the current profile of
+// every other author. Stored names are kept if the profiles cannot be loaded.
+func (h apiHandler) presentMessages(ctx context.Context, messages []store.Message) []messageResponse {
+	response := make([]messageResponse, 0, len(messages))
+	var ids []string
+	for _, m := range messages {
+		m = maskMessage(m)
+		if m.UserID != nil {
+			ids = append(ids, *m.UserID)
+		}
+		response = append(response, messageResponse{Message: m})
+	}
+
+	if len(ids) == 0 {
+		return response
+	}
+
+	users, err := h.q.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		slog.Warn("failed to load author profiles", "error", err)
+		return response
+	}
+
+	profiles := make(map[string]store.User, len(users))
+	for _, u := range users {
+		profiles[u.ID] = u
+	}
+	for i, m := range response {
+		if m.UserID == nil {
+			continue
+		}
+		if u, ok := profiles[*m.UserID]; ok {
+			response[i].AuthorName = u.Name
+			if u.AvatarUrl != nil {
+				response[i].AuthorAvatarURL = *u.AvatarUrl
+			}
+		}
+	}
+	return response
+}
+
+func (h apiHandler) presentMessage(ctx context.Context, m store.Message) messageResponse {
+	return h.presentMessages(ctx, []store.Message{m})[0]
+}
+
+type userResponse struct {
+	store.User
+	Messages []messageResponse `json:"messages"`
+}
+
+func (h apiHandler) handleGetUser(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "user_id")
+
+	limit := int64(defaultUserMessages)
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		limit, err = strconv.ParseInt(raw, 10, 32)
+		if err != nil || limit <= 0 || limit > maxUserMessages {
+			writeError(w, r, errInvalidParameter.with("limit"))
+			return
+		}
+	}
+
+	user, err := h.q.GetUser(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, r, errUserNotFound)
+			return
+		}
+		slog.Error("failed to get user", "error", err, "user_id", userID)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	// Authors still find their own anonymous messages, masked like everywhere
+	self, ok := profileFromRequest(r)
+	messages, err := h.q.GetUserMessages(r.Context(), store.GetUserMessagesParams{
+		UserID:           user.ID,
+		IncludeAnonymous: ok && self.ID == user.ID,
+		MaxResults:       int32(limit),
+	})
+	if err != nil {
+		slog.Error("failed to get user messages", "error", err, "user_id", userID)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	messages, err = h.readableMessages(r, messages)
+	if err != nil {
+		slog.Error("failed to get message rooms", "error", err, "user_id", userID)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	sendJSON(w, userResponse{User: user, Messages: h.presentMessages(r.Context(), messages)})
+}
+
+// readableMessages drops the messages of rooms the caller cannot read, see
+// canReadRoom. The page may come out shorter than the limit.
+func (h apiHandler) readableMessages(r *http.Request, messages []store.Message) ([]store.Message, error) {
+	readable := make(map[uuid.UUID]bool)
+	kept := messages[:0]
+	for _, m := range messages {
+		ok, seen := readable[m.RoomID]
+		if !seen {
+			room, err := h.q.GetRoom(r.Context(), m.RoomID)
+			if err != nil {
+				return nil, err
+			}
+			ok = canReadRoom(r, room)
+			readable[m.RoomID] = ok
+		}
+		if ok {
+			kept = append(kept, m)
+		}
+	}
+	return kept, nil
+}
@@ -3,6 +3,8 @@ package api
 import (
 	"errors"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
@@ -15,11 +17,28 @@ var (
 	ErrInvalidAuthorName = errors.New("author name cannot be empty")
 	ErrInvalidTheme      = errors.New("theme cannot be empty")
 	ErrThemeTooLong      = errors.New("theme exceeds maximum length")
+	ErrEmptyPollQuestion = errors.New("poll question cannot be empty")
+	ErrPollQuestionLong  = errors.New("poll question exceeds maximum length")
+	ErrPollOptionCount   = errors.New("poll must have between 2 and 10 options")
+	ErrEmptyPollOption   = errors.New("poll option cannot be empty")
+	ErrPollOptionTooLong = errors.New("poll option exceeds maximum length")
+	ErrDuplicateOption   = errors.New("poll options must be unique")
+	ErrEmptyGuestName    = errors.New("guest name cannot be empty")
+	ErrGuestNameTooLong  = errors.New("guest name exceeds maximum length")
+	ErrInvalidGuestName  = errors.New("guest name contains invalid characters")
+	ErrReservedGuestName = errors.New("guest name is reserved")
 )
 
 const (
 	MaxMessageLength = 2000 // Maximum characters for a message
 	MaxThemeLength   = 100  // Maximum characters for a room theme
+
+	MaxPollQuestionLength = 300 // Maximum characters for a poll question
+	MaxPollOptionLength   = 100 // Maximum characters for a poll option
+	MinPollOptions        = 2   // Minimum options in a poll
+	MaxPollOptions        = 10  // Maximum options in a poll
+
+	MaxGuestNameLength = 40 // Maximum characters for a guest display name
 )
 
 // ValidateUUID validates if a string is a valid UUID
@@ -69,3 +88,64 @@ func ValidateTheme(theme string) error {
 
 	return nil
 }
+
+// ValidatePoll validates a poll question and its options
+func ValidatePoll(question string, options []string) error {
+	if strings.TrimSpace(question) == "" {
+		return ErrEmptyPollQuestion
+	}
+
+	if utf8.RuneCountInString(question) > MaxPollQuestionLength {
+		return ErrPollQuestionLong
+	}
+
+	if len(options) < MinPollOptions || len(options) > MaxPollOptions {
+		return ErrPollOptionCount
+	}
+
+	seen := make(map[string]bool, len(options))
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return ErrEmptyPollOption
+		}
+		if utf8.RuneCountInString(option) > MaxPollOptionLength {
+			return ErrPollOptionTooLong
+		}
+		if seen[strings.ToLower(option)] {
+			return ErrDuplicateOption
+		}
+		seen[strings.ToLower(option)] = true
+	}
+
+	return nil
+}
+
+// Names guests may not take, compared case-insensitively
+var reservedGuestNames = []string{"guest", "admin", "moderator", "system"}
+
+// ValidateGuestName validates a guest display name
+func ValidateGuestName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ErrEmptyGuestName
+	}
+
+	if utf8.RuneCountInString(name) > MaxGuestNameLength {
+		return ErrGuestNameTooLong
+	}
+
+	for _, r := range name {
+		if unicode.IsControl(r) || !unicode.IsPrint(r) && r != ' ' {
+			return ErrInvalidGuestName
+		}
+	}
+
+	for _, reserved := range reservedGuestNames {
+		if strings.EqualFold(name, reserved) {
+			return ErrReservedGuestName
+		}
+	}
+
+	return nil
+}
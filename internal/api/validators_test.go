@@ -1,6 +1,7 @@
 package api
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -155,3 +156,118 @@ func TestValidateTheme(t *testing.T) {
 		})
 	}
 }
+
+func TestValidatePoll(t *testing.T) {
+	tests := []struct {
+		name     string
+		question string
+		options  []string
+		wantErr  bool
+	}{
+		{
+			name:     "Valid poll",
+			question: "Which topic next?",
+			options:  []string{"Go", "Rust"},
+			wantErr:  false,
+		},
+		{
+			name:     "Empty question",
+			question: "  ",
+			options:  []string{"Go", "Rust"},
+			wantErr:  true,
+		},
+		{
+			name:     "Too few options",
+			question: "Which topic next?",
+			options:  []string{"Go"},
+			wantErr:  true,
+		},
+		{
+			name:     "Empty option",
+			question: "Which topic next?",
+			options:  []string{"Go", " "},
+			wantErr:  true,
+		},
+		{
+			name:     "Duplicate options",
+			question: "Which topic next?",
+			options:  []string{"Go", "go"},
+			wantErr:  true,
+		},
+		{
+			name:     "Question too long",
+			question: string(make([]rune, MaxPollQuestionLength+1)),
+			options:  []string{"Go", "Rust"},
+			wantErr:  true,
+		},
+		{
+			name:     "Accented question at the limit",
+			question: strings.Repeat("é", MaxPollQuestionLength),
+			options:  []string{"Go", "Rust"},
+			wantErr:  false,
+		},
+		{
+			name:     "Accented option at the limit",
+			question: "Which topic next?",
+			options:  []string{strings.Repeat("ã", MaxPollOptionLength), "Rust"},
+			wantErr:  false,
+		},
+		{
+			name:     "Accented option too long",
+			question: "Which topic next?",
+			options:  []string{strings.Repeat("ã", MaxPollOptionLength+1), "Rust"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePoll(tt.question, tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePoll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateGuestName(t *testing.T) {
+	tests := []struct {
+		name      string
+		guestName string
+		wantErr   error
+	}{
+		{
+			name:      "Valid name",
+			guestName: "Ana Souza",
+			wantErr:   nil,
+		},
+		{
+			name:      "Empty name",
+			guestName: "   ",
+			wantErr:   ErrEmptyGuestName,
+		},
+		{
+			name:      "Name too long",
+			guestName: strings.Repeat("a", MaxGuestNameLength+1),
+			wantErr:   ErrGuestNameTooLong,
+		},
+		{
+			name:      "Control characters",
+			guestName: "Ana\nSouza",
+			wantErr:   ErrInvalidGuestName,
+		},
+		{
+			name:      "Reserved name",
+			guestName: "Admin",
+			wantErr:   ErrReservedGuestName,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateGuestName(tt.guestName); err != tt.wantErr {
+				t.Errorf("ValidateGuestName() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
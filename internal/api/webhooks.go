@@ -0,0 +1,304 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// webhookEventKinds are the broadcast kinds a webhook can subscribe to
+var webhookEventKinds = []string{
+	MessageKindMessageCreated,
+	MessageKindMessageRactionIncreased,
+	MessageKindMessageRactionDecreased,
+	MessageKindMessageAnswered,
+	MessageKindMessageHidden,
+	MessageKindMessageUnhidden,
+	MessageKindPollCreated,
+	MessageKindPollVotesUpdated,
+	MessageKindPollClosed,
+	MessageKindTopQuestionsChanged,
+}
+
+const (
+	deliveryStatusPending   = "pending"
+	deliveryStatusDelivered = "delivered"
+	deliveryStatusDead      = "dead"
+)
+
+// enqueueWebhooks queues the event for every subscribed webhook. The insert
+// runs in the background so a slow database never delays the fan-out.
+func (h apiHandler) enqueueWebhooks(ctx context.Context, msg Message) {
+	if h.webhooks == nil {
+		return
+	}
+
+	roomID, err := uuid.Parse(msg.RoomID)
+	if err != nil {
+		return
+	}
+
+	h.goTracked(func() {
+		if err := h.webhooks.Enqueue(ctx, msg.Kind, roomID, msg); err != nil {
+			slog.Error("failed to enqueue webhooks", "error", err, "room_id", msg.RoomID, "kind", msg.Kind)
+		}
+	})
+}
+
+// webhookResponse hides the signing secret, which is only shown on creation
+type webhookResponse struct {
+	store.Webhook
+	Secret string `json:"secret,omitempty"`
+}
+
+// webhookDeliveryResponse returns the payload as JSON instead of base64
+type webhookDeliveryResponse struct {
+	store.WebhookDelivery
+	Payload json.RawMessage `json:"payload"`
+}
+
+func newWebhookDeliveryResponse(d store.WebhookDelivery) webhookDeliveryResponse {
+	return webhookDeliveryResponse{WebhookDelivery: d, Payload: d.Payload}
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+type createWebhookRequest struct {
+	URL        string   `json:"url"`
+	RoomID     string   `json:"room_id,omitempty"`
+	EventKinds []string `json:"event_kinds,omitempty"`
+}
+
+func (h apiHandler) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	var body createWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, errInvalidJSON)
+		return
+	}
+
+	target, err := url.Parse(strings.TrimSpace(body.URL))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		writeError(w, r, errInvalidWebhookURL)
+		return
+	}
+
+	// Without a room the webhook receives the events of every room
+	var roomID uuid.UUID
+	if body.RoomID != "" {
+		id, err := uuid.Parse(body.RoomID)
+		if err != nil {
+			writeError(w, r, errInvalidRoomID)
+			return
+		}
+		if _, err := h.q.GetRoom(r.Context(), id); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				writeError(w, r, errRoomNotFound)
+				return
+			}
+			slog.Error("failed to get room", "error", err)
+			writeError(w, r, errInternal)
+			return
+		}
+		roomID = id
+	}
+
+	eventKinds := make([]string, 0, len(body.EventKinds))
+	for _, kind := range body.EventKinds {
+		if kind = strings.TrimSpace(kind); kind == "" {
+			continue
+		}
+		if !slices.Contains(webhookEventKinds, kind) {
+			writeError(w, r, errUnknownEventKind.with(kind))
+			return
+		}
+		if !slices.Contains(eventKinds, kind) {
+			eventKinds = append(eventKinds, kind)
+		}
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		slog.Error("failed to generate webhook secret", "error", err)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	createdBy, _ := authorFromRequest(r)
+	hook, err := h.q.InsertWebhook(r.Context(), store.InsertWebhookParams{
+		RoomID:     nullable(roomID),
+		Url:        target.String(),
+		Secret:     secret,
+		EventKinds: eventKinds,
+		CreatedBy:  createdBy,
+	})
+	if err != nil {
+		slog.Error("failed to insert webhook", "error", err)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	slog.Info("webhook created", "webhook_id", hook.ID, "url", hook.Url)
+
+	h.audit(r, auditEntry{
+		Action:     auditWebhookCreate,
+		RoomID:     roomID,
+		TargetType: "webhook",
+		TargetID:   hook.ID.String(),
+		After:      webhookResponse{Webhook: hook},
+	})
+
+	sendJSON(w, webhookResponse{Webhook: hook, Secret: hook.Secret})
+}
+
+func (h apiHandler) handleGetWebhooks(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	hooks, err := h.q.GetWebhooks(r.Context())
+	if err != nil {
+		slog.Error("failed to get webhooks", "error", err)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	response := make([]webhookResponse, 0, len(hooks))
+	for _, hook := range hooks {
+		response = append(response, webhookResponse{Webhook: hook})
+	}
+
+	sendJSON(w, response)
+}
+
+func (h apiHandler) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "webhook_id"))
+	if err != nil {
+		writeError(w, r, errInvalidWebhookID)
+		return
+	}
+
+	deleted, err := h.q.DeleteWebhook(r.Context(), id)
+	if err != nil {
+		slog.Error("failed to delete webhook", "error", err, "webhook_id", id)
+		writeError(w, r, errInternal)
+		return
+	}
+	if deleted == 0 {
+		writeError(w, r, errWebhookNotFound)
+		return
+	}
+
+	h.audit(r, auditEntry{
+		Action:     auditWebhookDelete,
+		TargetType: "webhook",
+		TargetID:   id.String(),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetWebhookDeliveries lists deliveries by status, dead letters by default
+func (h apiHandler) handleGetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = deliveryStatusDead
+	case deliveryStatusPending, deliveryStatusDelivered, deliveryStatusDead:
+	default:
+		writeError(w, r, errInvalidParameter.with("status"))
+		return
+	}
+
+	limit := int64(50)
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		limit, err = strconv.ParseInt(raw, 10, 32)
+		if err != nil || limit <= 0 || limit > 500 {
+			writeError(w, r, errInvalidParameter.with("limit"))
+			return
+		}
+	}
+
+	deliveries, err := h.q.GetWebhookDeliveries(r.Context(), store.GetWebhookDeliveriesParams{
+		Status: status,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		slog.Error("failed to get webhook deliveries", "error", err)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	response := make([]webhookDeliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		response = append(response, newWebhookDeliveryResponse(d))
+	}
+
+	sendJSON(w, response)
+}
+
+func (h apiHandler) handleRedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "delivery_id"))
+	if err != nil {
+		writeError(w, r, errInvalidDeliveryID)
+		return
+	}
+
+	delivery, err := h.q.RedeliverWebhookDelivery(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, r, errDeliveryNotFound)
+			return
+		}
+		slog.Error("failed to redeliver webhook", "error", err, "delivery_id", id)
+		writeError(w, r, errInternal)
+		return
+	}
+
+	slog.Info("webhook delivery requeued", "delivery_id", id)
+
+	h.audit(r, auditEntry{
+		Action:     auditWebhookRedeliver,
+		RoomID:     delivery.RoomID,
+		TargetType: "webhook_delivery",
+		TargetID:   id.String(),
+		After:      map[string]string{"status": delivery.Status},
+	})
+
+	sendJSON(w, newWebhookDeliveryResponse(delivery))
+}
@@ -0,0 +1,87 @@
+package apidoc
+
+// Event documents one kind of frame of the WebSocket stream
+type Event struct {
+	Kind    string
+	Summary string
+	// Value is a value of the type carried by the frame. Frames without a
+	// value are sent bare, as {"kind": Kind}; the others are wrapped with the
+	// room they come from, as {"kind": Kind, "room_id": ..., "value": Value}.
+	Value any
+}
+
+// Channel is the WebSocket endpoint the events go through
+type Channel struct {
+	Name        string
+	Address     string
+	Description string
+	// Params describes the parameters of the address by name
+	Params map[string]Param
+	// Receive are the frames sent by the server, Send those of the client
+	Receive []Event
+	Send    []Event
+}
+
+// AsyncAPI builds an AsyncAPI 3.0 document of the channel
+func AsyncAPI(info Info, channel Channel) Object {
+	s := NewSchemas()
+	messages := Object{}
+	refs := Object{}
+	operations := Object{}
+
+	channelRef := "#/channels/" + channel.Name
+	add := func(action string, events []Event) {
+		for _, e := range events {
+			messages[e.Kind] = s.message(e)
+			refs[e.Kind] = Object{"$ref": "#/components/messages/" + e.Kind}
+			operations[action+"_"+e.Kind] = Object{
+				"action":   action,
+				"summary":  e.Summary,
+				"channel":  Object{"$ref": channelRef},
+				"messages": []Object{{"$ref": channelRef + "/messages/" + e.Kind}},
+			}
+		}
+	}
+	add("receive", channel.Receive)
+	add("send", channel.Send)
+
+	parameters := Object{}
+	for name, p := range channel.Params {
+		parameters[name] = Object{"description": p.Description}
+	}
+
+	return Object{
+		"asyncapi": "3.0.0",
+		"info":     info.object(),
+		"channels": Object{
+			channel.Name: Object{
+				"address":     channel.Address,
+				"description": channel.Description,
+				"parameters":  parameters,
+				"messages":    refs,
+			},
+		},
+		"operations": operations,
+		"components": Object{
+			"messages": messages,
+			"schemas":  s.Components(),
+		},
+	}
+}
+
+func (s *Schemas) message(e Event) Object {
+	properties := Object{"kind": Object{"type": "string", "const": e.Kind}}
+	required := []string{"kind"}
+	if e.Value != nil {
+		properties["room_id"] = Object{"type": "string", "format": "uuid"}
+		properties["value"] = s.Of(e.Value)
+		required = append(required, "room_id", "value")
+	}
+
+	return Object{
+		"name":        e.Kind,
+		"summary":     e.Summary,
+		"contentType": "application/json",
+		"payload":     Object{"type": "object", "properties": properties, "required": required},
+	}
+}
@@ -0,0 +1,191 @@
+package apidoc
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Info identifies the API in both documents
+type Info struct {
+	Title       string
+	Version     string
+	Description string
+}
+
+func (i Info) object() Object {
+	obj := Object{"title": i.Title, "version": i.Version}
+	if i.Description != "" {
+		obj["description"] = i.Description
+	}
+	return obj
+}
+
+// Param is a path or query parameter
+type Param struct {
+	Name        string
+	Description string
+	// Schema is the schema of the value, a plain string when nil
+	Schema Object
+}
+
+func (p Param) object(in string) Object {
+	schema := p.Schema
+	if schema == nil {
+		schema = Object{"type": "string"}
+	}
+	obj := Object{"name": p.Name, "in": in, "schema": schema}
+	if p.Description != "" {
+		obj["description"] = p.Description
+	}
+	if in == "path" {
+		obj["required"] = true
+	}
+	return obj
+}
+
+// Route documents one operation of the REST API
+type Route struct {
+	Method string
+	// Path uses the chi syntax, e.g. /api/rooms/{room_id}
+	Path        string
+	Summary     string
+	Description string
+	Tag         string
+	// Permission is the room permission the route requires, if any
+	Permission string
+	Query      []Param
+	// Request and Response are values of the types the handler decodes and
+	// encodes, nil when there is no body
+	Request  any
+	Response any
+	// Status is the status of a success, 200 by default
+	Status int
+	// ContentTypes are the media types of the response, application/json by
+	// default
+	ContentTypes []string
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// OpenAPI builds an OpenAPI 3.1 document. Path parameters are shared by every
+// route and looked up by name in params. errorResponse is a value of the type
+// of the body of every error response.
+func OpenAPI(info Info, params map[string]Param, routes []Route, errorResponse any) Object {
+	s := NewSchemas()
+	errorSchema := s.Of(errorResponse)
+	paths := Object{}
+	for _, route := range routes {
+		item, ok := paths[route.Path].(Object)
+		if !ok {
+			item = Object{}
+			paths[route.Path] = item
+		}
+		method := strings.ToLower(route.Method)
+		if _, dup := item[method]; dup {
+			panic(fmt.Sprintf("apidoc: %s %s is documented twice", route.Method, route.Path))
+		}
+		item[method] = s.operation(route, params, errorSchema)
+	}
+
+	return Object{
+		"openapi": "3.1.0",
+		"info":    info.object(),
+		"paths":   paths,
+		// Credentials are optional, rooms can be read anonymously
+		"security": []Object{{"bearerAuth": []string{}}, {"apiKey": []string{}}, {}},
+		"components": Object{
+			"schemas": s.Components(),
+			"securitySchemes": Object{
+				"bearerAuth": Object{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"apiKey":     Object{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+		},
+	}
+}
+
+func (s *Schemas) operation(route Route, params map[string]Param, errorSchema Object) Object {
+	op := Object{
+		"operationId": operationID(route),
+		"summary":     route.Summary,
+	}
+	if route.Tag != "" {
+		op["tags"] = []string{route.Tag}
+	}
+
+	description := route.Description
+	if route.Permission != "" {
+		description = strings.TrimSpace(description + "\n\nRequires the `" + route.Permission + "` permission on the room.")
+	}
+	if description != "" {
+		op["description"] = description
+	}
+
+	var parameters []Object
+	for _, m := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+		p, ok := params[m[1]]
+		if !ok {
+			panic(fmt.Sprintf("apidoc: path parameter %s of %s is undocumented", m[1], route.Path))
+		}
+		p.Name = m[1]
+		parameters = append(parameters, p.object("path"))
+	}
+	for _, p := range route.Query {
+		parameters = append(parameters, p.object("query"))
+	}
+	if len(parameters) > 0 {
+		op["parameters"] = parameters
+	}
+
+	if route.Request != nil {
+		op["requestBody"] = Object{
+			"required": true,
+			"content":  Object{"application/json": Object{"schema": s.Of(route.Request)}},
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Object{"description": http.StatusText(status)}
+	types := route.ContentTypes
+	if len(types) == 0 && route.Response != nil {
+		types = []string{"application/json"}
+	}
+	if len(types) > 0 {
+		schema := Object{}
+		if route.Response != nil {
+			schema = s.Of(route.Response)
+		}
+		content := Object{}
+		for _, t := range types {
+			content[t] = Object{"schema": schema}
+		}
+		success["content"] = content
+	}
+
+	op["responses"] = Object{
+		fmt.Sprint(status): success,
+		"default": Object{
+			"description": "The error",
+			"content":     Object{"application/json": Object{"schema": errorSchema}},
+		},
+	}
+	return op
+}
+
+// operationID derives a unique id from the method and the path, e.g.
+// get_api_rooms_room_id_messages
+func operationID(route Route) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(route.Method))
+	for _, part := range strings.FieldsFunc(route.Path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '.'
+	}) {
+		b.WriteByte('_')
+		b.WriteString(part)
+	}
+	return b.String()
+}
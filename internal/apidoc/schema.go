@@ -0,0 +1,215 @@
+// Package apidoc builds the OpenAPI and AsyncAPI documents of the API from
+// the Go types that the handlers encode and decode, so that the documents
+// cannot drift from the wire format.
+package apidoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Object is a JSON object of a document
+type Object = map[string]any
+
+// schemaRef is where both documents keep their named schemas
+const schemaRef = "#/components/schemas/"
+
+// knownSchemas describes the types whose JSON form is not their struct fields
+var knownSchemas = map[reflect.Type]Object{
+	reflect.TypeFor[time.Time]():       {"type": "string", "format": "date-time"},
+	reflect.TypeFor[uuid.UUID]():       {"type": "string", "format": "uuid"},
+	reflect.TypeFor[json.RawMessage](): {},
+}
+
+// Schemas turns Go types into JSON Schemas. Named structs become shared
+// components referenced by name, everything else is inlined.
+type Schemas struct {
+	components Object
+	names      map[reflect.Type]string
+}
+
+func NewSchemas() *Schemas {
+	return &Schemas{components: Object{}, names: make(map[reflect.Type]string)}
+}
+
+// Of returns the schema of the JSON encoding of v, which is typically the
+// zero value of a type
+func (s *Schemas) Of(v any) Object {
+	return s.schema(reflect.TypeOf(v))
+}
+
+// Components returns the named schemas referenced so far
+func (s *Schemas) Components() Object {
+	return s.components
+}
+
+func (s *Schemas) schema(t reflect.Type) Object {
+	if t == nil {
+		return Object{}
+	}
+	if known, ok := knownSchemas[t]; ok {
+		return known
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.schema(t.Elem())
+	case reflect.Bool:
+		return Object{"type": "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return Object{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return Object{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return Object{"type": "number"}
+	case reflect.String:
+		return Object{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Object{"type": "string", "format": "byte"}
+		}
+		return Object{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return Object{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Interface:
+		return Object{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return Object{"$ref": schemaRef + s.component(t)}
+	default:
+		panic(fmt.Sprintf("apidoc: cannot describe %s", t))
+	}
+}
+
+// component registers the named struct and returns its component name
+func (s *Schemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+
+	name := exportedName(t.Name())
+	if _, taken := s.components[name]; taken {
+		pkg := t.PkgPath()
+		name = exportedName(pkg[strings.LastIndexByte(pkg, '/')+1:]) + name
+	}
+	if _, taken := s.components[name]; taken {
+		panic(fmt.Sprintf("apidoc: two types are named %s", name))
+	}
+
+	// Registered before the fields so that recursive types terminate
+	s.names[t] = name
+	s.components[name] = Object{}
+	s.components[name] = s.object(t)
+	return name
+}
+
+// object describes the fields of a struct the way encoding/json encodes them
+func (s *Schemas) object(t reflect.Type) Object {
+	properties := Object{}
+	var required []string
+	for _, f := range jsonFields(t) {
+		schema := s.schema(f.typ)
+		if !f.omitEmpty {
+			required = append(required, f.name)
+			// A nil pointer that is not omitted encodes as null
+			if f.typ.Kind() == reflect.Pointer {
+				schema = nullable(schema)
+			}
+		}
+		properties[f.name] = schema
+	}
+
+	obj := Object{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		obj["required"] = required
+	}
+	return obj
+}
+
+// nullable adds null to the types the schema allows
+func nullable(schema Object) Object {
+	typ, ok := schema["type"].(string)
+	if !ok {
+		return Object{"oneOf": []Object{schema, {"type": "null"}}}
+	}
+
+	out := make(Object, len(schema))
+	for k, v := range schema {
+		out[k] = v
+	}
+	out["type"] = []string{typ, "null"}
+	return out
+}
+
+type jsonField struct {
+	name      string
+	typ       reflect.Type
+	omitEmpty bool
+	depth     int
+}
+
+// jsonFields lists the encoded fields of the struct in order, flattening
+// embedded structs. As in encoding/json, a field hides the deeper fields of
+// the same name.
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	index := make(map[string]int)
+
+	var walk func(t reflect.Type, depth int)
+	walk = func(t reflect.Type, depth int) {
+		for i := range t.NumField() {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+
+			ft := f.Type
+			if f.Anonymous && name == "" {
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					walk(ft, depth+1)
+					continue
+				}
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+
+			field := jsonField{name: name, typ: ft, omitEmpty: strings.Contains(opts, "omitempty"), depth: depth}
+			if i, ok := index[name]; ok {
+				if fields[i].depth > depth {
+					fields[i] = field
+				}
+				continue
+			}
+			index[name] = len(fields)
+			fields = append(fields, field)
+		}
+	}
+	walk(t, 0)
+	return fields
+}
+
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
@@ -0,0 +1,62 @@
+package apidoc
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type base struct {
+	ID      string `json:"id"`
+	Secret  string `json:"secret"`
+	Payload []byte `json:"payload"`
+}
+
+type response struct {
+	base
+	Secret  string          `json:"secret,omitempty"`
+	Payload json.RawMessage `json:"payload"`
+	Closed  *time.Time      `json:"closed_at"`
+	Parent  *response       `json:"parent,omitempty"`
+	Ignored string          `json:"-"`
+	hidden  string
+}
+
+func TestSchemas(t *testing.T) {
+	s := NewSchemas()
+
+	got := s.Of([]response{})
+	want := Object{"type": "array", "items": Object{"$ref": schemaRef + "Response"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Of([]response) = %v, want %v", got, want)
+	}
+
+	obj := s.Components()["Response"].(Object)
+	properties := obj["properties"].(Object)
+	wantProperties := Object{
+		"id":        Object{"type": "string"},
+		"secret":    Object{"type": "string"},
+		"payload":   Object{},
+		"closed_at": Object{"type": []string{"string", "null"}, "format": "date-time"},
+		"parent":    Object{"$ref": schemaRef + "Response"},
+	}
+	if !reflect.DeepEqual(properties, wantProperties) {
+		t.Errorf("properties = %v, want %v", properties, wantProperties)
+	}
+
+	// The outer secret shadows the embedded one, and so does its omitempty
+	wantRequired := []string{"id", "payload", "closed_at"}
+	if !reflect.DeepEqual(obj["required"], wantRequired) {
+		t.Errorf("required = %v, want %v", obj["required"], wantRequired)
+	}
+}
+
+func TestOpenAPIUndocumentedParam(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("OpenAPI() did not panic on an undocumented path parameter")
+		}
+	}()
+	OpenAPI(Info{}, nil, []Route{{Method: "GET", Path: "/rooms/{room_id}"}}, nil)
+}
@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoKeys         = errors.New("no verification keys configured")
+	ErrKeyNotFound    = errors.New("verification key not found")
+	ErrInvalidPEM     = errors.New("invalid PEM public key")
+	ErrUnsupportedKey = errors.New("unsupported public key type")
+)
+
+var (
+	hmacMethods       = []string{"HS256", "HS384", "HS512"}
+	asymmetricMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+)
+
+type Config struct {
+	// HMACSecret verifies HS256/384/512 tokens
+	HMACSecret []byte
+	// PublicKeyPEM verifies asymmetric tokens with a single static key
+	PublicKeyPEM []byte
+	// JWKSURL verifies asymmetric tokens with the key matching their "kid"
+	JWKSURL string
+	// JWKSCacheTTL is how long a fetched key set is trusted before refetching
+	JWKSCacheTTL time.Duration
+	// JWKSMinRefresh throttles refetches triggered by an unknown "kid"
+	JWKSMinRefresh time.Duration
+	// Issuer, when set, must match the "iss" claim
+	Issuer string
+	// Audience, when set, must be listed in the "aud" claim
+	Audience string
+	// ClockSkew is the leeway applied to "exp", "nbf" and "iat"
+	ClockSkew time.Duration
+	// HTTPClient fetches the JWKS, http.DefaultClient when nil
+	HTTPClient *http.Client
+}
+
+// Verifier checks JWT signatures, algorithms and registered claims
+type Verifier struct {
+	secret    []byte
+	publicKey crypto.PublicKey
+	jwks      *jwks
+	parser    *jwt.Parser
+}
+
+// NewVerifier builds a verifier from cfg. Only the algorithms backed by a
+// configured key are accepted, so an HMAC token can never be checked against
+// a public key.
+func NewVerifier(cfg Config) (*Verifier, error) {
+	v := &Verifier{secret: cfg.HMACSecret}
+
+	if len(cfg.PublicKeyPEM) > 0 {
+		key, err := ParsePublicKeyPEM(cfg.PublicKeyPEM)
+		if err != nil {
+			return nil, err
+		}
+		v.publicKey = key
+	}
+
+	if cfg.JWKSURL != "" {
+		v.jwks = newJWKS(cfg.JWKSURL, cfg.HTTPClient, cfg.JWKSCacheTTL, cfg.JWKSMinRefresh)
+	}
+
+	var methods []string
+	if len(v.secret) > 0 {
+		methods = append(methods, hmacMethods...)
+	}
+	if v.publicKey != nil || v.jwks != nil {
+		methods = append(methods, asymmetricMethods...)
+	}
+	if len(methods) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithLeeway(cfg.ClockSkew),
+		jwt.WithIssuedAt(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	v.parser = jwt.NewParser(opts...)
+
+	return v, nil
+}
+
+// Verify parses the token and returns its claims when the signature and the
+// registered claims are valid
+func (v *Verifier) Verify(ctx context.Context, token string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return v.key(ctx, t)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (v *Verifier) key(ctx context.Context, t *jwt.Token) (interface{}, error) {
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok {
+		return v.secret, nil
+	}
+
+	if v.jwks != nil {
+		kid, _ := t.Header["kid"].(string)
+		key, err := v.jwks.key(ctx, kid)
+		if err == nil || v.publicKey == nil {
+			return key, err
+		}
+	}
+	return v.publicKey, nil
+}
+
+// ParsePublicKeyPEM reads a PKIX or PKCS#1 public key, or the key of an
+// X.509 certificate
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidPEM
+	}
+
+	var key crypto.PublicKey
+	switch block.Type {
+	case "PUBLIC KEY":
+		k, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPEM, err)
+		}
+		key = k
+	case "RSA PUBLIC KEY":
+		k, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPEM, err)
+		}
+		key = k
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPEM, err)
+		}
+		key = cert.PublicKey
+	default:
+		return nil, fmt.Errorf("%w: unexpected block %q", ErrInvalidPEM, block.Type)
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
@@ -0,0 +1,295 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksServer publishes a mutable key set and counts fetches
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    []jwk
+	fetches atomic.Int32
+	// hold, when set, delays every response until it is closed
+	hold chan struct{}
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	s := &jwksServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		if s.hold != nil {
+			<-s.hold
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) setKeys(keys ...jwk) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) jwk {
+	return jwk{Kty: "RSA", Kid: kid, Use: "sig", N: b64(key.N.Bytes()), E: b64(big.NewInt(int64(key.E)).Bytes())}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) jwk {
+	return jwk{Kty: "EC", Kid: kid, Crv: "P-256", X: b64(key.X.FillBytes(make([]byte, 32))), Y: b64(key.Y.FillBytes(make([]byte, 32)))}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return s
+}
+
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"sub": "user-1",
+		"iss": "https://auth.example.com",
+		"aud": "msgwss",
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+}
+
+func TestVerifierJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	srv := newJWKSServer(t)
+	srv.setKeys(rsaJWK("rsa-1", rsaKey), ecJWK("ec-1", ecKey))
+
+	v, err := NewVerifier(Config{
+		JWKSURL:  srv.URL,
+		Issuer:   "https://auth.example.com",
+		Audience: "msgwss",
+	})
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	wrongIssuer := validClaims()
+	wrongIssuer["iss"] = "https://evil.example.com"
+	wrongAudience := validClaims()
+	wrongAudience["aud"] = "other"
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"RS256", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims()), false},
+		{"ES256", sign(t, jwt.SigningMethodES256, "ec-1", ecKey, validClaims()), false},
+		{"Expired", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, expired), true},
+		{"Wrong issuer", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, wrongIssuer), true},
+		{"Wrong audience", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, wrongAudience), true},
+		{"Unknown signer", sign(t, jwt.SigningMethodRS256, "rsa-1", otherKey, validClaims()), true},
+		{"HMAC without secret", sign(t, jwt.SigningMethodHS256, "rsa-1", []byte("secret"), validClaims()), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := v.Verify(context.Background(), tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && claims["sub"] != "user-1" {
+				t.Errorf("Verify() sub = %v, want user-1", claims["sub"])
+			}
+		})
+	}
+}
+
+func TestVerifierJWKSRotation(t *testing.T) {
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	srv := newJWKSServer(t)
+	srv.setKeys(rsaJWK("old", oldKey))
+
+	v, err := NewVerifier(Config{JWKSURL: srv.URL, JWKSMinRefresh: time.Minute})
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	now := time.Now()
+	v.jwks.now = func() time.Time { return now }
+
+	if _, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "old", oldKey, validClaims())); err != nil {
+		t.Fatalf("Verify() with the old key error = %v", err)
+	}
+
+	srv.setKeys(rsaJWK("old", oldKey), rsaJWK("new", newKey))
+	rotated := sign(t, jwt.SigningMethodRS256, "new", newKey, validClaims())
+
+	// Unknown kids do not refetch more often than JWKSMinRefresh
+	if _, err := v.Verify(context.Background(), rotated); err == nil {
+		t.Fatal("Verify() accepted a kid before the refresh interval elapsed")
+	}
+	if got := srv.fetches.Load(); got != 1 {
+		t.Errorf("fetches = %d, want 1", got)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := v.Verify(context.Background(), rotated); err != nil {
+		t.Fatalf("Verify() with the rotated key error = %v", err)
+	}
+	if got := srv.fetches.Load(); got != 2 {
+		t.Errorf("fetches = %d, want 2", got)
+	}
+
+	// Cached keys keep working while the endpoint is down
+	srv.Close()
+	now = now.Add(time.Hour)
+	if _, err := v.Verify(context.Background(), rotated); err != nil {
+		t.Errorf("Verify() with an unreachable jwks error = %v", err)
+	}
+}
+
+func TestVerifierJWKSConcurrentRefresh(t *testing.T) {
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	srv := newJWKSServer(t)
+	srv.setKeys(rsaJWK("old", oldKey))
+
+	v, err := NewVerifier(Config{JWKSURL: srv.URL, JWKSMinRefresh: time.Minute})
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	now := time.Now()
+	v.jwks.now = func() time.Time { return now }
+	cached := sign(t, jwt.SigningMethodRS256, "old", oldKey, validClaims())
+	if _, err := v.Verify(context.Background(), cached); err != nil {
+		t.Fatalf("Verify() with the old key error = %v", err)
+	}
+
+	srv.setKeys(rsaJWK("old", oldKey), rsaJWK("new", newKey))
+	srv.hold = make(chan struct{})
+	now = now.Add(time.Minute)
+	rotated := sign(t, jwt.SigningMethodRS256, "new", newKey, validClaims())
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := v.Verify(context.Background(), rotated)
+			errs <- err
+		}()
+	}
+	for srv.fetches.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// A known kid is served from the cache while the refresh is in flight
+	done := make(chan error, 1)
+	go func() {
+		_, err := v.Verify(context.Background(), cached)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Verify() with a cached key error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Verify() with a cached key waited for the jwks fetch")
+	}
+
+	close(srv.hold)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Verify() with the rotated key error = %v", err)
+		}
+	}
+	if got := srv.fetches.Load(); got != 2 {
+		t.Errorf("fetches = %d, want 2, concurrent refreshes should share one", got)
+	}
+}
+
+func TestVerifierStaticKeyAndSecret(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	v, err := NewVerifier(Config{
+		HMACSecret:   []byte("secret"),
+		PublicKeyPEM: pemKey,
+		ClockSkew:    time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	skewed := validClaims()
+	skewed["exp"] = time.Now().Add(-30 * time.Second).Unix()
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"ES256 static key", sign(t, jwt.SigningMethodES256, "", ecKey, validClaims()), false},
+		{"HS256 secret", sign(t, jwt.SigningMethodHS256, "", []byte("secret"), validClaims()), false},
+		{"HS256 wrong secret", sign(t, jwt.SigningMethodHS256, "", []byte("other"), validClaims()), true},
+		{"HS256 keyed with the public key", sign(t, jwt.SigningMethodHS256, "", pemKey, validClaims()), true},
+		{"Expired within skew", sign(t, jwt.SigningMethodES256, "", ecKey, skewed), false},
+		{"Unsigned", sign(t, jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, validClaims()), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := v.Verify(context.Background(), tt.token); (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewVerifierWithoutKeys(t *testing.T) {
+	if _, err := NewVerifier(Config{}); err != ErrNoKeys {
+		t.Errorf("NewVerifier() error = %v, want %v", err, ErrNoKeys)
+	}
+}
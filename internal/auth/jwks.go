@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// fetchTimeout bounds a JWKS request, which outlives the request that
+// started it
+const fetchTimeout = 10 * time.Second
+
+// jwk is a single JSON Web Key as published in a JWKS document (RFC 7517)
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jwks caches the keys published at a URL. The set is refetched once it is
+// older than ttl, or early when a token names an unknown "kid" so rotated
+// keys are picked up without a restart.
+type jwks struct {
+	url        string
+	client     *http.Client
+	ttl        time.Duration
+	minRefresh time.Duration
+	now        func() time.Time
+
+	group       singleflight.Group
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+func newJWKS(url string, client *http.Client, ttl, minRefresh time.Duration) *jwks {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	if minRefresh <= 0 {
+		minRefresh = 30 * time.Second
+	}
+	return &jwks{url: url, client: client, ttl: ttl, minRefresh: minRefresh, now: time.Now}
+}
+
+// key returns the key for kid. An empty kid matches the only key of a set
+// holding exactly one.
+func (j *jwks) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	j.mu.Lock()
+	_, known := j.lookup(kid)
+	stale := j.keys == nil || j.now().Sub(j.fetchedAt) > j.ttl
+	j.mu.Unlock()
+
+	if stale || !known {
+		// Concurrent callers share one fetch, the others keep reading the cache
+		_, err, _ := j.group.Do("", func() (any, error) { return nil, j.refresh(ctx) })
+		if err != nil {
+			j.mu.Lock()
+			empty := j.keys == nil
+			j.mu.Unlock()
+			if empty {
+				return nil, err
+			}
+		}
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	key, ok := j.lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("%w: kid %q", ErrKeyNotFound, kid)
+	}
+	return key, nil
+}
+
+// refresh fetches the set unless the last attempt is more recent than
+// minRefresh. The mutex is not held during the request.
+func (j *jwks) refresh(ctx context.Context) error {
+	j.mu.Lock()
+	now := j.now()
+	if !j.lastAttempt.IsZero() && now.Sub(j.lastAttempt) < j.minRefresh {
+		j.mu.Unlock()
+		return nil
+	}
+	j.lastAttempt = now
+	j.mu.Unlock()
+
+	// The fetch is shared, so one caller going away must not cancel it
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+	defer cancel()
+	keys, err := j.fetch(ctx)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err != nil {
+		// Keep serving the last known keys while the endpoint is down
+		slog.Warn("failed to refresh jwks", "error", err, "url", j.url)
+		return err
+	}
+	j.keys = keys
+	j.fetchedAt = now
+	return nil
+}
+
+func (j *jwks) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+func (j *jwks) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := j.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks endpoint returned %d", res.StatusCode)
+	}
+
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid jwks document: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			slog.Warn("skipping jwks key", "error", err, "kid", k.Kid)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("%w: rsa exponent too large", ErrUnsupportedKey)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("%w: curve %q", ErrUnsupportedKey, k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("%w: point is not on the curve", ErrUnsupportedKey)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("%w: curve %q", ErrUnsupportedKey, k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: invalid ed25519 key", ErrUnsupportedKey)
+		}
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, fmt.Errorf("%w: kty %q", ErrUnsupportedKey, k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("%w: invalid base64url integer", ErrUnsupportedKey)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,534 @@
+// Package config loads the server configuration from defaults, an optional
+// .env file, the environment and command line flags, in increasing order of
+// precedence.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/auth"
+	"github.com/felipemacedo1/go-msg-wss/internal/ratelimit"
+	"github.com/felipemacedo1/go-msg-wss/internal/tracing"
+	"github.com/felipemacedo1/go-msg-wss/internal/webhook"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+)
+
+const defaultEnvFile = ".env"
+
+// Where the effective value of a setting came from
+const (
+	SourceDefault = "default"
+	SourceEnvFile = "env file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// sslModes are the libpq sslmode values accepted in DATABASE_URL
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+type Config struct {
+	Port           int
+	LogLevel       slog.Level
+	AllowedOrigins []string
+
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+	// DrainDelay is how long readiness reports draining before the server
+	// stops accepting connections, so load balancers see it
+	DrainDelay time.Duration
+
+	// Store is the persistence backend: postgres, sqlite or memory. The
+	// memory store needs no database and loses everything on restart.
+	Store string
+	// SQLitePath is the database file of the sqlite store
+	SQLitePath string
+	// DatabaseURL is DATABASE_URL, or a URL built from the
+	// MSGWSS_DATABASE_* parts when it is unset
+	DatabaseURL string
+	// MigrateOnStart applies pending embedded migrations before serving
+	MigrateOnStart bool
+
+	JWT           auth.Config
+	GuestTokenTTL time.Duration
+
+	RateLimitBackend string
+	RateLimits       RateLimits
+
+	TopQuestionsInterval time.Duration
+	TopQuestionsCount    int
+
+	Webhook webhook.Options
+
+	MetricsAddr  string
+	ReadyTimeout time.Duration
+	Tracing      tracing.Config
+
+	effective []Value
+}
+
+type RateLimits struct {
+	Messages  ratelimit.Limit
+	Reactions ratelimit.Limit
+	Subscribe ratelimit.Limit
+	Guests    ratelimit.Limit
+}
+
+// Value is the effective raw value of a setting, with secrets redacted
+type Value struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// setting binds an environment variable and its flag to a Config field.
+// Secrets set envOnly so that they never show up in ps or /proc.
+type setting struct {
+	key     string
+	def     string
+	usage   string
+	parse   func(string) error
+	redact  func(string) string
+	envOnly bool
+}
+
+// flagValue records the raw flag so that it is parsed like the environment.
+// Boolean settings may be passed without a value, e.g. -migrate-on-start.
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+func (f *flagValue) String() string     { return f.value }
+func (f *flagValue) Set(v string) error { f.value = v; return nil }
+func (f *flagValue) IsBoolFlag() bool   { return f.isBool }
+
+// database holds the MSGWSS_DATABASE_* parts until the URL is built
+type database struct {
+	host, port, user, password, name, sslMode string
+}
+
+// Load parses args (without the program name) and the environment for the
+// server. All invalid values are reported together.
+func Load(args []string) (Config, error) {
+	return load(flag.NewFlagSet("msgwss", flag.ContinueOnError), args, true)
+}
+
+// LoadStore is Load for commands that only open the store, such as migrate
+// and export. Settings that only the server needs, like the JWT keys, are not
+// required. The settings are added to fs next to the flags of the command,
+// and the arguments left after them stay in fs.Args().
+func LoadStore(fs *flag.FlagSet, args []string) (Config, error) {
+	return load(fs, args, false)
+}
+
+func load(fs *flag.FlagSet, args []string, serve bool) (Config, error) {
+	var (
+		cfg Config
+		db  database
+	)
+	settings := cfg.settings(&db)
+
+	envFile := fs.String("env-file", defaultEnvFile, "optional file with KEY=value lines")
+	flags := make(map[string]*flagValue, len(settings))
+	for _, s := range settings {
+		if s.envOnly {
+			continue
+		}
+		usage := s.usage + " (env " + s.key
+		if s.def != "" {
+			usage += ", default " + s.def
+		}
+		usage += ")"
+		flags[s.key] = &flagValue{isBool: s.def == "false" || s.def == "true"}
+		fs.Var(flags[s.key], flagName(s.key), usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	visited := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { visited[f.Name] = true })
+
+	fromFile, err := loadEnvFile(*envFile, visited["env-file"])
+	if err != nil {
+		return Config{}, err
+	}
+
+	var errs []error
+	for _, s := range settings {
+		raw, source := s.def, SourceDefault
+		if v, ok := os.LookupEnv(s.key); ok && v != "" {
+			raw, source = v, SourceEnv
+			if fromFile[s.key] {
+				source = SourceEnvFile
+			}
+		}
+		if !s.envOnly && visited[flagName(s.key)] {
+			raw, source = flags[s.key].value, SourceFlag
+		}
+
+		shown := raw
+		if s.redact != nil && raw != "" {
+			shown = s.redact(raw)
+		}
+
+		if err := s.parse(raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s=%q: %w", s.key, shown, err))
+			continue
+		}
+		cfg.effective = append(cfg.effective, Value{Key: s.key, Value: shown, Source: source})
+	}
+	if err := cfg.resolve(db, serve); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return Config{}, errors.Join(errs...)
+	}
+	return cfg, nil
+}
+
+// Print writes the effective configuration, one KEY=value per line
+func (c Config) Print(w io.Writer) {
+	for _, v := range c.effective {
+		fmt.Fprintf(w, "%s=%s (%s)\n", v.Key, v.Value, v.Source)
+	}
+}
+
+// Effective returns the raw value and source of every setting
+func (c Config) Effective() []Value {
+	return c.effective
+}
+
+func (c *Config) settings(db *database) []setting {
+	return []setting{
+		{key: "PORT", def: "8080", usage: "HTTP listen port", parse: func(s string) error {
+			port, err := strconv.Atoi(s)
+			if err != nil || port < 1 || port > 65535 {
+				return errors.New("must be a port number between 1 and 65535")
+			}
+			c.Port = port
+			return nil
+		}},
+		{key: "LOG_LEVEL", def: "info", usage: "debug, info, warn or error", parse: func(s string) error {
+			return c.LogLevel.UnmarshalText([]byte(s))
+		}},
+		{key: "ALLOWED_ORIGINS", def: "*", usage: "comma separated CORS and WebSocket origins, * allows any", parse: func(s string) error {
+			c.AllowedOrigins = nil
+			for _, origin := range strings.Split(s, ",") {
+				origin = strings.TrimSpace(origin)
+				if origin == "" {
+					continue
+				}
+				if origin != "*" {
+					if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" {
+						return fmt.Errorf("origin %q must look like https://example.com", origin)
+					}
+				}
+				c.AllowedOrigins = append(c.AllowedOrigins, origin)
+			}
+			if len(c.AllowedOrigins) == 0 {
+				return errors.New("at least one origin is required")
+			}
+			return nil
+		}},
+		{key: "MSGWSS_HTTP_READ_TIMEOUT", def: "15s", usage: "time to read a request, headers included", parse: durationVar(&c.ReadTimeout, time.Second)},
+		{key: "MSGWSS_HTTP_WRITE_TIMEOUT", def: "60s", usage: "time to write a response, WebSockets excluded", parse: durationVar(&c.WriteTimeout, time.Second)},
+		{key: "MSGWSS_HTTP_IDLE_TIMEOUT", def: "120s", usage: "keep-alive idle time", parse: durationVar(&c.IdleTimeout, time.Second)},
+		{key: "MSGWSS_SHUTDOWN_TIMEOUT", def: "30s", usage: "time given to in-flight requests and broadcasts on shutdown", parse: durationVar(&c.ShutdownTimeout, time.Second)},
+		{key: "MSGWSS_DRAIN_DELAY", def: "5s", usage: "time readiness fails before shutdown starts, 0 skips it", parse: durationVar(&c.DrainDelay, 0)},
+
+		{key: "MSGWSS_STORE", def: "postgres", usage: "postgres, sqlite for small installs, or memory for development without a database", parse: func(s string) error {
+			if s != "postgres" && s != "sqlite" && s != "memory" {
+				return errors.New("must be postgres, sqlite or memory")
+			}
+			c.Store = s
+			return nil
+		}},
+		{key: "MSGWSS_SQLITE_PATH", def: "msgwss.db", usage: "database file of the sqlite store, created when missing", parse: stringVar(&c.SQLitePath)},
+		{key: "DATABASE_URL", usage: "postgres:// URL or keyword/value DSN, takes precedence over MSGWSS_DATABASE_*", redact: redactURL, parse: stringVar(&c.DatabaseURL)},
+		{key: "MSGWSS_MIGRATE_ON_START", def: "false", usage: "apply pending migrations before serving", parse: boolVar(&c.MigrateOnStart)},
+		{key: "MSGWSS_DATABASE_HOST", usage: "database host", parse: stringVar(&db.host)},
+		{key: "MSGWSS_DATABASE_PORT", def: "5432", usage: "database port", parse: stringVar(&db.port)},
+		{key: "MSGWSS_DATABASE_USER", usage: "database user", parse: stringVar(&db.user)},
+		{key: "MSGWSS_DATABASE_PASSWORD", usage: "database password", redact: redactAll, parse: stringVar(&db.password)},
+		{key: "MSGWSS_DATABASE_NAME", usage: "database name", parse: stringVar(&db.name)},
+		{key: "MSGWSS_DATABASE_SSLMODE", def: "disable", usage: strings.Join(sslModes, ", "), parse: func(s string) error {
+			if err := checkSSLMode(s); err != nil {
+				return err
+			}
+			db.sslMode = s
+			return nil
+		}},
+
+		{key: "MSGWSS_JWT_SECRET", usage: "HMAC secret for HS256 tokens and guest tokens", redact: redactAll, envOnly: true, parse: func(s string) error {
+			c.JWT.HMACSecret = []byte(s)
+			return nil
+		}},
+		{key: "MSGWSS_JWT_PUBLIC_KEY_FILE", usage: "PEM public key or certificate for asymmetric tokens", parse: func(s string) error {
+			if s == "" {
+				return nil
+			}
+			data, err := os.ReadFile(s)
+			if err != nil {
+				return err
+			}
+			if _, err := auth.ParsePublicKeyPEM(data); err != nil {
+				return err
+			}
+			c.JWT.PublicKeyPEM = data
+			return nil
+		}},
+		{key: "MSGWSS_JWT_JWKS_URL", usage: "JWKS endpoint for asymmetric tokens", parse: func(s string) error {
+			if s == "" {
+				return nil
+			}
+			if u, err := url.Parse(s); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				return errors.New("must be an http(s) URL")
+			}
+			c.JWT.JWKSURL = s
+			return nil
+		}},
+		{key: "MSGWSS_JWT_JWKS_CACHE_TTL", def: "10m", usage: "how long a fetched key set is trusted", parse: durationVar(&c.JWT.JWKSCacheTTL, 0)},
+		{key: "MSGWSS_JWT_ISSUER", usage: "required iss claim", parse: stringVar(&c.JWT.Issuer)},
+		{key: "MSGWSS_JWT_AUDIENCE", usage: "required aud claim", parse: stringVar(&c.JWT.Audience)},
+		{key: "MSGWSS_JWT_CLOCK_SKEW", def: "30s", usage: "leeway for exp, nbf and iat", parse: durationVar(&c.JWT.ClockSkew, 0)},
+		{key: "MSGWSS_GUEST_TOKEN_TTL", def: "1h", usage: "lifetime of guest tokens", parse: durationVar(&c.GuestTokenTTL, time.Second)},
+
+		{key: "MSGWSS_RATE_LIMIT_BACKEND", def: "memory", usage: "memory or postgres", parse: func(s string) error {
+			if s != "memory" && s != "postgres" {
+				return errors.New("must be memory or postgres")
+			}
+			c.RateLimitBackend = s
+			return nil
+		}},
+		{key: "MSGWSS_RATE_LIMIT_MESSAGES", def: "10/1m", usage: "<requests>/<period> or off", parse: limitVar(&c.RateLimits.Messages)},
+		{key: "MSGWSS_RATE_LIMIT_REACTIONS", def: "60/1m", usage: "<requests>/<period> or off", parse: limitVar(&c.RateLimits.Reactions)},
+		{key: "MSGWSS_RATE_LIMIT_SUBSCRIBE", def: "20/1m", usage: "<requests>/<period> or off", parse: limitVar(&c.RateLimits.Subscribe)},
+		{key: "MSGWSS_RATE_LIMIT_GUESTS", def: "10/1m", usage: "<requests>/<period> or off", parse: limitVar(&c.RateLimits.Guests)},
+
+		{key: "MSGWSS_TOP_QUESTIONS_INTERVAL", def: "10s", usage: "top questions push interval, 0 disables", parse: durationVar(&c.TopQuestionsInterval, 0)},
+		{key: "MSGWSS_TOP_QUESTIONS_COUNT", def: "5", usage: "how many top questions are pushed", parse: intVar(&c.TopQuestionsCount, 1)},
+
+		{key: "MSGWSS_WEBHOOK_POLL_INTERVAL", def: "2s", usage: "how often due deliveries are claimed", parse: durationVar(&c.Webhook.PollInterval, time.Millisecond)},
+		{key: "MSGWSS_WEBHOOK_MAX_ATTEMPTS", def: "8", usage: "attempts before a delivery is dead lettered", parse: intVar(&c.Webhook.MaxAttempts, 1)},
+		{key: "MSGWSS_WEBHOOK_BASE_BACKOFF", def: "10s", usage: "delay before the first retry", parse: durationVar(&c.Webhook.BaseBackoff, time.Millisecond)},
+		{key: "MSGWSS_WEBHOOK_MAX_BACKOFF", def: "1h", usage: "upper bound of the retry delay", parse: durationVar(&c.Webhook.MaxBackoff, time.Millisecond)},
+		{key: "MSGWSS_WEBHOOK_TIMEOUT", def: "10s", usage: "timeout of a single delivery", parse: durationVar(&c.Webhook.Timeout, time.Millisecond)},
+
+		{key: "MSGWSS_METRICS_ADDR", def: "127.0.0.1:9090", usage: "Prometheus listen address, off disables", parse: func(s string) error {
+			if s != "off" {
+				if _, _, err := net.SplitHostPort(s); err != nil {
+					return err
+				}
+			}
+			c.MetricsAddr = s
+			return nil
+		}},
+		{key: "MSGWSS_READY_TIMEOUT", def: "2s", usage: "timeout of each readiness check", parse: durationVar(&c.ReadyTimeout, time.Millisecond)},
+		{key: "MSGWSS_TRACING_EXPORTER", def: tracing.ExporterNone, usage: "none, stdout or otlp", parse: func(s string) error {
+			switch s {
+			case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+				c.Tracing.Exporter = s
+				return nil
+			}
+			return errors.New("must be none, stdout or otlp")
+		}},
+		{key: "MSGWSS_TRACING_SAMPLE_RATIO", def: "1", usage: "share of root spans kept, between 0 and 1", parse: func(s string) error {
+			ratio, err := strconv.ParseFloat(s, 64)
+			if err != nil || ratio < 0 || ratio > 1 {
+				return errors.New("must be a number between 0 and 1")
+			}
+			c.Tracing.SampleRatio = ratio
+			return nil
+		}},
+	}
+}
+
+// resolve validates the settings that depend on each other. The JWT and
+// webhook settings are only checked when serving.
+func (c *Config) resolve(db database, serve bool) error {
+	var errs []error
+
+	switch {
+	case c.Store == "memory" || c.Store == "sqlite":
+		if c.RateLimitBackend == "postgres" {
+			errs = append(errs, fmt.Errorf("MSGWSS_RATE_LIMIT_BACKEND: postgres needs MSGWSS_STORE=postgres, not %s", c.Store))
+		}
+	case c.DatabaseURL == "":
+		if db.host == "" || db.user == "" || db.name == "" {
+			errs = append(errs, errors.New("database: set DATABASE_URL or MSGWSS_DATABASE_HOST, MSGWSS_DATABASE_USER and MSGWSS_DATABASE_NAME"))
+		} else {
+			u := url.URL{
+				Scheme:   "postgres",
+				User:     url.UserPassword(db.user, db.password),
+				Host:     net.JoinHostPort(db.host, db.port),
+				Path:     "/" + db.name,
+				RawQuery: url.Values{"sslmode": {db.sslMode}}.Encode(),
+			}
+			c.DatabaseURL = u.String()
+		}
+	default:
+		if err := checkDatabaseURL(c.DatabaseURL); err != nil {
+			errs = append(errs, fmt.Errorf("DATABASE_URL: %w", err))
+		}
+	}
+
+	if !serve {
+		return errors.Join(errs...)
+	}
+
+	if len(c.JWT.HMACSecret) == 0 && len(c.JWT.PublicKeyPEM) == 0 && c.JWT.JWKSURL == "" {
+		errs = append(errs, errors.New("jwt: set MSGWSS_JWT_SECRET, MSGWSS_JWT_PUBLIC_KEY_FILE or MSGWSS_JWT_JWKS_URL"))
+	}
+
+	if c.Webhook.BaseBackoff > c.Webhook.MaxBackoff {
+		errs = append(errs, errors.New("webhook: MSGWSS_WEBHOOK_BASE_BACKOFF is larger than MSGWSS_WEBHOOK_MAX_BACKOFF"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// loadEnvFile adds the variables of path that are not already set to the
+// environment, so that libraries reading it directly see them too. A missing
+// default file is not an error.
+func loadEnvFile(path string, explicit bool) (map[string]bool, error) {
+	values, err := godotenv.Read(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !explicit {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read env file: %w", err)
+	}
+
+	loaded := make(map[string]bool, len(values))
+	for key, value := range values {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return nil, err
+		}
+		loaded[key] = true
+	}
+	return loaded, nil
+}
+
+func checkDatabaseURL(raw string) error {
+	if u, err := url.Parse(raw); err == nil && u.Scheme != "" {
+		if mode := u.Query().Get("sslmode"); mode != "" {
+			if err := checkSSLMode(mode); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The parse error may quote the password
+	cfg, err := pgxpool.ParseConfig(raw)
+	if err != nil {
+		return errors.New("not a valid postgres connection string")
+	}
+	if cfg.ConnConfig.Database == "" {
+		return errors.New("database name is missing")
+	}
+	return nil
+}
+
+func checkSSLMode(mode string) error {
+	for _, m := range sslModes {
+		if mode == m {
+			return nil
+		}
+	}
+	return fmt.Errorf("sslmode must be one of %s", strings.Join(sslModes, ", "))
+}
+
+func redactAll(string) string {
+	return "[redacted]"
+}
+
+// redactURL hides the password of URL connection strings. Keyword/value
+// strings are hidden entirely since they may carry one anywhere.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" {
+		return redactAll(raw)
+	}
+	return u.Redacted()
+}
+
+// flagName turns MSGWSS_RATE_LIMIT_MESSAGES into rate-limit-messages
+func flagName(key string) string {
+	key = strings.TrimPrefix(key, "MSGWSS_")
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+func stringVar(dst *string) func(string) error {
+	return func(s string) error {
+		*dst = s
+		return nil
+	}
+}
+
+func durationVar(dst *time.Duration, min time.Duration) func(string) error {
+	return func(s string) error {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return errors.New("must be a duration like 30s, 5m or 1h")
+		}
+		if d < min {
+			return fmt.Errorf("must be at least %s", min)
+		}
+		*dst = d
+		return nil
+	}
+}
+
+func boolVar(dst *bool) func(string) error {
+	return func(s string) error {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		*dst = b
+		return nil
+	}
+}
+
+func intVar(dst *int, min int) func(string) error {
+	return func(s string) error {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		if n < min {
+			return fmt.Errorf("must be at least %d", min)
+		}
+		*dst = n
+		return nil
+	}
+}
+
+func limitVar(dst *ratelimit.Limit) func(string) error {
+	return func(s string) error {
+		limit, err := ratelimit.ParseLimit(s)
+		if err != nil {
+			return err
+		}
+		*dst = limit
+		return nil
+	}
+}
@@ -0,0 +1,45 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+)
+
+var csvHeader = []string{
+	"id", "created_at", "author_id", "author_name", "message", "reaction_count", "answered", "hidden",
+}
+
+// csvWriter writes one row per message; polls have no place in the table
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) begin(store.Room, []Poll, time.Time) error {
+	return c.w.Write(csvHeader)
+}
+
+func (c *csvWriter) message(m store.Message) error {
+	return c.w.Write([]string{
+		m.ID.String(),
+		m.CreatedAt.UTC().Format(time.RFC3339),
+		m.AuthorID,
+		m.AuthorName,
+		m.Message,
+		strconv.FormatInt(m.ReactionCount, 10),
+		strconv.FormatBool(m.Answered),
+		strconv.FormatBool(m.Hidden),
+	})
+}
+
+func (c *csvWriter) end() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,259 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+// pageSize is how many messages are loaded from the store at a time
+const pageSize = 500
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+type Format string
+
+const (
+	FormatJSON     Format = "json"
+	FormatCSV      Format = "csv"
+	FormatMarkdown Format = "markdown"
+)
+
+// ParseFormat accepts the format names and their usual file extensions
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "json":
+		return FormatJSON, nil
+	case "csv":
+		return FormatCSV, nil
+	case "md", "markdown":
+		return FormatMarkdown, nil
+	default:
+		return "", fmt.Errorf("%w %q", ErrUnknownFormat, s)
+	}
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+// Extension returns the file extension of the format
+func (f Format) Extension() string {
+	if f == FormatMarkdown {
+		return "md"
+	}
+	return string(f)
+}
+
+type Options struct {
+	Format        Format
+	IncludeHidden bool
+	Anonymize     bool
+}
+
+// Source is the subset of the store needed to export a room
+type Source interface {
+	GetRoomMessagesPage(ctx context.Context, arg store.GetRoomMessagesPageParams) ([]store.Message, error)
+	GetRoomPolls(ctx context.Context, roomID uuid.UUID) ([]store.Poll, error)
+	GetRoomPollTallies(ctx context.Context, roomID uuid.UUID) ([]store.GetRoomPollTalliesRow, error)
+	GetUsersByIDs(ctx context.Context, ids []string) ([]store.User, error)
+}
+
+type PollOption struct {
+	ID    uuid.UUID `json:"id"`
+	Label string    `json:"label"`
+	Votes int64     `json:"votes"`
+}
+
+type Poll struct {
+	store.Poll
+	Options    []PollOption `json:"options"`
+	TotalVotes int64        `json:"total_votes"`
+}
+
+// writer renders one format. Messages arrive one at a time so that large
+// rooms never have to be held in memory.
+type writer interface {
+	begin(room store.Room, polls []Poll, exportedAt time.Time) error
+	message(m store.Message) error
+	end() error
+}
+
+// Room writes the transcript of a room to w
+func Room(ctx context.Context, w io.Writer, src Source, room store.Room, opts Options) error {
+	var out writer
+	switch opts.Format {
+	case FormatJSON, "":
+		out = newJSONWriter(w)
+	case FormatCSV:
+		out = newCSVWriter(w)
+	case FormatMarkdown:
+		out = newMarkdownWriter(w)
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownFormat, opts.Format)
+	}
+
+	anon := newAnonymizer(opts.Anonymize)
+
+	polls, err := loadPolls(ctx, src, room.ID)
+	if err != nil {
+		return fmt.Errorf("load polls: %w", err)
+	}
+	for i := range polls {
+		polls[i].CreatedBy = anon.authorID(polls[i].CreatedBy)
+	}
+
+	if err := out.begin(room, polls, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	after := store.GetRoomMessagesPageParams{
+		RoomID:        room.ID,
+		IncludeHidden: opts.IncludeHidden,
+		PageSize:      pageSize,
+	}
+	for {
+		page, err := src.GetRoomMessagesPage(ctx, after)
+		if err != nil {
+			return fmt.Errorf("load messages: %w", err)
+		}
+
+		names, err := currentNames(ctx, src, page)
+		if err != nil {
+			return fmt.Errorf("load authors: %w", err)
+		}
+
+		for _, m := range page {
+			if m.UserID != nil {
+				if name, ok := names[*m.UserID]; ok {
+					m.AuthorName = name
+				}
+			}
+
+			// Anonymous authors are never revealed, even to moderators
+			if m.Anonymous {
+				m.AuthorID, m.AuthorName = "anonymous", "Anonymous"
+			} else {
+				m.AuthorID, m.AuthorName = anon.author(m.AuthorID, m.AuthorName)
+			}
+			if m.Anonymous || opts.Anonymize {
+				m.UserID = nil
+			}
+			if err := out.message(m); err != nil {
+				return err
+			}
+		}
+
+		if len(page) < pageSize {
+			break
+		}
+		last := page[len(page)-1]
+		after.AfterCreatedAt = last.CreatedAt
+		after.AfterID = last.ID
+	}
+
+	return out.end()
+}
+
+func loadPolls(ctx context.Context, src Source, roomID uuid.UUID) ([]Poll, error) {
+	polls, err := src.GetRoomPolls(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	tallies, err := src.GetRoomPollTallies(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	byPoll := make(map[uuid.UUID][]PollOption, len(polls))
+	totals := make(map[uuid.UUID]int64, len(polls))
+	for _, t := range tallies {
+		byPoll[t.PollID] = append(byPoll[t.PollID], PollOption{ID: t.ID, Label: t.Label, Votes: t.Votes})
+		totals[t.PollID] += t.Votes
+	}
+
+	result := make([]Poll, 0, len(polls))
+	for _, p := range polls {
+		options := byPoll[p.ID]
+		if options == nil {
+			options = []PollOption{}
+		}
+		result = append(result, Poll{Poll: p, Options: options, TotalVotes: totals[p.ID]})
+	}
+	return result, nil
+}
+
+// currentNames maps the users behind a page of messages to their current
+// profile names
+func currentNames(ctx context.Context, src Source, page []store.Message) (map[string]string, error) {
+	var ids []string
+	for _, m := range page {
+		if m.UserID != nil {
+			ids = append(ids, *m.UserID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	users, err := src.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.Name
+	}
+	return names, nil
+}
+
+// anonymizer replaces author identities with stable per-export pseudonyms
+type anonymizer struct {
+	enabled bool
+	ids     map[string]int
+}
+
+func newAnonymizer(enabled bool) *anonymizer {
+	return &anonymizer{enabled: enabled, ids: make(map[string]int)}
+}
+
+func (a *anonymizer) number(authorID string) int {
+	n, ok := a.ids[authorID]
+	if !ok {
+		n = len(a.ids) + 1
+		a.ids[authorID] = n
+	}
+	return n
+}
+
+func (a *anonymizer) authorID(authorID string) string {
+	if !a.enabled {
+		return authorID
+	}
+	return fmt.Sprintf("participant-%d", a.number(authorID))
+}
+
+func (a *anonymizer) author(authorID, authorName string) (string, string) {
+	if !a.enabled {
+		return authorID, authorName
+	}
+	n := a.number(authorID)
+	return fmt.Sprintf("participant-%d", n), fmt.Sprintf("Participant %d", n)
+}
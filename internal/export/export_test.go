@@ -0,0 +1,178 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+type fakeSource struct {
+	messages []store.Message
+	users    []store.User
+	pages    int
+}
+
+func (f *fakeSource) GetRoomMessagesPage(_ context.Context, arg store.GetRoomMessagesPageParams) ([]store.Message, error) {
+	f.pages++
+	var page []store.Message
+	for _, m := range f.messages {
+		if m.Hidden && !arg.IncludeHidden {
+			continue
+		}
+		if !m.CreatedAt.After(arg.AfterCreatedAt) {
+			continue
+		}
+		page = append(page, m)
+		if len(page) == int(arg.PageSize) {
+			break
+		}
+	}
+	return page, nil
+}
+
+func (f *fakeSource) GetRoomPolls(context.Context, uuid.UUID) ([]store.Poll, error) {
+	return []store.Poll{{ID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), Question: "Next topic?", Status: "open"}}, nil
+}
+
+func (f *fakeSource) GetRoomPollTallies(context.Context, uuid.UUID) ([]store.GetRoomPollTalliesRow, error) {
+	pollID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	return []store.GetRoomPollTalliesRow{
+		{ID: uuid.New(), PollID: pollID, Label: "Go", Votes: 3},
+		{ID: uuid.New(), PollID: pollID, Label: "Rust", Votes: 1},
+	}, nil
+}
+
+func (f *fakeSource) GetUsersByIDs(context.Context, []string) ([]store.User, error) {
+	return f.users, nil
+}
+
+func newFakeSource(n int) *fakeSource {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	f := &fakeSource{}
+	for i := 0; i < n; i++ {
+		f.messages = append(f.messages, store.Message{
+			ID:         uuid.New(),
+			Message:    "question",
+			AuthorID:   []string{"alice", "bob"}[i%2],
+			AuthorName: []string{"Alice", "Bob"}[i%2],
+			CreatedAt:  start.Add(time.Duration(i) * time.Second),
+			Hidden:     i == 1,
+			UserID:     &[]string{"alice", "bob"}[i%2],
+		})
+	}
+	return f
+}
+
+func TestRoomJSONPagesThroughMessages(t *testing.T) {
+	src := newFakeSource(pageSize + 10)
+
+	var buf bytes.Buffer
+	err := Room(context.Background(), &buf, src, store.Room{Theme: "Q&A"}, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("Room() error = %v", err)
+	}
+
+	var got struct {
+		Room     store.Room      `json:"room"`
+		Polls    []Poll          `json:"polls"`
+		Messages []store.Message `json:"messages"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("export is not valid JSON: %v", err)
+	}
+
+	if len(got.Messages) != pageSize+9 {
+		t.Errorf("got %d messages, want %d without the hidden one", len(got.Messages), pageSize+9)
+	}
+	if src.pages != 2 {
+		t.Errorf("loaded %d pages, want 2", src.pages)
+	}
+	if len(got.Polls) != 1 || got.Polls[0].TotalVotes != 4 {
+		t.Errorf("unexpected polls: %+v", got.Polls)
+	}
+}
+
+func TestRoomCSVAnonymized(t *testing.T) {
+	src := newFakeSource(3)
+
+	var buf bytes.Buffer
+	err := Room(context.Background(), &buf, src, store.Room{}, Options{Format: FormatCSV, IncludeHidden: true, Anonymize: true})
+	if err != nil {
+		t.Fatalf("Room() error = %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("export is not valid CSV: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want header and 3 rows", len(records))
+	}
+
+	for _, row := range records[1:] {
+		if strings.Contains(row[2], "alice") || strings.Contains(row[3], "Bob") {
+			t.Errorf("author was not anonymized: %v", row)
+		}
+	}
+	if records[1][2] != records[3][2] {
+		t.Errorf("same author should get the same pseudonym: %q != %q", records[1][2], records[3][2])
+	}
+}
+
+func TestRoomMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	err := Room(context.Background(), &buf, newFakeSource(1), store.Room{Theme: "Weekly *sync*"}, Options{Format: FormatMarkdown})
+	if err != nil {
+		t.Fatalf("Room() error = %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{`# Weekly \*sync\*`, "## Polls", "| Go | 3 |", "## Questions", "1. **Alice**"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRoomAuthors(t *testing.T) {
+	src := newFakeSource(3)
+	src.users = []store.User{{ID: "alice", Name: "Alice Renamed"}}
+	src.messages[1].Anonymous = true
+
+	var buf bytes.Buffer
+	err := Room(context.Background(), &buf, src, store.Room{}, Options{Format: FormatCSV, IncludeHidden: true})
+	if err != nil {
+		t.Fatalf("Room() error = %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("export is not valid CSV: %v", err)
+	}
+
+	if got := records[1][3]; got != "Alice Renamed" {
+		t.Errorf("author name = %q, want the current profile name", got)
+	}
+	if got := records[2][2:4]; got[0] != "anonymous" || got[1] != "Anonymous" {
+		t.Errorf("anonymous author = %v, want it masked", got)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for input, want := range map[string]Format{"": FormatJSON, "CSV": FormatCSV, "md": FormatMarkdown} {
+		if got, err := ParseFormat(input); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("ParseFormat(xml) should fail")
+	}
+}
@@ -0,0 +1,58 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+)
+
+// jsonWriter streams {"room":...,"exported_at":...,"polls":[...],"messages":[...]}
+type jsonWriter struct {
+	w     io.Writer
+	enc   *json.Encoder
+	count int
+}
+
+func newJSONWriter(w io.Writer) *jsonWriter {
+	return &jsonWriter{w: w, enc: json.NewEncoder(w)}
+}
+
+func (j *jsonWriter) begin(room store.Room, polls []Poll, exportedAt time.Time) error {
+	if _, err := io.WriteString(j.w, `{"room":`); err != nil {
+		return err
+	}
+	if err := j.enc.Encode(room); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(j.w, `,"exported_at":`); err != nil {
+		return err
+	}
+	if err := j.enc.Encode(exportedAt); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(j.w, `,"polls":`); err != nil {
+		return err
+	}
+	if err := j.enc.Encode(polls); err != nil {
+		return err
+	}
+	_, err := io.WriteString(j.w, `,"messages":[`)
+	return err
+}
+
+func (j *jsonWriter) message(m store.Message) error {
+	if j.count > 0 {
+		if _, err := io.WriteString(j.w, ","); err != nil {
+			return err
+		}
+	}
+	j.count++
+	return j.enc.Encode(m)
+}
+
+func (j *jsonWriter) end() error {
+	_, err := io.WriteString(j.w, "]}\n")
+	return err
+}
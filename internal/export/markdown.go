@@ -0,0 +1,86 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+)
+
+const markdownTimeLayout = "2006-01-02 15:04 MST"
+
+type markdownWriter struct {
+	w     *bufio.Writer
+	count int
+}
+
+func newMarkdownWriter(w io.Writer) *markdownWriter {
+	return &markdownWriter{w: bufio.NewWriter(w)}
+}
+
+func (m *markdownWriter) begin(room store.Room, polls []Poll, exportedAt time.Time) error {
+	fmt.Fprintf(m.w, "# %s\n\n", escapeMarkdown(room.Theme))
+	fmt.Fprintf(m.w, "Room `%s`, exported %s.\n\n", room.ID, exportedAt.Format(markdownTimeLayout))
+
+	if len(polls) > 0 {
+		fmt.Fprint(m.w, "## Polls\n\n")
+		for _, p := range polls {
+			kind := "single choice"
+			if p.MultipleChoice {
+				kind = "multiple choice"
+			}
+			fmt.Fprintf(m.w, "### %s\n\n", escapeMarkdown(p.Question))
+			fmt.Fprintf(m.w, "_%s, %s, %d votes_\n\n", p.Status, kind, p.TotalVotes)
+			fmt.Fprint(m.w, "| Option | Votes |\n| --- | ---: |\n")
+			for _, o := range p.Options {
+				fmt.Fprintf(m.w, "| %s | %d |\n", escapeTableCell(o.Label), o.Votes)
+			}
+			fmt.Fprint(m.w, "\n")
+		}
+	}
+
+	_, err := fmt.Fprint(m.w, "## Questions\n\n")
+	return err
+}
+
+func (m *markdownWriter) message(msg store.Message) error {
+	m.count++
+
+	meta := []string{msg.CreatedAt.UTC().Format(markdownTimeLayout)}
+	meta = append(meta, fmt.Sprintf("%d reactions", msg.ReactionCount))
+	if msg.Answered {
+		meta = append(meta, "answered")
+	}
+	if msg.Hidden {
+		meta = append(meta, "hidden")
+	}
+
+	fmt.Fprintf(m.w, "%d. **%s** (%s)\n\n", m.count, escapeMarkdown(msg.AuthorName), strings.Join(meta, ", "))
+	for _, line := range strings.Split(msg.Message, "\n") {
+		fmt.Fprintf(m.w, "    > %s\n", line)
+	}
+	_, err := fmt.Fprint(m.w, "\n")
+	return err
+}
+
+func (m *markdownWriter) end() error {
+	if m.count == 0 {
+		fmt.Fprint(m.w, "_No questions._\n")
+	}
+	return m.w.Flush()
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "#", `\#`, "[", `\[`, "]", `\]`,
+)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+func escapeTableCell(s string) string {
+	return strings.ReplaceAll(escapeMarkdown(s), "|", `\|`)
+}
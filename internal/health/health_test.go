@@ -0,0 +1,119 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func readiness(t *testing.T, c *Checker) (int, Report) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	c.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	return rec.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	ok := Check{Name: "database", Run: func(context.Context) error { return nil }}
+	failing := Check{Name: "migrations", Run: func(context.Context) error { return errors.New("version 12, want 13") }}
+	slow := Check{Name: "database", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	tests := []struct {
+		name       string
+		checks     []Check
+		draining   bool
+		wantCode   int
+		wantStatus string
+		wantChecks map[string]string
+	}{
+		{"all ok", []Check{ok}, false, http.StatusOK, StatusOK, map[string]string{"database": StatusOK}},
+		{"one failing", []Check{ok, failing}, false, http.StatusServiceUnavailable, StatusFail, map[string]string{"database": StatusOK, "migrations": StatusFail}},
+		{"timeout", []Check{slow}, false, http.StatusServiceUnavailable, StatusFail, map[string]string{"database": StatusFail}},
+		{"draining", []Check{ok}, true, http.StatusServiceUnavailable, StatusDraining, map[string]string{"database": StatusOK}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(20*time.Millisecond, tt.checks...)
+			if tt.draining {
+				c.SetDraining()
+			}
+
+			code, report := readiness(t, c)
+			if code != tt.wantCode {
+				t.Errorf("code = %d, want %d", code, tt.wantCode)
+			}
+			if report.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", report.Status, tt.wantStatus)
+			}
+			for name, want := range tt.wantChecks {
+				if got := report.Checks[name].Status; got != want {
+					t.Errorf("check %s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLivenessIgnoresDraining(t *testing.T) {
+	c := New(time.Second)
+	c.SetDraining()
+
+	rec := httptest.NewRecorder()
+	c.Liveness(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("code = %d, want 200", rec.Code)
+	}
+}
+
+func TestDrainFailsReadinessBeforeReturning(t *testing.T) {
+	c := New(time.Second)
+	srv := httptest.NewServer(http.HandlerFunc(c.Readiness))
+	defer srv.Close()
+
+	drained := make(chan struct{})
+	go func() {
+		c.Drain(context.Background(), 200*time.Millisecond)
+		close(drained)
+	}()
+
+	// The server keeps serving the probe for the whole delay
+	for !c.Draining() {
+		time.Sleep(time.Millisecond)
+	}
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("code = %d, want 503 while draining", resp.StatusCode)
+	}
+	select {
+	case <-drained:
+		t.Error("Drain returned before the delay")
+	default:
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	New(time.Second).Drain(ctx, time.Hour)
+	if time.Since(start) > time.Second {
+		t.Error("Drain did not stop when ctx was done")
+	}
+	<-drained
+}
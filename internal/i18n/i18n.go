@@ -0,0 +1,76 @@
+// Package i18n picks the language of the messages shown to people and holds
+// their translations
+package i18n
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+)
+
+// Locale is a BCP 47 tag of a supported language
+type Locale string
+
+const (
+	English             Locale = "en"
+	BrazilianPortuguese Locale = "pt-BR"
+)
+
+// Default is the locale of clients that accept none of the supported ones
+const Default = English
+
+// Locales are the supported locales, Default first
+var Locales = []Locale{English, BrazilianPortuguese}
+
+var matcher = language.NewMatcher([]language.Tag{language.English, language.BrazilianPortuguese})
+
+// Negotiate returns the supported locale that best matches an
+// Accept-Language header, e.g. pt-BR for "pt-PT,pt;q=0.9,en;q=0.8"
+func Negotiate(acceptLanguage string) Locale {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default
+	}
+	return Locales[index]
+}
+
+// Catalog maps message keys to their text in each locale
+type Catalog map[string]map[Locale]string
+
+// Add registers the text of the key in every locale, in the order of
+// Locales. It panics when the key exists or a translation is missing, so
+// that an incomplete catalog fails at startup.
+func (c Catalog) Add(key string, texts ...string) {
+	if _, ok := c[key]; ok {
+		panic(fmt.Sprintf("i18n: %s is defined twice", key))
+	}
+	if len(texts) != len(Locales) {
+		panic(fmt.Sprintf("i18n: %s has %d translations, want %d", key, len(texts), len(Locales)))
+	}
+
+	c[key] = make(map[Locale]string, len(texts))
+	for i, text := range texts {
+		c[key][Locales[i]] = text
+	}
+}
+
+// Sprintf formats the text of the key in the locale, falling back to Default
+// and then to the key itself
+func (c Catalog) Sprintf(l Locale, key string, args ...any) string {
+	texts, ok := c[key]
+	if !ok {
+		return key
+	}
+	text, ok := texts[l]
+	if !ok {
+		text = texts[Default]
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
@@ -0,0 +1,55 @@
+package i18n
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Locale
+	}{
+		{"", English},
+		{"pt-BR", BrazilianPortuguese},
+		{"pt-br,pt;q=0.9", BrazilianPortuguese},
+		{"pt-PT,pt;q=0.9,en;q=0.8", BrazilianPortuguese},
+		{"en-US,en;q=0.9,pt-BR;q=0.8", English},
+		{"en;q=0.5,pt-BR", BrazilianPortuguese},
+		{"de-DE", English},
+		{"*", English},
+		{"not a header;;", English},
+	}
+
+	for _, tt := range tests {
+		if got := Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	c := Catalog{}
+	c.Add("hello", "hello %s", "olá %s")
+
+	if got := c.Sprintf(BrazilianPortuguese, "hello", "Ada"); got != "olá Ada" {
+		t.Errorf("Sprintf(pt-BR) = %q", got)
+	}
+	if got := c.Sprintf("fr", "hello", "Ada"); got != "hello Ada" {
+		t.Errorf("Sprintf(fr) = %q, want the default locale", got)
+	}
+	if got := c.Sprintf(English, "missing"); got != "missing" {
+		t.Errorf("Sprintf(missing) = %q, want the key", got)
+	}
+
+	for name, add := range map[string]func(){
+		"duplicate key":       func() { c.Add("hello", "hi", "oi") },
+		"missing translation": func() { c.Add("bye", "bye") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Add with a %s did not panic", name)
+				}
+			}()
+			add()
+		}()
+	}
+}
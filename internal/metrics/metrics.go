@@ -0,0 +1,135 @@
+// Package metrics holds the Prometheus collectors exposed on the metrics
+// listener. Collectors live in their own registry so that only msgwss series
+// and the Go runtime are exported.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "msgwss"
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, chi route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and chi route pattern. WebSocket upgrades are not observed.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	WSConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "websocket_connections",
+		Help:      "Open WebSocket connections per room.",
+	}, []string{"room_id"})
+
+	WSDisconnects = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "websocket_disconnects_total",
+		Help:      "WebSocket connections closed for any reason.",
+	})
+
+	WSWriteFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "websocket_write_failures_total",
+		Help:      "Broadcast writes that failed and dropped the subscriber.",
+	})
+
+	BroadcastFanout = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "broadcast_fanout_size",
+		Help:      "Subscribers reached by a single broadcast.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+	})
+
+	BroadcastDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "broadcast_duration_seconds",
+		Help:      "Time spent writing a broadcast to every subscriber of a room.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 4, 8),
+	})
+
+	MessagesCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_created_total",
+		Help:      "Messages posted to any room.",
+	})
+
+	ReactionsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reactions_created_total",
+		Help:      "Reactions added to messages.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		WSConnections,
+		WSDisconnects,
+		WSWriteFailures,
+		BroadcastFanout,
+		BroadcastDuration,
+		MessagesCreated,
+		ReactionsCreated,
+	)
+}
+
+// Handler serves the registry in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Middleware counts requests and observes their latency under the chi route
+// pattern, so that path parameters do not explode the label cardinality
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		// The 101 of an upgrade is written on the hijacked connection, so the
+		// wrapper never sees it
+		upgrade := websocket.IsWebSocketUpgrade(r)
+
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+
+		status := ww.Status()
+		switch {
+		case status != 0:
+		case upgrade:
+			status = http.StatusSwitchingProtocols
+		default:
+			status = http.StatusOK
+		}
+
+		HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		// Upgraded connections last as long as the socket
+		if !upgrade {
+			HTTPDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddlewareUsesRoutePattern(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/api/rooms/{room_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	for _, id := range []string{"a", "b", "c"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/rooms/"+id, nil))
+	}
+
+	got := testutil.ToFloat64(HTTPRequests.WithLabelValues(http.MethodGet, "/api/rooms/{room_id}", "418"))
+	if got != 3 {
+		t.Errorf("requests for the route pattern = %v, want 3", got)
+	}
+}
+
+func TestMiddlewareSkipsUpgrades(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/subscribe/{room_id}", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+
+	done := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.ServeHTTP(w, req)
+		done <- struct{}{}
+	}))
+	defer srv.Close()
+
+	series := testutil.CollectAndCount(HTTPDuration)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/subscribe/a", nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	conn.Close()
+	<-done
+
+	if got := testutil.CollectAndCount(HTTPDuration); got != series {
+		t.Errorf("duration series = %d, want the upgrade not observed", got)
+	}
+	if got := testutil.ToFloat64(HTTPRequests.WithLabelValues(http.MethodGet, "/subscribe/{room_id}", "200")); got != 0 {
+		t.Errorf("upgrades counted as 200 = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(HTTPRequests.WithLabelValues(http.MethodGet, "/subscribe/{room_id}", "101")); got != 1 {
+		t.Errorf("upgrades counted as 101 = %v, want 1", got)
+	}
+}
+
+func TestHandlerServesTextFormat(t *testing.T) {
+	MessagesCreated.Inc()
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "msgwss_messages_created_total") {
+		t.Errorf("metrics output is missing msgwss_messages_created_total:\n%s", body)
+	}
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Limit
+		wantErr bool
+	}{
+		{name: "Per minute", input: "10/1m", want: Limit{Rate: 10.0 / 60, Burst: 10}},
+		{name: "Short unit", input: "5/s", want: Limit{Rate: 5, Burst: 5}},
+		{name: "Off", input: "off", want: Limit{}},
+		{name: "Empty", input: "", want: Limit{}},
+		{name: "Missing period", input: "10", wantErr: true},
+		{name: "Bad count", input: "x/1m", wantErr: true},
+		{name: "Bad period", input: "10/forever", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLimit(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLimit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLimit() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryAllow(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	limit := Limit{Rate: 1, Burst: 2}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		res, _ := m.Allow(ctx, "k", limit)
+		if !res.Allowed {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+
+	res, _ := m.Allow(ctx, "k", limit)
+	if res.Allowed {
+		t.Fatal("third request should be limited")
+	}
+	if res.RetryAfter != time.Second {
+		t.Errorf("RetryAfter = %v, want 1s", res.RetryAfter)
+	}
+
+	if res, _ := m.Allow(ctx, "other", limit); !res.Allowed {
+		t.Error("other keys should have their own bucket")
+	}
+
+	now = now.Add(time.Second)
+	if res, _ := m.Allow(ctx, "k", limit); !res.Allowed {
+		t.Error("request should be allowed after refill")
+	}
+}
+
+func TestMemorySweep(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	_, _ = m.Allow(context.Background(), "k", Limit{Rate: 1, Burst: 1})
+
+	now = now.Add(memorySweepInterval)
+	_, _ = m.Allow(context.Background(), "other", Limit{Rate: 1, Burst: 1})
+
+	if _, ok := m.buckets["k"]; ok {
+		t.Error("refilled bucket should have been swept")
+	}
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store/pgstore"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	postgresPruneInterval = 10 * time.Minute
+	postgresPruneAge      = time.Hour
+)
+
+// Postgres is a Limiter that keeps its buckets in the rate_limit_buckets
+// table so that every replica shares the same budget.
+type Postgres struct {
+	q         *pgstore.Queries
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+func NewPostgres(q *pgstore.Queries) *Postgres {
+	return &Postgres{q: q}
+}
+
+func (p *Postgres) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	if limit.Disabled() {
+		return Result{Allowed: true}, nil
+	}
+
+	p.prune(ctx)
+
+	_, err := p.q.TakeRateLimitToken(ctx, pgstore.TakeRateLimitTokenParams{
+		Key:   key,
+		Burst: float64(limit.Burst),
+		Rate:  limit.Rate,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// The bucket holds less than one token, so at most one full
+			// token interval is needed before the next request fits.
+			return Result{RetryAfter: retryAfter(0, limit)}, nil
+		}
+		return Result{}, err
+	}
+
+	return Result{Allowed: true}, nil
+}
+
+// prune removes buckets that have not been touched for a while
+func (p *Postgres) prune(ctx context.Context) {
+	p.mu.Lock()
+	if time.Since(p.lastPrune) < postgresPruneInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.lastPrune = time.Now()
+	p.mu.Unlock()
+
+	cutoff := pgtype.Timestamptz{Time: time.Now().Add(-postgresPruneAge), Valid: true}
+	if err := p.q.DeleteStaleRateLimitBuckets(ctx, cutoff); err != nil {
+		slog.Warn("failed to prune rate limit buckets", "error", err)
+	}
+}
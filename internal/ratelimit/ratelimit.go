@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidLimit = errors.New("invalid rate limit")
+
+// Limit describes a token bucket holding at most Burst tokens that refills
+// at Rate tokens per second. A zero Limit disables limiting.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Disabled reports whether the limit lets every request through
+func (l Limit) Disabled() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed    bool
+	RetryAfter time.Duration
+}
+
+// Limiter takes tokens from the bucket identified by key
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// ParseLimit parses limits written as "<requests>/<period>", e.g. "10/1m" or
+// "5/s". The bucket burst equals the request count. "off" and "0" disable
+// the limit.
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" || strings.EqualFold(s, "off") {
+		return Limit{}, nil
+	}
+
+	rawCount, rawPeriod, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("%w %q: expected <requests>/<period>", ErrInvalidLimit, s)
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(rawCount))
+	if err != nil || count < 0 {
+		return Limit{}, fmt.Errorf("%w %q: bad request count", ErrInvalidLimit, s)
+	}
+
+	rawPeriod = strings.TrimSpace(rawPeriod)
+	// Allow the short forms "s", "m" and "h" for a single unit
+	if rawPeriod != "" && !strings.ContainsAny(rawPeriod[:1], "0123456789") {
+		rawPeriod = "1" + rawPeriod
+	}
+	period, err := time.ParseDuration(rawPeriod)
+	if err != nil || period <= 0 {
+		return Limit{}, fmt.Errorf("%w %q: bad period", ErrInvalidLimit, s)
+	}
+
+	return Limit{Rate: float64(count) / period.Seconds(), Burst: count}, nil
+}
+
+// retryAfter returns how long it takes to refill the bucket from tokens to one token
+func retryAfter(tokens float64, limit Limit) time.Duration {
+	missing := 1 - tokens
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(missing / limit.Rate * float64(time.Second)))
+}
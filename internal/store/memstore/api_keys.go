@@ -0,0 +1,91 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+// copyAPIKey detaches the slices of a key from the stored row
+func copyAPIKey(key *store.ApiKey) store.ApiKey {
+	c := *key
+	c.RoomIds = slices.Clone(key.RoomIds)
+	c.Permissions = slices.Clone(key.Permissions)
+	return c
+}
+
+func (s *Store) GetAPIKeyByHash(_ context.Context, keyHash string) (store.ApiKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.apiKeys {
+		if key.KeyHash == keyHash && key.RevokedAt == nil {
+			return copyAPIKey(key), nil
+		}
+	}
+	return store.ApiKey{}, store.ErrNotFound
+}
+
+func (s *Store) GetAPIKeys(_ context.Context) ([]store.ApiKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []store.ApiKey
+	for _, key := range s.apiKeys {
+		items = append(items, copyAPIKey(key))
+	}
+	slices.SortFunc(items, func(a, b store.ApiKey) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return items, nil
+}
+
+func (s *Store) InsertAPIKey(_ context.Context, arg store.InsertAPIKeyParams) (store.ApiKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.apiKeys {
+		if key.KeyHash == arg.KeyHash {
+			return store.ApiKey{}, fmt.Errorf("memstore: duplicate api key hash")
+		}
+	}
+
+	key := &store.ApiKey{
+		ID:          uuid.New(),
+		Name:        arg.Name,
+		Prefix:      arg.Prefix,
+		KeyHash:     arg.KeyHash,
+		RoomIds:     append([]uuid.UUID{}, arg.RoomIds...),
+		Permissions: append([]string{}, arg.Permissions...),
+		CreatedBy:   arg.CreatedBy,
+		CreatedAt:   s.timestamp(),
+	}
+	s.apiKeys[key.ID] = key
+	return copyAPIKey(key), nil
+}
+
+func (s *Store) RevokeAPIKey(_ context.Context, id uuid.UUID) (store.ApiKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[id]
+	if !ok || key.RevokedAt != nil {
+		return store.ApiKey{}, store.ErrNotFound
+	}
+	key.RevokedAt = s.nullableTimestamp()
+	return copyAPIKey(key), nil
+}
+
+func (s *Store) TouchAPIKey(_ context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.apiKeys[id]; ok {
+		key.LastUsedAt = s.nullableTimestamp()
+	}
+	return nil
+}
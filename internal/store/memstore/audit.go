@@ -0,0 +1,59 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+)
+
+// GetAuditLog applies the same optional filters as the query, newest first
+func (s *Store) GetAuditLog(_ context.Context, arg store.GetAuditLogParams) ([]store.AuditLog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matches := func(e store.AuditLog) bool {
+		switch {
+		case arg.Action != nil && e.Action != *arg.Action,
+			arg.ActorID != nil && e.ActorID != *arg.ActorID,
+			arg.RoomID != nil && (e.RoomID == nil || *e.RoomID != *arg.RoomID),
+			arg.TargetID != nil && e.TargetID != *arg.TargetID,
+			arg.Since != nil && e.CreatedAt.Before(*arg.Since),
+			arg.Until != nil && !e.CreatedAt.Before(*arg.Until),
+			arg.BeforeID != 0 && e.ID >= arg.BeforeID:
+			return false
+		}
+		return true
+	}
+
+	var items []store.AuditLog
+	for i := len(s.auditLog) - 1; i >= 0 && len(items) < int(arg.MaxResults); i-- {
+		if e := s.auditLog[i]; matches(e) {
+			e.Before = bytes.Clone(e.Before)
+			e.After = bytes.Clone(e.After)
+			items = append(items, e)
+		}
+	}
+	return items, nil
+}
+
+// InsertAuditLogEntry appends an entry. Entries are never updated or removed.
+func (s *Store) InsertAuditLogEntry(_ context.Context, arg store.InsertAuditLogEntryParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.auditLog = append(s.auditLog, store.AuditLog{
+		ID:         int64(len(s.auditLog) + 1),
+		Action:     arg.Action,
+		ActorID:    arg.ActorID,
+		Ip:         arg.Ip,
+		RequestID:  arg.RequestID,
+		RoomID:     arg.RoomID,
+		TargetType: arg.TargetType,
+		TargetID:   arg.TargetID,
+		Before:     bytes.Clone(arg.Before),
+		After:      bytes.Clone(arg.After),
+		CreatedAt:  s.timestamp(),
+	})
+	return nil
+}
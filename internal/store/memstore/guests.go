@@ -0,0 +1,40 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+)
+
+// ClaimGuestName takes a free name, renews a name the guest already holds or
+// takes over an expired one. A name held by another guest is not found.
+func (s *Store) ClaimGuestName(_ context.Context, arg store.ClaimGuestNameParams) (store.GuestName, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRoom(arg.RoomID); err != nil {
+		return store.GuestName{}, err
+	}
+
+	key := guestNameKey{roomID: arg.RoomID, nameKey: arg.NameKey}
+	if held, ok := s.guestNames[key]; ok && held.GuestID != arg.GuestID && !held.ExpiresAt.Before(s.now()) {
+		return store.GuestName{}, store.ErrNotFound
+	}
+
+	name := store.GuestName(arg)
+	s.guestNames[key] = &name
+	return name, nil
+}
+
+func (s *Store) DeleteExpiredGuestNames(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, name := range s.guestNames {
+		if name.ExpiresAt.Before(now) {
+			delete(s.guestNames, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,368 @@
+// Package memstore implements store.Store in process memory. It mirrors the
+// semantics of the Postgres queries, including not-found errors and foreign
+// keys, so the server can run without a database in development and tests.
+// Nothing survives a restart.
+package memstore
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+var _ store.Store = (*Store)(nil)
+
+// Store keeps every table in maps guarded by a single mutex. Rows are copied
+// in and out so callers never share memory with the store.
+type Store struct {
+	mu  sync.Mutex
+	now func() time.Time
+
+	rooms      map[uuid.UUID]*store.Room
+	roomOrder  []uuid.UUID
+	messages   map[uuid.UUID]*store.Message
+	msgOrder   []uuid.UUID
+	polls      map[uuid.UUID]*store.Poll
+	options    map[uuid.UUID]*pollOption
+	ballots    map[pollVoter]bool
+	votes      map[pollVoter][]uuid.UUID
+	sanctions  map[uuid.UUID]*store.RoomSanction
+	apiKeys    map[uuid.UUID]*store.ApiKey
+	guestNames map[guestNameKey]*store.GuestName
+	users      map[string]*store.User
+	auditLog   []store.AuditLog
+	webhooks   map[uuid.UUID]*store.Webhook
+	deliveries map[uuid.UUID]*store.WebhookDelivery
+}
+
+// pollOption is a row of poll_options, which the store interface only
+// exposes through the tallies
+type pollOption struct {
+	ID       uuid.UUID
+	PollID   uuid.UUID
+	Label    string
+	Position int32
+}
+
+type pollVoter struct {
+	pollID  uuid.UUID
+	voterID string
+}
+
+type guestNameKey struct {
+	roomID  uuid.UUID
+	nameKey string
+}
+
+func New() *Store {
+	return &Store{
+		now:        time.Now,
+		rooms:      make(map[uuid.UUID]*store.Room),
+		messages:   make(map[uuid.UUID]*store.Message),
+		polls:      make(map[uuid.UUID]*store.Poll),
+		options:    make(map[uuid.UUID]*pollOption),
+		ballots:    make(map[pollVoter]bool),
+		votes:      make(map[pollVoter][]uuid.UUID),
+		sanctions:  make(map[uuid.UUID]*store.RoomSanction),
+		apiKeys:    make(map[uuid.UUID]*store.ApiKey),
+		guestNames: make(map[guestNameKey]*store.GuestName),
+		users:      make(map[string]*store.User),
+		webhooks:   make(map[uuid.UUID]*store.Webhook),
+		deliveries: make(map[uuid.UUID]*store.WebhookDelivery),
+	}
+}
+
+// timestamp returns the current time at the microsecond precision Postgres
+// stores, so keyset pagination sees the same values clients do
+func (s *Store) timestamp() time.Time {
+	return s.now().Truncate(time.Microsecond)
+}
+
+// nullableTimestamp is timestamp for a nullable column
+func (s *Store) nullableTimestamp() *time.Time {
+	t := s.timestamp()
+	return &t
+}
+
+func (s *Store) checkRoom(id uuid.UUID) error {
+	if _, ok := s.rooms[id]; !ok {
+		return fmt.Errorf("memstore: room %s does not exist", id)
+	}
+	return nil
+}
+
+// compareTime orders timestamps the way Postgres does, NULL after every value
+func compareTime(a, b *time.Time) int {
+	switch {
+	case a != nil && b != nil:
+		return a.Compare(*b)
+	case a != nil:
+		return -1
+	case b != nil:
+		return 1
+	}
+	return 0
+}
+
+// active reports whether expires is NULL or still in the future
+func active(expires *time.Time, now time.Time) bool {
+	return expires == nil || expires.After(now)
+}
+
+func (s *Store) GetRoom(_ context.Context, id uuid.UUID) (store.Room, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, ok := s.rooms[id]
+	if !ok {
+		return store.Room{}, store.ErrNotFound
+	}
+	return *room, nil
+}
+
+func (s *Store) GetRooms(_ context.Context) ([]store.Room, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []store.Room
+	for _, id := range s.roomOrder {
+		items = append(items, *s.rooms[id])
+	}
+	return items, nil
+}
+
+func (s *Store) InsertRoom(_ context.Context, arg store.InsertRoomParams) (uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room := &store.Room{
+		ID:              uuid.New(),
+		Theme:           arg.Theme,
+		AllowGuests:     arg.AllowGuests,
+		AnonymousPolicy: arg.AnonymousPolicy,
+	}
+	s.rooms[room.ID] = room
+	s.roomOrder = append(s.roomOrder, room.ID)
+	return room.ID, nil
+}
+
+func (s *Store) SetRoomAllowGuests(_ context.Context, arg store.SetRoomAllowGuestsParams) (store.Room, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, ok := s.rooms[arg.ID]
+	if !ok {
+		return store.Room{}, store.ErrNotFound
+	}
+	room.AllowGuests = arg.AllowGuests
+	return *room, nil
+}
+
+func (s *Store) SetRoomAnonymousPolicy(_ context.Context, arg store.SetRoomAnonymousPolicyParams) (store.Room, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, ok := s.rooms[arg.ID]
+	if !ok {
+		return store.Room{}, store.ErrNotFound
+	}
+	room.AnonymousPolicy = arg.AnonymousPolicy
+	return *room, nil
+}
+
+func (s *Store) GetMessage(_ context.Context, id uuid.UUID) (store.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.messages[id]
+	if !ok {
+		return store.Message{}, store.ErrNotFound
+	}
+	return *m, nil
+}
+
+// roomMessages returns the messages of a room in insertion order
+func (s *Store) roomMessages(roomID uuid.UUID, keep func(m *store.Message) bool) []store.Message {
+	var items []store.Message
+	for _, id := range s.msgOrder {
+		if m := s.messages[id]; m.RoomID == roomID && keep(m) {
+			items = append(items, *m)
+		}
+	}
+	return items
+}
+
+func (s *Store) GetRoomHotMessages(_ context.Context, arg store.GetRoomHotMessagesParams) ([]store.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := s.roomMessages(arg.RoomID, func(m *store.Message) bool {
+		return !m.Hidden && (arg.IncludeAnswered || !m.Answered)
+	})
+
+	now := s.now()
+	score := func(m store.Message) float64 {
+		hours := now.Sub(m.CreatedAt).Hours()
+		return float64(m.ReactionCount+1) / math.Pow(hours+2, arg.Gravity)
+	}
+	slices.SortStableFunc(items, func(a, b store.Message) int {
+		if c := cmp.Compare(score(b), score(a)); c != 0 {
+			return c
+		}
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	if arg.MaxResults > 0 && len(items) > int(arg.MaxResults) {
+		items = items[:arg.MaxResults]
+	}
+	return items, nil
+}
+
+func (s *Store) GetRoomMessages(_ context.Context, arg store.GetRoomMessagesParams) ([]store.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.roomMessages(arg.RoomID, func(m *store.Message) bool { return arg.IncludeHidden || !m.Hidden }), nil
+}
+
+func (s *Store) GetRoomMessagesPage(_ context.Context, arg store.GetRoomMessagesPageParams) ([]store.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := s.roomMessages(arg.RoomID, func(m *store.Message) bool {
+		if m.Hidden && !arg.IncludeHidden {
+			return false
+		}
+		if c := m.CreatedAt.Compare(arg.AfterCreatedAt); c != 0 {
+			return c > 0
+		}
+		return bytes.Compare(m.ID[:], arg.AfterID[:]) > 0
+	})
+	slices.SortFunc(items, func(a, b store.Message) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+
+	return limit(items, arg.PageSize), nil
+}
+
+func (s *Store) GetUserMessages(_ context.Context, arg store.GetUserMessagesParams) ([]store.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []store.Message
+	for _, m := range s.messages {
+		if m.UserID != nil && *m.UserID == arg.UserID && (arg.IncludeAnonymous || !m.Anonymous) && !m.Hidden {
+			items = append(items, *m)
+		}
+	}
+	slices.SortFunc(items, func(a, b store.Message) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return bytes.Compare(b.ID[:], a.ID[:])
+	})
+
+	return limit(items, arg.MaxResults), nil
+}
+
+func (s *Store) InsertMessage(_ context.Context, arg store.InsertMessageParams) (store.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRoom(arg.RoomID); err != nil {
+		return store.Message{}, err
+	}
+	if arg.UserID != nil {
+		if _, ok := s.users[*arg.UserID]; !ok {
+			return store.Message{}, fmt.Errorf("memstore: user %s does not exist", *arg.UserID)
+		}
+	}
+
+	m := &store.Message{
+		ID:         uuid.New(),
+		RoomID:     arg.RoomID,
+		Message:    arg.Message,
+		AuthorID:   arg.AuthorID,
+		AuthorName: arg.AuthorName,
+		CreatedAt:  s.timestamp(),
+		IsBot:      arg.IsBot,
+		Anonymous:  arg.Anonymous,
+		UserID:     arg.UserID,
+	}
+	s.messages[m.ID] = m
+	s.msgOrder = append(s.msgOrder, m.ID)
+	return *m, nil
+}
+
+func (s *Store) MarkMessageAsAnswered(_ context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if m, ok := s.messages[id]; ok {
+		m.Answered = true
+	}
+	return nil
+}
+
+func (s *Store) SetMessageHidden(_ context.Context, arg store.SetMessageHiddenParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if m, ok := s.messages[arg.ID]; ok {
+		m.Hidden = arg.Hidden
+	}
+	return nil
+}
+
+func (s *Store) ReactToMessage(_ context.Context, id uuid.UUID) (store.ReactToMessageRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.messages[id]
+	if !ok {
+		return store.ReactToMessageRow{}, store.ErrNotFound
+	}
+	m.ReactionCount++
+	return store.ReactToMessageRow{PreviousCount: m.ReactionCount - 1, ReactionCount: m.ReactionCount}, nil
+}
+
+func (s *Store) RemoveReactionFromMessage(_ context.Context, id uuid.UUID) (store.RemoveReactionFromMessageRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.messages[id]
+	if !ok {
+		return store.RemoveReactionFromMessageRow{}, store.ErrNotFound
+	}
+	previous := m.ReactionCount
+	m.ReactionCount = max(0, m.ReactionCount-1)
+	return store.RemoveReactionFromMessageRow{PreviousCount: previous, ReactionCount: m.ReactionCount}, nil
+}
+
+// limit applies a SQL LIMIT
+func limit[T any](items []T, n int32) []T {
+	if len(items) > int(n) {
+		items = items[:max(n, 0)]
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return items
+}
+
+// sameText is the negation of IS DISTINCT FROM for nullable text
+func sameText(a, b *string) bool {
+	return (a == nil) == (b == nil) && (a == nil || *a == *b)
+}
@@ -0,0 +1,169 @@
+package memstore
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) CastPollVote(_ context.Context, arg store.CastPollVoteParams) ([]uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	poll, ok := s.polls[arg.PollID]
+	if !ok || poll.Status != "open" {
+		return nil, nil
+	}
+	ballot := pollVoter{pollID: poll.ID, voterID: arg.VoterID}
+	if s.ballots[ballot] {
+		return nil, nil
+	}
+	s.ballots[ballot] = true
+
+	var items []uuid.UUID
+	for _, o := range s.pollOptions(poll.ID) {
+		if slices.Contains(arg.OptionIds, o.ID) {
+			items = append(items, o.ID)
+		}
+	}
+	s.votes[ballot] = items
+	return items, nil
+}
+
+func (s *Store) ClosePoll(_ context.Context, arg store.ClosePollParams) (store.Poll, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	poll, ok := s.polls[arg.ID]
+	if !ok || poll.RoomID != arg.RoomID || poll.Status != "open" {
+		return store.Poll{}, store.ErrNotFound
+	}
+	poll.Status = "closed"
+	poll.ClosedAt = s.nullableTimestamp()
+	return *poll, nil
+}
+
+func (s *Store) GetPoll(_ context.Context, id uuid.UUID) (store.Poll, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	poll, ok := s.polls[id]
+	if !ok {
+		return store.Poll{}, store.ErrNotFound
+	}
+	return *poll, nil
+}
+
+// pollOptions returns the options of a poll by position
+func (s *Store) pollOptions(pollID uuid.UUID) []pollOption {
+	var items []pollOption
+	for _, o := range s.options {
+		if o.PollID == pollID {
+			items = append(items, *o)
+		}
+	}
+	slices.SortFunc(items, func(a, b pollOption) int {
+		return cmp.Compare(a.Position, b.Position)
+	})
+	return items
+}
+
+// tallies counts the votes of every option of a poll
+func (s *Store) tallies(pollID uuid.UUID) []store.GetPollTalliesRow {
+	counts := make(map[uuid.UUID]int64)
+	for ballot, optionIDs := range s.votes {
+		if ballot.pollID == pollID {
+			for _, id := range optionIDs {
+				counts[id]++
+			}
+		}
+	}
+
+	var items []store.GetPollTalliesRow
+	for _, o := range s.pollOptions(pollID) {
+		items = append(items, store.GetPollTalliesRow{
+			ID:       o.ID,
+			PollID:   o.PollID,
+			Label:    o.Label,
+			Position: o.Position,
+			Votes:    counts[o.ID],
+		})
+	}
+	return items
+}
+
+func (s *Store) GetPollTallies(_ context.Context, pollID uuid.UUID) ([]store.GetPollTalliesRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.tallies(pollID), nil
+}
+
+func (s *Store) GetRoomPollTallies(_ context.Context, roomID uuid.UUID) ([]store.GetRoomPollTalliesRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pollIDs []uuid.UUID
+	for _, poll := range s.polls {
+		if poll.RoomID == roomID {
+			pollIDs = append(pollIDs, poll.ID)
+		}
+	}
+	slices.SortFunc(pollIDs, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+
+	var items []store.GetRoomPollTalliesRow
+	for _, id := range pollIDs {
+		for _, t := range s.tallies(id) {
+			items = append(items, store.GetRoomPollTalliesRow(t))
+		}
+	}
+	return items, nil
+}
+
+func (s *Store) GetRoomPolls(_ context.Context, roomID uuid.UUID) ([]store.Poll, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []store.Poll
+	for _, poll := range s.polls {
+		if poll.RoomID == roomID {
+			items = append(items, *poll)
+		}
+	}
+	slices.SortFunc(items, func(a, b store.Poll) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return items, nil
+}
+
+func (s *Store) InsertPoll(_ context.Context, arg store.InsertPollParams) (uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRoom(arg.RoomID); err != nil {
+		return uuid.Nil, err
+	}
+
+	poll := &store.Poll{
+		ID:             uuid.New(),
+		RoomID:         arg.RoomID,
+		Question:       arg.Question,
+		MultipleChoice: arg.MultipleChoice,
+		Status:         "open",
+		CreatedBy:      arg.CreatedBy,
+		CreatedAt:      s.timestamp(),
+	}
+	s.polls[poll.ID] = poll
+
+	// Positions start at 1 like WITH ORDINALITY
+	for i, label := range arg.Options {
+		o := &pollOption{ID: uuid.New(), PollID: poll.ID, Label: label, Position: int32(i + 1)}
+		s.options[o.ID] = o
+	}
+	return poll.ID, nil
+}
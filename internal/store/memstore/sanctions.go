@@ -0,0 +1,104 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) FindActiveRoomSanction(_ context.Context, arg store.FindActiveRoomSanctionParams) (store.RoomSanction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var items []store.RoomSanction
+	for _, sanction := range s.sanctions {
+		if sanction.RoomID != arg.RoomID || !slices.Contains(arg.Kinds, sanction.Kind) {
+			continue
+		}
+		if sanction.RevokedAt != nil || !active(sanction.ExpiresAt, now) {
+			continue
+		}
+		// NULL never equals anything, on either side
+		byAuthor := arg.AuthorID != nil && sanction.AuthorID != nil && *sanction.AuthorID == *arg.AuthorID
+		byIP := arg.Ip != nil && sanction.Ip != nil && *sanction.Ip == *arg.Ip
+		if byAuthor || byIP {
+			items = append(items, *sanction)
+		}
+	}
+	if len(items) == 0 {
+		return store.RoomSanction{}, store.ErrNotFound
+	}
+
+	// kind ASC, expires_at DESC NULLS FIRST
+	slices.SortFunc(items, func(a, b store.RoomSanction) int {
+		if c := strings.Compare(a.Kind, b.Kind); c != 0 {
+			return c
+		}
+		return compareTime(b.ExpiresAt, a.ExpiresAt)
+	})
+	return items[0], nil
+}
+
+func (s *Store) GetActiveRoomSanctions(_ context.Context, arg store.GetActiveRoomSanctionsParams) ([]store.RoomSanction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var items []store.RoomSanction
+	for _, sanction := range s.sanctions {
+		if sanction.RoomID == arg.RoomID && sanction.Kind == arg.Kind && sanction.RevokedAt == nil && active(sanction.ExpiresAt, now) {
+			items = append(items, *sanction)
+		}
+	}
+	slices.SortFunc(items, func(a, b store.RoomSanction) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return items, nil
+}
+
+func (s *Store) InsertRoomSanction(_ context.Context, arg store.InsertRoomSanctionParams) (store.RoomSanction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRoom(arg.RoomID); err != nil {
+		return store.RoomSanction{}, err
+	}
+	if arg.Kind != "ban" && arg.Kind != "mute" {
+		return store.RoomSanction{}, fmt.Errorf("memstore: invalid sanction kind %q", arg.Kind)
+	}
+	if arg.AuthorID == nil && arg.Ip == nil {
+		return store.RoomSanction{}, fmt.Errorf("memstore: sanction needs an author or an ip")
+	}
+
+	sanction := &store.RoomSanction{
+		ID:        uuid.New(),
+		RoomID:    arg.RoomID,
+		Kind:      arg.Kind,
+		AuthorID:  arg.AuthorID,
+		Ip:        arg.Ip,
+		Reason:    arg.Reason,
+		CreatedBy: arg.CreatedBy,
+		CreatedAt: s.timestamp(),
+		ExpiresAt: arg.ExpiresAt,
+	}
+	s.sanctions[sanction.ID] = sanction
+	return *sanction, nil
+}
+
+func (s *Store) RevokeRoomSanction(_ context.Context, arg store.RevokeRoomSanctionParams) (store.RoomSanction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sanction, ok := s.sanctions[arg.ID]
+	if !ok || sanction.RoomID != arg.RoomID || sanction.Kind != arg.Kind || sanction.RevokedAt != nil {
+		return store.RoomSanction{}, store.ErrNotFound
+	}
+	sanction.RevokedAt = s.nullableTimestamp()
+	return *sanction, nil
+}
@@ -0,0 +1,59 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+)
+
+func (s *Store) GetUser(_ context.Context, id string) (store.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return store.User{}, store.ErrNotFound
+	}
+	return *user, nil
+}
+
+func (s *Store) GetUsersByIDs(_ context.Context, ids []string) ([]store.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var items []store.User
+	for _, id := range ids {
+		if user, ok := s.users[id]; ok && !seen[id] {
+			seen[id] = true
+			items = append(items, *user)
+		}
+	}
+	return items, nil
+}
+
+// UpsertUser only bumps updated_at when the profile changed
+func (s *Store) UpsertUser(_ context.Context, arg store.UpsertUserParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.timestamp()
+	user, ok := s.users[arg.ID]
+	if !ok {
+		s.users[arg.ID] = &store.User{
+			ID:        arg.ID,
+			Name:      arg.Name,
+			AvatarUrl: arg.AvatarUrl,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		return nil
+	}
+
+	if user.Name != arg.Name || !sameText(user.AvatarUrl, arg.AvatarUrl) {
+		user.Name = arg.Name
+		user.AvatarUrl = arg.AvatarUrl
+		user.UpdatedAt = now
+	}
+	return nil
+}
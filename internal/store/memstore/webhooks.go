@@ -0,0 +1,213 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+func copyWebhook(w *store.Webhook) store.Webhook {
+	c := *w
+	c.EventKinds = slices.Clone(w.EventKinds)
+	return c
+}
+
+func copyDelivery(d *store.WebhookDelivery) store.WebhookDelivery {
+	c := *d
+	c.Payload = bytes.Clone(d.Payload)
+	return c
+}
+
+// ClaimWebhookDeliveries leases the due pending deliveries by pushing their
+// next attempt past the lease
+func (s *Store) ClaimWebhookDeliveries(_ context.Context, arg store.ClaimWebhookDeliveriesParams) ([]store.ClaimWebhookDeliveriesRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var due []*store.WebhookDelivery
+	for _, d := range s.deliveries {
+		if d.Status == "pending" && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+	slices.SortFunc(due, func(a, b *store.WebhookDelivery) int {
+		return a.NextAttemptAt.Compare(b.NextAttemptAt)
+	})
+	due = limit(due, arg.BatchSize)
+
+	lease := s.timestamp().Add(time.Duration(arg.LeaseSeconds) * time.Second)
+
+	var items []store.ClaimWebhookDeliveriesRow
+	for _, d := range due {
+		d.NextAttemptAt = lease
+		w := s.webhooks[d.WebhookID]
+		items = append(items, store.ClaimWebhookDeliveriesRow{
+			ID:        d.ID,
+			WebhookID: d.WebhookID,
+			EventKind: d.EventKind,
+			RoomID:    d.RoomID,
+			Payload:   bytes.Clone(d.Payload),
+			Attempts:  d.Attempts,
+			Url:       w.Url,
+			Secret:    w.Secret,
+		})
+	}
+	return items, nil
+}
+
+// DeleteWebhook removes a webhook with its deliveries
+func (s *Store) DeleteWebhook(_ context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.webhooks[id]; !ok {
+		return 0, nil
+	}
+	delete(s.webhooks, id)
+	for deliveryID, d := range s.deliveries {
+		if d.WebhookID == id {
+			delete(s.deliveries, deliveryID)
+		}
+	}
+	return 1, nil
+}
+
+// EnqueueWebhookDeliveries queues a delivery for every webhook subscribed to
+// the room and event kind
+func (s *Store) EnqueueWebhookDeliveries(_ context.Context, arg store.EnqueueWebhookDeliveriesParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.timestamp()
+	var n int64
+	for _, w := range s.webhooks {
+		if w.RoomID != nil && *w.RoomID != arg.RoomID {
+			continue
+		}
+		if len(w.EventKinds) > 0 && !slices.Contains(w.EventKinds, arg.EventKind) {
+			continue
+		}
+
+		d := &store.WebhookDelivery{
+			ID:            uuid.New(),
+			WebhookID:     w.ID,
+			EventKind:     arg.EventKind,
+			RoomID:        arg.RoomID,
+			Payload:       bytes.Clone(arg.Payload),
+			Status:        "pending",
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		}
+		s.deliveries[d.ID] = d
+		n++
+	}
+	return n, nil
+}
+
+func (s *Store) GetWebhookDeliveries(_ context.Context, arg store.GetWebhookDeliveriesParams) ([]store.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []store.WebhookDelivery
+	for _, d := range s.deliveries {
+		if d.Status == arg.Status {
+			items = append(items, copyDelivery(d))
+		}
+	}
+	slices.SortFunc(items, func(a, b store.WebhookDelivery) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return limit(items, arg.Limit), nil
+}
+
+func (s *Store) GetWebhooks(_ context.Context) ([]store.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []store.Webhook
+	for _, w := range s.webhooks {
+		items = append(items, copyWebhook(w))
+	}
+	slices.SortFunc(items, func(a, b store.Webhook) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return items, nil
+}
+
+func (s *Store) InsertWebhook(_ context.Context, arg store.InsertWebhookParams) (store.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if arg.RoomID != nil {
+		if err := s.checkRoom(*arg.RoomID); err != nil {
+			return store.Webhook{}, err
+		}
+	}
+
+	w := &store.Webhook{
+		ID:         uuid.New(),
+		RoomID:     arg.RoomID,
+		Url:        arg.Url,
+		Secret:     arg.Secret,
+		EventKinds: append([]string{}, arg.EventKinds...),
+		CreatedBy:  arg.CreatedBy,
+		CreatedAt:  s.timestamp(),
+	}
+	s.webhooks[w.ID] = w
+	return copyWebhook(w), nil
+}
+
+func (s *Store) MarkWebhookDeliveryFailed(_ context.Context, arg store.MarkWebhookDeliveryFailedParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deliveries[arg.ID]
+	if !ok {
+		return nil
+	}
+	d.Status = "pending"
+	if arg.Dead {
+		d.Status = "dead"
+	}
+	d.Attempts++
+	d.LastStatusCode = arg.LastStatusCode
+	d.LastError = arg.LastError
+	d.NextAttemptAt = arg.NextAttemptAt
+	return nil
+}
+
+func (s *Store) MarkWebhookDeliverySucceeded(_ context.Context, arg store.MarkWebhookDeliverySucceededParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deliveries[arg.ID]
+	if !ok {
+		return nil
+	}
+	d.Status = "delivered"
+	d.Attempts++
+	d.LastStatusCode = arg.LastStatusCode
+	d.LastError = ""
+	d.DeliveredAt = s.nullableTimestamp()
+	return nil
+}
+
+func (s *Store) RedeliverWebhookDelivery(_ context.Context, id uuid.UUID) (store.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deliveries[id]
+	if !ok {
+		return store.WebhookDelivery{}, store.ErrNotFound
+	}
+	d.Status = "pending"
+	d.Attempts = 0
+	d.NextAttemptAt = s.timestamp()
+	return copyDelivery(d), nil
+}
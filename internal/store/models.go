@@ -0,0 +1,353 @@
+package store
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The models mirror the tables of the schema. Nullable columns are pointers,
+// nil being NULL, so they encode to JSON null like the columns they hold.
+
+type Room struct {
+	ID              uuid.UUID `json:"id"`
+	Theme           string    `json:"theme"`
+	AllowGuests     bool      `json:"allow_guests"`
+	AnonymousPolicy string    `json:"anonymous_policy"`
+}
+
+type Message struct {
+	ID            uuid.UUID `json:"id"`
+	RoomID        uuid.UUID `json:"room_id"`
+	Message       string    `json:"message"`
+	ReactionCount int64     `json:"reaction_count"`
+	Answered      bool      `json:"answered"`
+	AuthorID      string    `json:"author_id"`
+	AuthorName    string    `json:"author_name"`
+	CreatedAt     time.Time `json:"created_at"`
+	Hidden        bool      `json:"hidden"`
+	IsBot         bool      `json:"is_bot"`
+	Anonymous     bool      `json:"anonymous"`
+	UserID        *string   `json:"user_id"`
+}
+
+type Poll struct {
+	ID             uuid.UUID  `json:"id"`
+	RoomID         uuid.UUID  `json:"room_id"`
+	Question       string     `json:"question"`
+	MultipleChoice bool       `json:"multiple_choice"`
+	Status         string     `json:"status"`
+	CreatedBy      string     `json:"created_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ClosedAt       *time.Time `json:"closed_at"`
+}
+
+type RoomSanction struct {
+	ID        uuid.UUID  `json:"id"`
+	RoomID    uuid.UUID  `json:"room_id"`
+	Kind      string     `json:"kind"`
+	AuthorID  *string    `json:"author_id"`
+	Ip        *string    `json:"ip"`
+	Reason    string     `json:"reason"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+type ApiKey struct {
+	ID          uuid.UUID   `json:"id"`
+	Name        string      `json:"name"`
+	Prefix      string      `json:"prefix"`
+	KeyHash     string      `json:"key_hash"`
+	RoomIds     []uuid.UUID `json:"room_ids"`
+	Permissions []string    `json:"permissions"`
+	CreatedBy   string      `json:"created_by"`
+	CreatedAt   time.Time   `json:"created_at"`
+	LastUsedAt  *time.Time  `json:"last_used_at"`
+	RevokedAt   *time.Time  `json:"revoked_at"`
+}
+
+type GuestName struct {
+	RoomID    uuid.UUID `json:"room_id"`
+	NameKey   string    `json:"name_key"`
+	Name      string    `json:"name"`
+	GuestID   string    `json:"guest_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type User struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	AvatarUrl *string   `json:"avatar_url"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type AuditLog struct {
+	ID         int64      `json:"id"`
+	Action     string     `json:"action"`
+	ActorID    string     `json:"actor_id"`
+	Ip         string     `json:"ip"`
+	RequestID  string     `json:"request_id"`
+	RoomID     *uuid.UUID `json:"room_id"`
+	TargetType string     `json:"target_type"`
+	TargetID   string     `json:"target_id"`
+	Before     []byte     `json:"before"`
+	After      []byte     `json:"after"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type Webhook struct {
+	ID         uuid.UUID  `json:"id"`
+	RoomID     *uuid.UUID `json:"room_id"`
+	Url        string     `json:"url"`
+	Secret     string     `json:"secret"`
+	EventKinds []string   `json:"event_kinds"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID  `json:"id"`
+	WebhookID      uuid.UUID  `json:"webhook_id"`
+	EventKind      string     `json:"event_kind"`
+	RoomID         uuid.UUID  `json:"room_id"`
+	Payload        []byte     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int32      `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastStatusCode int32      `json:"last_status_code"`
+	LastError      string     `json:"last_error"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+type InsertRoomParams struct {
+	Theme           string `json:"theme"`
+	AllowGuests     bool   `json:"allow_guests"`
+	AnonymousPolicy string `json:"anonymous_policy"`
+}
+
+type SetRoomAllowGuestsParams struct {
+	ID          uuid.UUID `json:"id"`
+	AllowGuests bool      `json:"allow_guests"`
+}
+
+type SetRoomAnonymousPolicyParams struct {
+	ID              uuid.UUID `json:"id"`
+	AnonymousPolicy string    `json:"anonymous_policy"`
+}
+
+type GetRoomHotMessagesParams struct {
+	RoomID          uuid.UUID `json:"room_id"`
+	IncludeAnswered bool      `json:"include_answered"`
+	Gravity         float64   `json:"gravity"`
+	MaxResults      int32     `json:"max_results"`
+}
+
+type GetRoomMessagesParams struct {
+	RoomID        uuid.UUID `json:"room_id"`
+	IncludeHidden bool      `json:"include_hidden"`
+}
+
+// GetRoomMessagesPageParams pages through a room oldest first. The page
+// starts after the message at AfterCreatedAt and AfterID; the zero time
+// starts from the beginning.
+type GetRoomMessagesPageParams struct {
+	RoomID         uuid.UUID `json:"room_id"`
+	IncludeHidden  bool      `json:"include_hidden"`
+	AfterCreatedAt time.Time `json:"after_created_at"`
+	AfterID        uuid.UUID `json:"after_id"`
+	PageSize       int32     `json:"page_size"`
+}
+
+type GetUserMessagesParams struct {
+	UserID           string `json:"user_id"`
+	IncludeAnonymous bool   `json:"include_anonymous"`
+	MaxResults       int32  `json:"max_results"`
+}
+
+type InsertMessageParams struct {
+	RoomID     uuid.UUID `json:"room_id"`
+	Message    string    `json:"message"`
+	AuthorID   string    `json:"author_id"`
+	AuthorName string    `json:"author_name"`
+	IsBot      bool      `json:"is_bot"`
+	Anonymous  bool      `json:"anonymous"`
+	UserID     *string   `json:"user_id"`
+}
+
+type SetMessageHiddenParams struct {
+	ID     uuid.UUID `json:"id"`
+	Hidden bool      `json:"hidden"`
+}
+
+type ReactToMessageRow struct {
+	PreviousCount int64 `json:"previous_count"`
+	ReactionCount int64 `json:"reaction_count"`
+}
+
+type RemoveReactionFromMessageRow struct {
+	PreviousCount int64 `json:"previous_count"`
+	ReactionCount int64 `json:"reaction_count"`
+}
+
+type CastPollVoteParams struct {
+	VoterID   string      `json:"voter_id"`
+	PollID    uuid.UUID   `json:"poll_id"`
+	OptionIds []uuid.UUID `json:"option_ids"`
+}
+
+type ClosePollParams struct {
+	ID     uuid.UUID `json:"id"`
+	RoomID uuid.UUID `json:"room_id"`
+}
+
+type GetPollTalliesRow struct {
+	ID       uuid.UUID `json:"id"`
+	PollID   uuid.UUID `json:"poll_id"`
+	Label    string    `json:"label"`
+	Position int32     `json:"position"`
+	Votes    int64     `json:"votes"`
+}
+
+type GetRoomPollTalliesRow struct {
+	ID       uuid.UUID `json:"id"`
+	PollID   uuid.UUID `json:"poll_id"`
+	Label    string    `json:"label"`
+	Position int32     `json:"position"`
+	Votes    int64     `json:"votes"`
+}
+
+type InsertPollParams struct {
+	RoomID         uuid.UUID `json:"room_id"`
+	Question       string    `json:"question"`
+	MultipleChoice bool      `json:"multiple_choice"`
+	CreatedBy      string    `json:"created_by"`
+	Options        []string  `json:"options"`
+}
+
+type FindActiveRoomSanctionParams struct {
+	RoomID   uuid.UUID `json:"room_id"`
+	Kinds    []string  `json:"kinds"`
+	AuthorID *string   `json:"author_id"`
+	Ip       *string   `json:"ip"`
+}
+
+type GetActiveRoomSanctionsParams struct {
+	RoomID uuid.UUID `json:"room_id"`
+	Kind   string    `json:"kind"`
+}
+
+type InsertRoomSanctionParams struct {
+	RoomID    uuid.UUID  `json:"room_id"`
+	Kind      string     `json:"kind"`
+	AuthorID  *string    `json:"author_id"`
+	Ip        *string    `json:"ip"`
+	Reason    string     `json:"reason"`
+	CreatedBy string     `json:"created_by"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type RevokeRoomSanctionParams struct {
+	ID     uuid.UUID `json:"id"`
+	RoomID uuid.UUID `json:"room_id"`
+	Kind   string    `json:"kind"`
+}
+
+type InsertAPIKeyParams struct {
+	Name        string      `json:"name"`
+	Prefix      string      `json:"prefix"`
+	KeyHash     string      `json:"key_hash"`
+	RoomIds     []uuid.UUID `json:"room_ids"`
+	Permissions []string    `json:"permissions"`
+	CreatedBy   string      `json:"created_by"`
+}
+
+type ClaimGuestNameParams struct {
+	RoomID    uuid.UUID `json:"room_id"`
+	NameKey   string    `json:"name_key"`
+	Name      string    `json:"name"`
+	GuestID   string    `json:"guest_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type UpsertUserParams struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	AvatarUrl *string `json:"avatar_url"`
+}
+
+// GetAuditLogParams filters the audit log newest first. Nil filters match
+// every entry, and a BeforeID of 0 starts from the newest.
+type GetAuditLogParams struct {
+	Action     *string    `json:"action"`
+	ActorID    *string    `json:"actor_id"`
+	RoomID     *uuid.UUID `json:"room_id"`
+	TargetID   *string    `json:"target_id"`
+	Since      *time.Time `json:"since"`
+	Until      *time.Time `json:"until"`
+	BeforeID   int64      `json:"before_id"`
+	MaxResults int32      `json:"max_results"`
+}
+
+type InsertAuditLogEntryParams struct {
+	Action     string     `json:"action"`
+	ActorID    string     `json:"actor_id"`
+	Ip         string     `json:"ip"`
+	RequestID  string     `json:"request_id"`
+	RoomID     *uuid.UUID `json:"room_id"`
+	TargetType string     `json:"target_type"`
+	TargetID   string     `json:"target_id"`
+	Before     []byte     `json:"before"`
+	After      []byte     `json:"after"`
+}
+
+type ClaimWebhookDeliveriesParams struct {
+	LeaseSeconds int32 `json:"lease_seconds"`
+	BatchSize    int32 `json:"batch_size"`
+}
+
+type ClaimWebhookDeliveriesRow struct {
+	ID        uuid.UUID `json:"id"`
+	WebhookID uuid.UUID `json:"webhook_id"`
+	EventKind string    `json:"event_kind"`
+	RoomID    uuid.UUID `json:"room_id"`
+	Payload   []byte    `json:"payload"`
+	Attempts  int32     `json:"attempts"`
+	Url       string    `json:"url"`
+	Secret    string    `json:"secret"`
+}
+
+type EnqueueWebhookDeliveriesParams struct {
+	EventKind string    `json:"event_kind"`
+	RoomID    uuid.UUID `json:"room_id"`
+	Payload   []byte    `json:"payload"`
+}
+
+type GetWebhookDeliveriesParams struct {
+	Status string `json:"status"`
+	Limit  int32  `json:"limit"`
+}
+
+type InsertWebhookParams struct {
+	RoomID     *uuid.UUID `json:"room_id"`
+	Url        string     `json:"url"`
+	Secret     string     `json:"secret"`
+	EventKinds []string   `json:"event_kinds"`
+	CreatedBy  string     `json:"created_by"`
+}
+
+type MarkWebhookDeliveryFailedParams struct {
+	Dead           bool      `json:"dead"`
+	LastStatusCode int32     `json:"last_status_code"`
+	LastError      string    `json:"last_error"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	ID             uuid.UUID `json:"id"`
+}
+
+type MarkWebhookDeliverySucceededParams struct {
+	ID             uuid.UUID `json:"id"`
+	LastStatusCode int32     `json:"last_status_code"`
+}
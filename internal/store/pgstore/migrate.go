@@ -0,0 +1,101 @@
+package pgstore
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	pgxmigrate "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// schemaVersion is the version of the newest embedded migration
+var schemaVersion = latestMigration()
+
+// SchemaVersion is the schema version this build expects
+func SchemaVersion() uint {
+	return schemaVersion
+}
+
+// NewMigrator returns a golang-migrate instance reading the embedded
+// migrations. databaseURL is a postgres:// URL or a keyword/value DSN, as
+// accepted by pgx. Close it when done; that also closes its connection.
+func NewMigrator(databaseURL string) (*migrate.Migrate, error) {
+	// golang-migrate only parses URLs, so hand it a connection pgx opened
+	cfg, err := pgx.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, errors.New("pgstore: migrations need a valid postgres connection string")
+	}
+	db := stdlib.OpenDB(*cfg)
+
+	driver, err := pgxmigrate.WithInstance(db, &pgxmigrate.Config{})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("pgstore: open migrator: %w", err)
+	}
+
+	source, err := iofs.New(migrationsFS, "migrations")
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("pgstore: read embedded migrations: %w", err)
+	}
+	m, err := migrate.NewWithInstance("iofs", source, "pgx5", driver)
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("pgstore: open migrator: %w", err)
+	}
+	return m, nil
+}
+
+var ErrSchemaDirty = errors.New("pgstore: a migration failed and left the schema dirty")
+
+// CheckSchemaVersion compares the version recorded by golang-migrate in
+// schema_migrations with the newest embedded migration
+func CheckSchemaVersion(ctx context.Context, db DBTX) error {
+	var (
+		version int64
+		dirty   bool
+	)
+	if err := db.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty); err != nil {
+		return fmt.Errorf("pgstore: read schema version: %w", err)
+	}
+	if dirty {
+		return ErrSchemaDirty
+	}
+	if version != int64(SchemaVersion()) {
+		return fmt.Errorf("pgstore: schema is at version %d, want %d", version, SchemaVersion())
+	}
+	return nil
+}
+
+// latestMigration parses the NNN prefix of the embedded file names
+func latestMigration() uint {
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	if err != nil {
+		panic(err)
+	}
+
+	var latest uint
+	for _, e := range entries {
+		prefix, _, ok := strings.Cut(e.Name(), "_")
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			panic(fmt.Sprintf("pgstore: migration %s has no numeric version", e.Name()))
+		}
+		latest = max(latest, uint(v))
+	}
+	return latest
+}
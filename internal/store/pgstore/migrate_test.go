@@ -0,0 +1,35 @@
+package pgstore
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestEmbeddedMigrationsArePaired(t *testing.T) {
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		names[e.Name()] = true
+	}
+
+	ups := 0
+	for name := range names {
+		base, ok := strings.CutSuffix(name, ".up.sql")
+		if !ok {
+			continue
+		}
+		ups++
+		if !names[base+".down.sql"] {
+			t.Errorf("%s has no down migration", name)
+		}
+	}
+
+	if uint(ups) != SchemaVersion() {
+		t.Errorf("SchemaVersion() = %d, but there are %d up migrations", SchemaVersion(), ups)
+	}
+}
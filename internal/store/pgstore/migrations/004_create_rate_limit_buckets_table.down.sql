@@ -0,0 +1,3 @@
+-- 004_create_rate_limit_buckets_table.down.sql
+
+DROP TABLE IF EXISTS rate_limit_buckets;
@@ -0,0 +1,3 @@
+-- 005_create_room_sanctions_table.down.sql
+
+DROP TABLE IF EXISTS room_sanctions;
@@ -0,0 +1,17 @@
+-- 005_create_room_sanctions_table.up.sql
+
+CREATE TABLE IF NOT EXISTS room_sanctions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('ban', 'mute')),
+    author_id TEXT,
+    ip TEXT,
+    reason TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    CHECK (author_id IS NOT NULL OR ip IS NOT NULL)
+    );
+
+CREATE INDEX IF NOT EXISTS room_sanctions_room_id_idx ON room_sanctions (room_id, kind);
@@ -0,0 +1,39 @@
+-- 006_create_polls_tables.up.sql
+
+CREATE TABLE IF NOT EXISTS polls (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    question TEXT NOT NULL,
+    multiple_choice BOOLEAN NOT NULL DEFAULT FALSE,
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'closed')),
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    closed_at TIMESTAMPTZ
+    );
+
+CREATE INDEX IF NOT EXISTS polls_room_id_idx ON polls (room_id);
+
+CREATE TABLE IF NOT EXISTS poll_options (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    label TEXT NOT NULL,
+    position INTEGER NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS poll_options_poll_id_idx ON poll_options (poll_id);
+
+-- One ballot per voter and poll; a ballot holds one or more votes
+CREATE TABLE IF NOT EXISTS poll_ballots (
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    voter_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (poll_id, voter_id)
+    );
+
+CREATE TABLE IF NOT EXISTS poll_votes (
+    poll_id UUID NOT NULL,
+    option_id UUID NOT NULL REFERENCES poll_options(id) ON DELETE CASCADE,
+    voter_id TEXT NOT NULL,
+    PRIMARY KEY (option_id, voter_id),
+    FOREIGN KEY (poll_id, voter_id) REFERENCES poll_ballots(poll_id, voter_id) ON DELETE CASCADE
+    );
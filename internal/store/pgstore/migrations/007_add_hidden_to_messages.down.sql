@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS messages_room_id_created_at_idx;
+
+ALTER TABLE messages
+DROP COLUMN IF EXISTS hidden;
@@ -0,0 +1,6 @@
+-- 009_create_api_keys_table.down.sql
+
+ALTER TABLE messages
+DROP COLUMN IF EXISTS is_bot;
+
+DROP TABLE IF EXISTS api_keys;
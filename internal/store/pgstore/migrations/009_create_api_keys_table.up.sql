@@ -0,0 +1,20 @@
+-- 009_create_api_keys_table.up.sql
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    -- First characters of the key, kept to tell keys apart in listings
+    prefix TEXT NOT NULL,
+    -- Hex encoded SHA-256 of the full key; the key itself is never stored
+    key_hash TEXT NOT NULL UNIQUE,
+    -- Empty allows every room
+    room_ids UUID[] NOT NULL DEFAULT '{}',
+    permissions TEXT[] NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+    );
+
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS is_bot BOOLEAN NOT NULL DEFAULT FALSE;
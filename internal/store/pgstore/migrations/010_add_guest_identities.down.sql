@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS guest_names;
+
+ALTER TABLE rooms
+DROP COLUMN IF EXISTS allow_guests;
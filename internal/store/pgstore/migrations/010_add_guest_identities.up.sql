@@ -0,0 +1,15 @@
+-- 010_add_guest_identities.up.sql
+
+ALTER TABLE rooms
+    ADD COLUMN IF NOT EXISTS allow_guests BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- Display names held by guests, unique per room while the guest token lives
+CREATE TABLE IF NOT EXISTS guest_names (
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    -- Lower cased name used to detect duplicates
+    name_key TEXT NOT NULL,
+    name TEXT NOT NULL,
+    guest_id TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (room_id, name_key)
+    );
@@ -0,0 +1,5 @@
+ALTER TABLE messages
+DROP COLUMN IF EXISTS anonymous;
+
+ALTER TABLE rooms
+DROP COLUMN IF EXISTS anonymous_policy;
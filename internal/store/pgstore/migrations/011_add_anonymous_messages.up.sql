@@ -0,0 +1,12 @@
+-- 011_add_anonymous_messages.up.sql
+
+-- named: every message shows its author
+-- allowed: authors may hide their name per message
+-- only: every message is anonymous
+ALTER TABLE rooms
+    ADD COLUMN IF NOT EXISTS anonymous_policy TEXT NOT NULL DEFAULT 'named'
+    CHECK (anonymous_policy IN ('named', 'allowed', 'only'));
+
+-- The real author stays in author_id/author_name; the API masks it
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS anonymous BOOLEAN NOT NULL DEFAULT FALSE;
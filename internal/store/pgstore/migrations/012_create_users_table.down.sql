@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS messages_user_id_created_at_idx;
+
+ALTER TABLE messages
+DROP COLUMN IF EXISTS user_id;
+
+DROP TABLE IF EXISTS users;
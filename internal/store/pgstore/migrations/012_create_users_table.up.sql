@@ -0,0 +1,31 @@
+-- 012_create_users_table.up.sql
+
+-- Profiles synced from verified JWT claims; id is the token subject
+CREATE TABLE IF NOT EXISTS users (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    avatar_url TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+    );
+
+-- NULL for guests and bots
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS user_id TEXT REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS messages_user_id_created_at_idx ON messages (user_id, created_at);
+
+-- Link messages posted before profiles existed, keeping each author's latest name
+INSERT INTO users ( "id", "name", "created_at", "updated_at" )
+SELECT DISTINCT ON (author_id) author_id, author_name, created_at, created_at
+FROM messages
+WHERE
+    author_id <> 'guest'
+    AND author_id NOT LIKE 'guest:%'
+    AND author_id NOT LIKE 'bot:%'
+ORDER BY author_id, created_at DESC
+ON CONFLICT (id) DO NOTHING;
+
+UPDATE messages
+SET user_id = author_id
+WHERE author_id IN (SELECT id FROM users);
@@ -9,6 +9,41 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ApiKey struct {
+	ID          uuid.UUID          `db:"id" json:"id"`
+	Name        string             `db:"name" json:"name"`
+	Prefix      string             `db:"prefix" json:"prefix"`
+	KeyHash     string             `db:"key_hash" json:"key_hash"`
+	RoomIds     []uuid.UUID        `db:"room_ids" json:"room_ids"`
+	Permissions []string           `db:"permissions" json:"permissions"`
+	CreatedBy   string             `db:"created_by" json:"created_by"`
+	CreatedAt   pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	LastUsedAt  pgtype.Timestamptz `db:"last_used_at" json:"last_used_at"`
+	RevokedAt   pgtype.Timestamptz `db:"revoked_at" json:"revoked_at"`
+}
+
+type AuditLog struct {
+	ID         int64              `db:"id" json:"id"`
+	Action     string             `db:"action" json:"action"`
+	ActorID    string             `db:"actor_id" json:"actor_id"`
+	Ip         string             `db:"ip" json:"ip"`
+	RequestID  string             `db:"request_id" json:"request_id"`
+	RoomID     pgtype.UUID        `db:"room_id" json:"room_id"`
+	TargetType string             `db:"target_type" json:"target_type"`
+	TargetID   string             `db:"target_id" json:"target_id"`
+	Before     []byte             `db:"before" json:"before"`
+	After      []byte             `db:"after" json:"after"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type GuestName struct {
+	RoomID    uuid.UUID          `db:"room_id" json:"room_id"`
+	NameKey   string             `db:"name_key" json:"name_key"`
+	Name      string             `db:"name" json:"name"`
+	GuestID   string             `db:"guest_id" json:"guest_id"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+}
+
 type Message struct {
 	ID            uuid.UUID          `db:"id" json:"id"`
 	RoomID        uuid.UUID          `db:"room_id" json:"room_id"`
@@ -18,9 +53,97 @@ type Message struct {
 	AuthorID      string             `db:"author_id" json:"author_id"`
 	AuthorName    string             `db:"author_name" json:"author_name"`
 	CreatedAt     pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	Hidden        bool               `db:"hidden" json:"hidden"`
+	IsBot         bool               `db:"is_bot" json:"is_bot"`
+	Anonymous     bool               `db:"anonymous" json:"anonymous"`
+	UserID        pgtype.Text        `db:"user_id" json:"user_id"`
+}
+
+type Poll struct {
+	ID             uuid.UUID          `db:"id" json:"id"`
+	RoomID         uuid.UUID          `db:"room_id" json:"room_id"`
+	Question       string             `db:"question" json:"question"`
+	MultipleChoice bool               `db:"multiple_choice" json:"multiple_choice"`
+	Status         string             `db:"status" json:"status"`
+	CreatedBy      string             `db:"created_by" json:"created_by"`
+	CreatedAt      pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	ClosedAt       pgtype.Timestamptz `db:"closed_at" json:"closed_at"`
+}
+
+type PollBallot struct {
+	PollID    uuid.UUID          `db:"poll_id" json:"poll_id"`
+	VoterID   string             `db:"voter_id" json:"voter_id"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type PollOption struct {
+	ID       uuid.UUID `db:"id" json:"id"`
+	PollID   uuid.UUID `db:"poll_id" json:"poll_id"`
+	Label    string    `db:"label" json:"label"`
+	Position int32     `db:"position" json:"position"`
+}
+
+type PollVote struct {
+	PollID   uuid.UUID `db:"poll_id" json:"poll_id"`
+	OptionID uuid.UUID `db:"option_id" json:"option_id"`
+	VoterID  string    `db:"voter_id" json:"voter_id"`
+}
+
+type RateLimitBucket struct {
+	Key       string             `db:"key" json:"key"`
+	Tokens    float64            `db:"tokens" json:"tokens"`
+	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
 }
 
 type Room struct {
-	ID    uuid.UUID `db:"id" json:"id"`
-	Theme string    `db:"theme" json:"theme"`
+	ID              uuid.UUID `db:"id" json:"id"`
+	Theme           string    `db:"theme" json:"theme"`
+	AllowGuests     bool      `db:"allow_guests" json:"allow_guests"`
+	AnonymousPolicy string    `db:"anonymous_policy" json:"anonymous_policy"`
+}
+
+type RoomSanction struct {
+	ID        uuid.UUID          `db:"id" json:"id"`
+	RoomID    uuid.UUID          `db:"room_id" json:"room_id"`
+	Kind      string             `db:"kind" json:"kind"`
+	AuthorID  pgtype.Text        `db:"author_id" json:"author_id"`
+	Ip        pgtype.Text        `db:"ip" json:"ip"`
+	Reason    string             `db:"reason" json:"reason"`
+	CreatedBy string             `db:"created_by" json:"created_by"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	RevokedAt pgtype.Timestamptz `db:"revoked_at" json:"revoked_at"`
+}
+
+type User struct {
+	ID        string             `db:"id" json:"id"`
+	Name      string             `db:"name" json:"name"`
+	AvatarUrl pgtype.Text        `db:"avatar_url" json:"avatar_url"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type Webhook struct {
+	ID         uuid.UUID          `db:"id" json:"id"`
+	RoomID     pgtype.UUID        `db:"room_id" json:"room_id"`
+	Url        string             `db:"url" json:"url"`
+	Secret     string             `db:"secret" json:"secret"`
+	EventKinds []string           `db:"event_kinds" json:"event_kinds"`
+	CreatedBy  string             `db:"created_by" json:"created_by"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID          `db:"id" json:"id"`
+	WebhookID      uuid.UUID          `db:"webhook_id" json:"webhook_id"`
+	EventKind      string             `db:"event_kind" json:"event_kind"`
+	RoomID         uuid.UUID          `db:"room_id" json:"room_id"`
+	Payload        []byte             `db:"payload" json:"payload"`
+	Status         string             `db:"status" json:"status"`
+	Attempts       int32              `db:"attempts" json:"attempts"`
+	NextAttemptAt  pgtype.Timestamptz `db:"next_attempt_at" json:"next_attempt_at"`
+	LastStatusCode int32              `db:"last_status_code" json:"last_status_code"`
+	LastError      string             `db:"last_error" json:"last_error"`
+	CreatedAt      pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	DeliveredAt    pgtype.Timestamptz `db:"delivered_at" json:"delivered_at"`
 }
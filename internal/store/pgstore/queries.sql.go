@@ -9,11 +9,12 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const getMessage = `-- name: GetMessage :one
 SELECT
-    "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at"
+    "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "hidden", "is_bot", "anonymous", "user_id"
 FROM messages
 WHERE
     id = $1
@@ -31,13 +32,17 @@ func (q *Queries) GetMessage(ctx context.Context, id uuid.UUID) (Message, error)
 		&i.AuthorID,
 		&i.AuthorName,
 		&i.CreatedAt,
+		&i.Hidden,
+		&i.IsBot,
+		&i.Anonymous,
+		&i.UserID,
 	)
 	return i, err
 }
 
 const getRoom = `-- name: GetRoom :one
 SELECT
-    "id", "theme"
+    "id", "theme", "allow_guests", "anonymous_policy"
 FROM rooms
 WHERE id = $1
 `
@@ -45,20 +50,85 @@ WHERE id = $1
 func (q *Queries) GetRoom(ctx context.Context, id uuid.UUID) (Room, error) {
 	row := q.db.QueryRow(ctx, getRoom, id)
 	var i Room
-	err := row.Scan(&i.ID, &i.Theme)
+	err := row.Scan(&i.ID, &i.Theme, &i.AllowGuests, &i.AnonymousPolicy)
 	return i, err
 }
 
+const getRoomHotMessages = `-- name: GetRoomHotMessages :many
+SELECT
+    "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "hidden", "is_bot", "anonymous", "user_id"
+FROM messages
+WHERE
+    room_id = $1
+    AND NOT hidden
+    AND ($2::boolean OR NOT answered)
+ORDER BY
+    (reaction_count + 1) / POWER(EXTRACT(EPOCH FROM now() - created_at)::double precision / 3600 + 2, $3::double precision) DESC,
+    created_at DESC
+LIMIT NULLIF($4::integer, 0)
+`
+
+type GetRoomHotMessagesParams struct {
+	RoomID          uuid.UUID `db:"room_id" json:"room_id"`
+	IncludeAnswered bool      `db:"include_answered" json:"include_answered"`
+	Gravity         float64   `db:"gravity" json:"gravity"`
+	MaxResults      int32     `db:"max_results" json:"max_results"`
+}
+
+func (q *Queries) GetRoomHotMessages(ctx context.Context, arg GetRoomHotMessagesParams) ([]Message, error) {
+	rows, err := q.db.Query(ctx, getRoomHotMessages,
+		arg.RoomID,
+		arg.IncludeAnswered,
+		arg.Gravity,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Message
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.RoomID,
+			&i.Message,
+			&i.ReactionCount,
+			&i.Answered,
+			&i.AuthorID,
+			&i.AuthorName,
+			&i.CreatedAt,
+			&i.Hidden,
+			&i.IsBot,
+			&i.Anonymous,
+			&i.UserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getRoomMessages = `-- name: GetRoomMessages :many
 SELECT
-    "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at"
+    "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "hidden", "is_bot", "anonymous", "user_id"
 FROM messages
 WHERE
     room_id = $1
+    AND ($2::boolean OR NOT hidden)
 `
 
-func (q *Queries) GetRoomMessages(ctx context.Context, roomID uuid.UUID) ([]Message, error) {
-	rows, err := q.db.Query(ctx, getRoomMessages, roomID)
+type GetRoomMessagesParams struct {
+	RoomID        uuid.UUID `db:"room_id" json:"room_id"`
+	IncludeHidden bool      `db:"include_hidden" json:"include_hidden"`
+}
+
+func (q *Queries) GetRoomMessages(ctx context.Context, arg GetRoomMessagesParams) ([]Message, error) {
+	rows, err := q.db.Query(ctx, getRoomMessages, arg.RoomID, arg.IncludeHidden)
 	if err != nil {
 		return nil, err
 	}
@@ -75,6 +145,69 @@ func (q *Queries) GetRoomMessages(ctx context.Context, roomID uuid.UUID) ([]Mess
 			&i.AuthorID,
 			&i.AuthorName,
 			&i.CreatedAt,
+			&i.Hidden,
+			&i.IsBot,
+			&i.Anonymous,
+			&i.UserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRoomMessagesPage = `-- name: GetRoomMessagesPage :many
+SELECT
+    "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "hidden", "is_bot", "anonymous", "user_id"
+FROM messages
+WHERE
+    room_id = $1
+    AND ($2::boolean OR NOT hidden)
+    AND (created_at, id) > ($3::timestamptz, $4::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT $5
+`
+
+type GetRoomMessagesPageParams struct {
+	RoomID         uuid.UUID          `db:"room_id" json:"room_id"`
+	IncludeHidden  bool               `db:"include_hidden" json:"include_hidden"`
+	AfterCreatedAt pgtype.Timestamptz `db:"after_created_at" json:"after_created_at"`
+	AfterID        uuid.UUID          `db:"after_id" json:"after_id"`
+	PageSize       int32              `db:"page_size" json:"page_size"`
+}
+
+func (q *Queries) GetRoomMessagesPage(ctx context.Context, arg GetRoomMessagesPageParams) ([]Message, error) {
+	rows, err := q.db.Query(ctx, getRoomMessagesPage,
+		arg.RoomID,
+		arg.IncludeHidden,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Message
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.RoomID,
+			&i.Message,
+			&i.ReactionCount,
+			&i.Answered,
+			&i.AuthorID,
+			&i.AuthorName,
+			&i.CreatedAt,
+			&i.Hidden,
+			&i.IsBot,
+			&i.Anonymous,
+			&i.UserID,
 		); err != nil {
 			return nil, err
 		}
@@ -88,7 +221,7 @@ func (q *Queries) GetRoomMessages(ctx context.Context, roomID uuid.UUID) ([]Mess
 
 const getRooms = `-- name: GetRooms :many
 SELECT
-    "id", "theme"
+    "id", "theme", "allow_guests", "anonymous_policy"
 FROM rooms
 `
 
@@ -101,7 +234,7 @@ func (q *Queries) GetRooms(ctx context.Context) ([]Room, error) {
 	var items []Room
 	for rows.Next() {
 		var i Room
-		if err := rows.Scan(&i.ID, &i.Theme); err != nil {
+		if err := rows.Scan(&i.ID, &i.Theme, &i.AllowGuests, &i.AnonymousPolicy); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -114,16 +247,19 @@ func (q *Queries) GetRooms(ctx context.Context) ([]Room, error) {
 
 const insertMessage = `-- name: InsertMessage :one
 INSERT INTO messages
-    ( "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at" ) VALUES
-    ( $1, $2, 0, false, $3, $4, CURRENT_TIMESTAMP )
-RETURNING "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at"
+    ( "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "is_bot", "anonymous", "user_id" ) VALUES
+    ( $1, $2, 0, false, $3, $4, CURRENT_TIMESTAMP, $5, $6, $7 )
+RETURNING "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "hidden", "is_bot", "anonymous", "user_id"
 `
 
 type InsertMessageParams struct {
-	RoomID     uuid.UUID `db:"room_id" json:"room_id"`
-	Message    string    `db:"message" json:"message"`
-	AuthorID   string    `db:"author_id" json:"author_id"`
-	AuthorName string    `db:"author_name" json:"author_name"`
+	RoomID     uuid.UUID   `db:"room_id" json:"room_id"`
+	Message    string      `db:"message" json:"message"`
+	AuthorID   string      `db:"author_id" json:"author_id"`
+	AuthorName string      `db:"author_name" json:"author_name"`
+	IsBot      bool        `db:"is_bot" json:"is_bot"`
+	Anonymous  bool        `db:"anonymous" json:"anonymous"`
+	UserID     pgtype.Text `db:"user_id" json:"user_id"`
 }
 
 func (q *Queries) InsertMessage(ctx context.Context, arg InsertMessageParams) (Message, error) {
@@ -132,6 +268,9 @@ func (q *Queries) InsertMessage(ctx context.Context, arg InsertMessageParams) (M
 		arg.Message,
 		arg.AuthorID,
 		arg.AuthorName,
+		arg.IsBot,
+		arg.Anonymous,
+		arg.UserID,
 	)
 	var i Message
 	err := row.Scan(
@@ -143,19 +282,29 @@ func (q *Queries) InsertMessage(ctx context.Context, arg InsertMessageParams) (M
 		&i.AuthorID,
 		&i.AuthorName,
 		&i.CreatedAt,
+		&i.Hidden,
+		&i.IsBot,
+		&i.Anonymous,
+		&i.UserID,
 	)
 	return i, err
 }
 
 const insertRoom = `-- name: InsertRoom :one
 INSERT INTO rooms
-    ( "theme" ) VALUES
-    ( $1 )
+    ( "theme", "allow_guests", "anonymous_policy" ) VALUES
+    ( $1, $2, $3 )
 RETURNING "id"
 `
 
-func (q *Queries) InsertRoom(ctx context.Context, theme string) (uuid.UUID, error) {
-	row := q.db.QueryRow(ctx, insertRoom, theme)
+type InsertRoomParams struct {
+	Theme           string `db:"theme" json:"theme"`
+	AllowGuests     bool   `db:"allow_guests" json:"allow_guests"`
+	AnonymousPolicy string `db:"anonymous_policy" json:"anonymous_policy"`
+}
+
+func (q *Queries) InsertRoom(ctx context.Context, arg InsertRoomParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, insertRoom, arg.Theme, arg.AllowGuests, arg.AnonymousPolicy)
 	var id uuid.UUID
 	err := row.Scan(&id)
 	return id, err
@@ -175,33 +324,107 @@ func (q *Queries) MarkMessageAsAnswered(ctx context.Context, id uuid.UUID) error
 }
 
 const reactToMessage = `-- name: ReactToMessage :one
-UPDATE messages
+UPDATE messages m
 SET
-    reaction_count = reaction_count + 1
+    reaction_count = m.reaction_count + 1
+FROM (SELECT id, reaction_count FROM messages WHERE id = $1 FOR UPDATE) previous
 WHERE
-    id = $1
-RETURNING reaction_count
+    m.id = previous.id
+RETURNING previous.reaction_count AS previous_count, m.reaction_count
 `
 
-func (q *Queries) ReactToMessage(ctx context.Context, id uuid.UUID) (int64, error) {
+type ReactToMessageRow struct {
+	PreviousCount int64 `db:"previous_count" json:"previous_count"`
+	ReactionCount int64 `db:"reaction_count" json:"reaction_count"`
+}
+
+// The previous count is read under the row lock, for the audit log
+func (q *Queries) ReactToMessage(ctx context.Context, id uuid.UUID) (ReactToMessageRow, error) {
 	row := q.db.QueryRow(ctx, reactToMessage, id)
-	var reaction_count int64
-	err := row.Scan(&reaction_count)
-	return reaction_count, err
+	var i ReactToMessageRow
+	err := row.Scan(&i.PreviousCount, &i.ReactionCount)
+	return i, err
 }
 
 const removeReactionFromMessage = `-- name: RemoveReactionFromMessage :one
+UPDATE messages m
+SET
+    reaction_count = GREATEST(0, m.reaction_count - 1)
+FROM (SELECT id, reaction_count FROM messages WHERE id = $1 FOR UPDATE) previous
+WHERE
+    m.id = previous.id
+RETURNING previous.reaction_count AS previous_count, m.reaction_count
+`
+
+type RemoveReactionFromMessageRow struct {
+	PreviousCount int64 `db:"previous_count" json:"previous_count"`
+	ReactionCount int64 `db:"reaction_count" json:"reaction_count"`
+}
+
+// The previous count is read under the row lock, for the audit log
+func (q *Queries) RemoveReactionFromMessage(ctx context.Context, id uuid.UUID) (RemoveReactionFromMessageRow, error) {
+	row := q.db.QueryRow(ctx, removeReactionFromMessage, id)
+	var i RemoveReactionFromMessageRow
+	err := row.Scan(&i.PreviousCount, &i.ReactionCount)
+	return i, err
+}
+
+const setMessageHidden = `-- name: SetMessageHidden :exec
 UPDATE messages
 SET
-    reaction_count = GREATEST(0, reaction_count - 1)
+    hidden = $2
 WHERE
     id = $1
-RETURNING reaction_count
 `
 
-func (q *Queries) RemoveReactionFromMessage(ctx context.Context, id uuid.UUID) (int64, error) {
-	row := q.db.QueryRow(ctx, removeReactionFromMessage, id)
-	var reaction_count int64
-	err := row.Scan(&reaction_count)
-	return reaction_count, err
+type SetMessageHiddenParams struct {
+	ID     uuid.UUID `db:"id" json:"id"`
+	Hidden bool      `db:"hidden" json:"hidden"`
+}
+
+func (q *Queries) SetMessageHidden(ctx context.Context, arg SetMessageHiddenParams) error {
+	_, err := q.db.Exec(ctx, setMessageHidden, arg.ID, arg.Hidden)
+	return err
+}
+
+const setRoomAllowGuests = `-- name: SetRoomAllowGuests :one
+UPDATE rooms
+SET
+    allow_guests = $2
+WHERE
+    id = $1
+RETURNING "id", "theme", "allow_guests", "anonymous_policy"
+`
+
+type SetRoomAllowGuestsParams struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	AllowGuests bool      `db:"allow_guests" json:"allow_guests"`
+}
+
+func (q *Queries) SetRoomAllowGuests(ctx context.Context, arg SetRoomAllowGuestsParams) (Room, error) {
+	row := q.db.QueryRow(ctx, setRoomAllowGuests, arg.ID, arg.AllowGuests)
+	var i Room
+	err := row.Scan(&i.ID, &i.Theme, &i.AllowGuests, &i.AnonymousPolicy)
+	return i, err
+}
+
+const setRoomAnonymousPolicy = `-- name: SetRoomAnonymousPolicy :one
+UPDATE rooms
+SET
+    anonymous_policy = $2
+WHERE
+    id = $1
+RETURNING "id", "theme", "allow_guests", "anonymous_policy"
+`
+
+type SetRoomAnonymousPolicyParams struct {
+	ID              uuid.UUID `db:"id" json:"id"`
+	AnonymousPolicy string    `db:"anonymous_policy" json:"anonymous_policy"`
+}
+
+func (q *Queries) SetRoomAnonymousPolicy(ctx context.Context, arg SetRoomAnonymousPolicyParams) (Room, error) {
+	row := q.db.QueryRow(ctx, setRoomAnonymousPolicy, arg.ID, arg.AnonymousPolicy)
+	var i Room
+	err := row.Scan(&i.ID, &i.Theme, &i.AllowGuests, &i.AnonymousPolicy)
+	return i, err
 }
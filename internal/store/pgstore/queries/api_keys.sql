@@ -0,0 +1,35 @@
+-- name: InsertAPIKey :one
+INSERT INTO api_keys
+    ( "name", "prefix", "key_hash", "room_ids", "permissions", "created_by" ) VALUES
+    ( $1, $2, $3, $4, $5, $6 )
+RETURNING "id", "name", "prefix", "key_hash", "room_ids", "permissions", "created_by", "created_at", "last_used_at", "revoked_at";
+
+-- name: GetAPIKeyByHash :one
+SELECT
+    "id", "name", "prefix", "key_hash", "room_ids", "permissions", "created_by", "created_at", "last_used_at", "revoked_at"
+FROM api_keys
+WHERE
+    key_hash = $1
+    AND revoked_at IS NULL;
+
+-- name: GetAPIKeys :many
+SELECT
+    "id", "name", "prefix", "key_hash", "room_ids", "permissions", "created_by", "created_at", "last_used_at", "revoked_at"
+FROM api_keys
+ORDER BY created_at ASC;
+
+-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET
+    revoked_at = now()
+WHERE
+    id = $1
+    AND revoked_at IS NULL
+RETURNING "id", "name", "prefix", "key_hash", "room_ids", "permissions", "created_by", "created_at", "last_used_at", "revoked_at";
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET
+    last_used_at = now()
+WHERE
+    id = $1;
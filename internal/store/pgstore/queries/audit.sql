@@ -0,0 +1,20 @@
+-- name: InsertAuditLogEntry :exec
+INSERT INTO audit_log
+    ( "action", "actor_id", "ip", "request_id", "room_id", "target_type", "target_id", "before", "after" ) VALUES
+    ( $1, $2, $3, $4, $5, $6, $7, $8, $9 );
+
+-- name: GetAuditLog :many
+-- Newest first; pass the last id seen as before_id to get the next page
+SELECT
+    "id", "action", "actor_id", "ip", "request_id", "room_id", "target_type", "target_id", "before", "after", "created_at"
+FROM audit_log
+WHERE
+    (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action'))
+    AND (sqlc.narg('actor_id')::text IS NULL OR actor_id = sqlc.narg('actor_id'))
+    AND (sqlc.narg('room_id')::uuid IS NULL OR room_id = sqlc.narg('room_id'))
+    AND (sqlc.narg('target_id')::text IS NULL OR target_id = sqlc.narg('target_id'))
+    AND (sqlc.narg('since')::timestamptz IS NULL OR created_at >= sqlc.narg('since'))
+    AND (sqlc.narg('until')::timestamptz IS NULL OR created_at < sqlc.narg('until'))
+    AND (@before_id::bigint = 0 OR id < @before_id)
+ORDER BY id DESC
+LIMIT @max_results::integer;
@@ -0,0 +1,18 @@
+-- name: ClaimGuestName :one
+-- Takes the name unless another guest holds it and has not expired yet
+INSERT INTO guest_names
+    ( "room_id", "name_key", "name", "guest_id", "expires_at" ) VALUES
+    ( $1, $2, $3, $4, $5 )
+ON CONFLICT (room_id, name_key) DO UPDATE
+SET
+    name = EXCLUDED.name,
+    guest_id = EXCLUDED.guest_id,
+    expires_at = EXCLUDED.expires_at
+WHERE
+    guest_names.guest_id = EXCLUDED.guest_id
+    OR guest_names.expires_at < now()
+RETURNING "room_id", "name_key", "name", "guest_id", "expires_at";
+
+-- name: DeleteExpiredGuestNames :exec
+DELETE FROM guest_names
+WHERE expires_at < now();
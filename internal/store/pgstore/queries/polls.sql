@@ -0,0 +1,76 @@
+-- name: InsertPoll :one
+WITH poll AS (
+    INSERT INTO polls
+        ( "room_id", "question", "multiple_choice", "created_by" ) VALUES
+        ( @room_id, @question, @multiple_choice, @created_by )
+    RETURNING "id"
+), options AS (
+    INSERT INTO poll_options
+        ( "poll_id", "label", "position" )
+    SELECT poll.id, t.label, t.position
+    FROM poll, unnest(@options::text[]) WITH ORDINALITY AS t(label, position)
+)
+SELECT "id" FROM poll;
+
+-- name: GetPoll :one
+SELECT
+    "id", "room_id", "question", "multiple_choice", "status", "created_by", "created_at", "closed_at"
+FROM polls
+WHERE
+    id = $1;
+
+-- name: GetRoomPolls :many
+SELECT
+    "id", "room_id", "question", "multiple_choice", "status", "created_by", "created_at", "closed_at"
+FROM polls
+WHERE
+    room_id = $1
+ORDER BY created_at ASC;
+
+-- name: GetPollTallies :many
+SELECT
+    o."id", o."poll_id", o."label", o."position", COUNT(v."voter_id") AS votes
+FROM poll_options o
+LEFT JOIN poll_votes v ON v.option_id = o.id
+WHERE
+    o.poll_id = $1
+GROUP BY o.id
+ORDER BY o.position ASC;
+
+-- name: GetRoomPollTallies :many
+SELECT
+    o."id", o."poll_id", o."label", o."position", COUNT(v."voter_id") AS votes
+FROM poll_options o
+JOIN polls p ON p.id = o.poll_id
+LEFT JOIN poll_votes v ON v.option_id = o.id
+WHERE
+    p.room_id = $1
+GROUP BY o.id
+ORDER BY o.poll_id, o.position ASC;
+
+-- name: CastPollVote :many
+WITH ballot AS (
+    INSERT INTO poll_ballots
+        ( "poll_id", "voter_id" )
+    SELECT id, @voter_id FROM polls
+    WHERE id = @poll_id AND status = 'open'
+    ON CONFLICT DO NOTHING
+    RETURNING "poll_id", "voter_id"
+)
+INSERT INTO poll_votes
+    ( "poll_id", "option_id", "voter_id" )
+SELECT ballot.poll_id, o.id, ballot.voter_id
+FROM ballot
+JOIN poll_options o ON o.poll_id = ballot.poll_id AND o.id = ANY(@option_ids::uuid[])
+RETURNING "option_id";
+
+-- name: ClosePoll :one
+UPDATE polls
+SET
+    status = 'closed',
+    closed_at = now()
+WHERE
+    id = $1
+    AND room_id = $2
+    AND status = 'open'
+RETURNING "id", "room_id", "question", "multiple_choice", "status", "created_by", "created_at", "closed_at";
@@ -1,55 +1,68 @@
 -- name: GetRoom :one
 SELECT
-    "id", "theme"
+    "id", "theme", "allow_guests", "anonymous_policy"
 FROM rooms
 WHERE id = $1;
 
 -- name: GetRooms :many
 SELECT
-    "id", "theme"
+    "id", "theme", "allow_guests", "anonymous_policy"
 FROM rooms;
 
 -- name: InsertRoom :one
 INSERT INTO rooms
-    ( "theme" ) VALUES
-    ( $1 )
+    ( "theme", "allow_guests", "anonymous_policy" ) VALUES
+    ( $1, $2, $3 )
 RETURNING "id";
 
+-- name: SetRoomAllowGuests :one
+UPDATE rooms
+SET
+    allow_guests = $2
+WHERE
+    id = $1
+RETURNING "id", "theme", "allow_guests", "anonymous_policy";
+
 -- name: GetMessage :one
 SELECT
-    "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at"
+    "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "hidden", "is_bot", "anonymous", "user_id"
 FROM messages
 WHERE
     id = $1;
 
 -- name: GetRoomMessages :many
 SELECT
-    "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at"
+    "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "hidden", "is_bot", "anonymous", "user_id"
 FROM messages
 WHERE
-    room_id = $1;
+    room_id = @room_id
+    AND (@include_hidden::boolean OR NOT hidden);
 
 -- name: InsertMessage :one
 INSERT INTO messages
-    ( "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at" ) VALUES
-    ( $1, $2, 0, false, $3, $4, CURRENT_TIMESTAMP )
-RETURNING "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at";
+    ( "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "is_bot", "anonymous", "user_id" ) VALUES
+    ( $1, $2, 0, false, $3, $4, CURRENT_TIMESTAMP, $5, $6, $7 )
+RETURNING "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "hidden", "is_bot", "anonymous", "user_id";
 
 -- name: ReactToMessage :one
-UPDATE messages
+-- The previous count is read under the row lock, for the audit log
+UPDATE messages m
 SET
-    reaction_count = reaction_count + 1
+    reaction_count = m.reaction_count + 1
+FROM (SELECT id, reaction_count FROM messages WHERE id = $1 FOR UPDATE) previous
 WHERE
-    id = $1
-RETURNING reaction_count;
+    m.id = previous.id
+RETURNING previous.reaction_count AS previous_count, m.reaction_count;
 
 -- name: RemoveReactionFromMessage :one
-UPDATE messages
+-- The previous count is read under the row lock, for the audit log
+UPDATE messages m
 SET
-    reaction_count = GREATEST(0, reaction_count - 1)
+    reaction_count = GREATEST(0, m.reaction_count - 1)
+FROM (SELECT id, reaction_count FROM messages WHERE id = $1 FOR UPDATE) previous
 WHERE
-    id = $1
-RETURNING reaction_count;
+    m.id = previous.id
+RETURNING previous.reaction_count AS previous_count, m.reaction_count;
 
 -- name: MarkMessageAsAnswered :exec
 UPDATE messages
@@ -57,3 +70,42 @@ SET
     answered = true
 WHERE
     id = $1;
+
+-- name: GetRoomHotMessages :many
+SELECT
+    "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "hidden", "is_bot", "anonymous", "user_id"
+FROM messages
+WHERE
+    room_id = @room_id
+    AND NOT hidden
+    AND (@include_answered::boolean OR NOT answered)
+ORDER BY
+    (reaction_count + 1) / POWER(EXTRACT(EPOCH FROM now() - created_at)::double precision / 3600 + 2, @gravity::double precision) DESC,
+    created_at DESC
+LIMIT NULLIF(@max_results::integer, 0);
+
+-- name: GetRoomMessagesPage :many
+SELECT
+    "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "hidden", "is_bot", "anonymous", "user_id"
+FROM messages
+WHERE
+    room_id = @room_id
+    AND (@include_hidden::boolean OR NOT hidden)
+    AND (created_at, id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT @page_size;
+
+-- name: SetMessageHidden :exec
+UPDATE messages
+SET
+    hidden = $2
+WHERE
+    id = $1;
+
+-- name: SetRoomAnonymousPolicy :one
+UPDATE rooms
+SET
+    anonymous_policy = $2
+WHERE
+    id = $1
+RETURNING "id", "theme", "allow_guests", "anonymous_policy";
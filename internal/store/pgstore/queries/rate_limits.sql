@@ -0,0 +1,16 @@
+-- name: TakeRateLimitToken :one
+INSERT INTO rate_limit_buckets AS b
+    ( "key", "tokens", "updated_at" ) VALUES
+    ( @key, @burst::double precision - 1, now() )
+ON CONFLICT ("key") DO UPDATE
+SET
+    tokens = LEAST(@burst::double precision, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::double precision * @rate::double precision) - 1,
+    updated_at = now()
+WHERE
+    LEAST(@burst::double precision, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::double precision * @rate::double precision) >= 1
+RETURNING "tokens";
+
+-- name: DeleteStaleRateLimitBuckets :exec
+DELETE FROM rate_limit_buckets
+WHERE
+    updated_at < $1;
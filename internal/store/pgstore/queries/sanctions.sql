@@ -0,0 +1,40 @@
+-- name: InsertRoomSanction :one
+INSERT INTO room_sanctions
+    ( "room_id", "kind", "author_id", "ip", "reason", "created_by", "expires_at" ) VALUES
+    ( $1, $2, $3, $4, $5, $6, $7 )
+RETURNING "id", "room_id", "kind", "author_id", "ip", "reason", "created_by", "created_at", "expires_at", "revoked_at";
+
+-- name: GetActiveRoomSanctions :many
+SELECT
+    "id", "room_id", "kind", "author_id", "ip", "reason", "created_by", "created_at", "expires_at", "revoked_at"
+FROM room_sanctions
+WHERE
+    room_id = $1
+    AND kind = $2
+    AND revoked_at IS NULL
+    AND (expires_at IS NULL OR expires_at > now())
+ORDER BY created_at DESC;
+
+-- name: FindActiveRoomSanction :one
+SELECT
+    "id", "room_id", "kind", "author_id", "ip", "reason", "created_by", "created_at", "expires_at", "revoked_at"
+FROM room_sanctions
+WHERE
+    room_id = @room_id
+    AND kind = ANY(@kinds::text[])
+    AND revoked_at IS NULL
+    AND (expires_at IS NULL OR expires_at > now())
+    AND (author_id = @author_id OR ip = @ip)
+ORDER BY kind ASC, expires_at DESC NULLS FIRST
+LIMIT 1;
+
+-- name: RevokeRoomSanction :one
+UPDATE room_sanctions
+SET
+    revoked_at = now()
+WHERE
+    id = $1
+    AND room_id = $2
+    AND kind = $3
+    AND revoked_at IS NULL
+RETURNING "id", "room_id", "kind", "author_id", "ip", "reason", "created_by", "created_at", "expires_at", "revoked_at";
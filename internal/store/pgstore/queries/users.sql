@@ -0,0 +1,37 @@
+-- name: UpsertUser :exec
+INSERT INTO users
+    ( "id", "name", "avatar_url" ) VALUES
+    ( $1, $2, $3 )
+ON CONFLICT (id) DO UPDATE
+SET
+    name = EXCLUDED.name,
+    avatar_url = EXCLUDED.avatar_url,
+    updated_at = now()
+WHERE
+    users.name IS DISTINCT FROM EXCLUDED.name
+    OR users.avatar_url IS DISTINCT FROM EXCLUDED.avatar_url;
+
+-- name: GetUser :one
+SELECT
+    "id", "name", "avatar_url", "created_at", "updated_at"
+FROM users
+WHERE id = $1;
+
+-- name: GetUsersByIDs :many
+SELECT
+    "id", "name", "avatar_url", "created_at", "updated_at"
+FROM users
+WHERE id = ANY(@ids::text[]);
+
+-- name: GetUserMessages :many
+-- Hidden messages are never attributed to their author, anonymous ones only
+-- when the author asks for their own
+SELECT
+    "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "hidden", "is_bot", "anonymous", "user_id"
+FROM messages
+WHERE
+    user_id = @user_id
+    AND (@include_anonymous::boolean OR NOT anonymous)
+    AND NOT hidden
+ORDER BY created_at DESC, id DESC
+LIMIT @max_results::integer;
@@ -0,0 +1,83 @@
+-- name: InsertWebhook :one
+INSERT INTO webhooks
+    ( "room_id", "url", "secret", "event_kinds", "created_by" ) VALUES
+    ( $1, $2, $3, $4, $5 )
+RETURNING "id", "room_id", "url", "secret", "event_kinds", "created_by", "created_at";
+
+-- name: GetWebhooks :many
+SELECT
+    "id", "room_id", "url", "secret", "event_kinds", "created_by", "created_at"
+FROM webhooks
+ORDER BY created_at ASC;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE
+    id = $1;
+
+-- name: EnqueueWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries
+    ( "webhook_id", "event_kind", "room_id", "payload" )
+SELECT
+    id, @event_kind::text, @room_id::uuid, @payload::jsonb
+FROM webhooks
+WHERE
+    (room_id IS NULL OR room_id = @room_id::uuid)
+    AND (cardinality(event_kinds) = 0 OR @event_kind::text = ANY(event_kinds));
+
+-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries d
+SET
+    next_attempt_at = now() + @lease_seconds::integer * interval '1 second'
+FROM webhooks w
+WHERE
+    w.id = d.webhook_id
+    AND d.id IN (
+        SELECT id FROM webhook_deliveries
+        WHERE status = 'pending' AND next_attempt_at <= now()
+        ORDER BY next_attempt_at ASC
+        LIMIT @batch_size::integer
+        FOR UPDATE SKIP LOCKED
+    )
+RETURNING d."id", d."webhook_id", d."event_kind", d."room_id", d."payload", d."attempts", w."url", w."secret";
+
+-- name: MarkWebhookDeliverySucceeded :exec
+UPDATE webhook_deliveries
+SET
+    status = 'delivered',
+    attempts = attempts + 1,
+    last_status_code = $2,
+    last_error = '',
+    delivered_at = now()
+WHERE
+    id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+UPDATE webhook_deliveries
+SET
+    status = CASE WHEN @dead::boolean THEN 'dead' ELSE 'pending' END,
+    attempts = attempts + 1,
+    last_status_code = @last_status_code,
+    last_error = @last_error,
+    next_attempt_at = @next_attempt_at
+WHERE
+    id = @id;
+
+-- name: GetWebhookDeliveries :many
+SELECT
+    "id", "webhook_id", "event_kind", "room_id", "payload", "status", "attempts", "next_attempt_at", "last_status_code", "last_error", "created_at", "delivered_at"
+FROM webhook_deliveries
+WHERE
+    status = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: RedeliverWebhookDelivery :one
+UPDATE webhook_deliveries
+SET
+    status = 'pending',
+    attempts = 0,
+    next_attempt_at = now()
+WHERE
+    id = $1
+RETURNING "id", "webhook_id", "event_kind", "room_id", "payload", "status", "attempts", "next_attempt_at", "last_status_code", "last_error", "created_at", "delivered_at";
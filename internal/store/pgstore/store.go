@@ -0,0 +1,549 @@
+package pgstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var _ store.Store = (*Store)(nil)
+
+// Store implements store.Store on the generated queries, converting the
+// pgtype columns to the store models and pgx.ErrNoRows to store.ErrNotFound
+type Store struct {
+	q *Queries
+}
+
+// NewStore returns a store on a pool or connection
+func NewStore(db DBTX) *Store {
+	return &Store{q: New(db)}
+}
+
+// Queries returns the generated queries, for the features only Postgres
+// backs, such as the shared rate limit buckets
+func (s *Store) Queries() *Queries {
+	return s.q
+}
+
+// notFound turns pgx.ErrNoRows into store.ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+func convert[S, D any](items []S, fn func(S) D) []D {
+	if items == nil {
+		return nil
+	}
+	out := make([]D, len(items))
+	for i, item := range items {
+		out[i] = fn(item)
+	}
+	return out
+}
+
+// one converts the row of a :one query, keeping the error
+func one[S, D any](item S, err error, fn func(S) D) (D, error) {
+	if err != nil {
+		var zero D
+		return zero, notFound(err)
+	}
+	return fn(item), nil
+}
+
+// many converts the rows of a :many query
+func many[S, D any](items []S, err error, fn func(S) D) ([]D, error) {
+	if err != nil {
+		return nil, err
+	}
+	return convert(items, fn), nil
+}
+
+func timestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
+
+func nullTimestamptz(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return timestamptz(*t)
+}
+
+func timePtr(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func text(s *string) pgtype.Text {
+	if s == nil {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: *s, Valid: true}
+}
+
+func textPtr(t pgtype.Text) *string {
+	if !t.Valid {
+		return nil
+	}
+	return &t.String
+}
+
+func nullUUID(id *uuid.UUID) pgtype.UUID {
+	if id == nil {
+		return pgtype.UUID{}
+	}
+	return pgtype.UUID{Bytes: *id, Valid: true}
+}
+
+func uuidPtr(id pgtype.UUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	v := uuid.UUID(id.Bytes)
+	return &v
+}
+
+func toRoom(r Room) store.Room {
+	return store.Room(r)
+}
+
+func toMessage(m Message) store.Message {
+	return store.Message{
+		ID:            m.ID,
+		RoomID:        m.RoomID,
+		Message:       m.Message,
+		ReactionCount: m.ReactionCount,
+		Answered:      m.Answered,
+		AuthorID:      m.AuthorID,
+		AuthorName:    m.AuthorName,
+		CreatedAt:     m.CreatedAt.Time,
+		Hidden:        m.Hidden,
+		IsBot:         m.IsBot,
+		Anonymous:     m.Anonymous,
+		UserID:        textPtr(m.UserID),
+	}
+}
+
+func toPoll(p Poll) store.Poll {
+	return store.Poll{
+		ID:             p.ID,
+		RoomID:         p.RoomID,
+		Question:       p.Question,
+		MultipleChoice: p.MultipleChoice,
+		Status:         p.Status,
+		CreatedBy:      p.CreatedBy,
+		CreatedAt:      p.CreatedAt.Time,
+		ClosedAt:       timePtr(p.ClosedAt),
+	}
+}
+
+func toSanction(r RoomSanction) store.RoomSanction {
+	return store.RoomSanction{
+		ID:        r.ID,
+		RoomID:    r.RoomID,
+		Kind:      r.Kind,
+		AuthorID:  textPtr(r.AuthorID),
+		Ip:        textPtr(r.Ip),
+		Reason:    r.Reason,
+		CreatedBy: r.CreatedBy,
+		CreatedAt: r.CreatedAt.Time,
+		ExpiresAt: timePtr(r.ExpiresAt),
+		RevokedAt: timePtr(r.RevokedAt),
+	}
+}
+
+func toAPIKey(k ApiKey) store.ApiKey {
+	return store.ApiKey{
+		ID:          k.ID,
+		Name:        k.Name,
+		Prefix:      k.Prefix,
+		KeyHash:     k.KeyHash,
+		RoomIds:     k.RoomIds,
+		Permissions: k.Permissions,
+		CreatedBy:   k.CreatedBy,
+		CreatedAt:   k.CreatedAt.Time,
+		LastUsedAt:  timePtr(k.LastUsedAt),
+		RevokedAt:   timePtr(k.RevokedAt),
+	}
+}
+
+func toGuestName(g GuestName) store.GuestName {
+	return store.GuestName{
+		RoomID:    g.RoomID,
+		NameKey:   g.NameKey,
+		Name:      g.Name,
+		GuestID:   g.GuestID,
+		ExpiresAt: g.ExpiresAt.Time,
+	}
+}
+
+func toUser(u User) store.User {
+	return store.User{
+		ID:        u.ID,
+		Name:      u.Name,
+		AvatarUrl: textPtr(u.AvatarUrl),
+		CreatedAt: u.CreatedAt.Time,
+		UpdatedAt: u.UpdatedAt.Time,
+	}
+}
+
+func toAuditLog(a AuditLog) store.AuditLog {
+	return store.AuditLog{
+		ID:         a.ID,
+		Action:     a.Action,
+		ActorID:    a.ActorID,
+		Ip:         a.Ip,
+		RequestID:  a.RequestID,
+		RoomID:     uuidPtr(a.RoomID),
+		TargetType: a.TargetType,
+		TargetID:   a.TargetID,
+		Before:     a.Before,
+		After:      a.After,
+		CreatedAt:  a.CreatedAt.Time,
+	}
+}
+
+func toWebhook(w Webhook) store.Webhook {
+	return store.Webhook{
+		ID:         w.ID,
+		RoomID:     uuidPtr(w.RoomID),
+		Url:        w.Url,
+		Secret:     w.Secret,
+		EventKinds: w.EventKinds,
+		CreatedBy:  w.CreatedBy,
+		CreatedAt:  w.CreatedAt.Time,
+	}
+}
+
+func toDelivery(d WebhookDelivery) store.WebhookDelivery {
+	return store.WebhookDelivery{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		EventKind:      d.EventKind,
+		RoomID:         d.RoomID,
+		Payload:        d.Payload,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt.Time,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt.Time,
+		DeliveredAt:    timePtr(d.DeliveredAt),
+	}
+}
+
+func (s *Store) GetRoom(ctx context.Context, id uuid.UUID) (store.Room, error) {
+	r, err := s.q.GetRoom(ctx, id)
+	return one(r, err, toRoom)
+}
+
+func (s *Store) GetRooms(ctx context.Context) ([]store.Room, error) {
+	items, err := s.q.GetRooms(ctx)
+	return many(items, err, toRoom)
+}
+
+func (s *Store) InsertRoom(ctx context.Context, arg store.InsertRoomParams) (uuid.UUID, error) {
+	return s.q.InsertRoom(ctx, InsertRoomParams(arg))
+}
+
+func (s *Store) SetRoomAllowGuests(ctx context.Context, arg store.SetRoomAllowGuestsParams) (store.Room, error) {
+	r, err := s.q.SetRoomAllowGuests(ctx, SetRoomAllowGuestsParams(arg))
+	return one(r, err, toRoom)
+}
+
+func (s *Store) SetRoomAnonymousPolicy(ctx context.Context, arg store.SetRoomAnonymousPolicyParams) (store.Room, error) {
+	r, err := s.q.SetRoomAnonymousPolicy(ctx, SetRoomAnonymousPolicyParams(arg))
+	return one(r, err, toRoom)
+}
+
+func (s *Store) GetMessage(ctx context.Context, id uuid.UUID) (store.Message, error) {
+	m, err := s.q.GetMessage(ctx, id)
+	return one(m, err, toMessage)
+}
+
+func (s *Store) GetRoomHotMessages(ctx context.Context, arg store.GetRoomHotMessagesParams) ([]store.Message, error) {
+	items, err := s.q.GetRoomHotMessages(ctx, GetRoomHotMessagesParams(arg))
+	return many(items, err, toMessage)
+}
+
+func (s *Store) GetRoomMessages(ctx context.Context, arg store.GetRoomMessagesParams) ([]store.Message, error) {
+	items, err := s.q.GetRoomMessages(ctx, GetRoomMessagesParams(arg))
+	return many(items, err, toMessage)
+}
+
+// GetRoomMessagesPage starts from -infinity when AfterCreatedAt is zero
+func (s *Store) GetRoomMessagesPage(ctx context.Context, arg store.GetRoomMessagesPageParams) ([]store.Message, error) {
+	after := timestamptz(arg.AfterCreatedAt)
+	if arg.AfterCreatedAt.IsZero() {
+		after = pgtype.Timestamptz{InfinityModifier: pgtype.NegativeInfinity, Valid: true}
+	}
+	items, err := s.q.GetRoomMessagesPage(ctx, GetRoomMessagesPageParams{
+		RoomID:         arg.RoomID,
+		IncludeHidden:  arg.IncludeHidden,
+		AfterCreatedAt: after,
+		AfterID:        arg.AfterID,
+		PageSize:       arg.PageSize,
+	})
+	return many(items, err, toMessage)
+}
+
+func (s *Store) GetUserMessages(ctx context.Context, arg store.GetUserMessagesParams) ([]store.Message, error) {
+	items, err := s.q.GetUserMessages(ctx, GetUserMessagesParams{
+		UserID:           pgtype.Text{String: arg.UserID, Valid: true},
+		IncludeAnonymous: arg.IncludeAnonymous,
+		MaxResults:       arg.MaxResults,
+	})
+	return many(items, err, toMessage)
+}
+
+func (s *Store) InsertMessage(ctx context.Context, arg store.InsertMessageParams) (store.Message, error) {
+	m, err := s.q.InsertMessage(ctx, InsertMessageParams{
+		RoomID:     arg.RoomID,
+		Message:    arg.Message,
+		AuthorID:   arg.AuthorID,
+		AuthorName: arg.AuthorName,
+		IsBot:      arg.IsBot,
+		Anonymous:  arg.Anonymous,
+		UserID:     text(arg.UserID),
+	})
+	return one(m, err, toMessage)
+}
+
+func (s *Store) MarkMessageAsAnswered(ctx context.Context, id uuid.UUID) error {
+	return s.q.MarkMessageAsAnswered(ctx, id)
+}
+
+func (s *Store) SetMessageHidden(ctx context.Context, arg store.SetMessageHiddenParams) error {
+	return s.q.SetMessageHidden(ctx, SetMessageHiddenParams(arg))
+}
+
+func (s *Store) ReactToMessage(ctx context.Context, id uuid.UUID) (store.ReactToMessageRow, error) {
+	r, err := s.q.ReactToMessage(ctx, id)
+	return one(r, err, func(r ReactToMessageRow) store.ReactToMessageRow {
+		return store.ReactToMessageRow(r)
+	})
+}
+
+func (s *Store) RemoveReactionFromMessage(ctx context.Context, id uuid.UUID) (store.RemoveReactionFromMessageRow, error) {
+	r, err := s.q.RemoveReactionFromMessage(ctx, id)
+	return one(r, err, func(r RemoveReactionFromMessageRow) store.RemoveReactionFromMessageRow {
+		return store.RemoveReactionFromMessageRow(r)
+	})
+}
+
+func (s *Store) CastPollVote(ctx context.Context, arg store.CastPollVoteParams) ([]uuid.UUID, error) {
+	return s.q.CastPollVote(ctx, CastPollVoteParams(arg))
+}
+
+func (s *Store) ClosePoll(ctx context.Context, arg store.ClosePollParams) (store.Poll, error) {
+	p, err := s.q.ClosePoll(ctx, ClosePollParams(arg))
+	return one(p, err, toPoll)
+}
+
+func (s *Store) GetPoll(ctx context.Context, id uuid.UUID) (store.Poll, error) {
+	p, err := s.q.GetPoll(ctx, id)
+	return one(p, err, toPoll)
+}
+
+func (s *Store) GetPollTallies(ctx context.Context, pollID uuid.UUID) ([]store.GetPollTalliesRow, error) {
+	items, err := s.q.GetPollTallies(ctx, pollID)
+	return many(items, err, func(t GetPollTalliesRow) store.GetPollTalliesRow {
+		return store.GetPollTalliesRow(t)
+	})
+}
+
+func (s *Store) GetRoomPollTallies(ctx context.Context, roomID uuid.UUID) ([]store.GetRoomPollTalliesRow, error) {
+	items, err := s.q.GetRoomPollTallies(ctx, roomID)
+	return many(items, err, func(t GetRoomPollTalliesRow) store.GetRoomPollTalliesRow {
+		return store.GetRoomPollTalliesRow(t)
+	})
+}
+
+func (s *Store) GetRoomPolls(ctx context.Context, roomID uuid.UUID) ([]store.Poll, error) {
+	items, err := s.q.GetRoomPolls(ctx, roomID)
+	return many(items, err, toPoll)
+}
+
+func (s *Store) InsertPoll(ctx context.Context, arg store.InsertPollParams) (uuid.UUID, error) {
+	return s.q.InsertPoll(ctx, InsertPollParams(arg))
+}
+
+func (s *Store) FindActiveRoomSanction(ctx context.Context, arg store.FindActiveRoomSanctionParams) (store.RoomSanction, error) {
+	r, err := s.q.FindActiveRoomSanction(ctx, FindActiveRoomSanctionParams{
+		RoomID:   arg.RoomID,
+		Kinds:    arg.Kinds,
+		AuthorID: text(arg.AuthorID),
+		Ip:       text(arg.Ip),
+	})
+	return one(r, err, toSanction)
+}
+
+func (s *Store) GetActiveRoomSanctions(ctx context.Context, arg store.GetActiveRoomSanctionsParams) ([]store.RoomSanction, error) {
+	items, err := s.q.GetActiveRoomSanctions(ctx, GetActiveRoomSanctionsParams(arg))
+	return many(items, err, toSanction)
+}
+
+func (s *Store) InsertRoomSanction(ctx context.Context, arg store.InsertRoomSanctionParams) (store.RoomSanction, error) {
+	r, err := s.q.InsertRoomSanction(ctx, InsertRoomSanctionParams{
+		RoomID:    arg.RoomID,
+		Kind:      arg.Kind,
+		AuthorID:  text(arg.AuthorID),
+		Ip:        text(arg.Ip),
+		Reason:    arg.Reason,
+		CreatedBy: arg.CreatedBy,
+		ExpiresAt: nullTimestamptz(arg.ExpiresAt),
+	})
+	return one(r, err, toSanction)
+}
+
+func (s *Store) RevokeRoomSanction(ctx context.Context, arg store.RevokeRoomSanctionParams) (store.RoomSanction, error) {
+	r, err := s.q.RevokeRoomSanction(ctx, RevokeRoomSanctionParams(arg))
+	return one(r, err, toSanction)
+}
+
+func (s *Store) GetAPIKeyByHash(ctx context.Context, keyHash string) (store.ApiKey, error) {
+	k, err := s.q.GetAPIKeyByHash(ctx, keyHash)
+	return one(k, err, toAPIKey)
+}
+
+func (s *Store) GetAPIKeys(ctx context.Context) ([]store.ApiKey, error) {
+	items, err := s.q.GetAPIKeys(ctx)
+	return many(items, err, toAPIKey)
+}
+
+func (s *Store) InsertAPIKey(ctx context.Context, arg store.InsertAPIKeyParams) (store.ApiKey, error) {
+	k, err := s.q.InsertAPIKey(ctx, InsertAPIKeyParams(arg))
+	return one(k, err, toAPIKey)
+}
+
+func (s *Store) RevokeAPIKey(ctx context.Context, id uuid.UUID) (store.ApiKey, error) {
+	k, err := s.q.RevokeAPIKey(ctx, id)
+	return one(k, err, toAPIKey)
+}
+
+func (s *Store) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	return s.q.TouchAPIKey(ctx, id)
+}
+
+func (s *Store) ClaimGuestName(ctx context.Context, arg store.ClaimGuestNameParams) (store.GuestName, error) {
+	g, err := s.q.ClaimGuestName(ctx, ClaimGuestNameParams{
+		RoomID:    arg.RoomID,
+		NameKey:   arg.NameKey,
+		Name:      arg.Name,
+		GuestID:   arg.GuestID,
+		ExpiresAt: timestamptz(arg.ExpiresAt),
+	})
+	return one(g, err, toGuestName)
+}
+
+func (s *Store) DeleteExpiredGuestNames(ctx context.Context) error {
+	return s.q.DeleteExpiredGuestNames(ctx)
+}
+
+func (s *Store) GetUser(ctx context.Context, id string) (store.User, error) {
+	u, err := s.q.GetUser(ctx, id)
+	return one(u, err, toUser)
+}
+
+func (s *Store) GetUsersByIDs(ctx context.Context, ids []string) ([]store.User, error) {
+	items, err := s.q.GetUsersByIDs(ctx, ids)
+	return many(items, err, toUser)
+}
+
+func (s *Store) UpsertUser(ctx context.Context, arg store.UpsertUserParams) error {
+	return s.q.UpsertUser(ctx, UpsertUserParams{ID: arg.ID, Name: arg.Name, AvatarUrl: text(arg.AvatarUrl)})
+}
+
+func (s *Store) GetAuditLog(ctx context.Context, arg store.GetAuditLogParams) ([]store.AuditLog, error) {
+	items, err := s.q.GetAuditLog(ctx, GetAuditLogParams{
+		Action:     text(arg.Action),
+		ActorID:    text(arg.ActorID),
+		RoomID:     nullUUID(arg.RoomID),
+		TargetID:   text(arg.TargetID),
+		Since:      nullTimestamptz(arg.Since),
+		Until:      nullTimestamptz(arg.Until),
+		BeforeID:   arg.BeforeID,
+		MaxResults: arg.MaxResults,
+	})
+	return many(items, err, toAuditLog)
+}
+
+func (s *Store) InsertAuditLogEntry(ctx context.Context, arg store.InsertAuditLogEntryParams) error {
+	return s.q.InsertAuditLogEntry(ctx, InsertAuditLogEntryParams{
+		Action:     arg.Action,
+		ActorID:    arg.ActorID,
+		Ip:         arg.Ip,
+		RequestID:  arg.RequestID,
+		RoomID:     nullUUID(arg.RoomID),
+		TargetType: arg.TargetType,
+		TargetID:   arg.TargetID,
+		Before:     arg.Before,
+		After:      arg.After,
+	})
+}
+
+func (s *Store) ClaimWebhookDeliveries(ctx context.Context, arg store.ClaimWebhookDeliveriesParams) ([]store.ClaimWebhookDeliveriesRow, error) {
+	items, err := s.q.ClaimWebhookDeliveries(ctx, ClaimWebhookDeliveriesParams(arg))
+	return many(items, err, func(d ClaimWebhookDeliveriesRow) store.ClaimWebhookDeliveriesRow {
+		return store.ClaimWebhookDeliveriesRow(d)
+	})
+}
+
+func (s *Store) DeleteWebhook(ctx context.Context, id uuid.UUID) (int64, error) {
+	return s.q.DeleteWebhook(ctx, id)
+}
+
+func (s *Store) EnqueueWebhookDeliveries(ctx context.Context, arg store.EnqueueWebhookDeliveriesParams) (int64, error) {
+	return s.q.EnqueueWebhookDeliveries(ctx, EnqueueWebhookDeliveriesParams(arg))
+}
+
+func (s *Store) GetWebhookDeliveries(ctx context.Context, arg store.GetWebhookDeliveriesParams) ([]store.WebhookDelivery, error) {
+	items, err := s.q.GetWebhookDeliveries(ctx, GetWebhookDeliveriesParams(arg))
+	return many(items, err, toDelivery)
+}
+
+func (s *Store) GetWebhooks(ctx context.Context) ([]store.Webhook, error) {
+	items, err := s.q.GetWebhooks(ctx)
+	return many(items, err, toWebhook)
+}
+
+func (s *Store) InsertWebhook(ctx context.Context, arg store.InsertWebhookParams) (store.Webhook, error) {
+	w, err := s.q.InsertWebhook(ctx, InsertWebhookParams{
+		RoomID:     nullUUID(arg.RoomID),
+		Url:        arg.Url,
+		Secret:     arg.Secret,
+		EventKinds: arg.EventKinds,
+		CreatedBy:  arg.CreatedBy,
+	})
+	return one(w, err, toWebhook)
+}
+
+func (s *Store) MarkWebhookDeliveryFailed(ctx context.Context, arg store.MarkWebhookDeliveryFailedParams) error {
+	return s.q.MarkWebhookDeliveryFailed(ctx, MarkWebhookDeliveryFailedParams{
+		Dead:           arg.Dead,
+		LastStatusCode: arg.LastStatusCode,
+		LastError:      arg.LastError,
+		NextAttemptAt:  timestamptz(arg.NextAttemptAt),
+		ID:             arg.ID,
+	})
+}
+
+func (s *Store) MarkWebhookDeliverySucceeded(ctx context.Context, arg store.MarkWebhookDeliverySucceededParams) error {
+	return s.q.MarkWebhookDeliverySucceeded(ctx, MarkWebhookDeliverySucceededParams(arg))
+}
+
+func (s *Store) RedeliverWebhookDelivery(ctx context.Context, id uuid.UUID) (store.WebhookDelivery, error) {
+	d, err := s.q.RedeliverWebhookDelivery(ctx, id)
+	return one(d, err, toDelivery)
+}
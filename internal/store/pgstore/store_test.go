@@ -0,0 +1,74 @@
+package pgstore_test
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+	"github.com/felipemacedo1/go-msg-wss/internal/store/pgstore"
+	"github.com/felipemacedo1/go-msg-wss/internal/store/storetest"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TestConformance runs the store suite against the disposable database named
+// by MSGWSS_TEST_DATABASE_URL. Every table is truncated between subtests.
+func TestConformance(t *testing.T) {
+	if storetest.Postgres(t) == nil {
+		t.Skip(storetest.DatabaseURLEnv + " is not set")
+	}
+
+	storetest.Run(t, func(t *testing.T) store.Store { return storetest.Postgres(t) })
+}
+
+// TestMigratorKeywordDSN opens the migrator with the keyword/value form of
+// MSGWSS_TEST_DATABASE_URL, which config accepts as DATABASE_URL
+func TestMigratorKeywordDSN(t *testing.T) {
+	databaseURL := os.Getenv(storetest.DatabaseURLEnv)
+	if databaseURL == "" {
+		t.Skip(storetest.DatabaseURLEnv + " is not set")
+	}
+	// Migrate once through the shared helper, so that status has a version
+	storetest.Postgres(t)
+
+	cfg, err := pgx.ParseConfig(databaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sslMode := "prefer"
+	if u, err := url.Parse(databaseURL); err == nil && u.Query().Get("sslmode") != "" {
+		sslMode = u.Query().Get("sslmode")
+	}
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password='%s' dbname=%s sslmode=%s",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Database, sslMode)
+
+	m, err := pgstore.NewMigrator(dsn)
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+	defer m.Close()
+
+	version, dirty, err := m.Version()
+	if err != nil || dirty || version != pgstore.SchemaVersion() {
+		t.Errorf("Version() = %d, %v, %v, want %d", version, dirty, err, pgstore.SchemaVersion())
+	}
+}
+
+func TestMigratorDSNForms(t *testing.T) {
+	if _, err := pgstore.NewMigrator("host=db port=notaport"); err == nil || !strings.Contains(err.Error(), "connection string") {
+		t.Errorf("NewMigrator() of an invalid DSN error = %v, want it rejected", err)
+	}
+
+	// Nothing listens on port 1, so a parsed DSN fails when connecting
+	for _, dsn := range []string{
+		"host=127.0.0.1 port=1 user=app dbname=msgwss connect_timeout=1",
+		"postgres://app@127.0.0.1:1/msgwss?connect_timeout=1",
+	} {
+		if _, err := pgstore.NewMigrator(dsn); err == nil || strings.Contains(err.Error(), "connection string") {
+			t.Errorf("NewMigrator(%q) error = %v, want a connection error", dsn, err)
+		}
+	}
+}
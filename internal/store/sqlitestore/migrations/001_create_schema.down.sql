@@ -0,0 +1,29 @@
+DROP TRIGGER IF EXISTS audit_log_no_delete;
+
+DROP TRIGGER IF EXISTS audit_log_no_update;
+
+DROP TABLE IF EXISTS audit_log;
+
+DROP TABLE IF EXISTS guest_names;
+
+DROP TABLE IF EXISTS api_keys;
+
+DROP TABLE IF EXISTS webhook_deliveries;
+
+DROP TABLE IF EXISTS webhooks;
+
+DROP TABLE IF EXISTS poll_votes;
+
+DROP TABLE IF EXISTS poll_ballots;
+
+DROP TABLE IF EXISTS poll_options;
+
+DROP TABLE IF EXISTS polls;
+
+DROP TABLE IF EXISTS room_sanctions;
+
+DROP TABLE IF EXISTS messages;
+
+DROP TABLE IF EXISTS users;
+
+DROP TABLE IF EXISTS rooms;
@@ -0,0 +1,178 @@
+-- 001_create_schema.up.sql
+--
+-- SQLite has no uuid, timestamptz, array or jsonb types:
+--  * ids are random version 4 UUIDs in canonical text form
+--  * timestamps are UTC ISO 8601 text with milliseconds, which sorts and
+--    compares in time order
+--  * arrays and JSON documents are JSON text
+
+CREATE TABLE IF NOT EXISTS rooms (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+    theme TEXT NOT NULL,
+    allow_guests BOOLEAN NOT NULL DEFAULT TRUE,
+    anonymous_policy TEXT NOT NULL DEFAULT 'named' CHECK (anonymous_policy IN ('named', 'allowed', 'only'))
+);
+
+CREATE TABLE IF NOT EXISTS users (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    avatar_url TEXT,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS messages (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+    room_id TEXT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    message TEXT NOT NULL,
+    reaction_count INTEGER NOT NULL DEFAULT 0,
+    answered BOOLEAN NOT NULL DEFAULT FALSE,
+    author_id TEXT NOT NULL DEFAULT 'guest',
+    author_name TEXT NOT NULL DEFAULT 'Guest',
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    is_bot BOOLEAN NOT NULL DEFAULT FALSE,
+    anonymous BOOLEAN NOT NULL DEFAULT FALSE,
+    user_id TEXT REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS messages_room_id_created_at_idx ON messages (room_id, created_at, id);
+CREATE INDEX IF NOT EXISTS messages_user_id_created_at_idx ON messages (user_id, created_at);
+
+CREATE TABLE IF NOT EXISTS room_sanctions (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+    room_id TEXT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('ban', 'mute')),
+    author_id TEXT,
+    ip TEXT,
+    reason TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    expires_at TEXT,
+    revoked_at TEXT,
+    CHECK (author_id IS NOT NULL OR ip IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS room_sanctions_room_id_idx ON room_sanctions (room_id, kind);
+
+CREATE TABLE IF NOT EXISTS polls (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+    room_id TEXT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    question TEXT NOT NULL,
+    multiple_choice BOOLEAN NOT NULL DEFAULT FALSE,
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'closed')),
+    created_by TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    closed_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS polls_room_id_idx ON polls (room_id);
+
+CREATE TABLE IF NOT EXISTS poll_options (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+    poll_id TEXT NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    label TEXT NOT NULL,
+    position INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS poll_options_poll_id_idx ON poll_options (poll_id);
+
+CREATE TABLE IF NOT EXISTS poll_ballots (
+    poll_id TEXT NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    voter_id TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    PRIMARY KEY (poll_id, voter_id)
+);
+
+CREATE TABLE IF NOT EXISTS poll_votes (
+    poll_id TEXT NOT NULL,
+    option_id TEXT NOT NULL REFERENCES poll_options(id) ON DELETE CASCADE,
+    voter_id TEXT NOT NULL,
+    PRIMARY KEY (option_id, voter_id),
+    FOREIGN KEY (poll_id, voter_id) REFERENCES poll_ballots(poll_id, voter_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+    -- NULL subscribes to events of every room
+    room_id TEXT REFERENCES rooms(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    -- JSON array, empty subscribes to every event kind
+    event_kinds TEXT NOT NULL DEFAULT '[]',
+    created_by TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+    webhook_id TEXT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_kind TEXT NOT NULL,
+    room_id TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    last_status_code INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    delivered_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_status_idx ON webhook_deliveries (status, created_at);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+    name TEXT NOT NULL,
+    -- First characters of the key, kept to tell keys apart in listings
+    prefix TEXT NOT NULL,
+    -- Hex encoded SHA-256 of the full key; the key itself is never stored
+    key_hash TEXT NOT NULL UNIQUE,
+    -- JSON array of room ids, empty allows every room
+    room_ids TEXT NOT NULL DEFAULT '[]',
+    -- JSON array
+    permissions TEXT NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    last_used_at TEXT,
+    revoked_at TEXT
+);
+
+CREATE TABLE IF NOT EXISTS guest_names (
+    room_id TEXT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    -- Lower cased name used to detect duplicates
+    name_key TEXT NOT NULL,
+    name TEXT NOT NULL,
+    guest_id TEXT NOT NULL,
+    expires_at TEXT NOT NULL,
+    PRIMARY KEY (room_id, name_key)
+);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    action TEXT NOT NULL,
+    actor_id TEXT NOT NULL,
+    ip TEXT NOT NULL,
+    request_id TEXT NOT NULL,
+    -- NULL for actions outside a room, e.g. api keys and webhooks
+    room_id TEXT,
+    target_type TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    before TEXT,
+    after TEXT,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_room_id_idx ON audit_log (room_id, id);
+CREATE INDEX IF NOT EXISTS audit_log_actor_id_idx ON audit_log (actor_id, id);
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
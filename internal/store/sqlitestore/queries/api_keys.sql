@@ -0,0 +1,35 @@
+-- name: InsertAPIKey :one
+INSERT INTO api_keys
+    ( "name", "prefix", "key_hash", "room_ids", "permissions", "created_by" ) VALUES
+    ( ?, ?, ?, ?, ?, ? )
+RETURNING "id", "name", "prefix", "key_hash", "room_ids", "permissions", "created_by", "created_at", "last_used_at", "revoked_at";
+
+-- name: GetAPIKeyByHash :one
+SELECT
+    "id", "name", "prefix", "key_hash", "room_ids", "permissions", "created_by", "created_at", "last_used_at", "revoked_at"
+FROM api_keys
+WHERE
+    key_hash = ?
+    AND revoked_at IS NULL;
+
+-- name: GetAPIKeys :many
+SELECT
+    "id", "name", "prefix", "key_hash", "room_ids", "permissions", "created_by", "created_at", "last_used_at", "revoked_at"
+FROM api_keys
+ORDER BY created_at ASC;
+
+-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET
+    revoked_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now')
+WHERE
+    id = ?
+    AND revoked_at IS NULL
+RETURNING "id", "name", "prefix", "key_hash", "room_ids", "permissions", "created_by", "created_at", "last_used_at", "revoked_at";
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET
+    last_used_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now')
+WHERE
+    id = ?;
@@ -0,0 +1,76 @@
+-- name: InsertPoll :one
+INSERT INTO polls
+    ( "room_id", "question", "multiple_choice", "created_by" ) VALUES
+    ( ?, ?, ?, ? )
+RETURNING "id";
+
+-- name: InsertPollOption :exec
+INSERT INTO poll_options
+    ( "poll_id", "label", "position" ) VALUES
+    ( ?, ?, ? );
+
+-- name: GetPoll :one
+SELECT
+    "id", "room_id", "question", "multiple_choice", "status", "created_by", "created_at", "closed_at"
+FROM polls
+WHERE
+    id = ?;
+
+-- name: GetRoomPolls :many
+SELECT
+    "id", "room_id", "question", "multiple_choice", "status", "created_by", "created_at", "closed_at"
+FROM polls
+WHERE
+    room_id = ?
+ORDER BY created_at ASC;
+
+-- name: GetPollTallies :many
+SELECT
+    o."id", o."poll_id", o."label", o."position", COUNT(v."voter_id") AS votes
+FROM poll_options o
+LEFT JOIN poll_votes v ON v.option_id = o.id
+WHERE
+    o.poll_id = ?
+GROUP BY o.id
+ORDER BY o.position ASC;
+
+-- name: GetRoomPollTallies :many
+SELECT
+    o."id", o."poll_id", o."label", o."position", COUNT(v."voter_id") AS votes
+FROM poll_options o
+JOIN polls p ON p.id = o.poll_id
+LEFT JOIN poll_votes v ON v.option_id = o.id
+WHERE
+    p.room_id = ?
+GROUP BY o.id
+ORDER BY o.poll_id, o.position ASC;
+
+-- name: InsertPollBallot :execrows
+-- Inserts nothing when the poll is closed or the voter already voted
+INSERT INTO poll_ballots
+    ( "poll_id", "voter_id" )
+SELECT id, sqlc.arg(voter_id) FROM polls
+WHERE id = sqlc.arg(poll_id) AND status = 'open'
+ON CONFLICT DO NOTHING;
+
+-- name: InsertPollVotes :many
+-- option_ids is a JSON array
+INSERT INTO poll_votes
+    ( "poll_id", "option_id", "voter_id" )
+SELECT o.poll_id, o.id, sqlc.arg(voter_id)
+FROM poll_options o
+WHERE
+    o.poll_id = sqlc.arg(poll_id)
+    AND o.id IN (SELECT value FROM json_each(sqlc.arg(option_ids)))
+RETURNING "option_id";
+
+-- name: ClosePoll :one
+UPDATE polls
+SET
+    status = 'closed',
+    closed_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now')
+WHERE
+    id = ?
+    AND room_id = ?
+    AND status = 'open'
+RETURNING "id", "room_id", "question", "multiple_choice", "status", "created_by", "created_at", "closed_at";
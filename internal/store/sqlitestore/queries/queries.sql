@@ -0,0 +1,109 @@
+-- name: GetRoom :one
+SELECT
+    "id", "theme", "allow_guests", "anonymous_policy"
+FROM rooms
+WHERE id = ?;
+
+-- name: GetRooms :many
+SELECT
+    "id", "theme", "allow_guests", "anonymous_policy"
+FROM rooms;
+
+-- name: InsertRoom :one
+INSERT INTO rooms
+    ( "theme", "allow_guests", "anonymous_policy" ) VALUES
+    ( ?, ?, ? )
+RETURNING "id";
+
+-- name: SetRoomAllowGuests :one
+UPDATE rooms
+SET
+    allow_guests = sqlc.arg(allow_guests)
+WHERE
+    id = sqlc.arg(id)
+RETURNING "id", "theme", "allow_guests", "anonymous_policy";
+
+-- name: GetMessage :one
+SELECT
+    "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "hidden", "is_bot", "anonymous", "user_id"
+FROM messages
+WHERE
+    id = ?;
+
+-- name: GetRoomMessages :many
+SELECT
+    "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "hidden", "is_bot", "anonymous", "user_id"
+FROM messages
+WHERE
+    room_id = sqlc.arg(room_id)
+    AND (CAST(sqlc.arg(include_hidden) AS BOOLEAN) OR NOT hidden);
+
+-- name: InsertMessage :one
+INSERT INTO messages
+    ( "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "is_bot", "anonymous", "user_id" ) VALUES
+    ( ?, ?, 0, false, ?, ?, strftime('%Y-%m-%dT%H:%M:%fZ', 'now'), ?, ?, ? )
+RETURNING "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "hidden", "is_bot", "anonymous", "user_id";
+
+-- name: ReactToMessage :one
+-- A single statement, so concurrent reactions never lose an increment
+UPDATE messages
+SET
+    reaction_count = reaction_count + 1
+WHERE
+    id = ?
+RETURNING reaction_count;
+
+-- name: RemoveReactionFromMessage :one
+UPDATE messages
+SET
+    reaction_count = max(0, reaction_count - 1)
+WHERE
+    id = ?
+RETURNING reaction_count;
+
+-- name: MarkMessageAsAnswered :exec
+UPDATE messages
+SET
+    answered = true
+WHERE
+    id = ?;
+
+-- name: GetRoomHotMessages :many
+-- julianday differences are in days, hence the hours conversion
+SELECT
+    "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "hidden", "is_bot", "anonymous", "user_id"
+FROM messages
+WHERE
+    room_id = sqlc.arg(room_id)
+    AND NOT hidden
+    AND (CAST(sqlc.arg(include_answered) AS BOOLEAN) OR NOT answered)
+ORDER BY
+    (reaction_count + 1) / pow((julianday('now') - julianday(created_at)) * 24 + 2, CAST(sqlc.arg(gravity) AS REAL)) DESC,
+    created_at DESC
+LIMIT CASE WHEN CAST(sqlc.arg(max_results) AS INTEGER) > 0 THEN sqlc.arg(max_results) ELSE -1 END;
+
+-- name: GetRoomMessagesPage :many
+SELECT
+    "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "hidden", "is_bot", "anonymous", "user_id"
+FROM messages
+WHERE
+    room_id = sqlc.arg(room_id)
+    AND (CAST(sqlc.arg(include_hidden) AS BOOLEAN) OR NOT hidden)
+    AND (created_at, id) > (sqlc.arg(after_created_at), sqlc.arg(after_id))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(page_size);
+
+-- name: SetMessageHidden :exec
+UPDATE messages
+SET
+    hidden = sqlc.arg(hidden)
+WHERE
+    id = sqlc.arg(id);
+
+-- name: SetRoomAnonymousPolicy :one
+UPDATE rooms
+SET
+    anonymous_policy = sqlc.arg(anonymous_policy)
+WHERE
+    id = sqlc.arg(id)
+RETURNING "id", "theme", "allow_guests", "anonymous_policy";
@@ -0,0 +1,41 @@
+-- name: InsertRoomSanction :one
+INSERT INTO room_sanctions
+    ( "room_id", "kind", "author_id", "ip", "reason", "created_by", "expires_at" ) VALUES
+    ( ?, ?, ?, ?, ?, ?, ? )
+RETURNING "id", "room_id", "kind", "author_id", "ip", "reason", "created_by", "created_at", "expires_at", "revoked_at";
+
+-- name: GetActiveRoomSanctions :many
+SELECT
+    "id", "room_id", "kind", "author_id", "ip", "reason", "created_by", "created_at", "expires_at", "revoked_at"
+FROM room_sanctions
+WHERE
+    room_id = ?
+    AND kind = ?
+    AND revoked_at IS NULL
+    AND (expires_at IS NULL OR expires_at > strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+ORDER BY created_at DESC;
+
+-- name: FindActiveRoomSanction :one
+-- kinds is a JSON array
+SELECT
+    "id", "room_id", "kind", "author_id", "ip", "reason", "created_by", "created_at", "expires_at", "revoked_at"
+FROM room_sanctions
+WHERE
+    room_id = sqlc.arg(room_id)
+    AND kind IN (SELECT value FROM json_each(sqlc.arg(kinds)))
+    AND revoked_at IS NULL
+    AND (expires_at IS NULL OR expires_at > strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+    AND (author_id = sqlc.arg(author_id) OR ip = sqlc.arg(ip))
+ORDER BY kind ASC, expires_at DESC NULLS FIRST
+LIMIT 1;
+
+-- name: RevokeRoomSanction :one
+UPDATE room_sanctions
+SET
+    revoked_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now')
+WHERE
+    id = ?
+    AND room_id = ?
+    AND kind = ?
+    AND revoked_at IS NULL
+RETURNING "id", "room_id", "kind", "author_id", "ip", "reason", "created_by", "created_at", "expires_at", "revoked_at";
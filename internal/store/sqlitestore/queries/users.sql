@@ -0,0 +1,38 @@
+-- name: UpsertUser :exec
+INSERT INTO users
+    ( "id", "name", "avatar_url" ) VALUES
+    ( ?, ?, ? )
+ON CONFLICT (id) DO UPDATE
+SET
+    name = excluded.name,
+    avatar_url = excluded.avatar_url,
+    updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now')
+WHERE
+    users.name IS NOT excluded.name
+    OR users.avatar_url IS NOT excluded.avatar_url;
+
+-- name: GetUser :one
+SELECT
+    "id", "name", "avatar_url", "created_at", "updated_at"
+FROM users
+WHERE id = ?;
+
+-- name: GetUsersByIDs :many
+-- ids is a JSON array
+SELECT
+    "id", "name", "avatar_url", "created_at", "updated_at"
+FROM users
+WHERE id IN (SELECT value FROM json_each(sqlc.arg(ids)));
+
+-- name: GetUserMessages :many
+-- Hidden messages are never attributed to their author, anonymous ones only
+-- when the author asks for their own
+SELECT
+    "id", "room_id", "message", "reaction_count", "answered", "author_id", "author_name", "created_at", "hidden", "is_bot", "anonymous", "user_id"
+FROM messages
+WHERE
+    user_id = sqlc.arg(user_id)
+    AND (CAST(sqlc.arg(include_anonymous) AS BOOLEAN) OR NOT anonymous)
+    AND NOT hidden
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_results);
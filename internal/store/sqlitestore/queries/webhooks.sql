@@ -0,0 +1,84 @@
+-- name: InsertWebhook :one
+INSERT INTO webhooks
+    ( "room_id", "url", "secret", "event_kinds", "created_by" ) VALUES
+    ( ?, ?, ?, ?, ? )
+RETURNING "id", "room_id", "url", "secret", "event_kinds", "created_by", "created_at";
+
+-- name: GetWebhooks :many
+SELECT
+    "id", "room_id", "url", "secret", "event_kinds", "created_by", "created_at"
+FROM webhooks
+ORDER BY created_at ASC;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE
+    id = ?;
+
+-- name: EnqueueWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries
+    ( "webhook_id", "event_kind", "room_id", "payload" )
+SELECT
+    id, sqlc.arg(event_kind), sqlc.arg(room_id), sqlc.arg(payload)
+FROM webhooks
+WHERE
+    (room_id IS NULL OR room_id = sqlc.arg(room_id))
+    AND (json_array_length(event_kinds) = 0 OR sqlc.arg(event_kind) IN (SELECT value FROM json_each(event_kinds)));
+
+-- name: ClaimWebhookDeliveries :many
+-- SQLite has a single writer, so the update alone keeps two workers from
+-- claiming the same delivery
+UPDATE webhook_deliveries
+SET
+    next_attempt_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now', sqlc.arg(lease_seconds) || ' seconds')
+WHERE
+    id IN (
+        SELECT id FROM webhook_deliveries
+        WHERE status = 'pending' AND next_attempt_at <= strftime('%Y-%m-%dT%H:%M:%fZ', 'now')
+        ORDER BY next_attempt_at ASC
+        LIMIT sqlc.arg(batch_size)
+    )
+RETURNING "id", "webhook_id", "event_kind", "room_id", "payload", "attempts",
+    (SELECT w."url" FROM webhooks w WHERE w.id = webhook_id) AS url,
+    (SELECT w."secret" FROM webhooks w WHERE w.id = webhook_id) AS secret;
+
+-- name: MarkWebhookDeliverySucceeded :exec
+UPDATE webhook_deliveries
+SET
+    status = 'delivered',
+    attempts = attempts + 1,
+    last_status_code = sqlc.arg(last_status_code),
+    last_error = '',
+    delivered_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now')
+WHERE
+    id = sqlc.arg(id);
+
+-- name: MarkWebhookDeliveryFailed :exec
+UPDATE webhook_deliveries
+SET
+    status = CASE WHEN CAST(sqlc.arg(dead) AS BOOLEAN) THEN 'dead' ELSE 'pending' END,
+    attempts = attempts + 1,
+    last_status_code = sqlc.arg(last_status_code),
+    last_error = sqlc.arg(last_error),
+    next_attempt_at = sqlc.arg(next_attempt_at)
+WHERE
+    id = sqlc.arg(id);
+
+-- name: GetWebhookDeliveries :many
+SELECT
+    "id", "webhook_id", "event_kind", "room_id", "payload", "status", "attempts", "next_attempt_at", "last_status_code", "last_error", "created_at", "delivered_at"
+FROM webhook_deliveries
+WHERE
+    status = ?
+ORDER BY created_at DESC
+LIMIT ?;
+
+-- name: RedeliverWebhookDelivery :one
+UPDATE webhook_deliveries
+SET
+    status = 'pending',
+    attempts = 0,
+    next_attempt_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now')
+WHERE
+    id = ?
+RETURNING "id", "webhook_id", "event_kind", "room_id", "payload", "status", "attempts", "next_attempt_at", "last_status_code", "last_error", "created_at", "delivered_at";
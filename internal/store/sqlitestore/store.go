@@ -0,0 +1,616 @@
+// Package sqlitestore implements store.Store on a SQLite database file, for
+// demos and small installs that do not want to run Postgres. The queries in
+// queries/ mirror those of pgstore; sqlc generates the code for them with
+// sqlc.yaml, and Store converts the rows to the store models.
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+var _ store.Store = (*Store)(nil)
+
+// Store wraps the generated queries. Queries that take several statements in
+// SQLite, such as inserting a poll with its options, run in a transaction.
+type Store struct {
+	db *sql.DB
+	q  *Queries
+}
+
+// NewStore returns a store on a database opened with Open
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db, q: New(db)}
+}
+
+// inTx runs fn with queries bound to a transaction, committing when it
+// returns no error
+func (s *Store) inTx(ctx context.Context, fn func(q *Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(s.q.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// notFound turns sql.ErrNoRows into store.ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+func convert[S, D any](items []S, fn func(S) D) []D {
+	if items == nil {
+		return nil
+	}
+	out := make([]D, len(items))
+	for i, item := range items {
+		out[i] = fn(item)
+	}
+	return out
+}
+
+// one converts the row of a :one query, keeping the error
+func one[S, D any](item S, err error, fn func(S) D) (D, error) {
+	if err != nil {
+		var zero D
+		return zero, notFound(err)
+	}
+	return fn(item), nil
+}
+
+// many converts the rows of a :many query
+func many[S, D any](items []S, err error, fn func(S) D) ([]D, error) {
+	if err != nil {
+		return nil, err
+	}
+	return convert(items, fn), nil
+}
+
+func timestamp(t time.Time) Timestamp {
+	return Timestamp{Time: t, Valid: true}
+}
+
+func nullTimestamp(t *time.Time) Timestamp {
+	if t == nil {
+		return Timestamp{}
+	}
+	return timestamp(*t)
+}
+
+func timePtr(t Timestamp) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// jsonText stores a JSON document, nil being NULL
+func jsonText(doc []byte) *string {
+	if doc == nil {
+		return nil
+	}
+	s := string(doc)
+	return &s
+}
+
+func jsonBytes(s *string) []byte {
+	if s == nil {
+		return nil
+	}
+	return []byte(*s)
+}
+
+func toRoom(r Room) store.Room {
+	return store.Room(r)
+}
+
+func toMessage(m Message) store.Message {
+	return store.Message{
+		ID:            m.ID,
+		RoomID:        m.RoomID,
+		Message:       m.Message,
+		ReactionCount: m.ReactionCount,
+		Answered:      m.Answered,
+		AuthorID:      m.AuthorID,
+		AuthorName:    m.AuthorName,
+		CreatedAt:     m.CreatedAt.Time,
+		Hidden:        m.Hidden,
+		IsBot:         m.IsBot,
+		Anonymous:     m.Anonymous,
+		UserID:        m.UserID,
+	}
+}
+
+func toPoll(p Poll) store.Poll {
+	return store.Poll{
+		ID:             p.ID,
+		RoomID:         p.RoomID,
+		Question:       p.Question,
+		MultipleChoice: p.MultipleChoice,
+		Status:         p.Status,
+		CreatedBy:      p.CreatedBy,
+		CreatedAt:      p.CreatedAt.Time,
+		ClosedAt:       timePtr(p.ClosedAt),
+	}
+}
+
+func toSanction(r RoomSanction) store.RoomSanction {
+	return store.RoomSanction{
+		ID:        r.ID,
+		RoomID:    r.RoomID,
+		Kind:      r.Kind,
+		AuthorID:  r.AuthorID,
+		Ip:        r.Ip,
+		Reason:    r.Reason,
+		CreatedBy: r.CreatedBy,
+		CreatedAt: r.CreatedAt.Time,
+		ExpiresAt: timePtr(r.ExpiresAt),
+		RevokedAt: timePtr(r.RevokedAt),
+	}
+}
+
+func toAPIKey(k ApiKey) store.ApiKey {
+	return store.ApiKey{
+		ID:          k.ID,
+		Name:        k.Name,
+		Prefix:      k.Prefix,
+		KeyHash:     k.KeyHash,
+		RoomIds:     k.RoomIds,
+		Permissions: k.Permissions,
+		CreatedBy:   k.CreatedBy,
+		CreatedAt:   k.CreatedAt.Time,
+		LastUsedAt:  timePtr(k.LastUsedAt),
+		RevokedAt:   timePtr(k.RevokedAt),
+	}
+}
+
+func toGuestName(g GuestName) store.GuestName {
+	return store.GuestName{
+		RoomID:    g.RoomID,
+		NameKey:   g.NameKey,
+		Name:      g.Name,
+		GuestID:   g.GuestID,
+		ExpiresAt: g.ExpiresAt.Time,
+	}
+}
+
+func toUser(u User) store.User {
+	return store.User{
+		ID:        u.ID,
+		Name:      u.Name,
+		AvatarUrl: u.AvatarUrl,
+		CreatedAt: u.CreatedAt.Time,
+		UpdatedAt: u.UpdatedAt.Time,
+	}
+}
+
+func toAuditLog(a AuditLog) store.AuditLog {
+	return store.AuditLog{
+		ID:         a.ID,
+		Action:     a.Action,
+		ActorID:    a.ActorID,
+		Ip:         a.Ip,
+		RequestID:  a.RequestID,
+		RoomID:     a.RoomID,
+		TargetType: a.TargetType,
+		TargetID:   a.TargetID,
+		Before:     jsonBytes(a.Before),
+		After:      jsonBytes(a.After),
+		CreatedAt:  a.CreatedAt.Time,
+	}
+}
+
+func toWebhook(w Webhook) store.Webhook {
+	return store.Webhook{
+		ID:         w.ID,
+		RoomID:     w.RoomID,
+		Url:        w.Url,
+		Secret:     w.Secret,
+		EventKinds: w.EventKinds,
+		CreatedBy:  w.CreatedBy,
+		CreatedAt:  w.CreatedAt.Time,
+	}
+}
+
+func toDelivery(d WebhookDelivery) store.WebhookDelivery {
+	return store.WebhookDelivery{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		EventKind:      d.EventKind,
+		RoomID:         d.RoomID,
+		Payload:        []byte(d.Payload),
+		Status:         d.Status,
+		Attempts:       int32(d.Attempts),
+		NextAttemptAt:  d.NextAttemptAt.Time,
+		LastStatusCode: int32(d.LastStatusCode),
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt.Time,
+		DeliveredAt:    timePtr(d.DeliveredAt),
+	}
+}
+
+func (s *Store) GetRoom(ctx context.Context, id uuid.UUID) (store.Room, error) {
+	r, err := s.q.GetRoom(ctx, id)
+	return one(r, err, toRoom)
+}
+
+func (s *Store) GetRooms(ctx context.Context) ([]store.Room, error) {
+	items, err := s.q.GetRooms(ctx)
+	return many(items, err, toRoom)
+}
+
+func (s *Store) InsertRoom(ctx context.Context, arg store.InsertRoomParams) (uuid.UUID, error) {
+	return s.q.InsertRoom(ctx, InsertRoomParams(arg))
+}
+
+func (s *Store) SetRoomAllowGuests(ctx context.Context, arg store.SetRoomAllowGuestsParams) (store.Room, error) {
+	r, err := s.q.SetRoomAllowGuests(ctx, SetRoomAllowGuestsParams{AllowGuests: arg.AllowGuests, ID: arg.ID})
+	return one(r, err, toRoom)
+}
+
+func (s *Store) SetRoomAnonymousPolicy(ctx context.Context, arg store.SetRoomAnonymousPolicyParams) (store.Room, error) {
+	r, err := s.q.SetRoomAnonymousPolicy(ctx, SetRoomAnonymousPolicyParams{AnonymousPolicy: arg.AnonymousPolicy, ID: arg.ID})
+	return one(r, err, toRoom)
+}
+
+func (s *Store) GetMessage(ctx context.Context, id uuid.UUID) (store.Message, error) {
+	m, err := s.q.GetMessage(ctx, id)
+	return one(m, err, toMessage)
+}
+
+func (s *Store) GetRoomHotMessages(ctx context.Context, arg store.GetRoomHotMessagesParams) ([]store.Message, error) {
+	items, err := s.q.GetRoomHotMessages(ctx, GetRoomHotMessagesParams{
+		RoomID:          arg.RoomID,
+		IncludeAnswered: arg.IncludeAnswered,
+		Gravity:         arg.Gravity,
+		MaxResults:      int64(arg.MaxResults),
+	})
+	return many(items, err, toMessage)
+}
+
+func (s *Store) GetRoomMessages(ctx context.Context, arg store.GetRoomMessagesParams) ([]store.Message, error) {
+	items, err := s.q.GetRoomMessages(ctx, GetRoomMessagesParams(arg))
+	return many(items, err, toMessage)
+}
+
+func (s *Store) GetRoomMessagesPage(ctx context.Context, arg store.GetRoomMessagesPageParams) ([]store.Message, error) {
+	items, err := s.q.GetRoomMessagesPage(ctx, GetRoomMessagesPageParams{
+		RoomID:         arg.RoomID,
+		IncludeHidden:  arg.IncludeHidden,
+		AfterCreatedAt: timestamp(arg.AfterCreatedAt),
+		AfterID:        arg.AfterID,
+		PageSize:       int64(arg.PageSize),
+	})
+	return many(items, err, toMessage)
+}
+
+func (s *Store) GetUserMessages(ctx context.Context, arg store.GetUserMessagesParams) ([]store.Message, error) {
+	items, err := s.q.GetUserMessages(ctx, GetUserMessagesParams{
+		UserID:           &arg.UserID,
+		IncludeAnonymous: arg.IncludeAnonymous,
+		MaxResults:       int64(arg.MaxResults),
+	})
+	return many(items, err, toMessage)
+}
+
+func (s *Store) InsertMessage(ctx context.Context, arg store.InsertMessageParams) (store.Message, error) {
+	m, err := s.q.InsertMessage(ctx, InsertMessageParams(arg))
+	return one(m, err, toMessage)
+}
+
+func (s *Store) MarkMessageAsAnswered(ctx context.Context, id uuid.UUID) error {
+	return s.q.MarkMessageAsAnswered(ctx, id)
+}
+
+func (s *Store) SetMessageHidden(ctx context.Context, arg store.SetMessageHiddenParams) error {
+	return s.q.SetMessageHidden(ctx, SetMessageHiddenParams{Hidden: arg.Hidden, ID: arg.ID})
+}
+
+// ReactToMessage and RemoveReactionFromMessage read the previous count in the
+// transaction, since RETURNING only sees the updated row in SQLite
+func (s *Store) ReactToMessage(ctx context.Context, id uuid.UUID) (store.ReactToMessageRow, error) {
+	var row store.ReactToMessageRow
+	err := s.inTx(ctx, func(q *Queries) error {
+		m, err := q.GetMessage(ctx, id)
+		if err != nil {
+			return err
+		}
+		row.PreviousCount = m.ReactionCount
+		row.ReactionCount, err = q.ReactToMessage(ctx, id)
+		return err
+	})
+	return row, notFound(err)
+}
+
+func (s *Store) RemoveReactionFromMessage(ctx context.Context, id uuid.UUID) (store.RemoveReactionFromMessageRow, error) {
+	var row store.RemoveReactionFromMessageRow
+	err := s.inTx(ctx, func(q *Queries) error {
+		m, err := q.GetMessage(ctx, id)
+		if err != nil {
+			return err
+		}
+		row.PreviousCount = m.ReactionCount
+		row.ReactionCount, err = q.RemoveReactionFromMessage(ctx, id)
+		return err
+	})
+	return row, notFound(err)
+}
+
+// CastPollVote records the ballot first, so a voter who already voted or a
+// closed poll records no options
+func (s *Store) CastPollVote(ctx context.Context, arg store.CastPollVoteParams) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := s.inTx(ctx, func(q *Queries) error {
+		n, err := q.InsertPollBallot(ctx, InsertPollBallotParams{VoterID: arg.VoterID, PollID: arg.PollID})
+		if err != nil || n == 0 {
+			return err
+		}
+		ids, err = q.InsertPollVotes(ctx, InsertPollVotesParams{VoterID: arg.VoterID, PollID: arg.PollID, OptionIds: arg.OptionIds})
+		return err
+	})
+	return ids, err
+}
+
+func (s *Store) ClosePoll(ctx context.Context, arg store.ClosePollParams) (store.Poll, error) {
+	p, err := s.q.ClosePoll(ctx, ClosePollParams(arg))
+	return one(p, err, toPoll)
+}
+
+func (s *Store) GetPoll(ctx context.Context, id uuid.UUID) (store.Poll, error) {
+	p, err := s.q.GetPoll(ctx, id)
+	return one(p, err, toPoll)
+}
+
+func (s *Store) GetPollTallies(ctx context.Context, pollID uuid.UUID) ([]store.GetPollTalliesRow, error) {
+	items, err := s.q.GetPollTallies(ctx, pollID)
+	return many(items, err, func(t GetPollTalliesRow) store.GetPollTalliesRow {
+		return store.GetPollTalliesRow{ID: t.ID, PollID: t.PollID, Label: t.Label, Position: int32(t.Position), Votes: t.Votes}
+	})
+}
+
+func (s *Store) GetRoomPollTallies(ctx context.Context, roomID uuid.UUID) ([]store.GetRoomPollTalliesRow, error) {
+	items, err := s.q.GetRoomPollTallies(ctx, roomID)
+	return many(items, err, func(t GetRoomPollTalliesRow) store.GetRoomPollTalliesRow {
+		return store.GetRoomPollTalliesRow{ID: t.ID, PollID: t.PollID, Label: t.Label, Position: int32(t.Position), Votes: t.Votes}
+	})
+}
+
+func (s *Store) GetRoomPolls(ctx context.Context, roomID uuid.UUID) ([]store.Poll, error) {
+	items, err := s.q.GetRoomPolls(ctx, roomID)
+	return many(items, err, toPoll)
+}
+
+// InsertPoll inserts the poll and its options, numbered from 1 in the order
+// given
+func (s *Store) InsertPoll(ctx context.Context, arg store.InsertPollParams) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := s.inTx(ctx, func(q *Queries) error {
+		var err error
+		id, err = q.InsertPoll(ctx, InsertPollParams{
+			RoomID:         arg.RoomID,
+			Question:       arg.Question,
+			MultipleChoice: arg.MultipleChoice,
+			CreatedBy:      arg.CreatedBy,
+		})
+		if err != nil {
+			return err
+		}
+		for i, label := range arg.Options {
+			err := q.InsertPollOption(ctx, InsertPollOptionParams{PollID: id, Label: label, Position: int64(i + 1)})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return id, err
+}
+
+func (s *Store) FindActiveRoomSanction(ctx context.Context, arg store.FindActiveRoomSanctionParams) (store.RoomSanction, error) {
+	r, err := s.q.FindActiveRoomSanction(ctx, FindActiveRoomSanctionParams{
+		RoomID:   arg.RoomID,
+		Kinds:    arg.Kinds,
+		AuthorID: arg.AuthorID,
+		Ip:       arg.Ip,
+	})
+	return one(r, err, toSanction)
+}
+
+func (s *Store) GetActiveRoomSanctions(ctx context.Context, arg store.GetActiveRoomSanctionsParams) ([]store.RoomSanction, error) {
+	items, err := s.q.GetActiveRoomSanctions(ctx, GetActiveRoomSanctionsParams(arg))
+	return many(items, err, toSanction)
+}
+
+func (s *Store) InsertRoomSanction(ctx context.Context, arg store.InsertRoomSanctionParams) (store.RoomSanction, error) {
+	r, err := s.q.InsertRoomSanction(ctx, InsertRoomSanctionParams{
+		RoomID:    arg.RoomID,
+		Kind:      arg.Kind,
+		AuthorID:  arg.AuthorID,
+		Ip:        arg.Ip,
+		Reason:    arg.Reason,
+		CreatedBy: arg.CreatedBy,
+		ExpiresAt: nullTimestamp(arg.ExpiresAt),
+	})
+	return one(r, err, toSanction)
+}
+
+func (s *Store) RevokeRoomSanction(ctx context.Context, arg store.RevokeRoomSanctionParams) (store.RoomSanction, error) {
+	r, err := s.q.RevokeRoomSanction(ctx, RevokeRoomSanctionParams(arg))
+	return one(r, err, toSanction)
+}
+
+func (s *Store) GetAPIKeyByHash(ctx context.Context, keyHash string) (store.ApiKey, error) {
+	k, err := s.q.GetAPIKeyByHash(ctx, keyHash)
+	return one(k, err, toAPIKey)
+}
+
+func (s *Store) GetAPIKeys(ctx context.Context) ([]store.ApiKey, error) {
+	items, err := s.q.GetAPIKeys(ctx)
+	return many(items, err, toAPIKey)
+}
+
+func (s *Store) InsertAPIKey(ctx context.Context, arg store.InsertAPIKeyParams) (store.ApiKey, error) {
+	k, err := s.q.InsertAPIKey(ctx, InsertAPIKeyParams{
+		Name:        arg.Name,
+		Prefix:      arg.Prefix,
+		KeyHash:     arg.KeyHash,
+		RoomIds:     arg.RoomIds,
+		Permissions: arg.Permissions,
+		CreatedBy:   arg.CreatedBy,
+	})
+	return one(k, err, toAPIKey)
+}
+
+func (s *Store) RevokeAPIKey(ctx context.Context, id uuid.UUID) (store.ApiKey, error) {
+	k, err := s.q.RevokeAPIKey(ctx, id)
+	return one(k, err, toAPIKey)
+}
+
+func (s *Store) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	return s.q.TouchAPIKey(ctx, id)
+}
+
+func (s *Store) ClaimGuestName(ctx context.Context, arg store.ClaimGuestNameParams) (store.GuestName, error) {
+	g, err := s.q.ClaimGuestName(ctx, ClaimGuestNameParams{
+		RoomID:    arg.RoomID,
+		NameKey:   arg.NameKey,
+		Name:      arg.Name,
+		GuestID:   arg.GuestID,
+		ExpiresAt: timestamp(arg.ExpiresAt),
+	})
+	return one(g, err, toGuestName)
+}
+
+func (s *Store) DeleteExpiredGuestNames(ctx context.Context) error {
+	return s.q.DeleteExpiredGuestNames(ctx)
+}
+
+func (s *Store) GetUser(ctx context.Context, id string) (store.User, error) {
+	u, err := s.q.GetUser(ctx, id)
+	return one(u, err, toUser)
+}
+
+func (s *Store) GetUsersByIDs(ctx context.Context, ids []string) ([]store.User, error) {
+	items, err := s.q.GetUsersByIDs(ctx, ids)
+	return many(items, err, toUser)
+}
+
+func (s *Store) UpsertUser(ctx context.Context, arg store.UpsertUserParams) error {
+	return s.q.UpsertUser(ctx, UpsertUserParams(arg))
+}
+
+func (s *Store) GetAuditLog(ctx context.Context, arg store.GetAuditLogParams) ([]store.AuditLog, error) {
+	items, err := s.q.GetAuditLog(ctx, GetAuditLogParams{
+		Action:     arg.Action,
+		ActorID:    arg.ActorID,
+		RoomID:     arg.RoomID,
+		TargetID:   arg.TargetID,
+		Since:      nullTimestamp(arg.Since),
+		Until:      nullTimestamp(arg.Until),
+		BeforeID:   arg.BeforeID,
+		MaxResults: int64(arg.MaxResults),
+	})
+	return many(items, err, toAuditLog)
+}
+
+func (s *Store) InsertAuditLogEntry(ctx context.Context, arg store.InsertAuditLogEntryParams) error {
+	return s.q.InsertAuditLogEntry(ctx, InsertAuditLogEntryParams{
+		Action:     arg.Action,
+		ActorID:    arg.ActorID,
+		Ip:         arg.Ip,
+		RequestID:  arg.RequestID,
+		RoomID:     arg.RoomID,
+		TargetType: arg.TargetType,
+		TargetID:   arg.TargetID,
+		Before:     jsonText(arg.Before),
+		After:      jsonText(arg.After),
+	})
+}
+
+func (s *Store) ClaimWebhookDeliveries(ctx context.Context, arg store.ClaimWebhookDeliveriesParams) ([]store.ClaimWebhookDeliveriesRow, error) {
+	items, err := s.q.ClaimWebhookDeliveries(ctx, ClaimWebhookDeliveriesParams{
+		LeaseSeconds: int64(arg.LeaseSeconds),
+		BatchSize:    int64(arg.BatchSize),
+	})
+	return many(items, err, func(d ClaimWebhookDeliveriesRow) store.ClaimWebhookDeliveriesRow {
+		return store.ClaimWebhookDeliveriesRow{
+			ID:        d.ID,
+			WebhookID: d.WebhookID,
+			EventKind: d.EventKind,
+			RoomID:    d.RoomID,
+			Payload:   []byte(d.Payload),
+			Attempts:  int32(d.Attempts),
+			Url:       d.Url,
+			Secret:    d.Secret,
+		}
+	})
+}
+
+func (s *Store) DeleteWebhook(ctx context.Context, id uuid.UUID) (int64, error) {
+	return s.q.DeleteWebhook(ctx, id)
+}
+
+func (s *Store) EnqueueWebhookDeliveries(ctx context.Context, arg store.EnqueueWebhookDeliveriesParams) (int64, error) {
+	return s.q.EnqueueWebhookDeliveries(ctx, EnqueueWebhookDeliveriesParams{
+		EventKind: arg.EventKind,
+		RoomID:    arg.RoomID,
+		Payload:   string(arg.Payload),
+	})
+}
+
+func (s *Store) GetWebhookDeliveries(ctx context.Context, arg store.GetWebhookDeliveriesParams) ([]store.WebhookDelivery, error) {
+	items, err := s.q.GetWebhookDeliveries(ctx, GetWebhookDeliveriesParams{Status: arg.Status, Limit: int64(arg.Limit)})
+	return many(items, err, toDelivery)
+}
+
+func (s *Store) GetWebhooks(ctx context.Context) ([]store.Webhook, error) {
+	items, err := s.q.GetWebhooks(ctx)
+	return many(items, err, toWebhook)
+}
+
+func (s *Store) InsertWebhook(ctx context.Context, arg store.InsertWebhookParams) (store.Webhook, error) {
+	w, err := s.q.InsertWebhook(ctx, InsertWebhookParams{
+		RoomID:     arg.RoomID,
+		Url:        arg.Url,
+		Secret:     arg.Secret,
+		EventKinds: arg.EventKinds,
+		CreatedBy:  arg.CreatedBy,
+	})
+	return one(w, err, toWebhook)
+}
+
+func (s *Store) MarkWebhookDeliveryFailed(ctx context.Context, arg store.MarkWebhookDeliveryFailedParams) error {
+	return s.q.MarkWebhookDeliveryFailed(ctx, MarkWebhookDeliveryFailedParams{
+		Dead:           arg.Dead,
+		LastStatusCode: int64(arg.LastStatusCode),
+		LastError:      arg.LastError,
+		NextAttemptAt:  timestamp(arg.NextAttemptAt),
+		ID:             arg.ID,
+	})
+}
+
+func (s *Store) MarkWebhookDeliverySucceeded(ctx context.Context, arg store.MarkWebhookDeliverySucceededParams) error {
+	return s.q.MarkWebhookDeliverySucceeded(ctx, MarkWebhookDeliverySucceededParams{
+		LastStatusCode: int64(arg.LastStatusCode),
+		ID:             arg.ID,
+	})
+}
+
+func (s *Store) RedeliverWebhookDelivery(ctx context.Context, id uuid.UUID) (store.WebhookDelivery, error) {
+	d, err := s.q.RedeliverWebhookDelivery(ctx, id)
+	return one(d, err, toDelivery)
+}
@@ -0,0 +1,66 @@
+package sqlitestore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+	"github.com/felipemacedo1/go-msg-wss/internal/store/storetest"
+
+	"github.com/google/uuid"
+)
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store { return newStore(t) })
+}
+
+func newStore(t *testing.T) *Store {
+	t.Helper()
+
+	db, err := Open(context.Background(), filepath.Join(t.TempDir(), "msgwss.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewStore(db)
+}
+
+func TestGeneratedIDs(t *testing.T) {
+	ctx := context.Background()
+	s := newStore(t)
+
+	seen := make(map[uuid.UUID]bool)
+	for range 20 {
+		id, err := s.InsertRoom(ctx, store.InsertRoomParams{Theme: "Go", AnonymousPolicy: "named"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id.Version() != 4 || id.Variant() != uuid.RFC4122 {
+			t.Errorf("id %s is not a version 4 UUID", id)
+		}
+		if seen[id] {
+			t.Errorf("id %s generated twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+// TestAuditLogIsAppendOnly checks the triggers of the schema, which the store
+// interface cannot reach
+func TestAuditLogIsAppendOnly(t *testing.T) {
+	ctx := context.Background()
+	s := newStore(t)
+
+	err := s.InsertAuditLogEntry(ctx, store.InsertAuditLogEntryParams{Action: "room.create", ActorID: "mod", TargetType: "room", TargetID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, "UPDATE audit_log SET action = 'room.delete'"); err == nil {
+		t.Error("updating audit_log succeeded")
+	}
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM audit_log"); err == nil {
+		t.Error("deleting from audit_log succeeded")
+	}
+}
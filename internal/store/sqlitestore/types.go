@@ -0,0 +1,99 @@
+package sqlitestore
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// timeFormat is what strftime('%Y-%m-%dT%H:%M:%fZ') produces. Every timestamp
+// is written in it, so comparing the text in SQL compares the times.
+const timeFormat = "2006-01-02T15:04:05.000Z"
+
+// Timestamp is a nullable point in time stored as UTC ISO 8601 text. It
+// converts to and from the time.Time and *time.Time of the store models.
+type Timestamp struct {
+	Time  time.Time
+	Valid bool
+}
+
+func (t *Timestamp) Scan(src any) error {
+	var s string
+	switch src := src.(type) {
+	case nil:
+		*t = Timestamp{}
+		return nil
+	case string:
+		s = src
+	case []byte:
+		s = string(src)
+	default:
+		return fmt.Errorf("sqlitestore: cannot scan %T into Timestamp", src)
+	}
+
+	v, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return fmt.Errorf("sqlitestore: parse timestamp: %w", err)
+	}
+	*t = Timestamp{Time: v, Valid: true}
+	return nil
+}
+
+func (t Timestamp) Value() (driver.Value, error) {
+	if !t.Valid {
+		return nil, nil
+	}
+	return t.Time.UTC().Format(timeFormat), nil
+}
+
+// Strings is a text array stored as a JSON array
+type Strings []string
+
+func (s *Strings) Scan(src any) error {
+	return scanJSON(src, (*[]string)(s))
+}
+
+func (s Strings) Value() (driver.Value, error) {
+	return valueJSON([]string(s))
+}
+
+// UUIDs is a uuid array stored as a JSON array
+type UUIDs []uuid.UUID
+
+func (u *UUIDs) Scan(src any) error {
+	return scanJSON(src, (*[]uuid.UUID)(u))
+}
+
+func (u UUIDs) Value() (driver.Value, error) {
+	return valueJSON([]uuid.UUID(u))
+}
+
+func scanJSON[T any](src any, dst *[]T) error {
+	var data []byte
+	switch src := src.(type) {
+	case string:
+		data = []byte(src)
+	case []byte:
+		data = src
+	default:
+		return fmt.Errorf("sqlitestore: cannot scan %T into a JSON array", src)
+	}
+
+	*dst = []T{}
+	return json.Unmarshal(data, dst)
+}
+
+// valueJSON writes nil slices as [] so the columns never hold null
+func valueJSON[T any](items []T) (driver.Value, error) {
+	if items == nil {
+		items = []T{}
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,109 @@
+// Package store defines the persistence the API depends on. The Postgres
+// implementation lives in pgstore and the SQLite one in sqlitestore; memstore
+// keeps everything in process memory for development and tests.
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned by lookups and updates that match no row. Each
+// implementation maps the not found error of its driver to it.
+var ErrNotFound = errors.New("store: not found")
+
+// Store is everything the API handler reads and writes
+type Store interface {
+	Rooms
+	Messages
+	Reactions
+	Polls
+	Sanctions
+	APIKeys
+	Guests
+	Users
+	Audit
+	Webhooks
+}
+
+type Rooms interface {
+	GetRoom(ctx context.Context, id uuid.UUID) (Room, error)
+	GetRooms(ctx context.Context) ([]Room, error)
+	InsertRoom(ctx context.Context, arg InsertRoomParams) (uuid.UUID, error)
+	SetRoomAllowGuests(ctx context.Context, arg SetRoomAllowGuestsParams) (Room, error)
+	SetRoomAnonymousPolicy(ctx context.Context, arg SetRoomAnonymousPolicyParams) (Room, error)
+}
+
+type Messages interface {
+	GetMessage(ctx context.Context, id uuid.UUID) (Message, error)
+	GetRoomHotMessages(ctx context.Context, arg GetRoomHotMessagesParams) ([]Message, error)
+	GetRoomMessages(ctx context.Context, arg GetRoomMessagesParams) ([]Message, error)
+	GetRoomMessagesPage(ctx context.Context, arg GetRoomMessagesPageParams) ([]Message, error)
+	GetUserMessages(ctx context.Context, arg GetUserMessagesParams) ([]Message, error)
+	InsertMessage(ctx context.Context, arg InsertMessageParams) (Message, error)
+	MarkMessageAsAnswered(ctx context.Context, id uuid.UUID) error
+	SetMessageHidden(ctx context.Context, arg SetMessageHiddenParams) error
+}
+
+// Reactions update the reaction counter of a message atomically, returning
+// the count they replaced with the new one. Removing a reaction never takes
+// the counter below zero.
+type Reactions interface {
+	ReactToMessage(ctx context.Context, id uuid.UUID) (ReactToMessageRow, error)
+	RemoveReactionFromMessage(ctx context.Context, id uuid.UUID) (RemoveReactionFromMessageRow, error)
+}
+
+type Polls interface {
+	CastPollVote(ctx context.Context, arg CastPollVoteParams) ([]uuid.UUID, error)
+	ClosePoll(ctx context.Context, arg ClosePollParams) (Poll, error)
+	GetPoll(ctx context.Context, id uuid.UUID) (Poll, error)
+	GetPollTallies(ctx context.Context, pollID uuid.UUID) ([]GetPollTalliesRow, error)
+	GetRoomPollTallies(ctx context.Context, roomID uuid.UUID) ([]GetRoomPollTalliesRow, error)
+	GetRoomPolls(ctx context.Context, roomID uuid.UUID) ([]Poll, error)
+	InsertPoll(ctx context.Context, arg InsertPollParams) (uuid.UUID, error)
+}
+
+type Sanctions interface {
+	FindActiveRoomSanction(ctx context.Context, arg FindActiveRoomSanctionParams) (RoomSanction, error)
+	GetActiveRoomSanctions(ctx context.Context, arg GetActiveRoomSanctionsParams) ([]RoomSanction, error)
+	InsertRoomSanction(ctx context.Context, arg InsertRoomSanctionParams) (RoomSanction, error)
+	RevokeRoomSanction(ctx context.Context, arg RevokeRoomSanctionParams) (RoomSanction, error)
+}
+
+type APIKeys interface {
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey, error)
+	GetAPIKeys(ctx context.Context) ([]ApiKey, error)
+	InsertAPIKey(ctx context.Context, arg InsertAPIKeyParams) (ApiKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) (ApiKey, error)
+	TouchAPIKey(ctx context.Context, id uuid.UUID) error
+}
+
+type Guests interface {
+	ClaimGuestName(ctx context.Context, arg ClaimGuestNameParams) (GuestName, error)
+	DeleteExpiredGuestNames(ctx context.Context) error
+}
+
+type Users interface {
+	GetUser(ctx context.Context, id string) (User, error)
+	GetUsersByIDs(ctx context.Context, ids []string) ([]User, error)
+	UpsertUser(ctx context.Context, arg UpsertUserParams) error
+}
+
+type Audit interface {
+	GetAuditLog(ctx context.Context, arg GetAuditLogParams) ([]AuditLog, error)
+	InsertAuditLogEntry(ctx context.Context, arg InsertAuditLogEntryParams) error
+}
+
+type Webhooks interface {
+	ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]ClaimWebhookDeliveriesRow, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID) (int64, error)
+	EnqueueWebhookDeliveries(ctx context.Context, arg EnqueueWebhookDeliveriesParams) (int64, error)
+	GetWebhookDeliveries(ctx context.Context, arg GetWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	GetWebhooks(ctx context.Context) ([]Webhook, error)
+	InsertWebhook(ctx context.Context, arg InsertWebhookParams) (Webhook, error)
+	MarkWebhookDeliveryFailed(ctx context.Context, arg MarkWebhookDeliveryFailedParams) error
+	MarkWebhookDeliverySucceeded(ctx context.Context, arg MarkWebhookDeliverySucceededParams) error
+	RedeliverWebhookDelivery(ctx context.Context, id uuid.UUID) (WebhookDelivery, error)
+}
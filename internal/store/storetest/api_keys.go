@@ -0,0 +1,91 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+func testAPIKeys(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	roomID := insertRoom(t, s, "Go")
+
+	key, err := s.InsertAPIKey(ctx, store.InsertAPIKeyParams{
+		Name:        "bot",
+		Prefix:      "mk_abc",
+		KeyHash:     "hash-1",
+		RoomIds:     []uuid.UUID{roomID},
+		Permissions: []string{"post", "read"},
+		CreatedBy:   "admin",
+	})
+	if err != nil {
+		t.Fatalf("InsertAPIKey() error = %v", err)
+	}
+	if key.ID == uuid.Nil || key.Name != "bot" || key.Prefix != "mk_abc" || key.KeyHash != "hash-1" || key.CreatedBy != "admin" {
+		t.Errorf("InsertAPIKey() = %+v, want the given fields", key)
+	}
+	if !slices.Equal(key.RoomIds, []uuid.UUID{roomID}) || !slices.Equal(key.Permissions, []string{"post", "read"}) {
+		t.Errorf("InsertAPIKey() = %+v, want the given rooms and permissions", key)
+	}
+	if key.CreatedAt.IsZero() || key.LastUsedAt != nil || key.RevokedAt != nil {
+		t.Errorf("InsertAPIKey() = %+v, want an unused key", key)
+	}
+
+	// An empty room list allows every room
+	anyRoom, err := s.InsertAPIKey(ctx, store.InsertAPIKeyParams{
+		Name:        "reader",
+		Prefix:      "mk_def",
+		KeyHash:     "hash-2",
+		RoomIds:     []uuid.UUID{},
+		Permissions: []string{"read"},
+		CreatedBy:   "admin",
+	})
+	if err != nil || len(anyRoom.RoomIds) != 0 {
+		t.Fatalf("InsertAPIKey() = %+v, %v, want a key without rooms", anyRoom, err)
+	}
+
+	got, err := s.GetAPIKeyByHash(ctx, "hash-1")
+	if err != nil || got.ID != key.ID || !slices.Equal(got.RoomIds, key.RoomIds) {
+		t.Errorf("GetAPIKeyByHash() = %+v, %v, want the key", got, err)
+	}
+	if _, err := s.GetAPIKeyByHash(ctx, "missing"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetAPIKeyByHash() of an unknown hash error = %v, want ErrNotFound", err)
+	}
+
+	if err := s.TouchAPIKey(ctx, key.ID); err != nil {
+		t.Fatalf("TouchAPIKey() error = %v", err)
+	}
+	if got, err := s.GetAPIKeyByHash(ctx, "hash-1"); err != nil || got.LastUsedAt == nil {
+		t.Errorf("GetAPIKeyByHash() after TouchAPIKey() = %+v, %v, want the last use recorded", got, err)
+	}
+
+	revoked, err := s.RevokeAPIKey(ctx, key.ID)
+	if err != nil || revoked.ID != key.ID || revoked.RevokedAt == nil {
+		t.Fatalf("RevokeAPIKey() = %+v, %v, want the key revoked", revoked, err)
+	}
+	if _, err := s.RevokeAPIKey(ctx, key.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("revoking a revoked key error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.RevokeAPIKey(ctx, uuid.New()); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("RevokeAPIKey() of a missing key error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.GetAPIKeyByHash(ctx, "hash-1"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetAPIKeyByHash() of a revoked key error = %v, want ErrNotFound", err)
+	}
+
+	// Revoked keys stay listed
+	keys, err := s.GetAPIKeys(ctx)
+	if err != nil || len(keys) != 2 {
+		t.Fatalf("GetAPIKeys() = %+v, %v, want both keys", keys, err)
+	}
+	for _, k := range keys {
+		if (k.ID == key.ID) != (k.RevokedAt != nil) {
+			t.Errorf("GetAPIKeys() key %+v, want only %s revoked", k, key.ID)
+		}
+	}
+}
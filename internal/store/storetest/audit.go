@@ -0,0 +1,83 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+func testAuditLog(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	roomID := insertRoom(t, s, "Go")
+
+	// Entries are never deleted, so Postgres keeps those of earlier runs.
+	// Filtering by a fresh actor only sees the entries of this test.
+	actor := "mod:" + uuid.NewString()
+	since := time.Now().Add(-time.Second)
+
+	entries := []store.InsertAuditLogEntryParams{
+		{Action: "room.create", ActorID: actor, Ip: "203.0.113.1", RequestID: "req-1", TargetType: "room", TargetID: roomID.String(), After: []byte(`{"theme":"Go"}`)},
+		{Action: "message.react", ActorID: actor, Ip: "203.0.113.1", RequestID: "req-2", RoomID: &roomID, TargetType: "message", TargetID: "m1", Before: []byte(`{"reaction_count":0}`), After: []byte(`{"reaction_count":1}`)},
+		{Action: "message.react", ActorID: actor, Ip: "203.0.113.1", RequestID: "req-3", RoomID: &roomID, TargetType: "message", TargetID: "m2"},
+	}
+	for _, e := range entries {
+		if err := s.InsertAuditLogEntry(ctx, e); err != nil {
+			t.Fatalf("InsertAuditLogEntry() error = %v", err)
+		}
+	}
+
+	get := func(arg store.GetAuditLogParams) []store.AuditLog {
+		t.Helper()
+		arg.ActorID = &actor
+		if arg.MaxResults == 0 {
+			arg.MaxResults = 10
+		}
+		log, err := s.GetAuditLog(ctx, arg)
+		if err != nil {
+			t.Fatalf("GetAuditLog() error = %v", err)
+		}
+		return log
+	}
+
+	all := get(store.GetAuditLogParams{})
+	if len(all) != 3 || all[0].ID <= all[1].ID || all[1].ID <= all[2].ID {
+		t.Fatalf("GetAuditLog() = %+v, want the 3 entries newest first", all)
+	}
+	oldest := all[2]
+	if oldest.Action != "room.create" || oldest.Ip != "203.0.113.1" || oldest.RequestID != "req-1" || oldest.RoomID != nil || oldest.TargetType != "room" || oldest.CreatedAt.IsZero() {
+		t.Errorf("GetAuditLog() oldest entry = %+v, want the room creation", oldest)
+	}
+	if oldest.Before != nil || !jsonEqual(t, oldest.After, []byte(`{"theme":"Go"}`)) {
+		t.Errorf("GetAuditLog() oldest entry before = %s, after = %s, want null and the room", oldest.Before, oldest.After)
+	}
+	if !jsonEqual(t, all[1].Before, entries[1].Before) || !jsonEqual(t, all[1].After, entries[1].After) {
+		t.Errorf("GetAuditLog() entry before = %s, after = %s, want the reaction counts", all[1].Before, all[1].After)
+	}
+
+	later := time.Now().Add(time.Hour)
+	tests := []struct {
+		name string
+		arg  store.GetAuditLogParams
+		want int
+	}{
+		{"Action", store.GetAuditLogParams{Action: ptr("message.react")}, 2},
+		{"Room", store.GetAuditLogParams{RoomID: &roomID}, 2},
+		{"Target", store.GetAuditLogParams{TargetID: ptr("m1")}, 1},
+		{"Since", store.GetAuditLogParams{Since: &since}, 3},
+		{"Since later", store.GetAuditLogParams{Since: &later}, 0},
+		{"Until", store.GetAuditLogParams{Until: &since}, 0},
+		{"Before id", store.GetAuditLogParams{BeforeID: all[0].ID}, 2},
+		{"Max results", store.GetAuditLogParams{MaxResults: 1}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := get(tt.arg); len(got) != tt.want {
+				t.Errorf("GetAuditLog() returned %d entries, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
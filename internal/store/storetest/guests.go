@@ -0,0 +1,62 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+func testClaimGuestName(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	roomID := insertRoom(t, s, "Go")
+	otherID := insertRoom(t, s, "Rust")
+
+	claim := func(roomID uuid.UUID, nameKey, guestID string, expires time.Time) (store.GuestName, error) {
+		t.Helper()
+		name, err := s.ClaimGuestName(ctx, store.ClaimGuestNameParams{
+			RoomID:    roomID,
+			NameKey:   nameKey,
+			Name:      "Ada",
+			GuestID:   guestID,
+			ExpiresAt: expires,
+		})
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("ClaimGuestName() error = %v", err)
+		}
+		return name, err
+	}
+
+	hour := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	name, err := claim(roomID, "ada", "guest:1", hour)
+	if err != nil || name.RoomID != roomID || name.NameKey != "ada" || name.Name != "Ada" || name.GuestID != "guest:1" || !name.ExpiresAt.Equal(hour) {
+		t.Fatalf("first claim = %+v, %v, want the name", name, err)
+	}
+	if name, err := claim(roomID, "ada", "guest:1", hour.Add(time.Hour)); err != nil || !name.ExpiresAt.Equal(hour.Add(time.Hour)) {
+		t.Errorf("renewal by the holder = %+v, %v, want the expiry extended", name, err)
+	}
+	if _, err := claim(roomID, "ada", "guest:2", hour); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("claim of a held name error = %v, want ErrNotFound", err)
+	}
+	if _, err := claim(otherID, "ada", "guest:2", hour); err != nil {
+		t.Errorf("claim of the name in another room error = %v", err)
+	}
+
+	if _, err := claim(roomID, "bob", "guest:1", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("claim error = %v", err)
+	}
+	if name, err := claim(roomID, "bob", "guest:2", hour); err != nil || name.GuestID != "guest:2" {
+		t.Errorf("claim of an expired name = %+v, %v, want it taken over", name, err)
+	}
+
+	if err := s.DeleteExpiredGuestNames(ctx); err != nil {
+		t.Fatalf("DeleteExpiredGuestNames() error = %v", err)
+	}
+	if _, err := claim(roomID, "ada", "guest:2", hour); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("claim of a held name after DeleteExpiredGuestNames() error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,153 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+func insertPoll(t *testing.T, s store.Store, roomID uuid.UUID, multipleChoice bool, options ...string) uuid.UUID {
+	t.Helper()
+
+	id, err := s.InsertPoll(context.Background(), store.InsertPollParams{
+		RoomID:         roomID,
+		Question:       "Tabs or spaces?",
+		MultipleChoice: multipleChoice,
+		CreatedBy:      "mod",
+		Options:        options,
+	})
+	if err != nil {
+		t.Fatalf("InsertPoll() error = %v", err)
+	}
+	return id
+}
+
+func testPolls(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	roomID := insertRoom(t, s, "Go")
+	otherID := insertRoom(t, s, "Rust")
+
+	pollID := insertPoll(t, s, roomID, true, "Tabs", "Spaces", "Both")
+	insertPoll(t, s, otherID, false, "Yes", "No")
+
+	poll, err := s.GetPoll(ctx, pollID)
+	if err != nil {
+		t.Fatalf("GetPoll() error = %v", err)
+	}
+	if poll.ID != pollID || poll.RoomID != roomID || poll.Question != "Tabs or spaces?" || !poll.MultipleChoice || poll.CreatedBy != "mod" {
+		t.Errorf("GetPoll() = %+v, want the inserted poll", poll)
+	}
+	if poll.Status != "open" || poll.ClosedAt != nil || poll.CreatedAt.IsZero() {
+		t.Errorf("GetPoll() = %+v, want an open poll", poll)
+	}
+	if _, err := s.GetPoll(ctx, uuid.New()); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetPoll() of a missing poll error = %v, want ErrNotFound", err)
+	}
+
+	polls, err := s.GetRoomPolls(ctx, roomID)
+	if err != nil || len(polls) != 1 || polls[0].ID != pollID {
+		t.Errorf("GetRoomPolls() = %+v, %v, want only the poll of the room", polls, err)
+	}
+
+	tallies, err := s.GetPollTallies(ctx, pollID)
+	if err != nil {
+		t.Fatalf("GetPollTallies() error = %v", err)
+	}
+	for i, label := range []string{"Tabs", "Spaces", "Both"} {
+		if i >= len(tallies) || tallies[i].Label != label || tallies[i].Position != int32(i+1) || tallies[i].PollID != pollID || tallies[i].Votes != 0 {
+			t.Fatalf("GetPollTallies() = %+v, want the options numbered from 1 in order", tallies)
+		}
+	}
+	if len(tallies) != 3 {
+		t.Fatalf("GetPollTallies() returned %d options, want 3", len(tallies))
+	}
+
+	roomTallies, err := s.GetRoomPollTallies(ctx, roomID)
+	if err != nil || len(roomTallies) != 3 {
+		t.Fatalf("GetRoomPollTallies() = %+v, %v, want the 3 options of the room", roomTallies, err)
+	}
+	for i, row := range roomTallies {
+		if store.GetPollTalliesRow(row) != tallies[i] {
+			t.Errorf("GetRoomPollTallies()[%d] = %+v, want %+v", i, row, tallies[i])
+		}
+	}
+}
+
+func testCastPollVote(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	roomID := insertRoom(t, s, "Go")
+	pollID := insertPoll(t, s, roomID, true, "Yes", "No", "Maybe")
+	otherPollID := insertPoll(t, s, roomID, false, "Other")
+
+	options := func(pollID uuid.UUID) []uuid.UUID {
+		t.Helper()
+		tallies, err := s.GetPollTallies(ctx, pollID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []uuid.UUID
+		for _, row := range tallies {
+			ids = append(ids, row.ID)
+		}
+		return ids
+	}
+	ids := options(pollID)
+	yes, no := ids[0], ids[1]
+	other := options(otherPollID)[0]
+
+	vote := func(voter string, optionIDs ...uuid.UUID) int {
+		t.Helper()
+		recorded, err := s.CastPollVote(ctx, store.CastPollVoteParams{VoterID: voter, PollID: pollID, OptionIds: optionIDs})
+		if err != nil {
+			t.Fatalf("CastPollVote() error = %v", err)
+		}
+		return len(recorded)
+	}
+
+	if n := vote("a", yes, no); n != 2 {
+		t.Errorf("first vote recorded %d options, want 2", n)
+	}
+	if n := vote("a", yes); n != 0 {
+		t.Errorf("second vote by the same voter recorded %d options, want 0", n)
+	}
+	if n := vote("b", yes, other); n != 1 {
+		t.Errorf("vote with an option of another poll recorded %d options, want only the 1 of the poll", n)
+	}
+
+	recorded, err := s.CastPollVote(ctx, store.CastPollVoteParams{VoterID: "a", PollID: uuid.New(), OptionIds: []uuid.UUID{yes}})
+	if err != nil || len(recorded) != 0 {
+		t.Errorf("CastPollVote() on a missing poll = %v, %v, want nothing recorded", recorded, err)
+	}
+
+	if _, err := s.ClosePoll(ctx, store.ClosePollParams{ID: pollID, RoomID: insertRoom(t, s, "Rust")}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("ClosePoll() from another room error = %v, want ErrNotFound", err)
+	}
+	closed, err := s.ClosePoll(ctx, store.ClosePollParams{ID: pollID, RoomID: roomID})
+	if err != nil {
+		t.Fatalf("ClosePoll() error = %v", err)
+	}
+	if closed.Status != "closed" || closed.ClosedAt == nil {
+		t.Errorf("ClosePoll() = %+v, want a closed poll with its closing time", closed)
+	}
+	if n := vote("c", yes); n != 0 {
+		t.Errorf("vote on a closed poll recorded %d options, want 0", n)
+	}
+	if _, err := s.ClosePoll(ctx, store.ClosePollParams{ID: pollID, RoomID: roomID}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("closing a closed poll error = %v, want ErrNotFound", err)
+	}
+
+	tallies, err := s.GetPollTallies(ctx, pollID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tallies[0].Votes != 2 || tallies[1].Votes != 1 || tallies[2].Votes != 0 {
+		t.Errorf("GetPollTallies() = %+v, want 2 votes for Yes and 1 for No", tallies)
+	}
+	if otherTallies, _ := s.GetPollTallies(ctx, otherPollID); otherTallies[0].Votes != 0 {
+		t.Errorf("GetPollTallies() of the other poll = %+v, want no votes", otherTallies)
+	}
+}
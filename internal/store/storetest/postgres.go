@@ -0,0 +1,64 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store/pgstore"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DatabaseURLEnv names a disposable Postgres database for tests, e.g. the one
+// from docker compose. Its tables are truncated freely.
+const DatabaseURLEnv = "MSGWSS_TEST_DATABASE_URL"
+
+// The migration runs once per test binary; its error is kept so that every
+// later caller fails too instead of testing an unmigrated database
+var (
+	migrateOnce sync.Once
+	migrateErr  error
+)
+
+// Postgres returns a pgstore on the database named by DatabaseURLEnv,
+// migrated once per test binary and emptied for the caller. It returns nil
+// when the variable is unset.
+func Postgres(t *testing.T) *pgstore.Store {
+	t.Helper()
+
+	databaseURL := os.Getenv(DatabaseURLEnv)
+	if databaseURL == "" {
+		return nil
+	}
+
+	migrateOnce.Do(func() {
+		m, err := pgstore.NewMigrator(databaseURL)
+		if err != nil {
+			migrateErr = err
+			return
+		}
+		defer m.Close()
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			migrateErr = err
+		}
+	})
+	if migrateErr != nil {
+		t.Fatalf("migrate up: %v", migrateErr)
+	}
+
+	pool, err := pgxpool.New(context.Background(), databaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	_, err = pool.Exec(context.Background(), "TRUNCATE rooms, users, api_keys, webhooks, rate_limit_buckets CASCADE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pgstore.NewStore(pool)
+}
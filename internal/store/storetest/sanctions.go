@@ -0,0 +1,127 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+func insertSanction(t *testing.T, s store.Store, roomID uuid.UUID, kind string, authorID, ip *string, expiresAt *time.Time) store.RoomSanction {
+	t.Helper()
+
+	sanction, err := s.InsertRoomSanction(context.Background(), store.InsertRoomSanctionParams{
+		RoomID:    roomID,
+		Kind:      kind,
+		AuthorID:  authorID,
+		Ip:        ip,
+		Reason:    "spam",
+		CreatedBy: "mod",
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		t.Fatalf("InsertRoomSanction() error = %v", err)
+	}
+	return sanction
+}
+
+func testSanctions(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	roomID := insertRoom(t, s, "Go")
+	otherID := insertRoom(t, s, "Rust")
+
+	// Millisecond precision survives every backend
+	later := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	expired := time.Now().Add(-time.Minute)
+
+	mute := insertSanction(t, s, roomID, "mute", ptr("ada"), nil, &later)
+	if mute.ID == uuid.Nil || mute.RoomID != roomID || mute.Kind != "mute" || *mute.AuthorID != "ada" || mute.Ip != nil {
+		t.Errorf("InsertRoomSanction() = %+v, want the given fields", mute)
+	}
+	if mute.Reason != "spam" || mute.CreatedBy != "mod" || mute.CreatedAt.IsZero() || !mute.ExpiresAt.Equal(later) || mute.RevokedAt != nil {
+		t.Errorf("InsertRoomSanction() = %+v, want the given fields", mute)
+	}
+
+	ipBan := insertSanction(t, s, roomID, "ban", nil, ptr("203.0.113.1"), nil)
+	insertSanction(t, s, roomID, "ban", ptr("ada"), nil, &expired)
+	insertSanction(t, s, otherID, "ban", ptr("ada"), nil, nil)
+	insertSanction(t, s, roomID, "ban", ptr("bob"), nil, &later)
+	bobBan := insertSanction(t, s, roomID, "ban", ptr("bob"), nil, nil)
+
+	find := func(kinds []string, authorID, ip *string) (uuid.UUID, error) {
+		t.Helper()
+		sanction, err := s.FindActiveRoomSanction(ctx, store.FindActiveRoomSanctionParams{
+			RoomID:   roomID,
+			Kinds:    kinds,
+			AuthorID: authorID,
+			Ip:       ip,
+		})
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("FindActiveRoomSanction() error = %v", err)
+		}
+		return sanction.ID, err
+	}
+
+	both := []string{"ban", "mute"}
+	tests := []struct {
+		name     string
+		kinds    []string
+		authorID *string
+		ip       *string
+		want     uuid.UUID
+	}{
+		{"Expired ban and mute by author", both, ptr("ada"), ptr("198.51.100.1"), mute.ID},
+		{"Ban by ip", both, ptr("carol"), ptr("203.0.113.1"), ipBan.ID},
+		{"Bans sort before mutes", both, ptr("ada"), ptr("203.0.113.1"), ipBan.ID},
+		{"Guest matched by ip only", []string{"ban"}, nil, ptr("203.0.113.1"), ipBan.ID},
+		{"Guest never matches an author", []string{"mute"}, nil, ptr("198.51.100.1"), uuid.Nil},
+		{"Expired and other room bans ignored", []string{"ban"}, ptr("ada"), ptr("198.51.100.1"), uuid.Nil},
+		{"Permanent sanction first", []string{"ban"}, ptr("bob"), nil, bobBan.ID},
+		{"Nobody", both, ptr("carol"), ptr("198.51.100.1"), uuid.Nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := find(tt.kinds, tt.authorID, tt.ip)
+			if tt.want == uuid.Nil {
+				if !errors.Is(err, store.ErrNotFound) {
+					t.Errorf("FindActiveRoomSanction() = %s, %v, want ErrNotFound", got, err)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("FindActiveRoomSanction() = %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+
+	bans, err := s.GetActiveRoomSanctions(ctx, store.GetActiveRoomSanctionsParams{RoomID: roomID, Kind: "ban"})
+	if err != nil || len(bans) != 3 {
+		t.Errorf("GetActiveRoomSanctions() = %+v, %v, want the 3 active bans of the room", bans, err)
+	}
+
+	if _, err := s.RevokeRoomSanction(ctx, store.RevokeRoomSanctionParams{ID: mute.ID, RoomID: otherID, Kind: "mute"}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("RevokeRoomSanction() from another room error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.RevokeRoomSanction(ctx, store.RevokeRoomSanctionParams{ID: mute.ID, RoomID: roomID, Kind: "ban"}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("RevokeRoomSanction() of another kind error = %v, want ErrNotFound", err)
+	}
+	revoked, err := s.RevokeRoomSanction(ctx, store.RevokeRoomSanctionParams{ID: mute.ID, RoomID: roomID, Kind: "mute"})
+	if err != nil || revoked.ID != mute.ID || revoked.RevokedAt == nil {
+		t.Fatalf("RevokeRoomSanction() = %+v, %v, want the sanction revoked", revoked, err)
+	}
+	if _, err := s.RevokeRoomSanction(ctx, store.RevokeRoomSanctionParams{ID: mute.ID, RoomID: roomID, Kind: "mute"}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("revoking a revoked sanction error = %v, want ErrNotFound", err)
+	}
+
+	if got, err := find([]string{"mute"}, ptr("ada"), nil); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("FindActiveRoomSanction() after revoking = %s, %v, want ErrNotFound", got, err)
+	}
+	mutes, err := s.GetActiveRoomSanctions(ctx, store.GetActiveRoomSanctionsParams{RoomID: roomID, Kind: "mute"})
+	if err != nil || len(mutes) != 0 {
+		t.Errorf("GetActiveRoomSanctions() after revoking = %+v, %v, want none", mutes, err)
+	}
+}
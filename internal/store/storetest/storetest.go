@@ -0,0 +1,426 @@
+// Package storetest is the conformance suite every store.Store
+// implementation runs, so the backends stay interchangeable behind the API.
+package storetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+// Run checks the semantics of every store method on the stores returned by
+// newStore. Every subtest gets a fresh, empty store.
+func Run(t *testing.T, newStore func(t *testing.T) store.Store) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s store.Store)
+	}{
+		{"Rooms", testRooms},
+		{"RoomNotFound", testRoomNotFound},
+		{"InsertMessage", testInsertMessage},
+		{"MessageNotFound", testMessageNotFound},
+		{"RoomMessages", testRoomMessages},
+		{"RoomMessagesPage", testRoomMessagesPage},
+		{"HotMessages", testHotMessages},
+		{"Reactions", testReactions},
+		{"ConcurrentReactions", testConcurrentReactions},
+		{"Polls", testPolls},
+		{"CastPollVote", testCastPollVote},
+		{"Sanctions", testSanctions},
+		{"APIKeys", testAPIKeys},
+		{"ClaimGuestName", testClaimGuestName},
+		{"Users", testUsers},
+		{"UpsertUserKeepsUpdatedAt", testUpsertUserKeepsUpdatedAt},
+		{"UserMessages", testUserMessages},
+		{"AuditLog", testAuditLog},
+		{"Webhooks", testWebhooks},
+		{"WebhookDeliveries", testWebhookDeliveries},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newStore(t))
+		})
+	}
+}
+
+func insertRoom(t *testing.T, s store.Store, theme string) uuid.UUID {
+	t.Helper()
+
+	id, err := s.InsertRoom(context.Background(), store.InsertRoomParams{
+		Theme:           theme,
+		AllowGuests:     true,
+		AnonymousPolicy: "named",
+	})
+	if err != nil {
+		t.Fatalf("InsertRoom() error = %v", err)
+	}
+	return id
+}
+
+func insertMessage(t *testing.T, s store.Store, roomID uuid.UUID, text string) store.Message {
+	t.Helper()
+
+	m, err := s.InsertMessage(context.Background(), store.InsertMessageParams{
+		RoomID:     roomID,
+		Message:    text,
+		AuthorID:   "guest",
+		AuthorName: "Guest",
+	})
+	if err != nil {
+		t.Fatalf("InsertMessage() error = %v", err)
+	}
+	return m
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// jsonEqual compares JSON documents by value, since Postgres normalizes the
+// jsonb it returns
+func jsonEqual(t *testing.T, got, want []byte) bool {
+	t.Helper()
+
+	var gotValue, wantValue any
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Errorf("%s is not JSON: %v", got, err)
+		return false
+	}
+	if err := json.Unmarshal(want, &wantValue); err != nil {
+		t.Fatal(err)
+	}
+	return reflect.DeepEqual(gotValue, wantValue)
+}
+
+func messageIDs(messages []store.Message) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, m := range messages {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+func equalIDs(got, want []uuid.UUID) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func testRooms(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	id := insertRoom(t, s, "Go")
+	insertRoom(t, s, "Rust")
+
+	room, err := s.GetRoom(ctx, id)
+	if err != nil {
+		t.Fatalf("GetRoom() error = %v", err)
+	}
+	if room.ID != id || room.Theme != "Go" || !room.AllowGuests || room.AnonymousPolicy != "named" {
+		t.Errorf("GetRoom() = %+v, want the inserted room", room)
+	}
+
+	rooms, err := s.GetRooms(ctx)
+	if err != nil {
+		t.Fatalf("GetRooms() error = %v", err)
+	}
+	if len(rooms) != 2 {
+		t.Errorf("GetRooms() returned %d rooms, want 2", len(rooms))
+	}
+
+	room, err = s.SetRoomAllowGuests(ctx, store.SetRoomAllowGuestsParams{ID: id, AllowGuests: false})
+	if err != nil || room.AllowGuests {
+		t.Errorf("SetRoomAllowGuests() = %+v, %v, want guests disallowed", room, err)
+	}
+	room, err = s.SetRoomAnonymousPolicy(ctx, store.SetRoomAnonymousPolicyParams{ID: id, AnonymousPolicy: "only"})
+	if err != nil || room.AnonymousPolicy != "only" || room.AllowGuests {
+		t.Errorf("SetRoomAnonymousPolicy() = %+v, %v, want the policy updated and the rest kept", room, err)
+	}
+}
+
+func testRoomNotFound(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	missing := uuid.New()
+
+	if _, err := s.GetRoom(ctx, missing); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetRoom() error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.SetRoomAllowGuests(ctx, store.SetRoomAllowGuestsParams{ID: missing}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("SetRoomAllowGuests() error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.SetRoomAnonymousPolicy(ctx, store.SetRoomAnonymousPolicyParams{ID: missing, AnonymousPolicy: "named"}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("SetRoomAnonymousPolicy() error = %v, want ErrNotFound", err)
+	}
+
+	rooms, err := s.GetRooms(ctx)
+	if err != nil || len(rooms) != 0 {
+		t.Errorf("GetRooms() = %v, %v, want no rooms", rooms, err)
+	}
+}
+
+func testInsertMessage(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	roomID := insertRoom(t, s, "Go")
+
+	before := time.Now().Add(-time.Second)
+	m, err := s.InsertMessage(ctx, store.InsertMessageParams{
+		RoomID:     roomID,
+		Message:    "Is Go fast?",
+		AuthorID:   "guest:1",
+		AuthorName: "Ada",
+		IsBot:      true,
+		Anonymous:  true,
+	})
+	if err != nil {
+		t.Fatalf("InsertMessage() error = %v", err)
+	}
+
+	if m.ID == uuid.Nil {
+		t.Error("InsertMessage() did not generate an id")
+	}
+	if m.RoomID != roomID || m.Message != "Is Go fast?" || m.AuthorID != "guest:1" || m.AuthorName != "Ada" || !m.IsBot || !m.Anonymous {
+		t.Errorf("InsertMessage() = %+v, want the given fields", m)
+	}
+	if m.ReactionCount != 0 || m.Answered || m.Hidden || m.UserID != nil {
+		t.Errorf("InsertMessage() = %+v, want the column defaults", m)
+	}
+	if m.CreatedAt.IsZero() || m.CreatedAt.Before(before) || m.CreatedAt.After(time.Now().Add(time.Second)) {
+		t.Errorf("CreatedAt = %v, want the insert time", m.CreatedAt)
+	}
+
+	got, err := s.GetMessage(ctx, m.ID)
+	if err != nil {
+		t.Fatalf("GetMessage() error = %v", err)
+	}
+	if got.ID != m.ID || !got.CreatedAt.Equal(m.CreatedAt) {
+		t.Errorf("GetMessage() = %+v, want %+v", got, m)
+	}
+
+	if _, err := s.InsertMessage(ctx, store.InsertMessageParams{RoomID: uuid.New(), Message: "orphan"}); err == nil {
+		t.Error("InsertMessage() into a missing room error = nil, want an error")
+	}
+}
+
+func testMessageNotFound(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	missing := uuid.New()
+
+	if _, err := s.GetMessage(ctx, missing); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetMessage() error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.ReactToMessage(ctx, missing); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("ReactToMessage() error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.RemoveReactionFromMessage(ctx, missing); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("RemoveReactionFromMessage() error = %v, want ErrNotFound", err)
+	}
+	if err := s.MarkMessageAsAnswered(ctx, missing); err != nil {
+		t.Errorf("MarkMessageAsAnswered() error = %v, want nil for a missing message", err)
+	}
+}
+
+func testRoomMessages(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	roomID := insertRoom(t, s, "Go")
+	otherID := insertRoom(t, s, "Rust")
+
+	first := insertMessage(t, s, roomID, "first")
+	hidden := insertMessage(t, s, roomID, "hidden")
+	insertMessage(t, s, otherID, "elsewhere")
+
+	if err := s.SetMessageHidden(ctx, store.SetMessageHiddenParams{ID: hidden.ID, Hidden: true}); err != nil {
+		t.Fatalf("SetMessageHidden() error = %v", err)
+	}
+	if err := s.MarkMessageAsAnswered(ctx, first.ID); err != nil {
+		t.Fatalf("MarkMessageAsAnswered() error = %v", err)
+	}
+
+	messages, err := s.GetRoomMessages(ctx, store.GetRoomMessagesParams{RoomID: roomID})
+	if err != nil {
+		t.Fatalf("GetRoomMessages() error = %v", err)
+	}
+	if !equalIDs(messageIDs(messages), []uuid.UUID{first.ID}) {
+		t.Fatalf("GetRoomMessages() = %v, want only the visible message of the room", messageIDs(messages))
+	}
+	if !messages[0].Answered {
+		t.Error("GetRoomMessages() message is not answered")
+	}
+
+	messages, err = s.GetRoomMessages(ctx, store.GetRoomMessagesParams{RoomID: roomID, IncludeHidden: true})
+	if got := messageIDs(messages); err != nil || len(got) != 2 || !slices.Contains(got, first.ID) || !slices.Contains(got, hidden.ID) {
+		t.Errorf("GetRoomMessages(include hidden) = %v, %v, want both messages of the room", got, err)
+	}
+}
+
+func testRoomMessagesPage(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	roomID := insertRoom(t, s, "Go")
+
+	var want []uuid.UUID
+	for _, text := range []string{"a", "b", "c", "d", "e"} {
+		want = append(want, insertMessage(t, s, roomID, text).ID)
+	}
+	if err := s.SetMessageHidden(ctx, store.SetMessageHiddenParams{ID: want[2], Hidden: true}); err != nil {
+		t.Fatalf("SetMessageHidden() error = %v", err)
+	}
+
+	page := func(after store.Message, includeHidden bool) []store.Message {
+		t.Helper()
+		messages, err := s.GetRoomMessagesPage(ctx, store.GetRoomMessagesPageParams{
+			RoomID:         roomID,
+			IncludeHidden:  includeHidden,
+			AfterCreatedAt: after.CreatedAt,
+			AfterID:        after.ID,
+			PageSize:       2,
+		})
+		if err != nil {
+			t.Fatalf("GetRoomMessagesPage() error = %v", err)
+		}
+		return messages
+	}
+
+	// The zero cursor starts from the first message
+	var start store.Message
+	var got []uuid.UUID
+	for cursor := start; ; {
+		messages := page(cursor, true)
+		if len(messages) == 0 {
+			break
+		}
+		if len(messages) > 2 {
+			t.Fatalf("GetRoomMessagesPage() returned %d messages, want at most the page size", len(messages))
+		}
+		got = append(got, messageIDs(messages)...)
+		cursor = messages[len(messages)-1]
+	}
+
+	// Ties on created_at are broken by id, so compare as sets in that order
+	if len(got) != len(want) {
+		t.Fatalf("paging returned %d messages, want %d", len(got), len(want))
+	}
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range got {
+		if seen[id] {
+			t.Fatalf("paging returned %s twice", id)
+		}
+		seen[id] = true
+	}
+
+	visible := page(start, false)
+	visible = append(visible, page(visible[len(visible)-1], false)...)
+	for _, m := range visible {
+		if m.ID == want[2] {
+			t.Error("GetRoomMessagesPage() without hidden returned a hidden message")
+		}
+	}
+}
+
+func testHotMessages(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	roomID := insertRoom(t, s, "Go")
+
+	cold := insertMessage(t, s, roomID, "cold")
+	hot := insertMessage(t, s, roomID, "hot")
+	answered := insertMessage(t, s, roomID, "answered")
+	for range 3 {
+		if _, err := s.ReactToMessage(ctx, hot.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.ReactToMessage(ctx, answered.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.MarkMessageAsAnswered(ctx, answered.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	hotMessages := func(includeAnswered bool, max int32) []uuid.UUID {
+		t.Helper()
+		messages, err := s.GetRoomHotMessages(ctx, store.GetRoomHotMessagesParams{
+			RoomID:          roomID,
+			IncludeAnswered: includeAnswered,
+			Gravity:         1.8,
+			MaxResults:      max,
+		})
+		if err != nil {
+			t.Fatalf("GetRoomHotMessages() error = %v", err)
+		}
+		return messageIDs(messages)
+	}
+
+	if got := hotMessages(false, 0); !equalIDs(got, []uuid.UUID{hot.ID, cold.ID}) {
+		t.Errorf("GetRoomHotMessages() = %v, want the most reacted first and no answered", got)
+	}
+	if got := hotMessages(true, 0); len(got) != 3 || got[2] != cold.ID {
+		t.Errorf("GetRoomHotMessages(include answered) = %v, want all three with the cold one last", got)
+	}
+	if got := hotMessages(false, 1); !equalIDs(got, []uuid.UUID{hot.ID}) {
+		t.Errorf("GetRoomHotMessages(max 1) = %v, want only the hottest", got)
+	}
+}
+
+func testReactions(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	m := insertMessage(t, s, insertRoom(t, s, "Go"), "react to me")
+
+	for _, want := range []store.ReactToMessageRow{{ReactionCount: 1}, {PreviousCount: 1, ReactionCount: 2}} {
+		got, err := s.ReactToMessage(ctx, m.ID)
+		if err != nil || got != want {
+			t.Fatalf("ReactToMessage() = %+v, %v, want %+v", got, err, want)
+		}
+	}
+	for _, want := range []store.RemoveReactionFromMessageRow{{PreviousCount: 2, ReactionCount: 1}, {PreviousCount: 1}, {}} {
+		got, err := s.RemoveReactionFromMessage(ctx, m.ID)
+		if err != nil || got != want {
+			t.Fatalf("RemoveReactionFromMessage() = %+v, %v, want %+v", got, err, want)
+		}
+	}
+
+	got, err := s.GetMessage(ctx, m.ID)
+	if err != nil || got.ReactionCount != 0 {
+		t.Errorf("ReactionCount = %d, %v, want it never to go below zero", got.ReactionCount, err)
+	}
+}
+
+func testConcurrentReactions(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	m := insertMessage(t, s, insertRoom(t, s, "Go"), "popular")
+
+	const reactions = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, reactions)
+	for range reactions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.ReactToMessage(ctx, m.ID); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("ReactToMessage() error = %v", err)
+	}
+
+	got, err := s.GetMessage(ctx, m.ID)
+	if err != nil || got.ReactionCount != reactions {
+		t.Errorf("ReactionCount = %d, %v, want %d after concurrent reactions", got.ReactionCount, err, reactions)
+	}
+}
@@ -0,0 +1,130 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+func testUsers(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	if _, err := s.GetUser(ctx, "ada"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetUser() of a missing user error = %v, want ErrNotFound", err)
+	}
+
+	for _, id := range []string{"ada", "bob"} {
+		if err := s.UpsertUser(ctx, store.UpsertUserParams{ID: id, Name: id}); err != nil {
+			t.Fatalf("UpsertUser() error = %v", err)
+		}
+	}
+	user, err := s.GetUser(ctx, "ada")
+	if err != nil || user.ID != "ada" || user.Name != "ada" || user.AvatarUrl != nil || user.CreatedAt.IsZero() {
+		t.Errorf("GetUser() = %+v, %v, want the inserted user", user, err)
+	}
+
+	users, err := s.GetUsersByIDs(ctx, []string{"bob", "ada", "missing"})
+	if err != nil {
+		t.Fatalf("GetUsersByIDs() error = %v", err)
+	}
+	var ids []string
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	slices.Sort(ids)
+	if !slices.Equal(ids, []string{"ada", "bob"}) {
+		t.Errorf("GetUsersByIDs() = %v, want the existing users", ids)
+	}
+}
+
+func testUpsertUserKeepsUpdatedAt(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	params := store.UpsertUserParams{ID: "ada", Name: "Ada"}
+	if err := s.UpsertUser(ctx, params); err != nil {
+		t.Fatal(err)
+	}
+	created, err := s.GetUser(ctx, "ada")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Let the clock move past the millisecond timestamps of SQLite
+	time.Sleep(5 * time.Millisecond)
+	if err := s.UpsertUser(ctx, params); err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := s.GetUser(ctx, "ada"); !u.UpdatedAt.Equal(created.UpdatedAt) {
+		t.Errorf("UpdatedAt = %v after an identical upsert, want %v", u.UpdatedAt, created.UpdatedAt)
+	}
+
+	params.AvatarUrl = ptr("https://example.com/a.png")
+	if err := s.UpsertUser(ctx, params); err != nil {
+		t.Fatal(err)
+	}
+	u, _ := s.GetUser(ctx, "ada")
+	if !u.UpdatedAt.After(created.UpdatedAt) || !u.CreatedAt.Equal(created.CreatedAt) || u.AvatarUrl == nil || *u.AvatarUrl != *params.AvatarUrl {
+		t.Errorf("user = %+v, want the avatar set, updated_at bumped and created_at kept", u)
+	}
+}
+
+func testUserMessages(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	roomID := insertRoom(t, s, "Go")
+	for _, id := range []string{"ada", "bob"} {
+		if err := s.UpsertUser(ctx, store.UpsertUserParams{ID: id, Name: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	post := func(userID string, anonymous bool) uuid.UUID {
+		t.Helper()
+		// Keep created_at distinct so the order does not fall back to ids
+		time.Sleep(2 * time.Millisecond)
+		m, err := s.InsertMessage(ctx, store.InsertMessageParams{
+			RoomID:     roomID,
+			Message:    "hello",
+			AuthorID:   userID,
+			AuthorName: userID,
+			Anonymous:  anonymous,
+			UserID:     &userID,
+		})
+		if err != nil {
+			t.Fatalf("InsertMessage() error = %v", err)
+		}
+		return m.ID
+	}
+
+	first := post("ada", false)
+	anonymous := post("ada", true)
+	hidden := post("ada", false)
+	post("bob", false)
+	last := post("ada", false)
+	if err := s.SetMessageHidden(ctx, store.SetMessageHiddenParams{ID: hidden, Hidden: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	messages, err := s.GetUserMessages(ctx, store.GetUserMessagesParams{UserID: "ada", MaxResults: 10})
+	if err != nil {
+		t.Fatalf("GetUserMessages() error = %v", err)
+	}
+	if got := messageIDs(messages); !equalIDs(got, []uuid.UUID{last, first}) {
+		t.Errorf("GetUserMessages() = %v, want the visible named messages newest first", got)
+	}
+
+	messages, err = s.GetUserMessages(ctx, store.GetUserMessagesParams{UserID: "ada", MaxResults: 1})
+	if got := messageIDs(messages); err != nil || !equalIDs(got, []uuid.UUID{last}) {
+		t.Errorf("GetUserMessages(max 1) = %v, %v, want only the newest", got, err)
+	}
+
+	messages, err = s.GetUserMessages(ctx, store.GetUserMessagesParams{UserID: "ada", IncludeAnonymous: true, MaxResults: 10})
+	if got := messageIDs(messages); err != nil || !equalIDs(got, []uuid.UUID{last, anonymous, first}) {
+		t.Errorf("GetUserMessages(include anonymous) = %v, %v, want the anonymous message too", got, err)
+	}
+}
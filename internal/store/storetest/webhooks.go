@@ -0,0 +1,187 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+func insertWebhook(t *testing.T, s store.Store, roomID *uuid.UUID, kinds ...string) store.Webhook {
+	t.Helper()
+
+	// Empty, never nil, subscribes to every kind
+	if kinds == nil {
+		kinds = []string{}
+	}
+	w, err := s.InsertWebhook(context.Background(), store.InsertWebhookParams{
+		RoomID:     roomID,
+		Url:        "https://example.com/" + uuid.NewString(),
+		Secret:     uuid.NewString(),
+		EventKinds: kinds,
+		CreatedBy:  "admin",
+	})
+	if err != nil {
+		t.Fatalf("InsertWebhook() error = %v", err)
+	}
+	return w
+}
+
+func testWebhooks(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	roomID := insertRoom(t, s, "Go")
+
+	all := insertWebhook(t, s, nil)
+	scoped := insertWebhook(t, s, &roomID, "message_created", "poll_closed")
+	if all.ID == uuid.Nil || all.RoomID != nil || len(all.EventKinds) != 0 || all.CreatedBy != "admin" || all.CreatedAt.IsZero() {
+		t.Errorf("InsertWebhook() = %+v, want a webhook for every room and kind", all)
+	}
+	if *scoped.RoomID != roomID || len(scoped.EventKinds) != 2 || scoped.EventKinds[1] != "poll_closed" {
+		t.Errorf("InsertWebhook() = %+v, want the room and kinds kept", scoped)
+	}
+
+	webhooks, err := s.GetWebhooks(ctx)
+	if err != nil || len(webhooks) != 2 {
+		t.Fatalf("GetWebhooks() = %+v, %v, want both webhooks", webhooks, err)
+	}
+
+	n, err := s.DeleteWebhook(ctx, scoped.ID)
+	if err != nil || n != 1 {
+		t.Errorf("DeleteWebhook() = %d, %v, want 1", n, err)
+	}
+	if n, err := s.DeleteWebhook(ctx, scoped.ID); err != nil || n != 0 {
+		t.Errorf("DeleteWebhook() of a deleted webhook = %d, %v, want 0", n, err)
+	}
+	if webhooks, _ := s.GetWebhooks(ctx); len(webhooks) != 1 || webhooks[0].ID != all.ID {
+		t.Errorf("GetWebhooks() = %+v, want only the remaining webhook", webhooks)
+	}
+}
+
+func testWebhookDeliveries(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	roomID := insertRoom(t, s, "Go")
+	otherID := insertRoom(t, s, "Rust")
+
+	hooks := map[uuid.UUID]store.Webhook{}
+	for _, w := range []store.Webhook{
+		insertWebhook(t, s, nil),
+		insertWebhook(t, s, nil, "message_created"),
+		insertWebhook(t, s, &roomID, "message_created"),
+		insertWebhook(t, s, &otherID),
+		insertWebhook(t, s, nil, "poll_closed"),
+	} {
+		hooks[w.ID] = w
+	}
+
+	payload := []byte(`{"id":1}`)
+	n, err := s.EnqueueWebhookDeliveries(ctx, store.EnqueueWebhookDeliveriesParams{
+		EventKind: "message_created",
+		RoomID:    roomID,
+		Payload:   payload,
+	})
+	if err != nil || n != 3 {
+		t.Fatalf("EnqueueWebhookDeliveries() = %d, %v, want the 3 webhooks subscribed to the room and kind", n, err)
+	}
+
+	claim := func(batchSize int32) []store.ClaimWebhookDeliveriesRow {
+		t.Helper()
+		claimed, err := s.ClaimWebhookDeliveries(ctx, store.ClaimWebhookDeliveriesParams{LeaseSeconds: 60, BatchSize: batchSize})
+		if err != nil {
+			t.Fatalf("ClaimWebhookDeliveries() error = %v", err)
+		}
+		return claimed
+	}
+
+	if claimed := claim(2); len(claimed) != 2 {
+		t.Fatalf("ClaimWebhookDeliveries(batch 2) claimed %d deliveries, want 2", len(claimed))
+	}
+	claimed := claim(10)
+	if len(claimed) != 1 {
+		t.Fatalf("ClaimWebhookDeliveries() claimed %d deliveries, want the 1 left unleased", len(claimed))
+	}
+	d := claimed[0]
+	w := hooks[d.WebhookID]
+	if d.ID == uuid.Nil || d.EventKind != "message_created" || d.RoomID != roomID || d.Attempts != 0 || d.Url != w.Url || d.Secret != w.Secret || !jsonEqual(t, d.Payload, payload) {
+		t.Errorf("ClaimWebhookDeliveries() = %+v, want the delivery with its webhook %+v", d, w)
+	}
+	if again := claim(10); len(again) != 0 {
+		t.Errorf("ClaimWebhookDeliveries() claimed %d deliveries, want none while leased", len(again))
+	}
+
+	// A failed attempt is retried once it is due again
+	err = s.MarkWebhookDeliveryFailed(ctx, store.MarkWebhookDeliveryFailedParams{
+		ID:             d.ID,
+		LastStatusCode: 500,
+		LastError:      "server error",
+		NextAttemptAt:  time.Now().Add(-time.Second),
+	})
+	if err != nil {
+		t.Fatalf("MarkWebhookDeliveryFailed() error = %v", err)
+	}
+	retry := claim(10)
+	if len(retry) != 1 || retry[0].ID != d.ID || retry[0].Attempts != 1 {
+		t.Fatalf("ClaimWebhookDeliveries() after a failure = %+v, want the retry", retry)
+	}
+
+	err = s.MarkWebhookDeliveryFailed(ctx, store.MarkWebhookDeliveryFailedParams{
+		ID:             d.ID,
+		Dead:           true,
+		LastStatusCode: 500,
+		LastError:      "server error",
+		NextAttemptAt:  time.Now().Add(-time.Second),
+	})
+	if err != nil {
+		t.Fatalf("MarkWebhookDeliveryFailed() error = %v", err)
+	}
+	if again := claim(10); len(again) != 0 {
+		t.Errorf("ClaimWebhookDeliveries() claimed %+v, want dead deliveries left alone", again)
+	}
+	dead, err := s.GetWebhookDeliveries(ctx, store.GetWebhookDeliveriesParams{Status: "dead", Limit: 10})
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("GetWebhookDeliveries(dead) = %+v, %v, want the dead delivery", dead, err)
+	}
+	if dead[0].ID != d.ID || dead[0].Attempts != 2 || dead[0].LastStatusCode != 500 || dead[0].LastError != "server error" || dead[0].DeliveredAt != nil {
+		t.Errorf("GetWebhookDeliveries(dead) = %+v, want both attempts recorded", dead[0])
+	}
+
+	redelivered, err := s.RedeliverWebhookDelivery(ctx, d.ID)
+	if err != nil || redelivered.Status != "pending" || redelivered.Attempts != 0 {
+		t.Fatalf("RedeliverWebhookDelivery() = %+v, %v, want a fresh pending delivery", redelivered, err)
+	}
+	if _, err := s.RedeliverWebhookDelivery(ctx, uuid.New()); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("RedeliverWebhookDelivery() of a missing delivery error = %v, want ErrNotFound", err)
+	}
+	retry = claim(10)
+	if len(retry) != 1 || retry[0].ID != d.ID {
+		t.Fatalf("ClaimWebhookDeliveries() after redelivery = %+v, want the delivery", retry)
+	}
+
+	err = s.MarkWebhookDeliverySucceeded(ctx, store.MarkWebhookDeliverySucceededParams{ID: d.ID, LastStatusCode: 204})
+	if err != nil {
+		t.Fatalf("MarkWebhookDeliverySucceeded() error = %v", err)
+	}
+	delivered, err := s.GetWebhookDeliveries(ctx, store.GetWebhookDeliveriesParams{Status: "delivered", Limit: 10})
+	if err != nil || len(delivered) != 1 {
+		t.Fatalf("GetWebhookDeliveries(delivered) = %+v, %v, want the delivered one", delivered, err)
+	}
+	if got := delivered[0]; got.Attempts != 1 || got.LastStatusCode != 204 || got.LastError != "" || got.DeliveredAt == nil {
+		t.Errorf("GetWebhookDeliveries(delivered) = %+v, want the successful attempt", got)
+	}
+
+	// Deleting a webhook drops its deliveries
+	pending, err := s.GetWebhookDeliveries(ctx, store.GetWebhookDeliveriesParams{Status: "pending", Limit: 1})
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("GetWebhookDeliveries(pending, limit 1) = %+v, %v, want 1 delivery", pending, err)
+	}
+	if _, err := s.DeleteWebhook(ctx, pending[0].WebhookID); err != nil {
+		t.Fatal(err)
+	}
+	pending, err = s.GetWebhookDeliveries(ctx, store.GetWebhookDeliveriesParams{Status: "pending", Limit: 10})
+	if err != nil || len(pending) != 1 {
+		t.Errorf("GetWebhookDeliveries(pending) = %+v, %v, want only the delivery of the remaining webhook", pending, err)
+	}
+}
@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/tracing/tracingtest"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestMiddlewareNamesSpanAfterRoute(t *testing.T) {
+	exporter := tracingtest.Setup()
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/api/rooms/{room_id}/messages", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/rooms/abc/messages", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	if got, want := spans[0].Name, "GET /api/rooms/{room_id}/messages"; got != want {
+		t.Errorf("span name = %q, want %q", got, want)
+	}
+	if got, want := spans[0].SpanContext.TraceID().String(), "4bf92f3577b34da6a3ce929d0e0e4736"; got != want {
+		t.Errorf("trace id = %s, want the caller's %s", got, want)
+	}
+}
+
+func TestQueryTracer(t *testing.T) {
+	exporter := tracingtest.Setup()
+
+	var tracer QueryTracer
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "-- name: GetRoom :one\nSELECT 1"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+
+	ctx = tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT version FROM schema_migrations"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("boom")})
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	if spans[0].Name != "pgstore.GetRoom" || spans[0].Status.Code == codes.Error {
+		t.Errorf("first span = %q (%v), want pgstore.GetRoom without error", spans[0].Name, spans[0].Status.Code)
+	}
+	if spans[1].Name != "pgstore.SELECT" || spans[1].Status.Code != codes.Error {
+		t.Errorf("second span = %q (%v), want pgstore.SELECT with error", spans[1].Name, spans[1].Status.Code)
+	}
+}
@@ -0,0 +1,19 @@
+// Package tracingtest records spans in memory for tests. It is kept apart from
+// tracing so the test exporter never ends up in the server binary.
+package tracingtest
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Setup installs a provider that records every span synchronously in the
+// returned exporter
+func Setup() *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	return exporter
+}
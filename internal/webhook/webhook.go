@@ -0,0 +1,239 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+// Headers sent with every delivery. The signature is the hex encoded
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret.
+const (
+	HeaderSignature = "X-Msgwss-Signature"
+	HeaderTimestamp = "X-Msgwss-Timestamp"
+	HeaderEvent     = "X-Msgwss-Event"
+	HeaderDelivery  = "X-Msgwss-Delivery"
+)
+
+// Store is the subset of the store used to queue and deliver webhooks
+type Store interface {
+	EnqueueWebhookDeliveries(ctx context.Context, arg store.EnqueueWebhookDeliveriesParams) (int64, error)
+	ClaimWebhookDeliveries(ctx context.Context, arg store.ClaimWebhookDeliveriesParams) ([]store.ClaimWebhookDeliveriesRow, error)
+	MarkWebhookDeliverySucceeded(ctx context.Context, arg store.MarkWebhookDeliverySucceededParams) error
+	MarkWebhookDeliveryFailed(ctx context.Context, arg store.MarkWebhookDeliveryFailedParams) error
+}
+
+type Options struct {
+	// PollInterval is how often due deliveries are claimed
+	PollInterval time.Duration
+	// BatchSize is how many deliveries are claimed per poll
+	BatchSize int
+	// MaxAttempts is how many failed attempts move a delivery to the dead letters
+	MaxAttempts int
+	// BaseBackoff is the wait after the first failure, doubled on every retry
+	BaseBackoff time.Duration
+	// MaxBackoff caps the wait between retries
+	MaxBackoff time.Duration
+	// Timeout bounds a single HTTP attempt
+	Timeout time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.PollInterval <= 0 {
+		o.PollInterval = 2 * time.Second
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 20
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 8
+	}
+	if o.BaseBackoff <= 0 {
+		o.BaseBackoff = 10 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Hour
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	return o
+}
+
+// Dispatcher queues events in the webhook_deliveries table and delivers them
+// with exponential backoff. Deliveries survive restarts and are claimed with
+// row locks, so several replicas can run a dispatcher at once.
+type Dispatcher struct {
+	store  Store
+	client *http.Client
+	opts   Options
+	now    func() time.Time
+}
+
+func NewDispatcher(store Store, opts Options) *Dispatcher {
+	opts = opts.withDefaults()
+	return &Dispatcher{
+		store:  store,
+		client: &http.Client{Timeout: opts.Timeout},
+		opts:   opts,
+		now:    time.Now,
+	}
+}
+
+// Enqueue records a delivery of the event for every matching webhook
+func (d *Dispatcher) Enqueue(ctx context.Context, kind string, roomID uuid.UUID, event any) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal webhook payload: %w", err)
+	}
+
+	_, err = d.store.EnqueueWebhookDeliveries(ctx, store.EnqueueWebhookDeliveriesParams{
+		EventKind: kind,
+		RoomID:    roomID,
+		Payload:   payload,
+	})
+	return err
+}
+
+// Run delivers due webhooks until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.deliverDue(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("failed to deliver webhooks", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverDue claims one batch of due deliveries and attempts them concurrently
+func (d *Dispatcher) deliverDue(ctx context.Context) error {
+	// The lease keeps other dispatchers away while an attempt is in flight
+	lease := 2 * d.opts.Timeout
+	deliveries, err := d.store.ClaimWebhookDeliveries(ctx, store.ClaimWebhookDeliveriesParams{
+		LeaseSeconds: int32(lease.Seconds()),
+		BatchSize:    int32(d.opts.BatchSize),
+	})
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.attempt(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, delivery store.ClaimWebhookDeliveriesRow) {
+	status, err := d.post(ctx, delivery)
+	if err == nil {
+		err = d.store.MarkWebhookDeliverySucceeded(ctx, store.MarkWebhookDeliverySucceededParams{
+			ID:             delivery.ID,
+			LastStatusCode: int32(status),
+		})
+		if err != nil {
+			slog.Error("failed to mark webhook delivered", "error", err, "delivery_id", delivery.ID)
+		}
+		return
+	}
+
+	attempts := int(delivery.Attempts) + 1
+	dead := attempts >= d.opts.MaxAttempts
+	next := d.now().Add(d.backoff(attempts))
+
+	slog.Warn("webhook delivery failed", "delivery_id", delivery.ID, "url", delivery.Url,
+		"attempts", attempts, "dead", dead, "error", err)
+
+	err = d.store.MarkWebhookDeliveryFailed(ctx, store.MarkWebhookDeliveryFailedParams{
+		Dead:           dead,
+		LastStatusCode: int32(status),
+		LastError:      err.Error(),
+		NextAttemptAt:  next,
+		ID:             delivery.ID,
+	})
+	if err != nil {
+		slog.Error("failed to record webhook failure", "error", err, "delivery_id", delivery.ID)
+	}
+}
+
+// backoff returns the wait before the next attempt after the given number of
+// failed attempts
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.opts.BaseBackoff
+	for i := 1; i < attempts && wait < d.opts.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, d.opts.MaxBackoff)
+}
+
+// post sends the delivery and returns the response status. Any status outside
+// 2xx is an error.
+func (d *Dispatcher) post(ctx context.Context, delivery store.ClaimWebhookDeliveriesRow) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "msgwss-webhooks/1")
+	req.Header.Set(HeaderEvent, delivery.EventKind)
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature header value for a payload
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header in constant time. Receivers should also
+// reject timestamps too far in the past to prevent replays.
+func Verify(secret string, timestamp int64, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, payload)), []byte(signature))
+}
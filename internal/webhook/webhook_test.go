@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/store"
+
+	"github.com/google/uuid"
+)
+
+// fakeStore keeps deliveries in memory and hands out every pending one on claim
+type fakeStore struct {
+	mu        sync.Mutex
+	pending   []store.ClaimWebhookDeliveriesRow
+	succeeded []store.MarkWebhookDeliverySucceededParams
+	failed    []store.MarkWebhookDeliveryFailedParams
+}
+
+func (f *fakeStore) EnqueueWebhookDeliveries(context.Context, store.EnqueueWebhookDeliveriesParams) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeStore) ClaimWebhookDeliveries(context.Context, store.ClaimWebhookDeliveriesParams) ([]store.ClaimWebhookDeliveriesRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	claimed := f.pending
+	f.pending = nil
+	return claimed, nil
+}
+
+func (f *fakeStore) MarkWebhookDeliverySucceeded(_ context.Context, arg store.MarkWebhookDeliverySucceededParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.succeeded = append(f.succeeded, arg)
+	return nil
+}
+
+func (f *fakeStore) MarkWebhookDeliveryFailed(_ context.Context, arg store.MarkWebhookDeliveryFailedParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failed = append(f.failed, arg)
+	return nil
+}
+
+func TestDeliverySignedAndAcknowledged(t *testing.T) {
+	const secret = "s3cr3t"
+	payload := []byte(`{"kind":"message_created","room_id":"r","value":{}}`)
+
+	var got *http.Request
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	fake := &fakeStore{pending: []store.ClaimWebhookDeliveriesRow{{
+		ID:        uuid.New(),
+		EventKind: "message_created",
+		Payload:   payload,
+		Url:       receiver.URL,
+		Secret:    secret,
+	}}}
+
+	d := NewDispatcher(fake, Options{})
+	if err := d.deliverDue(context.Background()); err != nil {
+		t.Fatalf("deliverDue() error = %v", err)
+	}
+
+	if got == nil {
+		t.Fatal("receiver was not called")
+	}
+	if got.Header.Get(HeaderEvent) != "message_created" {
+		t.Errorf("event header = %q", got.Header.Get(HeaderEvent))
+	}
+
+	timestamp, err := strconv.ParseInt(got.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("bad timestamp header: %v", err)
+	}
+	if !Verify(secret, timestamp, body, got.Header.Get(HeaderSignature)) {
+		t.Error("signature does not verify")
+	}
+	if Verify("other", timestamp, body, got.Header.Get(HeaderSignature)) {
+		t.Error("signature verifies with the wrong secret")
+	}
+
+	if len(fake.succeeded) != 1 || fake.succeeded[0].LastStatusCode != http.StatusNoContent {
+		t.Errorf("delivery not marked as succeeded: %+v", fake.succeeded)
+	}
+}
+
+func TestDeliveryRetriesWithBackoffThenDies(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	now := time.Unix(1_700_000_000, 0)
+	fake := &fakeStore{}
+	d := NewDispatcher(fake, Options{MaxAttempts: 3, BaseBackoff: time.Second, MaxBackoff: time.Minute})
+	d.now = func() time.Time { return now }
+
+	delivery := store.ClaimWebhookDeliveriesRow{ID: uuid.New(), Url: receiver.URL, Payload: []byte(`{}`)}
+	for attempt := 0; attempt < 3; attempt++ {
+		delivery.Attempts = int32(attempt)
+		fake.pending = []store.ClaimWebhookDeliveriesRow{delivery}
+		if err := d.deliverDue(context.Background()); err != nil {
+			t.Fatalf("deliverDue() error = %v", err)
+		}
+	}
+
+	if len(fake.failed) != 3 {
+		t.Fatalf("got %d failures, want 3", len(fake.failed))
+	}
+
+	wantWaits := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+	for i, f := range fake.failed {
+		if wait := f.NextAttemptAt.Sub(now); wait != wantWaits[i] {
+			t.Errorf("attempt %d: next attempt in %v, want %v", i+1, wait, wantWaits[i])
+		}
+		if f.LastStatusCode != http.StatusInternalServerError {
+			t.Errorf("attempt %d: status = %d", i+1, f.LastStatusCode)
+		}
+		if wantDead := i == 2; f.Dead != wantDead {
+			t.Errorf("attempt %d: dead = %v, want %v", i+1, f.Dead, wantDead)
+		}
+	}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	d := NewDispatcher(&fakeStore{}, Options{BaseBackoff: time.Second, MaxBackoff: 5 * time.Second})
+	if got := d.backoff(10); got != 5*time.Second {
+		t.Errorf("backoff(10) = %v, want 5s", got)
+	}
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// IssueGuestToken joins the room as a guest with a display name that no other
+// guest of the room holds. It needs no authentication; use the token with
+// Clone(WithToken(...)).
+func (c *Client) IssueGuestToken(ctx context.Context, roomID, name string) (GuestToken, error) {
+	var token GuestToken
+	body := map[string]string{"room_id": roomID, "name": name}
+	err := c.do(ctx, http.MethodPost, c.endpoint("/api/auth/guest/", nil), body, &token)
+	return token, err
+}
+
+// RefreshGuestToken exchanges the guest token of the client for a new one
+// with the same identity and name
+func (c *Client) RefreshGuestToken(ctx context.Context) (GuestToken, error) {
+	var token GuestToken
+	err := c.do(ctx, http.MethodPost, c.endpoint("/api/auth/guest/refresh", nil), nil, &token)
+	return token, err
+}
+
+// GetUser returns the profile of a signed in user with up to limit of their
+// latest messages, 0 for the server default
+func (c *Client) GetUser(ctx context.Context, userID string, limit int) (User, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var user User
+	err := c.do(ctx, http.MethodGet, c.endpoint(pathf("/api/users/%s", userID), query), nil, &user)
+	return user, err
+}
+
+type CreateAPIKeyParams struct {
+	Name string `json:"name"`
+	// RoomIDs restricts the key to these rooms, every room when empty
+	RoomIDs []string `json:"room_ids,omitempty"`
+	// Permissions are the Permission constants granted to the key
+	Permissions []string `json:"permissions"`
+}
+
+// CreateAPIKey creates a key for bots and integrations. The secret is only
+// returned here, in APIKey.Key. It needs an admin token.
+func (c *Client) CreateAPIKey(ctx context.Context, params CreateAPIKeyParams) (APIKey, error) {
+	var key APIKey
+	err := c.do(ctx, http.MethodPost, c.endpoint("/api/apikeys/", nil), params, &key)
+	return key, err
+}
+
+func (c *Client) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	var keys []APIKey
+	err := c.do(ctx, http.MethodGet, c.endpoint("/api/apikeys/", nil), nil, &keys)
+	return keys, err
+}
+
+// RevokeAPIKey disables the key and returns it with RevokedAt set
+func (c *Client) RevokeAPIKey(ctx context.Context, keyID string) (APIKey, error) {
+	var key APIKey
+	err := c.do(ctx, http.MethodDelete, c.endpoint(pathf("/api/apikeys/%s", keyID), nil), nil, &key)
+	return key, err
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// AuditLogQuery filters the audit log. Zero fields match everything.
+type AuditLogQuery struct {
+	Action   string
+	ActorID  string
+	TargetID string
+	RoomID   string
+	Since    time.Time
+	Until    time.Time
+	// BeforeID pages backwards: pass the ID of the last entry of the previous
+	// page
+	BeforeID int64
+	// Limit defaults to 100 on the server
+	Limit int
+}
+
+func (q AuditLogQuery) values() url.Values {
+	v := url.Values{}
+	for key, value := range map[string]string{"action": q.Action, "actor_id": q.ActorID, "target_id": q.TargetID, "room_id": q.RoomID} {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	if !q.Since.IsZero() {
+		v.Set("since", q.Since.Format(time.RFC3339))
+	}
+	if !q.Until.IsZero() {
+		v.Set("until", q.Until.Format(time.RFC3339))
+	}
+	if q.BeforeID > 0 {
+		v.Set("before_id", strconv.FormatInt(q.BeforeID, 10))
+	}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return v
+}
+
+// GetAuditLog returns the matching entries, newest first. It needs an admin
+// token.
+func (c *Client) GetAuditLog(ctx context.Context, query AuditLogQuery) ([]AuditLogEntry, error) {
+	var entries []AuditLogEntry
+	err := c.do(ctx, http.MethodGet, c.endpoint("/api/audit", query.values()), nil, &entries)
+	return entries, err
+}
+
+type CreateWebhookParams struct {
+	URL string `json:"url"`
+	// RoomID limits the webhook to one room, every room when empty
+	RoomID string `json:"room_id,omitempty"`
+	// EventKinds limits the webhook to these Kind constants, every kind when
+	// empty
+	EventKinds []string `json:"event_kinds,omitempty"`
+}
+
+// CreateWebhook registers an endpoint for the room events. The signing secret
+// is only returned here, in Webhook.Secret. It needs an admin token.
+func (c *Client) CreateWebhook(ctx context.Context, params CreateWebhookParams) (Webhook, error) {
+	var hook Webhook
+	err := c.do(ctx, http.MethodPost, c.endpoint("/api/webhooks/", nil), params, &hook)
+	return hook, err
+}
+
+func (c *Client) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	var hooks []Webhook
+	err := c.do(ctx, http.MethodGet, c.endpoint("/api/webhooks/", nil), nil, &hooks)
+	return hooks, err
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, webhookID string) error {
+	return c.do(ctx, http.MethodDelete, c.endpoint(pathf("/api/webhooks/%s", webhookID), nil), nil, nil)
+}
+
+// ListWebhookDeliveries returns the deliveries with the status, dead letters
+// when it is empty, up to limit or the server default when it is 0
+func (c *Client) ListWebhookDeliveries(ctx context.Context, status string, limit int) ([]WebhookDelivery, error) {
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", status)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var deliveries []WebhookDelivery
+	err := c.do(ctx, http.MethodGet, c.endpoint("/api/webhooks/deliveries", query), nil, &deliveries)
+	return deliveries, err
+}
+
+// RedeliverWebhook queues the delivery again, typically a dead letter
+func (c *Client) RedeliverWebhook(ctx context.Context, deliveryID string) (WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	err := c.do(ctx, http.MethodPost, c.endpoint(pathf("/api/webhooks/deliveries/%s/redeliver", deliveryID), nil), nil, &delivery)
+	return delivery, err
+}
@@ -0,0 +1,210 @@
+// Package client is the Go SDK for the msgwss REST API and its WebSocket
+// event stream.
+//
+// Every route has a typed method, failures come back as *Error values that
+// match the sentinel errors with errors.Is, and Subscribe decodes the room
+// events into concrete structs while reconnecting on its own:
+//
+//	c, err := client.New("https://msgwss.example.com", client.WithToken(jwt))
+//	id, err := c.CreateMessage(ctx, roomID, client.CreateMessageParams{Message: "Hello"})
+//
+//	sub, err := c.Subscribe(ctx, roomID, client.SubscribeOptions{})
+//	for event := range sub.Events() {
+//		switch v := event.Value.(type) {
+//		case *client.MessageMessageCreated:
+//			fmt.Println(v.AuthorName, v.Message)
+//		}
+//	}
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// Client calls one msgwss server. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	dialer     *websocket.Dialer
+	token      string
+	apiKey     string
+	userAgent  string
+	language   string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithToken authenticates every request with a bearer token: a user JWT, a
+// guest token or an API key
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithAPIKey authenticates every request with an API key sent in the
+// X-API-Key header, for proxies that strip Authorization
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithHTTPClient replaces http.DefaultClient for REST calls
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithDialer replaces websocket.DefaultDialer for subscriptions
+func WithDialer(d *websocket.Dialer) Option {
+	return func(c *Client) { c.dialer = d }
+}
+
+// WithUserAgent sets the User-Agent of every request
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// WithLanguage sets the Accept-Language of every request, which picks the
+// language of Error.Message and of error events, e.g. "pt-BR". The server
+// falls back to English.
+func WithLanguage(tag string) Option {
+	return func(c *Client) { c.language = tag }
+}
+
+// New returns a client for the server at baseURL, e.g. https://example.com.
+// The scheme must be http or https; subscriptions switch it to ws or wss.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid base URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("client: base URL must be an absolute http or https URL, got %q", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+	u.RawQuery = ""
+	u.Fragment = ""
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		dialer:     websocket.DefaultDialer,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Clone returns a copy of the client with opts applied, e.g. to act as a
+// guest right after issuing its token:
+//
+//	guest := c.Clone(client.WithToken(g.Token))
+func (c *Client) Clone(opts ...Option) *Client {
+	clone := *c
+	for _, opt := range opts {
+		opt(&clone)
+	}
+	return &clone
+}
+
+// pathf formats a route with path escaped arguments, e.g.
+// pathf("/api/rooms/%s/", roomID)
+func pathf(format string, args ...string) string {
+	escaped := make([]any, len(args))
+	for i, arg := range args {
+		escaped[i] = url.PathEscape(arg)
+	}
+	return fmt.Sprintf(format, escaped...)
+}
+
+// endpoint returns the absolute URL of the route
+func (c *Client) endpoint(path string, query url.Values) string {
+	endpoint := c.baseURL.String() + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	return endpoint
+}
+
+// header returns the authentication and language headers of the client
+func (c *Client) header() http.Header {
+	h := http.Header{}
+	if c.token != "" {
+		h.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.apiKey != "" {
+		h.Set("X-API-Key", c.apiKey)
+	}
+	if c.userAgent != "" {
+		h.Set("User-Agent", c.userAgent)
+	}
+	if c.language != "" {
+		h.Set("Accept-Language", c.language)
+	}
+	return h
+}
+
+// request sends body as JSON and returns the response when the status is 2xx.
+// Any other status is turned into an *Error and the body is closed.
+func (c *Client) request(ctx context.Context, method, endpoint string, body any) (*http.Response, error) {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("client: encode request: %w", err)
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, payload)
+	if err != nil {
+		return nil, fmt.Errorf("client: %w", err)
+	}
+	req.Header = c.header()
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, newError(req.Method, req.URL.Path, resp)
+	}
+	return resp, nil
+}
+
+// do sends the request and decodes the JSON response into out, unless out
+// is nil
+func (c *Client) do(ctx context.Context, method, endpoint string, body, out any) error {
+	resp, err := c.request(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("client: %s %s: empty response", method, resp.Request.URL.Path)
+		}
+		return fmt.Errorf("client: decode %s %s: %w", method, resp.Request.URL.Path, err)
+	}
+	return nil
+}
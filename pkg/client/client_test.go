@@ -0,0 +1,362 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/internal/api"
+	"github.com/felipemacedo1/go-msg-wss/internal/auth"
+	"github.com/felipemacedo1/go-msg-wss/internal/config"
+	"github.com/felipemacedo1/go-msg-wss/internal/store/memstore"
+	"github.com/felipemacedo1/go-msg-wss/pkg/client"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testJWTSecret = "test-jwt-secret"
+
+// newTestServer serves the API from an in-memory store
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	h := api.NewHandler(memstore.New(), config.Config{
+		AllowedOrigins:   []string{"*"},
+		JWT:              auth.Config{HMACSecret: []byte(testJWTSecret)},
+		GuestTokenTTL:    time.Hour,
+		RateLimitBackend: "memory",
+	})
+	srv := httptest.NewServer(h)
+	t.Cleanup(func() {
+		srv.Close()
+		_ = h.Shutdown(context.Background())
+	})
+	return srv
+}
+
+// newTestClient returns a client of srv acting with the claims, anonymously
+// when they are nil
+func newTestClient(t *testing.T, srv *httptest.Server, claims jwt.MapClaims) *client.Client {
+	t.Helper()
+
+	var opts []client.Option
+	if claims != nil {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts = append(opts, client.WithToken(token))
+	}
+
+	c, err := client.New(srv.URL+"/", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func moderator(t *testing.T, srv *httptest.Server) *client.Client {
+	return newTestClient(t, srv, jwt.MapClaims{"sub": "mod", "name": "Mod", "role": "moderator"})
+}
+
+func admin(t *testing.T, srv *httptest.Server) *client.Client {
+	return newTestClient(t, srv, jwt.MapClaims{"sub": "root", "name": "Root", "role": "admin"})
+}
+
+func TestNew(t *testing.T) {
+	for _, raw := range []string{"", "localhost:8080", "ws://localhost:8080", "http://"} {
+		if _, err := client.New(raw); err == nil {
+			t.Errorf("New(%q) succeeded, want an error", raw)
+		}
+	}
+}
+
+func TestRoomsAndMessages(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+	mod := moderator(t, srv)
+	alice := newTestClient(t, srv, jwt.MapClaims{"sub": "alice", "name": "Alice"})
+
+	roomID, err := mod.CreateRoom(ctx, client.CreateRoomParams{Theme: "SDK", AnonymousPolicy: client.AnonymousPolicyAllowed})
+	if err != nil {
+		t.Fatalf("CreateRoom() error = %v", err)
+	}
+
+	room, err := alice.GetRoom(ctx, roomID)
+	if err != nil {
+		t.Fatalf("GetRoom() error = %v", err)
+	}
+	want := client.Room{ID: roomID, Theme: "SDK", AllowGuests: true, AnonymousPolicy: client.AnonymousPolicyAllowed}
+	if room != want {
+		t.Errorf("GetRoom() = %+v, want %+v", room, want)
+	}
+
+	if room, err = mod.SetRoomAllowGuests(ctx, roomID, false); err != nil || room.AllowGuests {
+		t.Errorf("SetRoomAllowGuests() = %+v, %v", room, err)
+	}
+	if rooms, err := alice.ListRooms(ctx); err != nil || len(rooms) != 1 {
+		t.Errorf("ListRooms() = %v, %v", rooms, err)
+	}
+
+	first, err := alice.CreateMessage(ctx, roomID, client.CreateMessageParams{Message: "first", Anonymous: true})
+	if err != nil {
+		t.Fatalf("CreateMessage() error = %v", err)
+	}
+	second, err := alice.CreateMessage(ctx, roomID, client.CreateMessageParams{Message: "second"})
+	if err != nil {
+		t.Fatalf("CreateMessage() error = %v", err)
+	}
+
+	if count, err := alice.React(ctx, roomID, second); err != nil || count != 1 {
+		t.Errorf("React() = %d, %v, want 1", count, err)
+	}
+	if count, err := alice.React(ctx, roomID, second); err != nil || count != 2 {
+		t.Errorf("React() = %d, %v, want 2", count, err)
+	}
+	if count, err := alice.RemoveReaction(ctx, roomID, second); err != nil || count != 1 {
+		t.Errorf("RemoveReaction() = %d, %v, want 1", count, err)
+	}
+
+	hot, err := alice.ListMessages(ctx, roomID, client.ListMessagesOptions{Hot: true, Limit: 1})
+	if err != nil || len(hot) != 1 || hot[0].ID != second {
+		t.Errorf("ListMessages(hot) = %+v, %v, want %s first", hot, err, second)
+	}
+
+	if err := mod.MarkAnswered(ctx, roomID, first); err != nil {
+		t.Errorf("MarkAnswered() error = %v", err)
+	}
+	if err := mod.HideMessage(ctx, roomID, second); err != nil {
+		t.Errorf("HideMessage() error = %v", err)
+	}
+	if err := mod.UnhideMessage(ctx, roomID, second); err != nil {
+		t.Errorf("UnhideMessage() error = %v", err)
+	}
+
+	m, err := alice.GetMessage(ctx, roomID, first)
+	if err != nil {
+		t.Fatalf("GetMessage() error = %v", err)
+	}
+	if m.ID != first || m.RoomID != roomID || m.Message != "first" || !m.Answered || !m.Anonymous || m.CreatedAt.IsZero() {
+		t.Errorf("GetMessage() = %+v", m)
+	}
+	if m.UserID != nil || m.AuthorID == "alice" {
+		t.Errorf("GetMessage() revealed the anonymous author: %+v", m)
+	}
+
+	all, err := alice.ListMessages(ctx, roomID, client.ListMessagesOptions{})
+	if err != nil || len(all) != 2 {
+		t.Fatalf("ListMessages() = %+v, %v", all, err)
+	}
+
+	user, err := alice.GetUser(ctx, "alice", 10)
+	if err != nil || user.Name != "Alice" || len(user.Messages) != 2 || user.Messages[0].ID != second || user.Messages[1].ID != first {
+		t.Fatalf("GetUser() = %+v, %v", user, err)
+	}
+	if m := user.Messages[0]; m.UserID == nil || *m.UserID != "alice" || m.AuthorName != "Alice" {
+		t.Errorf("GetUser() message = %+v", m)
+	}
+	if m := user.Messages[1]; !m.Anonymous || m.UserID != nil || m.AuthorID == "alice" {
+		t.Errorf("GetUser() revealed the anonymous author on her own profile: %+v", m)
+	}
+
+	user, err = mod.GetUser(ctx, "alice", 10)
+	if err != nil || len(user.Messages) != 1 || user.Messages[0].ID != second {
+		t.Errorf("GetUser() by another user = %+v, %v", user, err)
+	}
+
+	if room, err = mod.SetRoomAnonymousPolicy(ctx, roomID, client.AnonymousPolicyOnly); err != nil || room.AnonymousPolicy != client.AnonymousPolicyOnly {
+		t.Errorf("SetRoomAnonymousPolicy() = %+v, %v", room, err)
+	}
+
+	export, err := mod.ExportRoom(ctx, roomID, client.ExportOptions{Format: client.ExportFormatCSV, Anonymize: true})
+	if err != nil {
+		t.Fatalf("ExportRoom() error = %v", err)
+	}
+	defer export.Close()
+	data, err := io.ReadAll(export)
+	if err != nil || !strings.Contains(string(data), "second") {
+		t.Errorf("ExportRoom() = %q, %v", data, err)
+	}
+}
+
+func TestPollsAndModeration(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+	mod := moderator(t, srv)
+	alice := newTestClient(t, srv, jwt.MapClaims{"sub": "alice", "name": "Alice"})
+
+	roomID, err := mod.CreateRoom(ctx, client.CreateRoomParams{Theme: "Polls"})
+	if err != nil {
+		t.Fatalf("CreateRoom() error = %v", err)
+	}
+
+	poll, err := mod.CreatePoll(ctx, roomID, client.CreatePollParams{Question: "Lunch?", Options: []string{"Yes", "No"}})
+	if err != nil {
+		t.Fatalf("CreatePoll() error = %v", err)
+	}
+	if poll.Status != client.PollStatusOpen || len(poll.Options) != 2 || poll.ClosedAt != nil {
+		t.Fatalf("CreatePoll() = %+v", poll)
+	}
+
+	voted, err := alice.Vote(ctx, roomID, poll.ID, poll.Options[0].ID)
+	if err != nil || voted.TotalVotes != 1 || voted.Options[0].Votes != 1 {
+		t.Errorf("Vote() = %+v, %v", voted, err)
+	}
+	if _, err := alice.Vote(ctx, roomID, poll.ID, poll.Options[1].ID); !errors.Is(err, client.ErrConflict) {
+		t.Errorf("second Vote() error = %v, want ErrConflict", err)
+	}
+
+	closed, err := mod.ClosePoll(ctx, roomID, poll.ID)
+	if err != nil || closed.Status != client.PollStatusClosed || closed.ClosedAt == nil {
+		t.Errorf("ClosePoll() = %+v, %v", closed, err)
+	}
+	if polls, err := alice.ListPolls(ctx, roomID); err != nil || len(polls) != 1 {
+		t.Errorf("ListPolls() = %+v, %v", polls, err)
+	}
+	if got, err := alice.GetPoll(ctx, roomID, poll.ID); err != nil || got.TotalVotes != 1 {
+		t.Errorf("GetPoll() = %+v, %v", got, err)
+	}
+
+	mute, err := mod.Mute(ctx, roomID, client.SanctionParams{
+		SanctionTarget: client.SanctionTarget{AuthorID: "alice"},
+		Reason:         "spam",
+		Duration:       time.Hour,
+	})
+	if err != nil || mute.Kind != client.SanctionKindMute || mute.ExpiresAt == nil || *mute.AuthorID != "alice" {
+		t.Fatalf("Mute() = %+v, %v", mute, err)
+	}
+	if _, err := alice.CreateMessage(ctx, roomID, client.CreateMessageParams{Message: "muted"}); !errors.Is(err, client.ErrForbidden) {
+		t.Errorf("CreateMessage() while muted error = %v, want ErrForbidden", err)
+	}
+	if mutes, err := mod.ListMutes(ctx, roomID); err != nil || len(mutes) != 1 {
+		t.Errorf("ListMutes() = %+v, %v", mutes, err)
+	}
+	if revoked, err := mod.RevokeMute(ctx, roomID, mute.ID); err != nil || revoked.RevokedAt == nil {
+		t.Errorf("RevokeMute() = %+v, %v", revoked, err)
+	}
+
+	ban, err := mod.Ban(ctx, roomID, client.SanctionParams{SanctionTarget: client.SanctionTarget{AuthorID: "alice"}})
+	if err != nil || ban.Kind != client.SanctionKindBan || ban.ExpiresAt != nil {
+		t.Fatalf("Ban() = %+v, %v", ban, err)
+	}
+	if bans, err := mod.ListBans(ctx, roomID); err != nil || len(bans) != 1 {
+		t.Errorf("ListBans() = %+v, %v", bans, err)
+	}
+	if _, err := mod.RevokeBan(ctx, roomID, ban.ID); err != nil {
+		t.Errorf("RevokeBan() error = %v", err)
+	}
+
+	if kicked, err := mod.Kick(ctx, roomID, client.SanctionTarget{AuthorID: "alice"}); err != nil || kicked != 0 {
+		t.Errorf("Kick() = %d, %v, want 0 without subscriptions", kicked, err)
+	}
+}
+
+func TestGuestsAndAdmin(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+	mod := moderator(t, srv)
+	root := admin(t, srv)
+	anonymous := newTestClient(t, srv, nil)
+
+	roomID, err := mod.CreateRoom(ctx, client.CreateRoomParams{Theme: "Guests"})
+	if err != nil {
+		t.Fatalf("CreateRoom() error = %v", err)
+	}
+
+	token, err := anonymous.IssueGuestToken(ctx, roomID, "Bob")
+	if err != nil || token.Token == "" || token.Name != "Bob" || token.RoomID != roomID {
+		t.Fatalf("IssueGuestToken() = %+v, %v", token, err)
+	}
+	guest := anonymous.Clone(client.WithToken(token.Token))
+	refreshed, err := guest.RefreshGuestToken(ctx)
+	if err != nil || refreshed.GuestID != token.GuestID {
+		t.Errorf("RefreshGuestToken() = %+v, %v", refreshed, err)
+	}
+	if _, err := guest.CreateMessage(ctx, roomID, client.CreateMessageParams{Message: "hi"}); err != nil {
+		t.Errorf("CreateMessage() as guest error = %v", err)
+	}
+
+	key, err := root.CreateAPIKey(ctx, client.CreateAPIKeyParams{
+		Name:        "bot",
+		RoomIDs:     []string{roomID},
+		Permissions: []string{client.PermissionRead, client.PermissionPost},
+	})
+	if err != nil || !strings.HasPrefix(key.Key, key.Prefix) {
+		t.Fatalf("CreateAPIKey() = %+v, %v", key, err)
+	}
+	bot := anonymous.Clone(client.WithAPIKey(key.Key))
+	if _, err := bot.CreateMessage(ctx, roomID, client.CreateMessageParams{Message: "beep"}); err != nil {
+		t.Errorf("CreateMessage() with X-API-Key error = %v", err)
+	}
+	if _, err := bot.CreatePoll(ctx, roomID, client.CreatePollParams{Question: "?", Options: []string{"a", "b"}}); !errors.Is(err, client.ErrForbidden) {
+		t.Errorf("CreatePoll() without the moderate permission error = %v, want ErrForbidden", err)
+	}
+	if keys, err := root.ListAPIKeys(ctx); err != nil || len(keys) != 1 || keys[0].Key != "" {
+		t.Errorf("ListAPIKeys() = %+v, %v", keys, err)
+	}
+	if revoked, err := root.RevokeAPIKey(ctx, key.ID); err != nil || revoked.RevokedAt == nil {
+		t.Errorf("RevokeAPIKey() = %+v, %v", revoked, err)
+	}
+
+	hook, err := root.CreateWebhook(ctx, client.CreateWebhookParams{URL: "https://example.com/hook", RoomID: roomID})
+	if err != nil || hook.Secret == "" || hook.RoomID == nil || *hook.RoomID != roomID {
+		t.Fatalf("CreateWebhook() = %+v, %v", hook, err)
+	}
+	if hooks, err := root.ListWebhooks(ctx); err != nil || len(hooks) != 1 || hooks[0].Secret != "" {
+		t.Errorf("ListWebhooks() = %+v, %v", hooks, err)
+	}
+	if _, err := root.ListWebhookDeliveries(ctx, client.DeliveryStatusDead, 10); err != nil {
+		t.Errorf("ListWebhookDeliveries() error = %v", err)
+	}
+	if err := root.DeleteWebhook(ctx, hook.ID); err != nil {
+		t.Errorf("DeleteWebhook() error = %v", err)
+	}
+
+	entries, err := root.GetAuditLog(ctx, client.AuditLogQuery{RoomID: roomID, Since: time.Now().Add(-time.Minute), Limit: 50})
+	if err != nil || len(entries) == 0 {
+		t.Fatalf("GetAuditLog() = %+v, %v", entries, err)
+	}
+	if entries[len(entries)-1].Action != "room.create" {
+		t.Errorf("oldest audit entry = %+v, want room.create", entries[len(entries)-1])
+	}
+}
+
+func TestErrors(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+	mod := moderator(t, srv)
+
+	roomID, err := mod.CreateRoom(ctx, client.CreateRoomParams{Theme: "Errors"})
+	if err != nil {
+		t.Fatalf("CreateRoom() error = %v", err)
+	}
+
+	_, err = mod.GetMessage(ctx, roomID, "00000000-0000-0000-0000-000000000000")
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("GetMessage() error = %v, want *Error", err)
+	}
+	if apiErr.StatusCode != 404 || apiErr.Code != "message_not_found" || apiErr.Message != "message not found" || apiErr.RequestID == "" {
+		t.Errorf("GetMessage() error = %+v", apiErr)
+	}
+	if !errors.Is(err, client.ErrNotFound) || errors.Is(err, client.ErrBadRequest) {
+		t.Errorf("GetMessage() error = %v, want ErrNotFound only", err)
+	}
+
+	_, err = mod.Clone(client.WithLanguage("pt-BR")).GetRoom(ctx, "00000000-0000-0000-0000-000000000000")
+	if !errors.As(err, &apiErr) || apiErr.Code != "room_not_found" || apiErr.Message != "sala não encontrada" {
+		t.Errorf("GetRoom() in Portuguese error = %v, want room_not_found", err)
+	}
+
+	if _, err := mod.CreateRoom(ctx, client.CreateRoomParams{}); !errors.Is(err, client.ErrBadRequest) {
+		t.Errorf("CreateRoom() without theme error = %v, want ErrBadRequest", err)
+	}
+	if _, err := newTestClient(t, srv, nil).ListAPIKeys(ctx); !errors.Is(err, client.ErrUnauthorized) {
+		t.Errorf("ListAPIKeys() anonymously error = %v, want ErrUnauthorized", err)
+	}
+}
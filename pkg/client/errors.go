@@ -0,0 +1,107 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sentinel errors matched by *Error through errors.Is, by status code
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrUnavailable  = errors.New("service unavailable")
+)
+
+var statusErrors = map[int]error{
+	http.StatusBadRequest:         ErrBadRequest,
+	http.StatusUnauthorized:       ErrUnauthorized,
+	http.StatusForbidden:          ErrForbidden,
+	http.StatusNotFound:           ErrNotFound,
+	http.StatusConflict:           ErrConflict,
+	http.StatusTooManyRequests:    ErrRateLimited,
+	http.StatusServiceUnavailable: ErrUnavailable,
+}
+
+// maxErrorBody bounds how much of an error response is read
+const maxErrorBody = 4 << 10
+
+// Error is a response of the server with a non 2xx status
+type Error struct {
+	StatusCode int
+	// Code identifies the error for programs, e.g. "room_not_found". It is
+	// empty when the response did not come from the API, e.g. from a proxy.
+	Code string
+	// Message is the explanation sent by the server, e.g. "room not found",
+	// in the language asked for with WithLanguage
+	Message string
+	// RequestID identifies the request in the logs of the server
+	RequestID string
+	Method    string
+	Path      string
+	// RetryAfter is how long the server asked to wait before retrying, from
+	// the Retry-After header of 429 and 503 responses
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.Code != "" {
+		return fmt.Sprintf("client: %s %s: %d %s: %s", e.Method, e.Path, e.StatusCode, e.Code, msg)
+	}
+	return fmt.Sprintf("client: %s %s: %d %s", e.Method, e.Path, e.StatusCode, msg)
+}
+
+// Is reports whether target is the sentinel error of the status code
+func (e *Error) Is(target error) bool {
+	sentinel, ok := statusErrors[e.StatusCode]
+	return ok && sentinel == target
+}
+
+// ErrorBody is the error of the JSON envelope of error responses, also the
+// value of error events
+type ErrorBody struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// newError reads the error response to the request. The server answers
+// errors with a JSON envelope; anything else in between, e.g. a proxy, is
+// kept as a plain text message.
+func newError(method, path string, resp *http.Response) *Error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	e := &Error{
+		StatusCode: resp.StatusCode,
+		Method:     method,
+		Path:       path,
+	}
+
+	var envelope struct {
+		Error *ErrorBody `json:"error"`
+	}
+	if json.Unmarshal(body, &envelope) == nil && envelope.Error != nil {
+		e.Code = envelope.Error.Code
+		e.Message = envelope.Error.Message
+		e.RequestID = envelope.Error.RequestID
+	} else {
+		e.Message = strings.TrimSpace(string(body))
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return e
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Kinds of the events pushed to subscribers and webhooks
+const (
+	KindMessageCreated           = "message_created"
+	KindMessageReactionIncreased = "message_reaction_increased"
+	KindMessageReactionDecreased = "message_reaction_decreased"
+	KindMessageAnswered          = "message_answered"
+	KindMessageHidden            = "message_hidden"
+	KindMessageUnhidden          = "message_unhidden"
+	KindPollCreated              = "poll_created"
+	KindPollVotesUpdated         = "poll_votes_updated"
+	KindPollClosed               = "poll_closed"
+	KindTopQuestionsChanged      = "top_questions_changed"
+
+	// KindError is sent to one subscriber only, when it sent an invalid
+	// frame or right before a moderator closes its connection
+	KindError = "error"
+)
+
+// Keepalive frames, which Subscription handles itself
+const (
+	kindClientPing = "client_ping"
+	kindServerPong = "server_pong"
+)
+
+// MessageMessageCreated is the new message, as GetMessage returns it
+type MessageMessageCreated struct {
+	RoomMessage
+}
+
+type MessageMessageReactionIncreased struct {
+	ID    string `json:"id"`
+	Count int64  `json:"count"`
+}
+
+type MessageMessageReactionDecreased struct {
+	ID    string `json:"id"`
+	Count int64  `json:"count"`
+}
+
+type MessageMessageAnswered struct {
+	ID string `json:"id"`
+}
+
+type MessageMessageHidden struct {
+	ID string `json:"id"`
+}
+
+type MessageMessageUnhidden struct {
+	ID string `json:"id"`
+}
+
+type MessagePollCreated struct {
+	Poll
+}
+
+type MessagePollVotesUpdated struct {
+	ID         string       `json:"id"`
+	Options    []PollOption `json:"options"`
+	TotalVotes int64        `json:"total_votes"`
+}
+
+// MessagePollClosed carries the final tallies
+type MessagePollClosed struct {
+	Poll
+}
+
+// MessageTopQuestionsChanged is the new ranking of the unanswered questions
+type MessageTopQuestionsChanged struct {
+	Messages []RoomMessage `json:"messages"`
+}
+
+// MessageError is the value of KindError events, e.g. the localized "kicked"
+// error preceding an ErrKicked
+type MessageError struct {
+	ErrorBody
+}
+
+// Event is one event of a room
+type Event struct {
+	Kind   string
+	RoomID string
+	// Value is a pointer to the struct of the kind, e.g.
+	// *MessageMessageCreated for KindMessageCreated, or the undecoded
+	// json.RawMessage of kinds this package does not know yet
+	Value any
+}
+
+// newEventValue returns a pointer to the value struct of the kind
+func newEventValue(kind string) any {
+	switch kind {
+	case KindMessageCreated:
+		return new(MessageMessageCreated)
+	case KindMessageReactionIncreased:
+		return new(MessageMessageReactionIncreased)
+	case KindMessageReactionDecreased:
+		return new(MessageMessageReactionDecreased)
+	case KindMessageAnswered:
+		return new(MessageMessageAnswered)
+	case KindMessageHidden:
+		return new(MessageMessageHidden)
+	case KindMessageUnhidden:
+		return new(MessageMessageUnhidden)
+	case KindPollCreated:
+		return new(MessagePollCreated)
+	case KindPollVotesUpdated:
+		return new(MessagePollVotesUpdated)
+	case KindPollClosed:
+		return new(MessagePollClosed)
+	case KindTopQuestionsChanged:
+		return new(MessageTopQuestionsChanged)
+	case KindError:
+		return new(MessageError)
+	default:
+		return nil
+	}
+}
+
+// DecodeEvent decodes an event envelope, as read from a subscription or sent
+// in a webhook delivery
+func DecodeEvent(data []byte) (Event, error) {
+	var envelope struct {
+		Kind   string          `json:"kind"`
+		Value  json.RawMessage `json:"value"`
+		RoomID string          `json:"room_id"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return Event{}, fmt.Errorf("client: decode event: %w", err)
+	}
+
+	event := Event{Kind: envelope.Kind, RoomID: envelope.RoomID}
+	value := newEventValue(envelope.Kind)
+	if value == nil {
+		event.Value = envelope.Value
+		return event, nil
+	}
+	if err := json.Unmarshal(envelope.Value, value); err != nil {
+		return Event{}, fmt.Errorf("client: decode %s event: %w", envelope.Kind, err)
+	}
+	event.Value = value
+	return event, nil
+}
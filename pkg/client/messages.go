@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type CreateMessageParams struct {
+	Message string `json:"message"`
+	// Anonymous hides the author from everyone but moderators, when the
+	// anonymous policy of the room allows it
+	Anonymous bool `json:"anonymous,omitempty"`
+}
+
+// CreateMessage posts a message to the room and returns its ID
+func (c *Client) CreateMessage(ctx context.Context, roomID string, params CreateMessageParams) (string, error) {
+	var created struct {
+		ID string `json:"id"`
+	}
+	err := c.do(ctx, http.MethodPost, c.endpoint(pathf("/api/rooms/%s/messages/", roomID), nil), params, &created)
+	return created.ID, err
+}
+
+// ListMessagesOptions selects the ranking of ListMessages. The zero value
+// lists every message of the room.
+type ListMessagesOptions struct {
+	// Hot ranks the unanswered messages by reactions and age, the way the top
+	// questions are ranked
+	Hot bool
+	// IncludeAnswered keeps the answered messages in the hot ranking
+	IncludeAnswered bool
+	// Limit caps the hot ranking, 0 for no limit
+	Limit int
+}
+
+func (c *Client) ListMessages(ctx context.Context, roomID string, opts ListMessagesOptions) ([]RoomMessage, error) {
+	query := url.Values{}
+	if opts.Hot {
+		query.Set("sort", "hot")
+		if opts.IncludeAnswered {
+			query.Set("include_answered", strconv.FormatBool(true))
+		}
+		if opts.Limit > 0 {
+			query.Set("limit", strconv.Itoa(opts.Limit))
+		}
+	}
+
+	var messages []RoomMessage
+	err := c.do(ctx, http.MethodGet, c.endpoint(pathf("/api/rooms/%s/messages/", roomID), query), nil, &messages)
+	return messages, err
+}
+
+func (c *Client) GetMessage(ctx context.Context, roomID, messageID string) (RoomMessage, error) {
+	var message RoomMessage
+	err := c.do(ctx, http.MethodGet, c.endpoint(pathf("/api/rooms/%s/messages/%s/", roomID, messageID), nil), nil, &message)
+	return message, err
+}
+
+// React adds a reaction to the message and returns the new count
+func (c *Client) React(ctx context.Context, roomID, messageID string) (int64, error) {
+	return c.react(ctx, http.MethodPatch, roomID, messageID)
+}
+
+// RemoveReaction removes a reaction from the message and returns the new count
+func (c *Client) RemoveReaction(ctx context.Context, roomID, messageID string) (int64, error) {
+	return c.react(ctx, http.MethodDelete, roomID, messageID)
+}
+
+func (c *Client) react(ctx context.Context, method, roomID, messageID string) (int64, error) {
+	var reaction struct {
+		Count int64 `json:"count"`
+	}
+	err := c.do(ctx, method, c.endpoint(pathf("/api/rooms/%s/messages/%s/react", roomID, messageID), nil), nil, &reaction)
+	return reaction.Count, err
+}
+
+// MarkAnswered marks the message as answered. It needs the answer permission.
+func (c *Client) MarkAnswered(ctx context.Context, roomID, messageID string) error {
+	return c.do(ctx, http.MethodPatch, c.endpoint(pathf("/api/rooms/%s/messages/%s/answer", roomID, messageID), nil), nil, nil)
+}
+
+// HideMessage hides the message from everyone but moderators
+func (c *Client) HideMessage(ctx context.Context, roomID, messageID string) error {
+	return c.do(ctx, http.MethodPatch, c.endpoint(pathf("/api/rooms/%s/messages/%s/hide", roomID, messageID), nil), nil, nil)
+}
+
+func (c *Client) UnhideMessage(ctx context.Context, roomID, messageID string) error {
+	return c.do(ctx, http.MethodPatch, c.endpoint(pathf("/api/rooms/%s/messages/%s/unhide", roomID, messageID), nil), nil, nil)
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// SanctionTarget identifies a participant by author ID, IP or both. A
+// MessageID stands for the author of that message, which also reaches the
+// authors of anonymous messages.
+type SanctionTarget struct {
+	AuthorID  string `json:"author_id,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	MessageID string `json:"message_id,omitempty"`
+}
+
+type SanctionParams struct {
+	SanctionTarget
+	Reason string
+	// Duration of the sanction, zero for a permanent one. It is rounded down
+	// to seconds.
+	Duration time.Duration
+}
+
+func (p SanctionParams) body() any {
+	return struct {
+		SanctionTarget
+		Reason          string `json:"reason,omitempty"`
+		DurationSeconds int64  `json:"duration_seconds,omitempty"`
+	}{p.SanctionTarget, p.Reason, int64(p.Duration / time.Second)}
+}
+
+// Kick closes the subscriptions of the participant in the room and returns
+// how many were closed. The participant may subscribe again, see Ban.
+func (c *Client) Kick(ctx context.Context, roomID string, target SanctionTarget) (int, error) {
+	var kicked struct {
+		Kicked int `json:"kicked"`
+	}
+	err := c.do(ctx, http.MethodPost, c.endpoint(pathf("/api/rooms/%s/moderation/kick", roomID), nil), target, &kicked)
+	return kicked.Kicked, err
+}
+
+// Ban keeps the participant out of the room and closes their subscriptions
+func (c *Client) Ban(ctx context.Context, roomID string, params SanctionParams) (Sanction, error) {
+	return c.createSanction(ctx, roomID, "bans", params)
+}
+
+// Mute keeps the participant from posting, reacting and voting in the room
+func (c *Client) Mute(ctx context.Context, roomID string, params SanctionParams) (Sanction, error) {
+	return c.createSanction(ctx, roomID, "mutes", params)
+}
+
+// ListBans returns the bans of the room that are neither expired nor revoked
+func (c *Client) ListBans(ctx context.Context, roomID string) ([]Sanction, error) {
+	return c.listSanctions(ctx, roomID, "bans")
+}
+
+// ListMutes returns the mutes of the room that are neither expired nor revoked
+func (c *Client) ListMutes(ctx context.Context, roomID string) ([]Sanction, error) {
+	return c.listSanctions(ctx, roomID, "mutes")
+}
+
+// RevokeBan lifts the ban and returns it with RevokedAt set
+func (c *Client) RevokeBan(ctx context.Context, roomID, sanctionID string) (Sanction, error) {
+	return c.revokeSanction(ctx, roomID, "bans", sanctionID)
+}
+
+// RevokeMute lifts the mute and returns it with RevokedAt set
+func (c *Client) RevokeMute(ctx context.Context, roomID, sanctionID string) (Sanction, error) {
+	return c.revokeSanction(ctx, roomID, "mutes", sanctionID)
+}
+
+func (c *Client) createSanction(ctx context.Context, roomID, kind string, params SanctionParams) (Sanction, error) {
+	var sanction Sanction
+	err := c.do(ctx, http.MethodPost, c.endpoint(pathf("/api/rooms/%s/moderation/", roomID)+kind, nil), params.body(), &sanction)
+	return sanction, err
+}
+
+func (c *Client) listSanctions(ctx context.Context, roomID, kind string) ([]Sanction, error) {
+	var sanctions []Sanction
+	err := c.do(ctx, http.MethodGet, c.endpoint(pathf("/api/rooms/%s/moderation/", roomID)+kind, nil), nil, &sanctions)
+	return sanctions, err
+}
+
+func (c *Client) revokeSanction(ctx context.Context, roomID, kind, sanctionID string) (Sanction, error) {
+	var sanction Sanction
+	path := pathf("/api/rooms/%s/moderation/", roomID) + kind + pathf("/%s", sanctionID)
+	err := c.do(ctx, http.MethodDelete, c.endpoint(path, nil), nil, &sanction)
+	return sanction, err
+}
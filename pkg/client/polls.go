@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+type CreatePollParams struct {
+	Question string `json:"question"`
+	// Options are the labels of the choices, in order
+	Options        []string `json:"options"`
+	MultipleChoice bool     `json:"multiple_choice,omitempty"`
+}
+
+// CreatePoll opens a poll in the room. It needs the moderate permission.
+func (c *Client) CreatePoll(ctx context.Context, roomID string, params CreatePollParams) (Poll, error) {
+	var poll Poll
+	err := c.do(ctx, http.MethodPost, c.endpoint(pathf("/api/rooms/%s/polls/", roomID), nil), params, &poll)
+	return poll, err
+}
+
+func (c *Client) ListPolls(ctx context.Context, roomID string) ([]Poll, error) {
+	var polls []Poll
+	err := c.do(ctx, http.MethodGet, c.endpoint(pathf("/api/rooms/%s/polls/", roomID), nil), nil, &polls)
+	return polls, err
+}
+
+func (c *Client) GetPoll(ctx context.Context, roomID, pollID string) (Poll, error) {
+	var poll Poll
+	err := c.do(ctx, http.MethodGet, c.endpoint(pathf("/api/rooms/%s/polls/%s/", roomID, pollID), nil), nil, &poll)
+	return poll, err
+}
+
+// Vote casts the ballot of the caller and returns the new tallies. Single
+// choice polls take exactly one option, and a second ballot fails with
+// ErrConflict.
+func (c *Client) Vote(ctx context.Context, roomID, pollID string, optionIDs ...string) (Poll, error) {
+	var poll Poll
+	body := map[string][]string{"option_ids": optionIDs}
+	err := c.do(ctx, http.MethodPost, c.endpoint(pathf("/api/rooms/%s/polls/%s/votes", roomID, pollID), nil), body, &poll)
+	return poll, err
+}
+
+// ClosePoll stops the voting and returns the final tallies
+func (c *Client) ClosePoll(ctx context.Context, roomID, pollID string) (Poll, error) {
+	var poll Poll
+	err := c.do(ctx, http.MethodPatch, c.endpoint(pathf("/api/rooms/%s/polls/%s/close", roomID, pollID), nil), nil, &poll)
+	return poll, err
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type CreateRoomParams struct {
+	Theme string `json:"theme"`
+	// AllowGuests defaults to true
+	AllowGuests *bool `json:"allow_guests,omitempty"`
+	// AnonymousPolicy defaults to AnonymousPolicyNamed
+	AnonymousPolicy string `json:"anonymous_policy,omitempty"`
+}
+
+// CreateRoom creates a room and returns its ID. It needs the moderate
+// permission.
+func (c *Client) CreateRoom(ctx context.Context, params CreateRoomParams) (string, error) {
+	var created struct {
+		ID string `json:"id"`
+	}
+	err := c.do(ctx, http.MethodPost, c.endpoint("/api/rooms/", nil), params, &created)
+	return created.ID, err
+}
+
+func (c *Client) ListRooms(ctx context.Context) ([]Room, error) {
+	var rooms []Room
+	err := c.do(ctx, http.MethodGet, c.endpoint("/api/rooms/", nil), nil, &rooms)
+	return rooms, err
+}
+
+func (c *Client) GetRoom(ctx context.Context, roomID string) (Room, error) {
+	var room Room
+	err := c.do(ctx, http.MethodGet, c.endpoint(pathf("/api/rooms/%s/", roomID), nil), nil, &room)
+	return room, err
+}
+
+// SetRoomAllowGuests opens or closes the room to guest tokens
+func (c *Client) SetRoomAllowGuests(ctx context.Context, roomID string, allow bool) (Room, error) {
+	var room Room
+	body := map[string]bool{"allow_guests": allow}
+	err := c.do(ctx, http.MethodPatch, c.endpoint(pathf("/api/rooms/%s/guests", roomID), nil), body, &room)
+	return room, err
+}
+
+// SetRoomAnonymousPolicy sets whether messages may or must hide their author
+func (c *Client) SetRoomAnonymousPolicy(ctx context.Context, roomID, policy string) (Room, error) {
+	var room Room
+	body := map[string]string{"anonymous_policy": policy}
+	err := c.do(ctx, http.MethodPatch, c.endpoint(pathf("/api/rooms/%s/anonymous-policy", roomID), nil), body, &room)
+	return room, err
+}
+
+// Export formats
+const (
+	ExportFormatJSON     = "json"
+	ExportFormatCSV      = "csv"
+	ExportFormatMarkdown = "markdown"
+)
+
+type ExportOptions struct {
+	// Format defaults to ExportFormatJSON
+	Format string
+	// IncludeHidden also exports hidden messages and needs the moderate
+	// permission
+	IncludeHidden bool
+	// Anonymize replaces the author names with stable pseudonyms
+	Anonymize bool
+}
+
+// ExportRoom streams the transcript of the room. The caller must close it.
+func (c *Client) ExportRoom(ctx context.Context, roomID string, opts ExportOptions) (io.ReadCloser, error) {
+	query := url.Values{}
+	if opts.Format != "" {
+		query.Set("format", opts.Format)
+	}
+	if opts.IncludeHidden {
+		query.Set("include_hidden", strconv.FormatBool(true))
+	}
+	if opts.Anonymize {
+		query.Set("anonymize", strconv.FormatBool(true))
+	}
+
+	resp, err := c.request(ctx, http.MethodGet, c.endpoint(pathf("/api/rooms/%s/export", roomID), query), nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,300 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Errors that end a subscription closed by a moderator. It is not reopened.
+var (
+	ErrKicked = errors.New("client: kicked from the room")
+	ErrBanned = errors.New("client: banned from the room")
+)
+
+var clientPing = []byte(`{"kind":"` + kindClientPing + `"}`)
+
+type SubscribeOptions struct {
+	// PingInterval is how often client_ping is sent to keep proxies from
+	// dropping an idle connection, 30s by default
+	PingInterval time.Duration
+	// ReadTimeout is how long a connection may stay silent, without events,
+	// pongs or pings, before it is considered dead and replaced, 75s by
+	// default
+	ReadTimeout time.Duration
+	// MinBackoff and MaxBackoff bound the exponential delay between
+	// reconnection attempts, 500ms and 30s by default
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Buffer is the capacity of the events channel, 64 by default
+	Buffer int
+
+	// OnConnect is called after every handshake, the first one included.
+	// Events are lost while disconnected, so this is where state fetched over
+	// REST should be refreshed.
+	OnConnect func()
+	// OnDisconnect is called when a connection drops or a reconnection
+	// attempt fails, with the delay before the next attempt
+	OnDisconnect func(err error, retryIn time.Duration)
+}
+
+func (o *SubscribeOptions) setDefaults() {
+	if o.PingInterval <= 0 {
+		o.PingInterval = 30 * time.Second
+	}
+	if o.ReadTimeout <= 0 {
+		o.ReadTimeout = 75 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 30 * time.Second
+	}
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = min(500*time.Millisecond, o.MaxBackoff)
+	}
+	o.MinBackoff = min(o.MinBackoff, o.MaxBackoff)
+	if o.Buffer <= 0 {
+		o.Buffer = 64
+	}
+}
+
+// backoff returns the delay before the attempt, counting from 0, with jitter
+// so that the subscribers of a restarted server do not return in lockstep
+func (o *SubscribeOptions) backoff(attempt int) time.Duration {
+	d := o.MaxBackoff
+	if attempt < 32 {
+		d = min(o.MinBackoff<<attempt, o.MaxBackoff)
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryDelay returns how long to wait before reconnecting after err, or false
+// when err ends the subscription
+func (o *SubscribeOptions) retryDelay(err error, attempt int) (time.Duration, bool) {
+	delay := o.backoff(attempt)
+
+	var apiErr *Error
+	var closeErr *websocket.CloseError
+	switch {
+	case errors.Is(err, ErrKicked), errors.Is(err, ErrBanned):
+		return 0, false
+	case errors.As(err, &apiErr):
+		// The room is gone or the caller is not allowed in, which retrying
+		// does not change
+		if apiErr.StatusCode < 500 && apiErr.StatusCode != http.StatusTooManyRequests {
+			return 0, false
+		}
+		return max(delay, apiErr.RetryAfter), true
+	case errors.As(err, &closeErr) && closeErr.Code == websocket.CloseGoingAway:
+		// A server shutting down tells when to come back
+		var hint struct {
+			Reconnect      bool  `json:"reconnect"`
+			RetryAfterMsec int64 `json:"retry_after_ms"`
+		}
+		if json.Unmarshal([]byte(closeErr.Text), &hint) == nil {
+			if !hint.Reconnect {
+				return 0, false
+			}
+			return max(delay, time.Duration(hint.RetryAfterMsec)*time.Millisecond), true
+		}
+	}
+	return delay, true
+}
+
+// Subscription streams the events of a room, reconnecting until it is closed,
+// its context is cancelled or the server refuses it for good
+type Subscription struct {
+	events chan Event
+	done   chan struct{}
+	cancel context.CancelFunc
+	err    error
+}
+
+// Subscribe connects to the event stream of the room. The first handshake
+// happens before it returns, so an unknown room or a ban fails here with an
+// *Error; later ones happen in the background.
+func (c *Client) Subscribe(ctx context.Context, roomID string, opts SubscribeOptions) (*Subscription, error) {
+	opts.setDefaults()
+
+	conn, err := c.dial(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Subscription{
+		events: make(chan Event, opts.Buffer),
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+	go s.run(ctx, c, roomID, &opts, conn)
+	return s, nil
+}
+
+// Events returns the decoded events. The channel is closed when the
+// subscription ends, after which Err tells why.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err returns the error that ended the subscription, e.g. ErrBanned or an
+// *Error of a refused handshake. It is nil while the subscription runs and
+// after Close or the cancellation of its context.
+func (s *Subscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Close ends the subscription and waits for the connection to close
+func (s *Subscription) Close() error {
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+func (s *Subscription) run(ctx context.Context, c *Client, roomID string, opts *SubscribeOptions, conn *websocket.Conn) {
+	defer close(s.done)
+	defer close(s.events)
+	defer s.cancel()
+
+	attempt := 0
+	for {
+		if opts.OnConnect != nil {
+			opts.OnConnect()
+		}
+		connected := time.Now()
+		err := s.serve(ctx, conn, opts)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// A connection that held up starts the backoff over
+		if time.Since(connected) > opts.MaxBackoff {
+			attempt = 0
+		}
+
+		for {
+			delay, retry := opts.retryDelay(err, attempt)
+			if !retry {
+				s.err = err
+				return
+			}
+			attempt++
+			if opts.OnDisconnect != nil {
+				opts.OnDisconnect(err, delay)
+			}
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			conn, err = c.dial(ctx, roomID)
+			if ctx.Err() != nil {
+				return
+			}
+			if err == nil {
+				break
+			}
+		}
+	}
+}
+
+// serve reads events from one connection until it fails or ctx is cancelled
+func (s *Subscription) serve(ctx context.Context, conn *websocket.Conn, opts *SubscribeOptions) error {
+	defer conn.Close()
+
+	// Only the pinger writes data frames; pongs are control frames, which
+	// may be written concurrently
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(opts.PingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ctx.Done():
+				frame := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+				_ = conn.WriteControl(websocket.CloseMessage, frame, time.Now().Add(time.Second))
+				conn.Close()
+				return
+			case <-ticker.C:
+				conn.SetWriteDeadline(time.Now().Add(opts.ReadTimeout))
+				if err := conn.WriteMessage(websocket.TextMessage, clientPing); err != nil {
+					conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(opts.ReadTimeout))
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		var netErr net.Error
+		if errors.Is(err, websocket.ErrCloseSent) || errors.As(err, &netErr) && netErr.Timeout() {
+			return nil
+		}
+		return err
+	})
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(opts.ReadTimeout))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) && closeErr.Code == websocket.ClosePolicyViolation {
+				if closeErr.Text == "banned" {
+					return ErrBanned
+				}
+				return ErrKicked
+			}
+			return err
+		}
+
+		event, err := DecodeEvent(data)
+		if err != nil || event.Kind == kindServerPong {
+			continue
+		}
+
+		select {
+		case s.events <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// dial opens a connection to the event stream of the room
+func (c *Client) dial(ctx context.Context, roomID string) (*websocket.Conn, error) {
+	u := *c.baseURL
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+	path := pathf("/subscribe/%s", roomID)
+
+	conn, resp, err := c.dialer.DialContext(ctx, u.String()+path, c.header())
+	if err != nil {
+		if errors.Is(err, websocket.ErrBadHandshake) && resp != nil {
+			return nil, newError(http.MethodGet, u.Path+path, resp)
+		}
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,253 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/felipemacedo1/go-msg-wss/pkg/client"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+)
+
+// nextEvent returns the next event of the subscription or fails the test
+func nextEvent(t *testing.T, sub *client.Subscription) client.Event {
+	t.Helper()
+
+	select {
+	case event, ok := <-sub.Events():
+		if !ok {
+			t.Fatalf("subscription ended: %v", sub.Err())
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+		return client.Event{}
+	}
+}
+
+// waitEnded waits for the events channel to close and returns Err
+func waitEnded(t *testing.T, sub *client.Subscription) error {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-sub.Events():
+			if !ok {
+				return sub.Err()
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for the subscription to end")
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+	mod := moderator(t, srv)
+
+	roomID, err := mod.CreateRoom(ctx, client.CreateRoomParams{Theme: "Events"})
+	if err != nil {
+		t.Fatalf("CreateRoom() error = %v", err)
+	}
+
+	// Pings far more often than the read timeout: the connection only
+	// survives the idle wait below if the server answers them
+	var connects atomic.Int32
+	sub, err := mod.Subscribe(ctx, roomID, client.SubscribeOptions{
+		PingInterval: 20 * time.Millisecond,
+		ReadTimeout:  200 * time.Millisecond,
+		OnConnect:    func() { connects.Add(1) },
+		OnDisconnect: func(err error, _ time.Duration) { t.Errorf("disconnected: %v", err) },
+	})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer sub.Close()
+
+	time.Sleep(500 * time.Millisecond)
+
+	messageID, err := mod.CreateMessage(ctx, roomID, client.CreateMessageParams{Message: "hello"})
+	if err != nil {
+		t.Fatalf("CreateMessage() error = %v", err)
+	}
+	event := nextEvent(t, sub)
+	created, ok := event.Value.(*client.MessageMessageCreated)
+	if event.Kind != client.KindMessageCreated || event.RoomID != roomID || !ok {
+		t.Fatalf("event = %+v, want message_created", event)
+	}
+	if created.ID != messageID || created.Message != "hello" || created.AuthorName != "Mod" {
+		t.Errorf("message_created value = %+v", created)
+	}
+
+	if _, err := mod.React(ctx, roomID, messageID); err != nil {
+		t.Fatalf("React() error = %v", err)
+	}
+	event = nextEvent(t, sub)
+	if v, ok := event.Value.(*client.MessageMessageReactionIncreased); !ok || v.ID != messageID || v.Count != 1 {
+		t.Errorf("event = %+v, want message_reaction_increased", event)
+	}
+
+	if err := mod.HideMessage(ctx, roomID, messageID); err != nil {
+		t.Fatalf("HideMessage() error = %v", err)
+	}
+	event = nextEvent(t, sub)
+	if v, ok := event.Value.(*client.MessageMessageHidden); !ok || v.ID != messageID {
+		t.Errorf("event = %+v, want message_hidden", event)
+	}
+
+	poll, err := mod.CreatePoll(ctx, roomID, client.CreatePollParams{Question: "Tea?", Options: []string{"Yes", "No"}})
+	if err != nil {
+		t.Fatalf("CreatePoll() error = %v", err)
+	}
+	event = nextEvent(t, sub)
+	if v, ok := event.Value.(*client.MessagePollCreated); !ok || v.ID != poll.ID || len(v.Options) != 2 {
+		t.Errorf("event = %+v, want poll_created", event)
+	}
+
+	if _, err := mod.Vote(ctx, roomID, poll.ID, poll.Options[1].ID); err != nil {
+		t.Fatalf("Vote() error = %v", err)
+	}
+	event = nextEvent(t, sub)
+	if v, ok := event.Value.(*client.MessagePollVotesUpdated); !ok || v.TotalVotes != 1 || v.Options[1].Votes != 1 {
+		t.Errorf("event = %+v, want poll_votes_updated", event)
+	}
+
+	if connects.Load() != 1 {
+		t.Errorf("connected %d times, want 1", connects.Load())
+	}
+
+	if err := sub.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if err := waitEnded(t, sub); err != nil {
+		t.Errorf("Err() after Close = %v, want nil", err)
+	}
+}
+
+func TestSubscribeRemoved(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+	mod := moderator(t, srv)
+	alice := newTestClient(t, srv, jwt.MapClaims{"sub": "alice", "name": "Alice"})
+
+	roomID, err := mod.CreateRoom(ctx, client.CreateRoomParams{Theme: "Bans"})
+	if err != nil {
+		t.Fatalf("CreateRoom() error = %v", err)
+	}
+
+	opts := client.SubscribeOptions{MinBackoff: time.Millisecond}
+	sub, err := alice.Subscribe(ctx, roomID, opts)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer sub.Close()
+
+	// The socket is registered after the handshake completes
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		kicked, err := mod.Kick(ctx, roomID, client.SanctionTarget{AuthorID: "alice"})
+		if err != nil {
+			t.Fatalf("Kick() error = %v", err)
+		}
+		if kicked == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the subscription never registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	event := nextEvent(t, sub)
+	if v, ok := event.Value.(*client.MessageError); !ok || v.Code != "kicked" || v.Message == "" {
+		t.Errorf("event = %+v, want the kicked error", event)
+	}
+	if err := waitEnded(t, sub); !errors.Is(err, client.ErrKicked) {
+		t.Errorf("Err() after a kick = %v, want ErrKicked", err)
+	}
+
+	if _, err := mod.Ban(ctx, roomID, client.SanctionParams{SanctionTarget: client.SanctionTarget{AuthorID: "alice"}}); err != nil {
+		t.Fatalf("Ban() error = %v", err)
+	}
+	if _, err := alice.Subscribe(ctx, roomID, opts); !errors.Is(err, client.ErrForbidden) {
+		t.Errorf("Subscribe() while banned error = %v, want ErrForbidden", err)
+	}
+}
+
+func TestSubscribeReconnects(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	var dials atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch dials.Add(1) {
+		case 2:
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		case 4:
+			http.Error(w, "room not found", http.StatusBadRequest)
+			return
+		}
+
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+
+		if dials.Load() == 1 {
+			frame := websocket.FormatCloseMessage(websocket.CloseGoingAway, `{"reconnect":true,"retry_after_ms":20}`)
+			_ = c.WriteControl(websocket.CloseMessage, frame, time.Now().Add(time.Second))
+			return
+		}
+		_ = c.WriteMessage(websocket.TextMessage, []byte(`{"kind":"message_answered","value":{"id":"m1"},"room_id":"r1"}`))
+		_ = c.WriteMessage(websocket.TextMessage, []byte(`{"kind":"future_kind","value":{"x":1},"room_id":"r1"}`))
+	}))
+	defer srv.Close()
+
+	c, err := client.New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var connects atomic.Int32
+	var delays []time.Duration
+	sub, err := c.Subscribe(context.Background(), "r1", client.SubscribeOptions{
+		MinBackoff:   time.Millisecond,
+		MaxBackoff:   5 * time.Millisecond,
+		OnConnect:    func() { connects.Add(1) },
+		OnDisconnect: func(_ error, retryIn time.Duration) { delays = append(delays, retryIn) },
+	})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer sub.Close()
+
+	event := nextEvent(t, sub)
+	if v, ok := event.Value.(*client.MessageMessageAnswered); !ok || v.ID != "m1" || event.RoomID != "r1" {
+		t.Errorf("event = %+v, want message_answered", event)
+	}
+	event = nextEvent(t, sub)
+	if event.Kind != "future_kind" || string(event.Value.(json.RawMessage)) != `{"x":1}` {
+		t.Errorf("event = %+v, want the raw value of an unknown kind", event)
+	}
+
+	// The third connection drops and the fourth handshake is refused for good
+	var apiErr *client.Error
+	if err := waitEnded(t, sub); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Err() = %v, want the 400 of the last handshake", err)
+	}
+	if connects.Load() != 2 {
+		t.Errorf("connected %d times, want 2", connects.Load())
+	}
+	// The shutdown hint overrides the backoff of the first retry
+	if len(delays) != 3 || delays[0] < 20*time.Millisecond || delays[1] > 5*time.Millisecond {
+		t.Errorf("retry delays = %v, want 20ms then backoff", delays)
+	}
+}
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Anonymous message policies of a room
+const (
+	AnonymousPolicyNamed   = "named"
+	AnonymousPolicyAllowed = "allowed"
+	AnonymousPolicyOnly    = "only"
+)
+
+// Sanction kinds
+const (
+	SanctionKindBan  = "ban"
+	SanctionKindMute = "mute"
+)
+
+// Poll statuses
+const (
+	PollStatusOpen   = "open"
+	PollStatusClosed = "closed"
+)
+
+// Webhook delivery statuses
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusDead      = "dead"
+)
+
+// API key permissions
+const (
+	PermissionRead     = "read"
+	PermissionPost     = "post"
+	PermissionAnswer   = "answer"
+	PermissionModerate = "moderate"
+)
+
+type Room struct {
+	ID              string `json:"id"`
+	Theme           string `json:"theme"`
+	AllowGuests     bool   `json:"allow_guests"`
+	AnonymousPolicy string `json:"anonymous_policy"`
+}
+
+// RoomMessage is a question or comment posted to a room. Anonymous messages
+// come with a placeholder author and no UserID.
+type RoomMessage struct {
+	ID            string    `json:"id"`
+	RoomID        string    `json:"room_id"`
+	Message       string    `json:"message"`
+	ReactionCount int64     `json:"reaction_count"`
+	Answered      bool      `json:"answered"`
+	Hidden        bool      `json:"hidden"`
+	AuthorID      string    `json:"author_id"`
+	AuthorName    string    `json:"author_name"`
+	IsBot         bool      `json:"is_bot"`
+	Anonymous     bool      `json:"anonymous"`
+	CreatedAt     time.Time `json:"created_at"`
+	// UserID is set when the author signed in with a user JWT
+	UserID *string `json:"user_id"`
+	// AuthorAvatarURL is the current avatar of the signed in author
+	AuthorAvatarURL string `json:"author_avatar_url,omitempty"`
+}
+
+type PollOption struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Votes int64  `json:"votes"`
+}
+
+// Poll is a poll together with its current vote tallies
+type Poll struct {
+	ID             string       `json:"id"`
+	RoomID         string       `json:"room_id"`
+	Question       string       `json:"question"`
+	MultipleChoice bool         `json:"multiple_choice"`
+	Status         string       `json:"status"`
+	CreatedBy      string       `json:"created_by"`
+	CreatedAt      time.Time    `json:"created_at"`
+	ClosedAt       *time.Time   `json:"closed_at"`
+	Options        []PollOption `json:"options"`
+	TotalVotes     int64        `json:"total_votes"`
+}
+
+// Sanction is a ban or a mute. AuthorID, IP or both identify the sanctioned
+// participant.
+type Sanction struct {
+	ID        string     `json:"id"`
+	RoomID    string     `json:"room_id"`
+	Kind      string     `json:"kind"`
+	AuthorID  *string    `json:"author_id"`
+	IP        *string    `json:"ip"`
+	Reason    string     `json:"reason"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// GuestToken is a short lived token for a guest of one room
+type GuestToken struct {
+	Token     string    `json:"token"`
+	GuestID   string    `json:"guest_id"`
+	Name      string    `json:"name"`
+	RoomID    string    `json:"room_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type APIKey struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Prefix string `json:"prefix"`
+	// Key is the secret, only returned by CreateAPIKey
+	Key         string     `json:"key,omitempty"`
+	RoomIDs     []string   `json:"room_ids"`
+	Permissions []string   `json:"permissions"`
+	CreatedBy   string     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+}
+
+// User is the profile of a signed in user with their latest messages
+type User struct {
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	AvatarURL *string       `json:"avatar_url"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	Messages  []RoomMessage `json:"messages"`
+}
+
+type AuditLogEntry struct {
+	ID         int64           `json:"id"`
+	Action     string          `json:"action"`
+	ActorID    string          `json:"actor_id"`
+	IP         string          `json:"ip"`
+	RequestID  string          `json:"request_id"`
+	RoomID     *string         `json:"room_id"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type Webhook struct {
+	ID     string  `json:"id"`
+	RoomID *string `json:"room_id"`
+	URL    string  `json:"url"`
+	// Secret signs the deliveries, only returned by CreateWebhook
+	Secret     string    `json:"secret,omitempty"`
+	EventKinds []string  `json:"event_kinds"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID        string `json:"id"`
+	WebhookID string `json:"webhook_id"`
+	EventKind string `json:"event_kind"`
+	RoomID    string `json:"room_id"`
+	// Payload is the event envelope, see Event
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode int32           `json:"last_status_code"`
+	LastError      string          `json:"last_error"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+}